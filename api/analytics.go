@@ -0,0 +1,162 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etsy/hound/config"
+)
+
+// What is recorded about a search for analytics.
+type SearchEvent struct {
+	Time time.Time
+
+	// The normalized query, or its hash or nothing at all depending on
+	// search-analytics-queries.
+	Query string
+
+	// The repos the search was scoped to, as they were asked for.
+	Repos string
+
+	// The number of files that matched.
+	Results int
+
+	Duration time.Duration
+
+	// Who searched, when the config has scopes, see config.Scopes.
+	Identity string `json:",omitempty"`
+}
+
+// How often a query was searched for.
+type QueryCount struct {
+	Query string
+	Count int
+
+	// The mean number of files that matched and the mean duration of the
+	// searches.
+	Results    int
+	DurationMs int
+}
+
+// Where search analytics go. The default keeps the most recent searches in
+// memory, use SetAnalyticsSink to keep them elsewhere.
+type AnalyticsSink interface {
+	// Record a search.
+	Record(e *SearchEvent)
+
+	// The (at most n) queries searched for most often since the given time,
+	// only counting the searches of identity unless it is nil.
+	Top(since time.Time, n int, identity *string) []*QueryCount
+}
+
+var (
+	gAnalytics AnalyticsSink
+)
+
+// Record searches in the sink from now on, nil stops recording them.
+func SetAnalyticsSink(sink AnalyticsSink) {
+	gAnalytics = sink
+}
+
+// An AnalyticsSink that keeps the last size searches in a ring buffer.
+type ringSink struct {
+	lck    sync.Mutex
+	events []SearchEvent
+	next   int
+	full   bool
+}
+
+func newRingSink(size int) *ringSink {
+	return &ringSink{
+		events: make([]SearchEvent, size),
+	}
+}
+
+func (s *ringSink) Record(e *SearchEvent) {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+
+	s.events[s.next] = *e
+	s.next++
+	if s.next == len(s.events) {
+		s.next = 0
+		s.full = true
+	}
+}
+
+func (s *ringSink) Top(since time.Time, n int, identity *string) []*QueryCount {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+
+	events := s.events[:s.next]
+	if s.full {
+		events = s.events
+	}
+
+	type totals struct {
+		count    int
+		results  int
+		duration time.Duration
+	}
+
+	byQuery := map[string]*totals{}
+	for i := range events {
+		e := &events[i]
+		if e.Time.Before(since) || identity != nil && e.Identity != *identity {
+			continue
+		}
+
+		t := byQuery[e.Query]
+		if t == nil {
+			t = &totals{}
+			byQuery[e.Query] = t
+		}
+		t.count++
+		t.results += e.Results
+		t.duration += e.Duration
+	}
+
+	top := make([]*QueryCount, 0, len(byQuery))
+	for q, t := range byQuery {
+		top = append(top, &QueryCount{
+			Query:      q,
+			Count:      t.count,
+			Results:    t.results / t.count,
+			DurationMs: int(t.duration / time.Duration(t.count) / time.Millisecond),
+		})
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Query < top[j].Query
+	})
+
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// The query as it is recorded: with its white space collapsed, lower cased
+// for case insensitive searches and then kept as configured.
+func analyticsQuery(q string, ignoreCase bool, keep string) string {
+	q = strings.Join(strings.Fields(q), " ")
+	if ignoreCase {
+		q = strings.ToLower(q)
+	}
+
+	switch keep {
+	case config.QueriesAsHash:
+		sum := sha256.Sum256([]byte(q))
+		return hex.EncodeToString(sum[:])
+	case config.QueriesAsRedacted:
+		return "[redacted]"
+	}
+	return q
+}
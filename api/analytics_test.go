@@ -0,0 +1,68 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/etsy/hound/config"
+)
+
+func TestRingSinkTop(t *testing.T) {
+	now := time.Now()
+	s := newRingSink(4)
+
+	s.Record(&SearchEvent{Time: now.Add(-time.Hour), Query: "old"})
+	s.Record(&SearchEvent{Time: now, Query: "a", Results: 2, Duration: 10 * time.Millisecond})
+	s.Record(&SearchEvent{Time: now, Query: "b"})
+	s.Record(&SearchEvent{Time: now, Query: "a", Results: 4, Duration: 20 * time.Millisecond})
+
+	top := s.Top(now.Add(-time.Minute), 10, nil)
+	if len(top) != 2 {
+		t.Fatalf("expected 2 queries, got %d", len(top))
+	}
+
+	if top[0].Query != "a" || top[0].Count != 2 || top[0].Results != 3 || top[0].DurationMs != 15 {
+		t.Fatalf("unexpected top query %+v", top[0])
+	}
+
+	if top[1].Query != "b" || top[1].Count != 1 {
+		t.Fatalf("unexpected second query %+v", top[1])
+	}
+
+	// the oldest search falls out once the ring is full
+	s.Record(&SearchEvent{Time: now, Query: "b"})
+	s.Record(&SearchEvent{Time: now, Query: "b"})
+
+	top = s.Top(now.Add(-24*time.Hour), 1, nil)
+	if len(top) != 1 || top[0].Query != "b" || top[0].Count != 3 {
+		t.Fatalf("unexpected top queries %+v", top)
+	}
+
+	// only the searches of the identity asked for are counted
+	alice := "alice"
+	s.Record(&SearchEvent{Time: now, Query: "mine", Identity: alice})
+	top = s.Top(now.Add(-time.Minute), 10, &alice)
+	if len(top) != 1 || top[0].Query != "mine" {
+		t.Fatalf("expected only the queries of alice, got %+v", top)
+	}
+}
+
+func TestAnalyticsQuery(t *testing.T) {
+	if q := analyticsQuery("  Foo   Bar ", true, config.QueriesAsPlain); q != "foo bar" {
+		t.Fatalf("unexpected query %q", q)
+	}
+
+	if q := analyticsQuery("Foo", false, ""); q != "Foo" {
+		t.Fatalf("unexpected query %q", q)
+	}
+
+	a := analyticsQuery("foo  bar", false, config.QueriesAsHash)
+	b := analyticsQuery("foo bar", false, config.QueriesAsHash)
+	if a != b || len(a) != 64 || a == "foo bar" {
+		t.Fatalf("unexpected hashes %q and %q", a, b)
+	}
+
+	if q := analyticsQuery("secret", false, config.QueriesAsRedacted); q == "secret" {
+		t.Fatal("expected the query to be redacted")
+	}
+}
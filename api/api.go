@@ -1,42 +1,133 @@
 package api
 
 import (
+	"crypto/hmac"
+	"crypto/sha1"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"sort"
+	"unicode/utf8"
 
 	"github.com/etsy/hound/config"
 	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
 	"github.com/etsy/hound/searcher"
+	"github.com/etsy/hound/vcs"
+	"github.com/etsy/hound/version"
 )
 
 const (
-	defaultLinesOfContext uint = 2
 	maxLinesOfContext     uint = 20
+	maxMatchesPerFile     uint = 1000
+	defaultFindLimit      uint = 50
+	maxFindLimit          uint = 1000
+	defaultSuggestLimit   uint = 10
+	maxSuggestLimit       uint = 100
 	defaultFilesOpened    int = 5
+	defaultFilesPageSize  int = 1000
+	blockWindow           int = 25
+	maxFilesPageSize      int = 10000
+	defaultTopQueries     uint = 20
+	maxTopQueries         uint = 1000
+	defaultTopWindow      = 24 * time.Hour
+	defaultUpdateTimeout  uint = 5 * 60 * 1000
+	maxUpdateTimeout      uint = 60 * 60 * 1000
+
+	// how long a response that was given a longer write deadline has to be
+	// written, past the time the handler gives up waiting
+	writeDeadlineSlack = 10 * time.Second
+
+	// the largest config that can be posted to /api/v1/config/diff
+	maxConfigSize         int64 = 10 << 20
 )
 
 type Stats struct {
-	FilesOpened int
+	// The number of files whose contents were read, across every repo and
+	// branch searched, and the number of them that matched.
+	FilesOpened    int
+	FilesWithMatch int
+
 	Duration    int
+	Languages   map[string]int `json:",omitempty"`
+}
+
+// Information about the index of a single repo.
+type IndexStats struct {
+	Revision string
+	Files    int
+	Size     int64
+	Built    time.Time
+
+	// When the index was last compacted, if it ever was.
+	Compacted *time.Time `json:",omitempty"`
+}
+
+// How many indexed files have each extension and each language, see
+// /api/v1/languages. Files of no known language aren't in Languages.
+type LanguageCounts struct {
+	Extensions map[string]int
+	Languages  map[string]int
 }
 
+func newLanguageCounts() *LanguageCounts {
+	return &LanguageCounts{
+		Extensions: map[string]int{},
+		Languages:  map[string]int{},
+	}
+}
+
+// Add the extensions counted by an index (see IndexRef.Extensions).
+func (c *LanguageCounts) add(exts map[string]int) {
+	for ext, n := range exts {
+		c.Extensions[ext] += n
+		if lang := index.LanguageOf(ext); lang != "" {
+			c.Languages[lang] += n
+		}
+	}
+}
+
+// The Status of /api/v1/health.
+const (
+	healthOk       = "ok"
+	healthDegraded = "degraded"
+	healthStarting = "starting"
+)
+
 var (
 	gSearchers map[string]*searcher.Searcher 
+
+	// Held while gSearchers is read or replaced. The map is never changed
+	// once it's set, changes replace it with a changed copy, so a request
+	// can go on using the one it got from GetSearchers.
+	gSearchersLck sync.RWMutex
+
+	// Closed once the searchers are first set, see waitForReady.
+	gReady     = make(chan struct{})
+	gReadyOnce sync.Once
+
+	// How long a request that comes in before the searchers are set waits
+	// for them before it fails, see config.MsReadyGracePeriod.
+	gReadyGracePeriod time.Duration
 )
 
 func writeJson(w http.ResponseWriter, data interface{}, status int) {
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Panicf("Failed to encode JSON: %v\n", err)
+		logger.Error("failed to encode JSON", logger.Fields{
+			"error": err,
+		})
 	}
 }
 
@@ -44,29 +135,118 @@ func writeResp(w http.ResponseWriter, data interface{}) {
 	writeJson(w, data, http.StatusOK)
 }
 
-func writeError(w http.ResponseWriter, err error, status int) {
-	writeJson(w, map[string]string{
-		"Error": err.Error(),
-	}, status)
-}
-
 type searchResponse struct {
 	repo string
 	res  *index.SearchResponse
 	err  error
 }
 
+// Collapse file matches that have the same path and the same matched lines
+// on different branches into a single match. The first one is kept and the
+// branches of the others are recorded on it. Returns the remaining matches
+// and the number of matches that were removed.
+func dedupeFileMatches(fms []*index.FileMatch) ([]*index.FileMatch, int) {
+	seen := map[string]*index.FileMatch{}
+	var res []*index.FileMatch
+	for _, fm := range fms {
+		lines := make([]string, 0, len(fm.Matches)+1)
+		lines = append(lines, fm.Filename)
+		for _, m := range fm.Matches {
+			lines = append(lines, m.Line)
+		}
+		key := strings.Join(lines, "\x00")
+
+		if first, ok := seen[key]; ok {
+			first.AlsoOnBranches = append(first.AlsoOnBranches, fm.Branch)
+			continue
+		}
+
+		seen[key] = fm
+		res = append(res, fm)
+	}
+
+	return res, len(fms) - len(res)
+}
+
+// Merge the suggestions of the repos, adding up the files each token is found
+// in. Every repo is asked for as many as are wanted in the end, so a token
+// that's not among the top ones of any repo is left out.
+func suggestAll(prefix string, limit int, repos []string, idx map[string]*searcher.Searcher) []*index.Suggestion {
+	counts := map[string]int{}
+	for _, repo := range repos {
+		s := idx[repo]
+		if s == nil {
+			continue
+		}
+
+		for _, sug := range s.Suggest(prefix, limit) {
+			counts[sug.Token] += sug.Count
+		}
+	}
+
+	sugs := make([]*index.Suggestion, 0, len(counts))
+	for token, n := range counts {
+		sugs = append(sugs, &index.Suggestion{Token: token, Count: n})
+	}
+
+	sort.Slice(sugs, func(i, j int) bool {
+		if sugs[i].Count != sugs[j].Count {
+			return sugs[i].Count > sugs[j].Count
+		}
+		return sugs[i].Token < sugs[j].Token
+	})
+
+	if len(sugs) > limit {
+		sugs = sugs[:limit]
+	}
+	return sugs
+}
+
 /**
  * Searches all repos in parallel.
  */
 func searchAll(
 	query string,
 	opts *index.SearchOptions,
+	ignoreCase *bool,
+	repos []string,
+	vrepos []string,
+	within fileSets,
+	dedupe bool,
+	idx map[string]*searcher.Searcher,
+	stats *Stats) (map[string]*index.SearchResponse, error) {
+
+	res := map[string]*index.SearchResponse{}
+	err := searchEach(query, opts, ignoreCase, repos, vrepos, within, dedupe, idx, stats,
+		func(results map[string]*index.SearchResponse) error {
+			for repo, r := range results {
+				res[repo] = r
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Search all repos in parallel like searchAll, but hand the results of each
+// repo to fn as soon as it has been searched, under the names of its virtual
+// repos if it's hidden. An error from fn stops the search. ignoreCase is
+// what the search asked for, see optionsFor. Unless within is nil, only the
+// files in it are searched.
+func searchEach(
+	query string,
+	opts *index.SearchOptions,
+	ignoreCase *bool,
 	repos []string,
 	vrepos []string,
+	within fileSets,
+	dedupe bool,
 	idx map[string]*searcher.Searcher,
-	filesOpened *int,
-	duration *int) (map[string]*index.SearchResponse, error) {
+	stats *Stats,
+	fn func(map[string]*index.SearchResponse) error) error {
 
 	startedAt := time.Now()
 
@@ -84,46 +264,186 @@ func searchAll(
 
 		an++;
 		go func(repo string, vrepos []string) {
-			fms, err := idx[repo].Search(query, opts, vrepos)
+			fms, err := idx[repo].Search(query, within.optionsFor(repo, optionsFor(opts, idx[repo], ignoreCase)), vrepos)
 			ch <- &searchResponse{repo, fms, err}
 		}(repo, vrepos)
 	}
 
-	res := map[string]*index.SearchResponse{}
 	for i := 0; i < an; i++ {
 		r := <-ch
 		if r.err != nil {
-			return nil, r.err
+			return r.err
 		}
 
-		if r.res.Matches == nil && r.res.VMatches == nil {
+		res := map[string]*index.SearchResponse{}
+		addSearchResponse(res, r, opts, dedupe, stats)
+		if len(res) == 0 {
 			continue
 		}
 
-		// check if it's hidden repo
-		if len(r.res.VMatches) > 0 {
-			for filerepo, vresult := range r.res.VMatches {
-				res[filerepo] = &index.SearchResponse{
-					Matches: 	vresult,
-					FilesWithMatch:	r.res.VFilesWithMatch[filerepo],
- 					Revision:	r.res.VRevision[filerepo],
-				}
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
+
+	stats.Duration = int(time.Now().Sub(startedAt).Seconds() * 1000)
+
+	return nil
+}
+
+// The most files each of n repos may open so that no more than max are
+// opened across all of them, at least one each.
+func maxFilesOpenedPerRepo(max, n int) int {
+	if n < 1 {
+		return max
+	}
+	if per := max / n; per > 0 {
+		return per
+	}
+	return 1
+}
+
+// The number of files a search for query would open across repos, which is
+// known from the trigram indexes alone, see index.Explanation.
+func countCandidates(
+	query string,
+	opts *index.SearchOptions,
+	ignoreCase *bool,
+	repos []string,
+	idx map[string]*searcher.Searcher) (int, error) {
+
+	var n int
+	for _, repo := range repos {
+		if idx[repo] == nil {
+			continue
+		}
+
+		e, err := idx[repo].Explain(query, optionsFor(opts, idx[repo], ignoreCase))
+		if err != nil {
+			return 0, err
+		}
+		n += e.Candidates
+	}
+	return n, nil
+}
+
+// Search a single repo as it was at rev, see Searcher.SearchRev.
+func searchRev(
+	query string,
+	opts *index.SearchOptions,
+	ignoreCase *bool,
+	repo string,
+	rev string,
+	idx map[string]*searcher.Searcher,
+	stats *Stats) (map[string]*index.SearchResponse, error) {
+
+	startedAt := time.Now()
+
+	res := map[string]*index.SearchResponse{}
+	if idx[repo] == nil {
+		return res, nil
+	}
+
+	fms, err := idx[repo].SearchRev(query, optionsFor(opts, idx[repo], ignoreCase), rev)
+	if err != nil {
+		return nil, err
+	}
+
+	addSearchResponse(res, &searchResponse{repo, fms, nil}, opts, false, stats)
+
+	stats.Duration = int(time.Now().Sub(startedAt).Seconds() * 1000)
+
+	return res, nil
+}
+
+// Add the response of a single searcher to the results, under the names of
+// the virtual repos it holds if it's hidden, and to the stats.
+func addSearchResponse(
+	res map[string]*index.SearchResponse,
+	r *searchResponse,
+	opts *index.SearchOptions,
+	dedupe bool,
+	stats *Stats) {
+
+	// a hidden searcher reports the files it opened for all of its
+	// virtual repos at once, and files are opened whether they match or not
+	stats.FilesOpened += r.res.FilesOpened
+
+	for lang, n := range r.res.LanguageCounts {
+		stats.Languages[lang] += n
+	}
+
+	if r.res.Matches == nil && r.res.VMatches == nil {
+		return
+	}
+
+	// check if it's hidden repo
+	if opts.CountOnly {
+		// there are no matches, only counts
+		for filerepo, filesWithMatch := range r.res.VFilesWithMatch {
+			res[filerepo] = &index.SearchResponse{
+				FilesWithMatch: filesWithMatch,
+				MatchCount:     r.res.VMatchCount[filerepo],
+				Revision:       r.res.VRevision[filerepo],
+				Limited:        r.res.Limited,
 			}
-		} else if r.res.Matches != nil {
+			stats.FilesWithMatch += filesWithMatch
+		}
+
+		if len(r.res.VFilesWithMatch) == 0 && r.res.FilesWithMatch > 0 {
 			res[r.repo] = r.res
+			stats.FilesWithMatch += r.res.FilesWithMatch
+		}
+	} else if len(r.res.VMatches) > 0 {
+		for filerepo, vresult := range r.res.VMatches {
+			filesWithMatch := r.res.VFilesWithMatch[filerepo]
+			if dedupe {
+				var removed int
+				vresult, removed = dedupeFileMatches(vresult)
+				filesWithMatch -= removed
+			}
+
+			res[filerepo] = &index.SearchResponse{
+				Matches: 	vresult,
+				FilesWithMatch:	filesWithMatch,
+				Revision:	r.res.VRevision[filerepo],
+				Limited:	r.res.Limited,
+			}
+			stats.FilesWithMatch += filesWithMatch
 		}
+	} else if r.res.Matches != nil {
+		res[r.repo] = r.res
+		stats.FilesWithMatch += r.res.FilesWithMatch
+	}
 
-		// unset the keys 
-		r.res.VMatches = nil
-		r.res.VFilesWithMatch = nil
-		r.res.VRevision = nil
+	// unset the keys 
+	r.res.VMatches = nil
+	r.res.VFilesWithMatch = nil
+	r.res.VRevision = nil
+	r.res.VMatchCount = nil
+}
 
-		*filesOpened += r.res.FilesOpened
+// Hand the search made by r to the analytics sink, if there is one. matched
+// is the number of files that matched.
+func recordSearch(r *http.Request, cfg *config.Config, ignoreCase bool, startedAt time.Time, matched int) {
+	sink := gAnalytics
+	if sink == nil {
+		return
 	}
 
-	*duration = int(time.Now().Sub(startedAt).Seconds() * 1000)
+	scope := r.FormValue("repos")
+	if group := r.FormValue("group"); group != "" {
+		scope = "group:" + group
+	}
 
-	return res, nil
+	sink.Record(&SearchEvent{
+		Time:     startedAt,
+		Query:    analyticsQuery(r.FormValue("q"), ignoreCase, cfg.SearchAnalyticsQueries),
+		Repos:    scope,
+		Results:  matched,
+		Duration: time.Since(startedAt),
+		Identity: gScopes.identityOf(r),
+	})
 }
 
 // Used for parsing flags from form values.
@@ -132,41 +452,237 @@ func parseAsBool(v string) bool {
 	return v == "true" || v == "1" || v == "fosho"
 }
 
-func parseAsRepoList(v string, idx map[string]*searcher.Searcher) ([]string,  []string) {
+// Parse a flag that may not have been given at all, which is nil. A flag
+// that was given is parsed like parseAsBool, so even i= is an explicit false.
+func parseAsOptionalBool(vals url.Values, name string) *bool {
+	v, ok := vals[name]
+	if !ok || len(v) == 0 {
+		return nil
+	}
+
+	b := parseAsBool(v[0])
+	return &b
+}
+
+// The options to search the repo of s with. A search that leaves ignoreCase
+// unset is case insensitive in the repos that are by default.
+func optionsFor(opts *index.SearchOptions, s *searcher.Searcher, ignoreCase *bool) *index.SearchOptions {
+	if ignoreCase != nil || s.Repo.CaseIgnored() == opts.IgnoreCase {
+		return opts
+	}
+
+	o := *opts
+	o.IgnoreCase = s.Repo.CaseIgnored()
+	return &o
+}
+
+// Parse a comma separated list, dropping empty entries.
+func parseAsList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// The names that the repos parameter is matched against.
+type repoNames struct {
+	// names of all searchers
+	repos []string
+
+	// names of the searchers that expose virtual repos
+	hidden []string
+
+	// virtual repo names, mapped to the searcher that holds them
+	vrepos map[string]string
+
+	// tags, mapped to the names of the searchers that carry them
+	groups map[string][]string
+
+	// aliases, mapped to the name of the searcher they stand for
+	aliases map[string]string
+
+	// names of the searchers left out of searches of every repo and globs
+	unlisted map[string]bool
+}
+
+func repoNamesOf(idx map[string]*searcher.Searcher) *repoNames {
+	names := &repoNames{
+		vrepos:   map[string]string{},
+		groups:   map[string][]string{},
+		unlisted: map[string]bool{},
+		aliases:  map[string]string{},
+	}
+
+	for name, searcher := range idx {
+		names.repos = append(names.repos, name)
+		repo := searcher.Config()
+		if repo.ExcludeFromWildcard {
+			names.unlisted[name] = true
+		}
+		for _, tag := range repo.Tags {
+			names.groups[tag] = append(names.groups[tag], name)
+		}
+		for _, alias := range repo.Aliases {
+			names.aliases[alias] = name
+		}
+		if searcher.HasVRepos() == true {
+			names.hidden = append(names.hidden, name)
+			for _, vrepo := range searcher.GetVRepos() {
+				names.vrepos[vrepo] = name
+			}
+		}
+	}
+
+	return names
+}
+
+// Does the repo name contain shell style glob characters?
+func isRepoGlob(v string) bool {
+	return strings.ContainsAny(v, "*?[")
+}
+
+func parseAsRepoList(v, group string, idx map[string]*searcher.Searcher) ([]string, []string, error) {
+	return expandRepoList(v, group, repoNamesOf(idx))
+}
+
+// Expand the comma separated repos parameter into the names of the searchers
+// to search and the virtual repos to limit the search to. Each entry can be an
+// exact name or a glob (e.g. team-a-*), which is matched against both repo
+// and virtual repo names. A glob that matches nothing is an error. The members
+// of the comma separated groups are added to the repos. Repos that are
+// excluded from wildcards are only searched when named exactly (which
+// includes naming one of their virtual repos or aliases) or through a group.
+// Aliases stand for the searchers they belong to, but aren't globbed.
+func expandRepoList(v, group string, names *repoNames) ([]string, []string, error) {
 	v = strings.TrimSpace(v)
+	group = strings.TrimSpace(group)
 	var repos []string
 	var vrepos []string
-	if v == "*" || v == "" {
-		for repo := range idx {
+	if v == "*" || (v == "" && group == "") {
+		for _, repo := range names.repos {
+			if !names.unlisted[repo] {
+				repos = append(repos, repo)
+			}
+		}
+		return repos, vrepos, nil
+	}
+
+	seenRepos := map[string]bool{}
+	addRepo := func(repo string) {
+		if !seenRepos[repo] {
+			seenRepos[repo] = true
 			repos = append(repos, repo)
 		}
-		return repos, vrepos
+	}
+
+	seenVRepos := map[string]bool{}
+	addVRepo := func(vrepo string) {
+		if !seenVRepos[vrepo] {
+			seenVRepos[vrepo] = true
+			vrepos = append(vrepos, vrepo)
+		}
+	}
+
+	isRepo := map[string]bool{}
+	for _, repo := range names.repos {
+		isRepo[repo] = true
+	}
+
+	for _, tag := range parseAsList(group) {
+		members, ok := names.groups[tag]
+		if !ok {
+			return nil, nil, fmt.Errorf("No such group: %s", tag)
+		}
+
+		for _, repo := range members {
+			addRepo(repo)
+		}
 	}
 
 	// if the repo doesn't exists in idx list, we enable all hidden repos
 	useHiddenRepos := false 
-	for _, repo := range strings.Split(v, ",") {
-		if idx[repo] == nil {
+	for _, repo := range parseAsList(v) {
+		if isRepoGlob(repo) {
+			matched := false
+			for _, name := range names.repos {
+				if names.unlisted[name] {
+					continue
+				}
+				if ok, _ := path.Match(repo, name); ok {
+					matched = true
+					addRepo(name)
+				}
+			}
+
+			for vrepo, owner := range names.vrepos {
+				if names.unlisted[owner] {
+					continue
+				}
+				if ok, _ := path.Match(repo, vrepo); ok {
+					matched = true
+					addVRepo(vrepo)
+					addRepo(owner)
+				}
+			}
+
+			if !matched {
+				return nil, nil, fmt.Errorf("No repos match %s", repo)
+			}
+			continue
+		}
+
+		if name, ok := names.aliases[repo]; ok && !isRepo[repo] {
+			repo = name
+		}
+
+		if !isRepo[repo] {
 			useHiddenRepos = true
 			// stiall add it into vrepos list for later 
-			vrepos = append(vrepos, repo)
+			addVRepo(repo)
+
+			// a named vrepo is searched even if its repo is unlisted
+			if owner, ok := names.vrepos[repo]; ok {
+				addRepo(owner)
+			}
 			continue 
 		}
-		repos = append(repos, repo)
+		addRepo(repo)
 	}
 
 	// add hidden repo for search 
 	if useHiddenRepos == true {
-		for repo, searcher := range idx {
-			if searcher.IsHidden() == true {
-				repos = append(repos, repo)
+		for _, repo := range names.hidden {
+			if !names.unlisted[repo] {
+				addRepo(repo)
 			}
 		}
 	}
 
 	// sort here as we need to use sortSearch 
 	sort.Strings(vrepos)
-	return repos, vrepos
+	return repos, vrepos, nil
+}
+
+// The query mode, which defaults to a single regular expression. literal is
+// the same as the literal mode, it can't be combined with any other.
+func parseMode(v string, literal bool) (string, error) {
+	if literal {
+		if v != "" && v != index.ModeLiteral {
+			return "", fmt.Errorf("Invalid mode with literal: %s", v)
+		}
+		return index.ModeLiteral, nil
+	}
+
+	switch v {
+	case "", index.ModeRegex:
+		return index.ModeRegex, nil
+	case index.ModeTerms, index.ModeLiteral:
+		return v, nil
+	}
+	return "", fmt.Errorf("Invalid mode: %s", v)
 }
 
 func parseAsUintValue(sv string, min, max, def uint) uint {
@@ -183,6 +699,62 @@ func parseAsUintValue(sv string, min, max, def uint) uint {
 	return uint(iv)
 }
 
+// Parse a point in time given either as RFC 3339 or in seconds since the
+// epoch. An empty value is the zero time.
+func parseAsTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+
+	return time.Parse(time.RFC3339Nano, v)
+}
+
+// Parse the time a search goes back to, either a point in time (see
+// parseAsTime) or how long ago it was, like 7d or 12h.
+func parseAsSince(v string, now time.Time) (time.Time, error) {
+	if t, err := parseAsTime(v); err == nil {
+		return t, nil
+	}
+
+	if days := strings.TrimSuffix(v, "d"); days != v {
+		n, err := strconv.ParseUint(days, 10, 32)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.AddDate(0, 0, -int(n)), nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-d), nil
+}
+
+// The first of repos whose file dates (see index.IndexRef.DatedSince) don't
+// go back as far as since, and how far they do go back. An empty name if
+// they all do or since is the zero time.
+func undatedRepo(since time.Time, repos []string, idx map[string]*searcher.Searcher) (string, time.Time) {
+	if since.IsZero() {
+		return "", time.Time{}
+	}
+
+	for _, repo := range repos {
+		s := idx[repo]
+		if s == nil {
+			continue
+		}
+		if from := s.IndexRef().DatedSince(); since.Before(from) {
+			return repo, from
+		}
+	}
+	return "", time.Time{}
+}
+
 func parseRangeInt(v string, i *int) {
 	*i = 0
 	if v == "" {
@@ -209,103 +781,1035 @@ func parseRangeValue(rv string) (int, int) {
 	return b, e
 }
 
-func SetSearchers(searchers map[string]*searcher.Searcher) {
-	// record it as global searchers when setup. it will be updated during hot-reloading 
-	gSearchers = searchers
-}
+// Find the searcher that holds repo, which is either the name of a searcher,
+// one of its aliases or the name of a virtual repo. For virtual repos, the
+// virtual repo is returned too.
+func findSearcher(repo string, idx map[string]*searcher.Searcher) (*searcher.Searcher, string) {
+	if s := idx[repo]; s != nil {
+		return s, ""
+	}
 
-func GetSearchers() map[string]*searcher.Searcher {
-	return gSearchers
-}
+	for _, s := range idx {
+		for _, alias := range s.Config().Aliases {
+			if alias == repo {
+				return s, ""
+			}
+		}
+	}
 
-func checkReady(w http.ResponseWriter) bool {
-	if gSearchers == nil || len(gSearchers) <= 0 {
-		writeError(w, errors.New("Server is not ready, please wait..."), http.StatusOK)
-		return false
+	for _, s := range idx {
+		if !s.HasVRepos() {
+			continue
+		}
+
+		vrepos := s.GetVRepos()
+		i := sort.SearchStrings(vrepos, repo)
+		if i < len(vrepos) && vrepos[i] == repo {
+			return s, repo
+		}
 	}
 
-	return true 
+	return nil, ""
 }
 
-func Setup(m *http.ServeMux) {
+// The files to search in each repo, by the name of its searcher. The names
+// are as in index.SearchOptions.Within.
+type fileSets map[string]map[string]bool
 
-	m.HandleFunc("/api/v1/repos", func(w http.ResponseWriter, r *http.Request) {
-		if checkReady(w) == false {
-			return
+// The files of a cached search's results, along with the repos and virtual
+// repos they're in, sorted.
+func fileSetsOf(cs *cachedSearch, idx map[string]*searcher.Searcher) (fileSets, []string, []string) {
+	names := repoNamesOf(idx)
+
+	within := fileSets{}
+	var vrepos []string
+	for name, res := range cs.results {
+		repo, prefix := name, ""
+		if idx[name] == nil {
+			owner, ok := names.vrepos[name]
+			if !ok {
+				// the repo has been removed since
+				continue
+			}
+			repo, prefix = owner, name+"/"
+			vrepos = append(vrepos, name)
 		}
 
-		res := map[string]*config.Repo{}
-		for name, searcher := range gSearchers {
-			if searcher.IsHidden() == true {
-				vrepos := searcher.GetVRepos()
-				for _, v := range vrepos {
-					res[v] = &config.Repo {
-						UrlPattern: searcher.Repo.UrlPattern,
-						Revision: searcher.GetVRepoRev(v),
-					}
+		if within[repo] == nil {
+			within[repo] = map[string]bool{}
+		}
+		for _, fm := range res.Matches {
+			within[repo][prefix+fm.Filename] = true
+		}
+	}
+
+	repos := make([]string, 0, len(within))
+	for repo := range within {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	sort.Strings(vrepos)
+
+	return within, repos, vrepos
+}
+
+// The options to search repo with, opts limited to the files of repo in sets.
+// A nil sets leaves opts as they are.
+func (sets fileSets) optionsFor(repo string, opts *index.SearchOptions) *index.SearchOptions {
+	if sets == nil {
+		return opts
+	}
+
+	o := *opts
+	o.Within = sets[repo]
+	if o.Within == nil {
+		o.Within = map[string]bool{}
+	}
+	return &o
+}
+
+// The number of files in all of the sets.
+func (sets fileSets) size() int {
+	var n int
+	for _, files := range sets {
+		n += len(files)
+	}
+	return n
+}
+
+// Return the page of list starting at offset with at most limit entries,
+// along with the offset of the next page (0 if this is the last page).
+func pageOf(list []string, offset, limit int) ([]string, int) {
+	if offset >= len(list) {
+		return []string{}, 0
+	}
+
+	end := offset + limit
+	if end >= len(list) {
+		return list[offset:], 0
+	}
+
+	return list[offset:end], end
+}
+
+// A copy of the config of s's repo that is safe to serve, the url of a repo
+// can carry the credentials that were expanded into it from the environment.
+func publicRepo(s *searcher.Searcher) *config.Repo {
+	pub := *s.Config()
+	pub.Url = vcs.ScrubUrl(pub.Url)
+	pub.Revision = s.Repo.Revision
+	return &pub
+}
+
+// The ETag of the repos response, it changes whenever a repo is added or
+// removed or its revision changes.
+func reposETag(repos map[string]*config.Repo, order []string, ordered bool) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%t\n", ordered)
+	for _, name := range order {
+		fmt.Fprintf(h, "%s\x00%s\n", name, repos[name].Revision)
+	}
+	return fmt.Sprintf("\"%x\"", h.Sum(nil))
+}
+
+// Does the If-None-Match header match the etag?
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// The time the most recent of the live indexes was built.
+func lastIndexed(idx map[string]*searcher.Searcher) time.Time {
+	var last time.Time
+	for _, s := range idx {
+		if t := s.IndexRef().Time; t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+func SetSearchers(searchers map[string]*searcher.Searcher) {
+	// record it as global searchers when setup. it will be updated during hot-reloading 
+	gSearchersLck.Lock()
+	gSearchers = searchers
+	gSearchersLck.Unlock()
+
+	if searchers != nil {
+		gReadyOnce.Do(func() {
+			close(gReady)
+		})
+	}
+}
+
+// The searchers of every repo, which must not be changed, see SetSearcher
+// and RemoveSearcher.
+func GetSearchers() map[string]*searcher.Searcher {
+	gSearchersLck.RLock()
+	defer gSearchersLck.RUnlock()
+	return gSearchers
+}
+
+// Make s the searcher of the repo name, in place of any it had.
+func SetSearcher(name string, s *searcher.Searcher) {
+	gSearchersLck.Lock()
+	defer gSearchersLck.Unlock()
+
+	next := make(map[string]*searcher.Searcher, len(gSearchers)+1)
+	for n, sr := range gSearchers {
+		next[n] = sr
+	}
+	next[name] = s
+	gSearchers = next
+}
+
+// Stop searching the repo name, the searcher itself is left running.
+func RemoveSearcher(name string) {
+	gSearchersLck.Lock()
+	defer gSearchersLck.Unlock()
+
+	next := make(map[string]*searcher.Searcher, len(gSearchers))
+	for n, sr := range gSearchers {
+		if n != name {
+			next[n] = sr
+		}
+	}
+	gSearchers = next
+}
+
+// Wait for the searchers to be set the first time, for up to the grace
+// period. Returns whether they were.
+func waitForReady() bool {
+	select {
+	case <-gReady:
+		return true
+	default:
+	}
+
+	if gReadyGracePeriod <= 0 {
+		return false
+	}
+
+	t := time.NewTimer(gReadyGracePeriod)
+	defer t.Stop()
+
+	select {
+	case <-gReady:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// Requests that come in while hound is starting wait a little for the
+// searchers rather than failing right away.
+func checkReady(w http.ResponseWriter) bool {
+	waitForReady()
+
+	if len(GetSearchers()) <= 0 {
+		writeLegacyError(w, errNotReady, errors.New("Server is not ready, please wait..."), http.StatusServiceUnavailable)
+		return false
+	}
+
+	return true 
+}
+
+// Whether r carries the admin-token of cfg, which it has to for the routes
+// that remove things. Without a token, only requests to the admin address
+// (onAdmin) are let through. See config.AdminToken.
+func checkAdminToken(w http.ResponseWriter, r *http.Request, cfg *config.Config, onAdmin bool) bool {
+	if cfg.AdminToken == "" {
+		if onAdmin {
+			return true
+		}
+		writeError(w, errForbidden,
+			errors.New("Set an admin-token or serve the admin routes on --admin-addr to allow this"),
+			http.StatusForbidden)
+		return false
+	}
+
+	tok := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if hmac.Equal([]byte(tok), []byte(cfg.AdminToken)) {
+		return true
+	}
+
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	writeError(w, errUnauthorized, errors.New("Missing or wrong admin token"), http.StatusUnauthorized)
+	return false
+}
+
+// Add the api routes to mux. The routes that change things or report on
+// hound's internals go on admin instead, unless it is nil, which keeps
+// everything on mux.
+func Setup(mux, admin *http.ServeMux, cfg *config.Config) {
+	// all api routes go through the cors and gzip handlers
+	m := http.NewServeMux()
+	mux.Handle("/api/", corsHandler(cfg.AllowedOrigins, gzipHandler(m)))
+
+	a := m
+	if admin != nil {
+		a = http.NewServeMux()
+		admin.Handle("/api/", corsHandler(cfg.AllowedOrigins, gzipHandler(a)))
+	}
+
+	gLegacyErrorStatus = cfg.LegacyErrorStatus
+	gReadyGracePeriod = time.Duration(cfg.MsReadyGracePeriod) * time.Millisecond
+	gScopes = newScopes(cfg)
+
+	setupWebhook(m, cfg)
+
+	// the configured default is still subject to the limit
+	var defaultContext uint
+	if n := cfg.LinesOfContext(); n > 0 {
+		defaultContext = uint(n)
+		if defaultContext > maxLinesOfContext {
+			defaultContext = maxLinesOfContext
+		}
+	}
+
+	var cache *searchCache
+	if cfg.SearchCacheSize > 0 {
+		cache = newSearchCache(
+			cfg.SearchCacheSize,
+			time.Duration(cfg.MsSearchCacheTtl)*time.Millisecond)
+	}
+
+	if cfg.SearchAnalyticsSize > 0 {
+		SetAnalyticsSink(newRingSink(cfg.SearchAnalyticsSize))
+	}
+
+	var limiter *rateLimiter
+	if cfg.SearchRateLimit > 0 {
+		limiter = newRateLimiter(cfg)
+	}
+
+	m.HandleFunc("/api/v1/repos", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		idx := visibleSearchers(r)
+
+		res := map[string]*config.Repo{}
+		for name, searcher := range idx {
+			if searcher.HasVRepos() == true {
+				vrepos := searcher.GetVRepos()
+				for _, v := range vrepos {
+					res[v] = &config.Repo {
+						UrlPattern: searcher.Repo.UrlPattern,
+						Revision: searcher.GetVRepoRev(v),
+					}
 				}
 			} else {
-				res[name] = searcher.Repo
+				res[name] = publicRepo(searcher)
+			}
+		}
+
+		order := make([]string, 0, len(res))
+		for name := range res {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+
+		ordered := parseAsBool(r.FormValue("ordered"))
+
+		etag := reposETag(res, order, ordered)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastIndexed(idx).UTC().Format(http.TimeFormat))
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		// the UI and the client decode the repos as a plain object, so the
+		// ordered form that also carries the sorted names is opt-in.
+		if !ordered {
+			writeResp(w, res)
+			return
+		}
+
+		writeResp(w, &struct {
+			Repos map[string]*config.Repo
+			Order []string
+		}{res, order})
+	})
+
+	m.HandleFunc("/api/v1/groups", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		groups := repoNamesOf(visibleSearchers(r)).groups
+		for _, members := range groups {
+			sort.Strings(members)
+		}
+
+		writeResp(w, groups)
+	})
+
+	// the extensions and languages of the files indexed in the repos, per
+	// repo and in total. Hidden repos are counted as a whole, so naming one
+	// of their virtual repos counts all of them.
+	m.HandleFunc("/api/v1/languages", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		idx := visibleSearchers(r)
+		if repo := forbiddenRepo(r.FormValue("repos"), idx); repo != "" {
+			writeError(w, errForbidden, fmt.Errorf("Not allowed to see repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
+		repos, _, err := parseAsRepoList(r.FormValue("repos"), r.FormValue("group"), idx)
+		if err != nil {
+			writeError(w, errNoSuchRepo, err, http.StatusNotFound)
+			return
+		}
+
+		var res struct {
+			Repos map[string]*LanguageCounts
+			*LanguageCounts
+		}
+
+		res.Repos = map[string]*LanguageCounts{}
+		res.LanguageCounts = newLanguageCounts()
+		for _, repo := range repos {
+			s := idx[repo]
+			if s == nil {
+				continue
+			}
+
+			exts := s.IndexRef().Extensions
+			res.Repos[repo] = newLanguageCounts()
+			res.Repos[repo].add(exts)
+			res.LanguageCounts.add(exts)
+		}
+
+		writeResp(w, &res)
+	})
+
+	a.HandleFunc("/api/v1/stats", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		var res struct {
+			Repos  map[string]*IndexStats
+			Files  int
+			Size   int64
+			DbPath *searcher.DiskUsage `json:",omitempty"`
+		}
+
+		res.Repos = map[string]*IndexStats{}
+		for name, searcher := range visibleSearchers(r) {
+			ref := searcher.IndexRef()
+			res.Repos[name] = &IndexStats{
+				Revision: searcher.Repo.Revision,
+				Files:    ref.Files,
+				Size:     ref.Size,
+				Built:    ref.Time,
+			}
+			if t := searcher.LastCompacted(); !t.IsZero() {
+				res.Repos[name].Compacted = &t
+			}
+			res.Files += ref.Files
+			res.Size += ref.Size
+		}
+
+		// measured periodically, as walking the dbpath can be slow. It's
+		// the whole dbpath, so only those who see everything see it.
+		if gScopes.seesAll(r) {
+			res.DbPath = searcher.LastDiskUsage()
+		}
+
+		writeResp(w, &res)
+	})
+
+	// reports on the builds under way, which includes the initial ones so
+	// it's available before hound is ready.
+	a.HandleFunc("/api/v1/builds", func(w http.ResponseWriter, r *http.Request) {
+		res := []*searcher.BuildStatus{}
+		for _, b := range searcher.Builds() {
+			if gScopes.seesRepo(r, b.Repo) {
+				res = append(res, b)
+			}
+		}
+		writeResp(w, res)
+	})
+
+	// the index directories in the dbpath and what they belong to, the
+	// ones that don't belong to anything are removed by a POST to gc.
+	a.HandleFunc("/api/v1/indexes", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		cfg.RLockRepos()
+		dirs, err := searcher.ListIndexes(cfg, GetSearchers())
+		cfg.RUnlockRepos()
+		if err != nil {
+			writeError(w, errInternal, err, http.StatusInternalServerError)
+			return
+		}
+
+		// the indexes of no repo, or of a repo the caller can't see, are
+		// left out for callers that don't see everything
+		res := []*searcher.IndexDir{}
+		for _, dir := range dirs {
+			if gScopes.seesAll(r) || dir.Repo != "" && gScopes.seesRepo(r, dir.Repo) {
+				dir.Url = vcs.ScrubUrl(dir.Url)
+				res = append(res, dir)
+			}
+		}
+		writeResp(w, res)
+	})
+
+	gc := func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		if r.Method != "POST" {
+			writeError(w, errMethodNotAllowed,
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+				http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !checkAdminToken(w, r, cfg, admin != nil) {
+			return
+		}
+
+		// the orphans aren't any namespace's
+		if !gScopes.seesAll(r) {
+			writeError(w, errForbidden, errors.New("Not allowed to remove indexes"), http.StatusForbidden)
+			return
+		}
+
+		cfg.RLockRepos()
+		removed, err := searcher.SweepOrphans(cfg, GetSearchers())
+		cfg.RUnlockRepos()
+		for _, dir := range removed {
+			logger.Info("removed orphaned index", logger.Fields{
+				"event": "gc",
+				"dir":   dir,
+			})
+		}
+		if err != nil {
+			writeError(w, errInternal, err, http.StatusInternalServerError)
+			return
+		}
+
+		if removed == nil {
+			removed = []string{}
+		}
+		writeResp(w, removed)
+	}
+
+	// removing indexes takes the admin address or the admin token, it's
+	// never open to everyone who can search (see checkAdminToken)
+	a.HandleFunc("/api/v1/indexes/gc", gc)
+
+	// whether every repo is searchable. Repos that failed to start are
+	// listed with their last error while they are retried, which makes
+	// hound degraded but still healthy as far as the status goes. So do
+	// repos whose pulls keep failing, which are polled less often.
+	a.HandleFunc("/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		var res struct {
+			Status      string
+			Repos       int
+			Failed      []*searcher.FailedRepo
+			CircuitOpen []*searcher.OpenCircuit
+		}
+
+		idx := visibleSearchers(r)
+		res.Repos = len(idx)
+
+		res.Failed = []*searcher.FailedRepo{}
+		for _, f := range searcher.Failed() {
+			if gScopes.seesRepo(r, f.Repo) {
+				res.Failed = append(res.Failed, f)
+			}
+		}
+
+		res.CircuitOpen = []*searcher.OpenCircuit{}
+		for _, s := range idx {
+			if c := s.Circuit(); c != nil {
+				res.CircuitOpen = append(res.CircuitOpen, c)
+			}
+		}
+		sort.Slice(res.CircuitOpen, func(i, j int) bool {
+			return res.CircuitOpen[i].Repo < res.CircuitOpen[j].Repo
+		})
+
+		status := http.StatusOK
+		switch {
+		case len(GetSearchers()) == 0:
+			res.Status = healthStarting
+			status = http.StatusServiceUnavailable
+		case len(res.Failed) > 0 || len(res.CircuitOpen) > 0:
+			res.Status = healthDegraded
+		default:
+			res.Status = healthOk
+		}
+
+		writeJson(w, &res, status)
+	})
+
+	// the repos that were reindexed at a new revision after since, oldest
+	// first. Polling with the time of the last change seen gets just the
+	// ones that came after it.
+	m.HandleFunc("/api/v1/changes", func(w http.ResponseWriter, r *http.Request) {
+		since, err := parseAsTime(r.FormValue("since"))
+		if err != nil {
+			writeError(w, errInvalidParam, fmt.Errorf("Invalid since: %s", r.FormValue("since")), http.StatusBadRequest)
+			return
+		}
+
+		var res struct {
+			Changes []*searcher.Change
+		}
+		idx := visibleSearchers(r)
+		res.Changes = []*searcher.Change{}
+		for _, c := range searcher.Changes(since) {
+			if idx[c.Repo] != nil {
+				res.Changes = append(res.Changes, c)
+			}
+		}
+
+		writeResp(w, &res)
+	})
+
+	// which build is running, also available before hound is ready.
+	m.HandleFunc("/api/v1/version", func(w http.ResponseWriter, r *http.Request) {
+		writeResp(w, version.Get())
+	})
+
+	search := func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		startedAt := time.Now()
+
+		var opt index.SearchOptions
+
+		idx := visibleSearchers(r)
+		if repo := forbiddenRepo(r.FormValue("repos"), idx); repo != "" {
+			writeLegacyError(w, errForbidden, fmt.Errorf("Not allowed to search repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
+		stats := parseAsBool(r.FormValue("stats"))
+		repos, vrepos, err := parseAsRepoList(r.FormValue("repos"), r.FormValue("group"), idx)
+		if err != nil {
+			writeLegacyError(w, errNoSuchRepo, err, http.StatusNotFound)
+			return
+		}
+
+		// a search within the results of an earlier one searches only the
+		// files they matched in, wherever the repos param points
+		var within fileSets
+		withinId := r.FormValue("within")
+		if withinId != "" {
+			cs := cache.getById(withinId)
+			if cs == nil {
+				writeLegacyError(w, errNoSuchResult,
+					fmt.Errorf("No results %s, they may have expired", withinId),
+					http.StatusNotFound)
+				return
+			}
+			within, repos, vrepos = fileSetsOf(cs, idx)
+		}
+
+		// an older revision is searched in a single repo only
+		rev := r.FormValue("rev")
+		if rev != "" && within != nil {
+			writeLegacyError(w, errInvalidParam,
+				errors.New("rev can't be used when searching within results"),
+				http.StatusBadRequest)
+			return
+		}
+		if rev != "" && (len(repos) != 1 || len(vrepos) > 0) {
+			writeLegacyError(w, errInvalidParam,
+				errors.New("rev can only be used when searching a single repo"),
+				http.StatusBadRequest)
+			return
+		}
+
+		// a search of a range of revisions, like the commits of a pull
+		// request, searches only the files they changed
+		diff := r.FormValue("diff")
+		if diff != "" {
+			if within != nil || rev != "" {
+				writeLegacyError(w, errInvalidParam,
+					errors.New("diff can't be used along with within or rev"),
+					http.StatusBadRequest)
+				return
+			}
+			if len(repos) != 1 || len(vrepos) > 0 {
+				writeLegacyError(w, errInvalidParam,
+					errors.New("diff can only be used when searching a single repo"),
+					http.StatusBadRequest)
+				return
+			}
+
+			files, err := idx[repos[0]].DiffFiles(diff)
+			if _, ok := err.(*vcs.RangeError); ok || err == searcher.ErrDiffNotSupported {
+				writeLegacyError(w, errInvalidParam,
+					fmt.Errorf("Can't search the files changed by %s: %s", diff, err),
+					http.StatusBadRequest)
+				return
+			} else if err == searcher.ErrTooManyDiffs {
+				writeLegacyError(w, errRateLimited, err, http.StatusTooManyRequests)
+				return
+			} else if err != nil {
+				logger.Warn("diff failed", logger.Fields{
+					"event": "search",
+					"repo":  repos[0],
+					"diff":  diff,
+					"error": err,
+				})
+				writeLegacyError(w, errSearchFailed,
+					fmt.Errorf("Can't search the files changed by %s: %s", diff, err),
+					http.StatusInternalServerError)
+				return
+			}
+
+			within = fileSets{repos[0]: map[string]bool{}}
+			for _, file := range files {
+				within[repos[0]][file] = true
+			}
+		}
+
+		query := r.FormValue("q")
+		if cfg.MaxQueryLength > 0 && len(query) > cfg.MaxQueryLength {
+			writeLegacyError(w, errQueryTooLong,
+				fmt.Errorf("Query is %d bytes long, the most allowed is %d", len(query), cfg.MaxQueryLength),
+				http.StatusBadRequest)
+			return
+		}
+
+		opt.Offset, opt.Limit = parseRangeValue(r.FormValue("rng"))
+		opt.FileRegexp = r.FormValue("files")
+		ignoreCase := parseAsOptionalBool(r.Form, "i")
+		if ignoreCase != nil {
+			opt.IgnoreCase = *ignoreCase
+		}
+		opt.Languages = parseAsList(r.FormValue("lang"))
+		opt.CountOnly = parseAsBool(r.FormValue("countOnly"))
+		opt.MaxMatchesPerFile = int(parseAsUintValue(
+			r.FormValue("maxMatchesPerFile"),
+			1,
+			maxMatchesPerFile,
+			maxMatchesPerFile))
+		opt.Blame = parseAsBool(r.FormValue("blame"))
+		opt.MaxLineLength = int(parseAsUintValue(r.FormValue("maxLineLength"), 0, 0, 0))
+		opt.CodeOnly = parseAsBool(r.FormValue("codeOnly"))
+		opt.CaseRank = parseAsBool(r.FormValue("caseRank"))
+
+		// the cache key holds the time, so a relative since is only
+		// precise to the minute for the cache to be of any use
+		if opt.Since, err = parseAsSince(r.FormValue("since"), startedAt.Truncate(time.Minute)); err != nil {
+			writeLegacyError(w, errInvalidParam, fmt.Errorf("Invalid since: %s", r.FormValue("since")), http.StatusBadRequest)
+			return
+		}
+
+		// a repo that only keeps some of its history can't tell the
+		// files changed before then from the ones that weren't
+		if repo, from := undatedRepo(opt.Since, repos, idx); repo != "" {
+			writeLegacyError(w, errInvalidParam,
+				fmt.Errorf("Repo %s only knows the files changed since %s, search a shorter time back",
+					repo, from.UTC().Format(time.RFC3339)),
+				http.StatusBadRequest)
+			return
+		}
+
+		opt.Sort = r.FormValue("sort")
+		if opt.Sort == "" {
+			opt.Sort = index.SortByScore
+		} else if opt.Sort != index.SortByScore && opt.Sort != index.SortByPath {
+			writeLegacyError(w, errInvalidParam, fmt.Errorf("Invalid sort: %s", opt.Sort), http.StatusBadRequest)
+			return
+		}
+
+		if opt.Mode, err = parseMode(r.FormValue("mode"), parseAsBool(r.FormValue("literal"))); err != nil {
+			writeLegacyError(w, errInvalidParam, err, http.StatusBadRequest)
+			return
+		}
+
+		// ctx sets the context on both sides of a match, ctxBefore and
+		// ctxAfter take precedence over it for their own side.
+		linesOfContext := parseAsUintValue(
+			r.FormValue("ctx"),
+			0,
+			maxLinesOfContext,
+			defaultContext)
+		opt.LinesBefore = parseAsUintValue(
+			r.FormValue("ctxBefore"),
+			0,
+			maxLinesOfContext,
+			linesOfContext)
+		opt.LinesAfter = parseAsUintValue(
+			r.FormValue("ctxAfter"),
+			0,
+			maxLinesOfContext,
+			linesOfContext)
+
+		// opt.Limit must not be too large if repo is more than one 
+		if len(repos) > 1 {
+			opt.Limit = defaultFilesOpened
+		}
+
+		// path:, -path:, file: and lang: in the query are filters
+		parsed, err := index.ParseQuery(query)
+		if err != nil {
+			writeLegacyError(w, errInvalidQuery, err, http.StatusBadRequest)
+			return
+		}
+
+		if err := parsed.Apply(&opt); err != nil {
+			writeLegacyError(w, errInvalidQuery, err, http.StatusBadRequest)
+			return
+		}
+
+		query = parsed.Pattern
+		if len(query) <= 0 {
+			writeLegacyError(w, errEmptyQuery, errors.New("No query"), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := index.ExtensionsFor(opt.Languages); err != nil {
+			writeLegacyError(w, errInvalidParam, err, http.StatusBadRequest)
+			return
+		}
+
+		// so that only the searches themselves fail with search_failed
+		if err := index.ValidateQuery(query, &opt); err != nil {
+			writeLegacyError(w, errInvalidQuery, err, http.StatusBadRequest)
+			return
+		}
+
+		// a pattern too short for the trigram index would open every
+		// file, unless the search is narrowed down to some paths
+		if opt.FileRegexp == "" && utf8.RuneCountInString(query) < cfg.MinQueryLength {
+			if cfg.ShortQueries != config.ShortQueriesLimit {
+				writeLegacyError(w, errQueryTooShort,
+					fmt.Errorf("Query is %d characters long, at least %d are needed to search the index. Add more characters or a path: to narrow it down",
+						utf8.RuneCountInString(query), cfg.MinQueryLength),
+					http.StatusBadRequest)
+				return
+			}
+			opt.MaxFilesOpened = maxFilesOpenedPerRepo(cfg.ShortQueryMaxFiles, len(repos))
+		}
+
+		// the regexps can't backtrack, so how long a search takes comes
+		// down to how many files it has to open, which are never more than
+		// the ones it's searching within or the most it may open
+		if cfg.MaxCandidateFiles > 0 && opt.MaxFilesOpened == 0 &&
+			(within == nil || within.size() > cfg.MaxCandidateFiles) {
+			n, err := countCandidates(query, &opt, ignoreCase, repos, idx)
+			if err != nil {
+				writeLegacyError(w, errInvalidQuery, err, http.StatusBadRequest)
+				return
+			}
+
+			if n > cfg.MaxCandidateFiles {
+				writeLegacyError(w, errQueryTooBroad,
+					fmt.Errorf("Query would search %d files, the most allowed is %d. Use a longer literal string, path: or fewer repos to narrow it down",
+						n, cfg.MaxCandidateFiles),
+					http.StatusBadRequest)
+				return
+			}
+		}
+
+		dedupe := parseAsBool(r.FormValue("dedupe"))
+
+		// what isn't part of the response isn't collected either
+		fields, err := parseFields(r.FormValue("fields"))
+		if err != nil {
+			writeLegacyError(w, errInvalidParam, err, http.StatusBadRequest)
+			return
+		}
+		if fields == nil && opt.CountOnly {
+			fields = countOnlyFields
+		}
+		fields.limit(&opt, dedupe)
+		if fields != nil && fields.stats {
+			stats = true
+		}
+
+		// streamed results are never cached, that would mean holding on
+		// to all of them
+		if parseAsBool(r.FormValue("stream")) {
+			searchStats := &Stats{Languages: map[string]int{}}
+			streamSearch(w, query, &opt, ignoreCase, repos, vrepos, within, rev, dedupe, fields, idx, searchStats)
+			recordSearch(r, cfg, opt.IgnoreCase, startedAt, searchStats.FilesWithMatch)
+			return
+		}
+
+		key := searchCacheKey(query, &opt, ignoreCase, repos, vrepos, dedupe, withinId, idx)
+
+		var results map[string]*index.SearchResponse
+		var searchStats *Stats
+
+		// the results for rev are only cached along with its index, and the
+		// ends of a diff can move without the index changing
+		var cs *cachedSearch
+		if rev == "" && diff == "" {
+			cs = cache.get(key)
+		}
+
+		if cs != nil {
+			results = cs.results
+			searchStats = cs.stats
+		} else {
+			searchStats = &Stats{Languages: map[string]int{}}
+			if rev == "" {
+				results, err = searchAll(query, &opt, ignoreCase, repos, vrepos, within, dedupe, idx, searchStats)
+			} else {
+				results, err = searchRev(query, &opt, ignoreCase, repos[0], rev, idx, searchStats)
 			}
+
+			if err != nil {
+				code, status := searchErrorCode(err)
+				if code == errSearchFailed {
+					logger.Warn("search failed", logger.Fields{
+						"event": "search",
+						"query": query,
+						"error": err,
+					})
+				}
+				writeLegacyError(w, code, err, status)
+				return
+			}
+
+			if rev == "" && diff == "" {
+				cs = &cachedSearch{
+					key:         key,
+					results:     results,
+					stats:       searchStats,
+				}
+				cache.put(cs)
+			}
+		}
+
+		logger.Debug("search", logger.Fields{
+			"event":       "search",
+			"query":       query,
+			"repos":       len(repos),
+			"filesOpened": searchStats.FilesOpened,
+			"durationMs":  searchStats.Duration,
+		})
+
+		var res struct {
+			Results interface{}
+			Stats   *Stats `json:",omitempty"`
+
+			// Pass as within to search the files of these results, only
+			// set when they're cached.
+			ResultId string `json:",omitempty"`
+
+			// How a search that found nothing could find something, only
+			// with hints=true.
+			Hints []*searchHint `json:",omitempty"`
 		}
 
-		writeResp(w, res)
-	})
+		res.Results = fields.projectAll(results)
+		if stats {
+			res.Stats = searchStats
+		}
+		if cs != nil {
+			res.ResultId = cs.id
+		}
+
+		var matched int
+		for _, sr := range results {
+			matched += sr.FilesWithMatch
+		}
+		recordSearch(r, cfg, opt.IgnoreCase, startedAt, matched)
+
+		// the hints take searches of their own, which a search that found
+		// something never waits on
+		if matched == 0 && rev == "" && parseAsBool(r.FormValue("hints")) {
+			res.Hints = hintsFor(query, &opt, ignoreCase, repos, vrepos, within, idx)
+		}
+
+		writeResp(w, &res)
+	}
 
-	m.HandleFunc("/api/v1/search", func(w http.ResponseWriter, r *http.Request) {
+	m.HandleFunc("/api/v1/search", limitRate(limiter, search))
+
+	// every query of a batch goes through the rate limit on its own, and
+	// the batches share the slots. A search holds its slot until it really
+	// is done, even after its query timed out.
+	batchSlots := make(chan bool, cfg.BatchSlots())
+	m.HandleFunc("/api/v1/search/batch", batchSearch(limitRate(limiter, search), batchSlots))
+
+	m.HandleFunc("/api/v1/explain", func(w http.ResponseWriter, r *http.Request) {
 		if checkReady(w) == false {
 			return
 		}
 
 		var opt index.SearchOptions
 
-		stats := parseAsBool(r.FormValue("stats"))
-		repos, vrepos := parseAsRepoList(r.FormValue("repos"), gSearchers)
-		query := r.FormValue("q")
-		opt.Offset, opt.Limit = parseRangeValue(r.FormValue("rng"))
-		opt.FileRegexp = r.FormValue("files")
-		opt.IgnoreCase = parseAsBool(r.FormValue("i"))
-		opt.LinesOfContext = parseAsUintValue(
-			r.FormValue("ctx"),
-			0,
-			maxLinesOfContext,
-			defaultLinesOfContext)
+		idx := visibleSearchers(r)
+		if repo := forbiddenRepo(r.FormValue("repos"), idx); repo != "" {
+			writeLegacyError(w, errForbidden, fmt.Errorf("Not allowed to search repository: %s", repo), http.StatusForbidden)
+			return
+		}
 
-		// opt.Limit must not be too large if repo is more than one 
-		if len(repos) > 1 {
-			opt.Limit = defaultFilesOpened
+		repos, _, err := parseAsRepoList(r.FormValue("repos"), r.FormValue("group"), idx)
+		if err != nil {
+			writeLegacyError(w, errNoSuchRepo, err, http.StatusNotFound)
+			return
+		}
+
+		// only the pattern matters here, the filters don't change the plan
+		parsed, err := index.ParseQuery(r.FormValue("q"))
+		if err != nil {
+			writeLegacyError(w, errInvalidQuery, err, http.StatusBadRequest)
+			return
 		}
 
-		query = strings.TrimSpace(query)
+		query := parsed.Pattern
 		if len(query) <= 0 {
-			writeError(w, errors.New("No query"), http.StatusOK)
+			writeLegacyError(w, errEmptyQuery, errors.New("No query"), http.StatusBadRequest)
 			return
 		}
 
-		var filesOpened int
-		var durationMs int
+		ignoreCase := parseAsOptionalBool(r.Form, "i")
+		if ignoreCase != nil {
+			opt.IgnoreCase = *ignoreCase
+		}
 
-		results, err := searchAll(query, &opt, repos, vrepos, gSearchers, &filesOpened, &durationMs)
-		if err != nil {
-			// TODO(knorton): Return ok status because the UI expects it for now.
-			writeError(w, err, http.StatusOK)
+		if opt.Mode, err = parseMode(r.FormValue("mode"), parseAsBool(r.FormValue("literal"))); err != nil {
+			writeLegacyError(w, errInvalidParam, err, http.StatusBadRequest)
 			return
 		}
 
-		var res struct {
-			Results map[string]*index.SearchResponse
-			Stats   *Stats `json:",omitempty"`
-		}
+		res := map[string]*index.Explanation{}
+		for _, repo := range repos {
+			s := idx[repo]
+			if s == nil {
+				continue
+			}
 
-		res.Results = results
-		if stats {
-			res.Stats = &Stats{
-				FilesOpened: filesOpened,
-				Duration:    durationMs,
+			exp, err := s.Explain(query, optionsFor(&opt, s, ignoreCase))
+			if err != nil {
+				writeLegacyError(w, errInvalidQuery, err, http.StatusBadRequest)
+				return
 			}
+			res[repo] = exp
 		}
 
-		writeResp(w, &res)
+		writeResp(w, res)
 	})
 
 	m.HandleFunc("/api/v1/excludes", func(w http.ResponseWriter, r *http.Request) {
@@ -313,54 +1817,400 @@ func Setup(m *http.ServeMux) {
 			return
 		}
 
-		repo := r.FormValue("repo")
+		idx := visibleSearchers(r)
+		if repo := forbiddenRepo(r.FormValue("repo"), idx); repo != "" {
+			writeError(w, errForbidden, fmt.Errorf("Not allowed to see repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
 		res := "[]"
+		if s, vrepo := findSearcher(r.FormValue("repo"), idx); s != nil {
+			var err error
+			if res, err = s.GetExcludedFiles(vrepo); err != nil {
+				logger.Warn("couldn't read excluded files", logger.Fields{
+					"repo":  r.FormValue("repo"),
+					"error": err,
+				})
 
-		if gSearchers[repo] == nil {
-			for _, searcher := range gSearchers {
-				if searcher.IsHidden() == true {
-					vrepos := searcher.GetVRepos()
-					i := sort.SearchStrings(vrepos, repo)
-					if i < len(vrepos) && vrepos[i] == repo {
-						res = searcher.GetExcludedFiles(repo)
-						break
-					}
+				// the index may have just been swapped out from under us
+				code, status := errInternal, http.StatusInternalServerError
+				if os.IsNotExist(err) {
+					code, status = errNotReady, http.StatusServiceUnavailable
 				}
+				writeError(w, code, err, status)
+				return
 			}
-		} else {
-			res = gSearchers[repo].GetExcludedFiles("")
 		}
 
 		w.Header().Set("Content-Type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow", "*")
 		fmt.Fprint(w, res)
 	})
 
-	m.HandleFunc("/api/v1/update", func(w http.ResponseWriter, r *http.Request) {
+	m.HandleFunc("/api/v1/file", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		repo := r.FormValue("repo")
+		name := filepath.FromSlash(r.FormValue("path"))
+		if !index.IsValidPath(name) {
+			writeError(w, errInvalidParam, fmt.Errorf("Invalid path: %s", r.FormValue("path")), http.StatusBadRequest)
+			return
+		}
+
+		idx := visibleSearchers(r)
+		if forbiddenRepo(repo, idx) != "" {
+			writeError(w, errForbidden, fmt.Errorf("Not allowed to see repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
+		s, vrepo := findSearcher(repo, idx)
+		if s == nil {
+			writeError(w, errNoSuchRepo, fmt.Errorf("No such repository: %s", repo), http.StatusNotFound)
+			return
+		}
+
+		f, rev, err := s.OpenFile(vrepo, r.FormValue("branch"), name)
+		if os.IsNotExist(err) {
+			writeError(w, errNoSuchFile, fmt.Errorf("No such file: %s", r.FormValue("path")), http.StatusNotFound)
+			return
+		} else if err != nil {
+			writeError(w, errInvalidParam, err, http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+
+		// rng is the older name for lines
+		lines := r.FormValue("lines")
+		if lines == "" {
+			lines = r.FormValue("rng")
+		}
+
+		writeFile(w, r, f, name, rev, lines, parseAsBool(r.FormValue("original")))
+	})
+
+	// the function or class around a line of a file, for more context than
+	// the lines around a match
+	m.HandleFunc("/api/v1/block", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		repo := r.FormValue("repo")
+		name := filepath.FromSlash(r.FormValue("path"))
+		if !index.IsValidPath(name) {
+			writeError(w, errInvalidParam, fmt.Errorf("Invalid path: %s", r.FormValue("path")), http.StatusBadRequest)
+			return
+		}
+
+		line, err := strconv.Atoi(r.FormValue("line"))
+		if err != nil || line < 1 {
+			writeError(w, errInvalidParam, fmt.Errorf("Invalid line: %s", r.FormValue("line")), http.StatusBadRequest)
+			return
+		}
+
+		idx := visibleSearchers(r)
+		if forbiddenRepo(repo, idx) != "" {
+			writeError(w, errForbidden, fmt.Errorf("Not allowed to see repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
+		s, vrepo := findSearcher(repo, idx)
+		if s == nil {
+			writeError(w, errNoSuchRepo, fmt.Errorf("No such repository: %s", repo), http.StatusNotFound)
+			return
+		}
+
+		f, rev, err := s.OpenFile(vrepo, r.FormValue("branch"), name)
+		if os.IsNotExist(err) {
+			writeError(w, errNoSuchFile, fmt.Errorf("No such file: %s", r.FormValue("path")), http.StatusNotFound)
+			return
+		} else if err != nil {
+			writeError(w, errInvalidParam, err, http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			writeError(w, errInternal, err, http.StatusInternalServerError)
+			return
+		}
+
+		res, err := blockAround(name, b, line)
+		if err != nil {
+			writeError(w, errInvalidRange, err, http.StatusBadRequest)
+			return
+		}
+		res.Repo = repo
+		res.Path = filepath.ToSlash(name)
+		res.Rev = rev
+
+		writeResp(w, res)
+	})
+
+	m.HandleFunc("/api/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		repo := r.FormValue("repo")
+		idx := visibleSearchers(r)
+		if forbiddenRepo(repo, idx) != "" {
+			writeError(w, errForbidden, fmt.Errorf("Not allowed to see repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
+		s, vrepo := findSearcher(repo, idx)
+		if s == nil {
+			writeError(w, errNoSuchRepo, fmt.Errorf("No such repository: %s", repo), http.StatusNotFound)
+			return
+		}
+
+		files, err := s.Files(vrepo, r.FormValue("branch"), filepath.FromSlash(r.FormValue("prefix")))
+		if err != nil {
+			writeError(w, errInvalidParam, err, http.StatusBadRequest)
+			return
+		}
+
+		// the list is paged with rng=offset:limit
+		offset, limit := parseRangeValue(r.FormValue("rng"))
+		if limit <= 0 {
+			limit = defaultFilesPageSize
+		} else if limit > maxFilesPageSize {
+			limit = maxFilesPageSize
+		}
+
+		var res struct {
+			Files []string
+			Total int
+			Next  int `json:",omitempty"`
+		}
+
+		res.Files, res.Next = pageOf(files, offset, limit)
+		res.Total = len(files)
+		for i, file := range res.Files {
+			res.Files[i] = filepath.ToSlash(file)
+		}
+
+		writeResp(w, &res)
+	})
+
+	// quick-open style file finder, q is matched fuzzily against the paths
+	// of the files in the repos.
+	m.HandleFunc("/api/v1/find", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		query := strings.TrimSpace(r.FormValue("q"))
+		if len(query) <= 0 {
+			writeError(w, errEmptyQuery, errors.New("No query"), http.StatusBadRequest)
+			return
+		}
+
+		idx := visibleSearchers(r)
+		if repo := forbiddenRepo(r.FormValue("repos"), idx); repo != "" {
+			writeError(w, errForbidden, fmt.Errorf("Not allowed to see repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
+		repos, vrepos, err := parseAsRepoList(r.FormValue("repos"), r.FormValue("group"), idx)
+		if err != nil {
+			writeError(w, errNoSuchRepo, err, http.StatusNotFound)
+			return
+		}
+
+		limit := int(parseAsUintValue(r.FormValue("limit"), 1, maxFindLimit, defaultFindLimit))
+
+		var files []*index.FoundFile
+		for _, repo := range repos {
+			s := idx[repo]
+			if s == nil {
+				continue
+			}
+
+			for _, f := range s.Find(query, limit, vrepos) {
+				if f.Repo == "" {
+					f.Repo = repo
+				}
+				f.Path = filepath.ToSlash(f.Path)
+				files = append(files, f)
+			}
+		}
+
+		index.SortFoundFiles(files)
+		if len(files) > limit {
+			files = files[:limit]
+		}
+
+		var res struct {
+			Files []*index.FoundFile
+		}
+		res.Files = files
+		if res.Files == nil {
+			res.Files = []*index.FoundFile{}
+		}
+
+		writeResp(w, &res)
+	})
+
+	// The tokens that start with q (in any case) in the repos that index
+	// them, those found in the most files across the repos first.
+	m.HandleFunc("/api/v1/suggest", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		prefix := strings.TrimSpace(r.FormValue("q"))
+		if len(prefix) <= 0 {
+			writeError(w, errEmptyQuery, errors.New("No query"), http.StatusBadRequest)
+			return
+		}
+
+		idx := visibleSearchers(r)
+		if repo := forbiddenRepo(r.FormValue("repos"), idx); repo != "" {
+			writeError(w, errForbidden, fmt.Errorf("Not allowed to see repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
+		repos, _, err := parseAsRepoList(r.FormValue("repos"), r.FormValue("group"), idx)
+		if err != nil {
+			writeError(w, errNoSuchRepo, err, http.StatusNotFound)
+			return
+		}
+
+		limit := int(parseAsUintValue(r.FormValue("limit"), 1, maxSuggestLimit, defaultSuggestLimit))
+
+		writeResp(w, &struct {
+			Suggestions []*index.Suggestion
+		}{suggestAll(prefix, limit, repos, idx)})
+	})
+
+	// the queries searched for most often within the window (a duration,
+	// 24h by default), when search analytics are turned on.
+	a.HandleFunc("/api/v1/analytics/top", func(w http.ResponseWriter, r *http.Request) {
+		sink := gAnalytics
+		if sink == nil {
+			writeError(w, errNotEnabled, errors.New("Search analytics are not enabled"), http.StatusNotFound)
+			return
+		}
+
+		window := defaultTopWindow
+		if v := r.FormValue("window"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				writeError(w, errInvalidParam, fmt.Errorf("Invalid window: %s", v), http.StatusBadRequest)
+				return
+			}
+			window = d
+		}
+
+		n := int(parseAsUintValue(r.FormValue("n"), 1, maxTopQueries, defaultTopQueries))
+
+		var res struct {
+			Queries []*QueryCount
+		}
+		// with scopes, callers only see their own queries, and those
+		// without an identity can't be told apart so they see none
+		res.Queries = []*QueryCount{}
+		if who := gScopes.callerOf(r); who == nil || *who != "" {
+			res.Queries = sink.Top(time.Now().Add(-window), n, who)
+		}
+
+		writeResp(w, &res)
+	})
+
+	// previews what reloading with the posted config would do to the repos
+	a.HandleFunc("/api/v1/config/diff", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			writeError(w, errMethodNotAllowed,
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+				http.StatusMethodNotAllowed)
+			return
+		}
+
+		b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigSize))
+		if err != nil {
+			writeError(w, errInvalidBody, err, http.StatusBadRequest)
+			return
+		}
+
+		// the diff names the repos of every namespace
+		if !gScopes.seesAll(r) {
+			writeError(w, errForbidden, errors.New("Not allowed to see the config"), http.StatusForbidden)
+			return
+		}
+
+		var next config.Config
+		asYaml := strings.Contains(r.Header.Get("Content-Type"), "yaml")
+		if err := next.LoadFromBytes(b, asYaml); err != nil {
+			writeError(w, errInvalidConfig, err, http.StatusBadRequest)
+			return
+		}
+
+		// a reload may be changing the repos at the same time
+		cfg.RLockRepos()
+		diff := cfg.Diff(&next)
+		cfg.RUnlockRepos()
+
+		writeResp(w, diff)
+	})
+
+	a.HandleFunc("/api/v1/update", func(w http.ResponseWriter, r *http.Request) {
 		if checkReady(w) == false {
 			return
 		}
 
 		if r.Method != "POST" {
-			writeError(w,
+			writeError(w, errMethodNotAllowed,
 				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
 				http.StatusMethodNotAllowed)
 			return
 		}
 
-		repos, _ := parseAsRepoList(r.FormValue("repos"), gSearchers)
+		idx := visibleSearchers(r)
+		if repo := forbiddenRepo(r.FormValue("repos"), idx); repo != "" {
+			writeError(w, errForbidden, fmt.Errorf("Not allowed to update repository: %s", repo), http.StatusForbidden)
+			return
+		}
+
+		repos, _, err := parseAsRepoList(r.FormValue("repos"), r.FormValue("group"), idx)
+		if err != nil {
+			writeError(w, errNoSuchRepo, err, http.StatusNotFound)
+			return
+		}
+
+		wait := parseAsBool(r.FormValue("wait"))
+		timeout := time.Duration(parseAsUintValue(
+			r.FormValue("msTimeout"),
+			1,
+			maxUpdateTimeout,
+			defaultUpdateTimeout)) * time.Millisecond
 
+		done := map[string]<-chan *searcher.UpdateResult{}
 		for _, repo := range repos {
-			searcher := gSearchers[repo]
-			if searcher == nil {
-				writeError(w,
+			s := idx[repo]
+			if s == nil {
+				writeError(w, errNoSuchRepo,
 					fmt.Errorf("No such repository: %s", repo),
 					http.StatusNotFound)
 				return
 			}
 
-			if !searcher.Update() {
-				writeError(w,
+			var ok bool
+			if wait {
+				done[repo], ok = s.UpdateAndNotify()
+			} else {
+				ok = s.Update()
+			}
+
+			if !ok {
+				logger.Warn("update rejected", logger.Fields{
+					"event": "update",
+					"repo":  repo,
+				})
+				writeError(w, errNotEnabled,
 					fmt.Errorf("Push updates are not enabled for repository %s", repo),
 					http.StatusForbidden)
 				return
@@ -368,6 +2218,107 @@ func Setup(m *http.ServeMux) {
 			}
 		}
 
-		writeResp(w, "ok")
+		if !wait {
+			writeResp(w, "ok")
+			return
+		}
+
+		// the server's write timeout is usually shorter than the wait
+		extendWriteDeadline(w, timeout)
+
+		res, err := waitForUpdates(done, timeout)
+		if err != nil {
+			writeError(w, errTimeout, err, http.StatusGatewayTimeout)
+			return
+		}
+
+		writeResp(w, res)
 	})
 }
+
+// Give the response to a request that waits for up to timeout, which can be
+// longer than the server's write timeout, until then to be written.
+func extendWriteDeadline(w http.ResponseWriter, timeout time.Duration) {
+	deadline := time.Now().Add(timeout + writeDeadlineSlack)
+	if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+		logger.Warn("failed to extend the write deadline", logger.Fields{
+			"error": err,
+		})
+	}
+}
+
+// The lines of a file around one of them, see /api/v1/block.
+type blockResponse struct {
+	Repo string
+	Path string
+	Rev  string
+
+	// The first and last lines, counting from 1. Block is false when the
+	// lines are just a window around the line, since the language isn't
+	// known or the line isn't in a function or class.
+	Start int
+	End   int
+	Block bool
+	Lines []string
+}
+
+// The function or class around line of the file name, whose contents are
+// buf, or else blockWindow lines either side of it.
+func blockAround(name string, buf []byte, line int) (*blockResponse, error) {
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	if line > len(lines) {
+		return nil, fmt.Errorf("Line %d is past the end of the file, which has %d lines", line, len(lines))
+	}
+
+	start, end, ok := index.EnclosingBlock(name, buf, line)
+	if !ok {
+		start = line - blockWindow
+		if start < 1 {
+			start = 1
+		}
+
+		end = line + blockWindow
+		if end > len(lines) {
+			end = len(lines)
+		}
+	}
+
+	return &blockResponse{
+		Start: start,
+		End:   end,
+		Block: ok,
+		Lines: lines[start-1 : end],
+	}, nil
+}
+
+// The result of an update that was waited on, see /api/v1/update.
+type updateResult struct {
+	Revision string
+	Changed  bool
+	Error    string `json:",omitempty"`
+}
+
+// Wait for the updates of the repos to finish, giving up on all of them if
+// they aren't done within timeout.
+func waitForUpdates(
+	done map[string]<-chan *searcher.UpdateResult,
+	timeout time.Duration) (map[string]*updateResult, error) {
+
+	deadline := time.After(timeout)
+	res := map[string]*updateResult{}
+	for repo, ch := range done {
+		select {
+		case u := <-ch:
+			res[repo] = &updateResult{
+				Revision: u.Revision,
+				Changed:  u.Changed,
+			}
+			if u.Err != nil {
+				res[repo].Error = u.Err.Error()
+			}
+		case <-deadline:
+			return nil, fmt.Errorf("The update of %s did not finish within %s", repo, timeout)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// the most queries a single batch can have
+	maxBatchSize int = 50
+
+	// the largest body that can be posted to /api/v1/search/batch
+	maxBatchBodySize int64 = 1 << 20
+
+	minBatchTimeout     uint = 1000
+	defaultBatchTimeout uint = 30 * 1000
+	maxBatchTimeout     uint = 5 * 60 * 1000
+)
+
+// The result of one query of a batch, which is what /api/v1/search responded
+// with to it.
+type batchResult struct {
+	Status   int
+	Response json.RawMessage
+}
+
+// Keeps the response to a query of a batch in memory.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{
+		header: http.Header{},
+		status: http.StatusOK,
+	}
+}
+
+func (b *batchRecorder) Header() http.Header {
+	return b.header
+}
+
+func (b *batchRecorder) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *batchRecorder) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// Converts a query of a batch, which has the parameters of /api/v1/search as
+// its keys, to a query string.
+func batchQueryValues(q map[string]interface{}) (url.Values, error) {
+	vals := url.Values{}
+	for k, v := range q {
+		switch v := v.(type) {
+		case string:
+			vals.Set(k, v)
+		case json.Number:
+			vals.Set(k, v.String())
+		case bool:
+			vals.Set(k, fmt.Sprint(v))
+		case nil:
+		default:
+			return nil, fmt.Errorf("Invalid value for %s, expected a string, number or bool", k)
+		}
+	}
+
+	// a batch responds with every result at once
+	vals.Del("stream")
+	return vals, nil
+}
+
+// Run a single query of a batch through search once one of slots, which are
+// shared by every batch (see config.Config.BatchConcurrency), is free,
+// giving up on it after timeout, which counts the wait for the slot. The
+// search itself can't be stopped, so one that takes too long finishes in
+// the background and only then frees its slot.
+func runBatchQuery(
+	search http.HandlerFunc,
+	slots chan bool,
+	r *http.Request,
+	vals url.Values,
+	timeout time.Duration) *batchResult {
+
+	req, err := http.NewRequest("GET", "/api/v1/search?"+vals.Encode(), nil)
+	if err != nil {
+		return batchError(errInvalidParam, err, http.StatusBadRequest)
+	}
+
+	// the query is searched as whoever made the batch, see identityOf
+	req = req.WithContext(r.Context())
+	req.RemoteAddr = r.RemoteAddr
+	req.Header = r.Header
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- true:
+	case <-timer.C:
+		return batchTimeout(timeout)
+	}
+
+	done := make(chan *batchRecorder, 1)
+	go func() {
+		defer func() { <-slots }()
+		rec := newBatchRecorder()
+		search(rec, req)
+		done <- rec
+	}()
+
+	select {
+	case rec := <-done:
+		body := bytes.TrimSpace(rec.body.Bytes())
+		if len(body) == 0 {
+			body = []byte("null")
+		}
+		return &batchResult{
+			Status:   rec.status,
+			Response: json.RawMessage(body),
+		}
+	case <-timer.C:
+		return batchTimeout(timeout)
+	}
+}
+
+func batchTimeout(timeout time.Duration) *batchResult {
+	return batchError(errTimeout,
+		fmt.Errorf("The search did not finish within %s", timeout),
+		http.StatusGatewayTimeout)
+}
+
+func batchError(code string, err error, status int) *batchResult {
+	b, _ := json.Marshal(&errorResponse{
+		Error: err.Error(),
+		Code:  code,
+	})
+	return &batchResult{
+		Status:   status,
+		Response: json.RawMessage(b),
+	}
+}
+
+// Handles /api/v1/search/batch, which takes a JSON array of queries and
+// responds with the results of each of them, in the same order. The queries
+// of every batch share the slots, so at most as many as it has are searched
+// at the same time, and each one has until msTimeout to finish.
+func batchSearch(search http.HandlerFunc, slots chan bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		if r.Method != "POST" {
+			writeError(w, errMethodNotAllowed,
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+				http.StatusMethodNotAllowed)
+			return
+		}
+
+		timeout := time.Duration(parseAsUintValue(
+			r.URL.Query().Get("msTimeout"),
+			minBatchTimeout,
+			maxBatchTimeout,
+			defaultBatchTimeout)) * time.Millisecond
+
+		b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+		if err != nil {
+			writeError(w, errInvalidBody, err, http.StatusBadRequest)
+			return
+		}
+
+		var queries []map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&queries); err != nil {
+			writeError(w, errInvalidBody, err, http.StatusBadRequest)
+			return
+		}
+
+		if len(queries) == 0 {
+			writeError(w, errInvalidBody,
+				errors.New("The batch has no queries"),
+				http.StatusBadRequest)
+			return
+		}
+
+		if len(queries) > maxBatchSize {
+			writeError(w, errInvalidBody,
+				fmt.Errorf("The batch has %d queries, the most it can have is %d", len(queries), maxBatchSize),
+				http.StatusBadRequest)
+			return
+		}
+
+		vals := make([]url.Values, len(queries))
+		for i, q := range queries {
+			if vals[i], err = batchQueryValues(q); err != nil {
+				writeError(w, errInvalidBody,
+					fmt.Errorf("Query %d: %s", i, err),
+					http.StatusBadRequest)
+				return
+			}
+		}
+
+		res := make([]*batchResult, len(queries))
+		done := make(chan bool, len(queries))
+		for i := range vals {
+			go func(i int) {
+				res[i] = runBatchQuery(search, slots, r, vals[i], timeout)
+				done <- true
+			}(i)
+		}
+
+		for range vals {
+			<-done
+		}
+
+		writeResp(w, res)
+	}
+}
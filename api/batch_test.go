@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/searcher"
+)
+
+func TestBatchQueryValues(t *testing.T) {
+	var q map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(`{"q": "needle", "ctx": 2, "i": true, "stream": true, "rng": null}`))
+	dec.UseNumber()
+	if err := dec.Decode(&q); err != nil {
+		t.Fatal(err)
+	}
+
+	vals, err := batchQueryValues(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := vals.Encode(); got != "ctx=2&i=true&q=needle" {
+		t.Fatalf("expected ctx=2&i=true&q=needle, got %s", got)
+	}
+
+	if _, err := batchQueryValues(map[string]interface{}{"q": []interface{}{"a"}}); err == nil {
+		t.Fatal("expected an error for a list")
+	}
+}
+
+func TestSearchBatch(t *testing.T) {
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	src := filepath.Join(dbpath, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"a.txt": "needle haystack\n",
+		"b.txt": "needle\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := searcher.New(dbpath, "a", &config.Repo{
+		Url:            "file://" + src,
+		Vcs:            "local",
+		MsBetweenPolls: 60000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	defer SetSearchers(gSearchers)
+	SetSearchers(map[string]*searcher.Searcher{"a": s})
+
+	batch := func(m *http.ServeMux, body string) (int, []*batchResult) {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/search/batch", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			return w.Code, nil
+		}
+
+		var res []*batchResult
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, res
+	}
+
+	m := http.NewServeMux()
+	Setup(m, nil, &config.Config{DbPath: dbpath})
+
+	_, res := batch(m, `[{"q": "needle"}, {"q": "haystack", "repos": "a"}, {"q": "("}, {"q": ""}]`)
+	if len(res) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(res))
+	}
+
+	// the results are in the same order as the queries
+	for i, n := range []int{2, 1} {
+		var sr struct {
+			Results map[string]*index.SearchResponse
+		}
+		if err := json.Unmarshal(res[i].Response, &sr); err != nil {
+			t.Fatal(err)
+		}
+		if res[i].Status != http.StatusOK || len(sr.Results["a"].Matches) != n {
+			t.Fatalf("query %d: expected %d files, got %d and %s", i, n, res[i].Status, res[i].Response)
+		}
+	}
+
+	for i, code := range map[int]string{2: errInvalidQuery, 3: errEmptyQuery} {
+		var er errorResponse
+		if err := json.Unmarshal(res[i].Response, &er); err != nil {
+			t.Fatal(err)
+		}
+		if er.Code != code {
+			t.Fatalf("query %d: expected %s, got %d and %s", i, code, res[i].Status, res[i].Response)
+		}
+	}
+
+	tooMany := "[" + strings.Repeat(`{"q": "needle"},`, maxBatchSize) + `{"q": "needle"}]`
+	if code, _ := batch(m, tooMany); code != http.StatusBadRequest {
+		t.Fatalf("expected a batch that is too big to be a 400, got %d", code)
+	}
+
+	if code, _ := batch(m, `{"q": "needle"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected a body that isn't a list to be a 400, got %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/search/batch", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected a GET to be a 405, got %d", w.Code)
+	}
+
+	// each query of the batch takes a search from the rate limit
+	m = http.NewServeMux()
+	Setup(m, nil, &config.Config{DbPath: dbpath, SearchRateLimit: 0.001, SearchRateBurst: 2})
+
+	_, res = batch(m, `[{"q": "needle"}, {"q": "needle"}, {"q": "needle"}]`)
+	limited := 0
+	for _, r := range res {
+		if r.Status == http.StatusTooManyRequests {
+			limited++
+		}
+	}
+	if limited != 1 {
+		t.Fatalf("expected 1 query to be rate limited, got %d", limited)
+	}
+}
+
+func TestSearchBatchTimeout(t *testing.T) {
+	release := make(chan bool)
+	hang := func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		writeResp(w, "done")
+	}
+
+	// only checkReady looks at them
+	defer SetSearchers(GetSearchers())
+	SetSearchers(map[string]*searcher.Searcher{"a": {}})
+
+	slots := make(chan bool, 1)
+	w := httptest.NewRecorder()
+	batchSearch(hang, slots)(w, httptest.NewRequest("POST",
+		"/api/v1/search/batch?msTimeout=1000",
+		strings.NewReader(`[{"q": "needle"}, {"q": "needle"}]`)))
+
+	var res []*batchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("%s: %s", err, w.Body.String())
+	}
+	for _, r := range res {
+		if r.Status != http.StatusGatewayTimeout {
+			t.Fatalf("expected both queries to time out, got %d", r.Status)
+		}
+	}
+
+	// the search that timed out keeps its slot until it's done
+	if len(slots) != 1 {
+		t.Fatalf("expected the hung search to hold its slot, got %d", len(slots))
+	}
+
+	close(release)
+	for i := 0; len(slots) != 0; i++ {
+		if i == 100 {
+			t.Fatal("expected the slot to be freed once the search was done")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/searcher"
+)
+
+// A bounded LRU cache of search results. Entries expire after the ttl, and
+// since the keys include the generation of every searched repo, results from
+// an index that has since been swapped out are never served. A nil cache
+// caches nothing.
+type searchCache struct {
+	lck     sync.Mutex
+	size    int
+	ttl     time.Duration
+	lru     *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+
+	// The same entries by the ids of their results, see cachedSearch.id.
+	ids map[string]*list.Element
+}
+
+// A search result as it is held in the cache. It must not be modified once
+// it has been put in the cache.
+type cachedSearch struct {
+	key     string
+	expires time.Time
+
+	// Identifies the results in responses, so that later searches can be
+	// made within them. Set when the search is put in the cache.
+	id string
+
+	results map[string]*index.SearchResponse
+	stats   *Stats
+}
+
+func newSearchCache(size int, ttl time.Duration) *searchCache {
+	return &searchCache{
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+		now:     time.Now,
+		ids:     map[string]*list.Element{},
+	}
+}
+
+// The key of a search, which covers everything that affects the results.
+func searchCacheKey(
+	query string,
+	opt *index.SearchOptions,
+	ignoreCase *bool,
+	repos,
+	vrepos []string,
+	dedupe bool,
+	within string,
+	idx map[string]*searcher.Searcher) string {
+
+	// whether the search left ignore-case to each repo
+	caseFromRepo := ignoreCase == nil
+
+	gens := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		if s := idx[repo]; s != nil {
+			gens = append(gens, fmt.Sprintf("%s@%d", repo, s.Generation()))
+		}
+	}
+	sort.Strings(gens)
+
+	return fmt.Sprintf("%q %+v %t %q %q %t %q", query, *opt, caseFromRepo, gens, vrepos, dedupe, within)
+}
+
+// The id of the results of the search with key. Like the key, it changes
+// whenever the results would.
+func searchResultId(key string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(key)))[:16]
+}
+
+// Get the cached search for key, nil if there is none or it has expired.
+func (c *searchCache) get(key string) *cachedSearch {
+	if c == nil {
+		return nil
+	}
+
+	c.lck.Lock()
+	defer c.lck.Unlock()
+
+	return c.use(c.entries[key])
+}
+
+// Get the cached search whose results have the given id, like get.
+func (c *searchCache) getById(id string) *cachedSearch {
+	if c == nil {
+		return nil
+	}
+
+	c.lck.Lock()
+	defer c.lck.Unlock()
+
+	return c.use(c.ids[id])
+}
+
+// The search cached in e unless it has expired, in which case it's removed.
+// The caller must hold the lock.
+func (c *searchCache) use(e *list.Element) *cachedSearch {
+	if e == nil {
+		return nil
+	}
+
+	cs := e.Value.(*cachedSearch)
+	if c.now().After(cs.expires) {
+		c.remove(e)
+		return nil
+	}
+
+	c.lru.MoveToFront(e)
+	return cs
+}
+
+// The caller must hold the lock.
+func (c *searchCache) remove(e *list.Element) {
+	cs := e.Value.(*cachedSearch)
+	c.lru.Remove(e)
+	delete(c.entries, cs.key)
+	delete(c.ids, cs.id)
+}
+
+// Cache a search, evicting the least recently used one if the cache is full.
+func (c *searchCache) put(cs *cachedSearch) {
+	if c == nil {
+		return
+	}
+
+	c.lck.Lock()
+	defer c.lck.Unlock()
+
+	cs.expires = c.now().Add(c.ttl)
+	cs.id = searchResultId(cs.key)
+
+	if e, ok := c.entries[cs.key]; ok {
+		e.Value = cs
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	e := c.lru.PushFront(cs)
+	c.entries[cs.key] = e
+	c.ids[cs.id] = e
+
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
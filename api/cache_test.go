@@ -0,0 +1,80 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newSearchCache(2, time.Minute)
+
+	c.put(&cachedSearch{key: "a"})
+	c.put(&cachedSearch{key: "b"})
+
+	// a is now the most recently used
+	if c.get("a") == nil {
+		t.Fatal("expected a to be cached")
+	}
+
+	c.put(&cachedSearch{key: "c"})
+
+	if c.get("b") != nil {
+		t.Fatal("expected b to be evicted")
+	}
+
+	if c.get("a") == nil || c.get("c") == nil {
+		t.Fatal("expected a and c to be cached")
+	}
+}
+
+func TestSearchCacheExpires(t *testing.T) {
+	now := time.Now()
+	c := newSearchCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.put(&cachedSearch{key: "a"})
+	if c.get("a") == nil {
+		t.Fatal("expected a to be cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if c.get("a") != nil {
+		t.Fatal("expected a to have expired")
+	}
+
+	if len(c.entries) != 0 || c.lru.Len() != 0 {
+		t.Fatal("expected the expired entry to be removed")
+	}
+}
+
+func TestSearchCacheIds(t *testing.T) {
+	c := newSearchCache(1, time.Minute)
+
+	a := &cachedSearch{key: "a"}
+	c.put(a)
+	if a.id == "" || a.id != searchResultId("a") {
+		t.Fatalf("expected a to get the id of its key, got %q", a.id)
+	}
+	if c.getById(a.id) != a {
+		t.Fatal("expected a to be found by its id")
+	}
+
+	c.put(&cachedSearch{key: "b"})
+	if c.getById(a.id) != nil {
+		t.Fatal("expected the id of an evicted search to be gone")
+	}
+	if len(c.ids) != 1 {
+		t.Fatalf("expected 1 id, got %d", len(c.ids))
+	}
+}
+
+func TestNilSearchCache(t *testing.T) {
+	var c *searchCache
+	c.put(&cachedSearch{key: "a"})
+	if c.get("a") != nil {
+		t.Fatal("expected a nil cache to cache nothing")
+	}
+	if c.getById(searchResultId("a")) != nil {
+		t.Fatal("expected a nil cache to have no ids")
+	}
+}
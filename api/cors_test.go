@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func corsRequest(h http.Handler, method, origin string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(method, "/api/v1/repos", nil)
+	r.Header.Set("Origin", origin)
+	if method == "OPTIONS" {
+		r.Header.Set("Access-Control-Request-Method", "GET")
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCors(t *testing.T) {
+	h := corsHandler([]string{"https://ok.example.com"},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := corsRequest(h, "GET", "https://ok.example.com")
+	if o := w.Header().Get("Access-Control-Allow-Origin"); o != "https://ok.example.com" {
+		t.Fatalf("expected allowed origin to be reflected, got %q", o)
+	}
+
+	w = corsRequest(h, "GET", "https://evil.example.com")
+	if o := w.Header().Get("Access-Control-Allow-Origin"); o != "" {
+		t.Fatalf("expected no cors header for unknown origin, got %q", o)
+	}
+
+	w = corsRequest(h, "OPTIONS", "https://ok.example.com")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Fatalf("expected preflight to be answered, got %d %v", w.Code, w.Header())
+	}
+}
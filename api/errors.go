@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/etsy/hound/searcher"
+)
+
+// The codes in the Code field of error responses. Unlike the messages, these
+// never change, so clients can tell errors apart by them.
+const (
+	errNotReady         = "not_ready"
+	errEmptyQuery       = "empty_query"
+	errInvalidQuery     = "invalid_query"
+	errQueryTooLong     = "query_too_long"
+	errQueryTooShort    = "query_too_short"
+	errQueryTooBroad    = "query_too_broad"
+	errInvalidParam     = "invalid_param"
+	errInvalidRange     = "invalid_range"
+	errInvalidBody      = "invalid_body"
+	errInvalidConfig    = "invalid_config"
+	errInvalidSignature = "invalid_signature"
+	errUnauthorized     = "unauthorized"
+	errNoSuchRepo       = "no_such_repo"
+	errNoSuchFile       = "no_such_file"
+	errNoSuchResult     = "no_such_result"
+	errForbidden        = "forbidden"
+	errMethodNotAllowed = "method_not_allowed"
+	errNotEnabled       = "not_enabled"
+	errSearchFailed     = "search_failed"
+	errRateLimited      = "rate_limited"
+	errTimeout          = "timeout"
+	errInternal         = "internal"
+)
+
+var (
+	// Send the errors of the search api with a 200, as older versions did.
+	gLegacyErrorStatus bool
+)
+
+// The body of every error response.
+type errorResponse struct {
+	Error string
+	Code  string
+}
+
+func writeError(w http.ResponseWriter, code string, err error, status int) {
+	writeJson(w, &errorResponse{
+		Error: err.Error(),
+		Code:  code,
+	}, status)
+}
+
+// The code and status of a search that failed with err, whether it's
+// streamed or not.
+func searchErrorCode(err error) (string, int) {
+	if _, ok := err.(*searcher.UnknownRevError); ok || err == searcher.ErrRevNotSupported {
+		return errInvalidParam, http.StatusBadRequest
+	} else if err == searcher.ErrTooManyRevBuilds {
+		return errRateLimited, http.StatusTooManyRequests
+	}
+	return errSearchFailed, http.StatusInternalServerError
+}
+
+// Write an error that older versions sent with a 200 (the ones from the
+// search api and from checking that hound is ready), which it still is if
+// legacy-error-status is set in the config.
+func writeLegacyError(w http.ResponseWriter, code string, err error, status int) {
+	if gLegacyErrorStatus {
+		status = http.StatusOK
+	}
+	writeError(w, code, err, status)
+}
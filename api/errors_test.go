@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, errEmptyQuery, errors.New("No query"), http.StatusBadRequest)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected a 400, got %d", w.Code)
+	}
+
+	var res errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Code != errEmptyQuery || res.Error != "No query" {
+		t.Fatalf("unexpected error response %+v", res)
+	}
+}
+
+func TestWriteLegacyError(t *testing.T) {
+	defer func() { gLegacyErrorStatus = false }()
+
+	w := httptest.NewRecorder()
+	writeLegacyError(w, errSearchFailed, errors.New("boom"), http.StatusInternalServerError)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected a 500, got %d", w.Code)
+	}
+
+	gLegacyErrorStatus = true
+
+	w = httptest.NewRecorder()
+	writeLegacyError(w, errSearchFailed, errors.New("boom"), http.StatusInternalServerError)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected a 200 for legacy clients, got %d", w.Code)
+	}
+}
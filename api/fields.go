@@ -0,0 +1,163 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/etsy/hound/index"
+)
+
+// The parts of a search response that the fields param can ask for.
+const (
+	// FilesWithMatch and, for count only searches, MatchCount
+	fieldCounts = "counts"
+
+	// the files that matched, without their lines
+	fieldFiles = "files"
+
+	// the files that matched along with their lines
+	fieldMatches = "matches"
+
+	// the revision that was searched
+	fieldRevision = "revision"
+
+	// the stats of the whole search, like the stats param
+	fieldStats = "stats"
+)
+
+var fieldNames = []string{fieldCounts, fieldFiles, fieldMatches, fieldRevision, fieldStats}
+
+// The parts of each repo's search response to respond with, see parseFields.
+// A nil fieldSet responds with all of them, as they are.
+type fieldSet struct {
+	counts, files, matches, revision, stats bool
+}
+
+// The fields of a countOnly search that didn't ask for any. It has no
+// matches to list, so it responds without them.
+var countOnlyFields = &fieldSet{counts: true, revision: true}
+
+// Parse the comma separated field names of the fields param. An empty
+// list is nil, which is the full response.
+func parseFields(v string) (*fieldSet, error) {
+	if v == "" {
+		return nil, nil
+	}
+
+	f := &fieldSet{}
+	for _, name := range strings.Split(v, ",") {
+		switch strings.TrimSpace(name) {
+		case fieldCounts:
+			f.counts = true
+		case fieldFiles:
+			f.files = true
+		case fieldMatches:
+			f.files = true
+			f.matches = true
+		case fieldRevision:
+			f.revision = true
+		case fieldStats:
+			f.stats = true
+		default:
+			return nil, fmt.Errorf("Invalid field: %s, expected some of %s",
+				name, strings.Join(fieldNames, ", "))
+		}
+	}
+	return f, nil
+}
+
+// Narrow opts down to what has to be collected for the fields. Without files
+// the matches are only counted, and without matches only the first line of
+// each file is collected, unless the files are ranked or deduped by their
+// lines, which takes all of them.
+func (f *fieldSet) limit(opts *index.SearchOptions, dedupe bool) {
+	if f == nil || f.matches {
+		return
+	}
+
+	if !f.files {
+		opts.CountOnly = true
+		return
+	}
+
+	opts.LinesBefore = 0
+	opts.LinesAfter = 0
+	opts.Blame = false
+	if opts.Sort != index.SortByScore && !opts.CaseRank && !dedupe {
+		opts.MaxMatchesPerFile = 1
+	}
+}
+
+// A search response with only the fields that were asked for.
+type projectedResponse struct {
+	Matches        interface{} `json:",omitempty"`
+	FilesWithMatch *int        `json:",omitempty"`
+	MatchCount     *int        `json:",omitempty"`
+	Revision       string      `json:",omitempty"`
+	Limited        bool        `json:",omitempty"`
+}
+
+// A file that matched, without its lines.
+type projectedFile struct {
+	Filename       string
+	Score          float64
+	Branch         string   `json:",omitempty"`
+	AlsoOnBranches []string `json:",omitempty"`
+	ExactMatches   int      `json:",omitempty"`
+}
+
+// The response for a single repo, with only the fields in f. The response
+// itself isn't changed since it may be cached.
+func (f *fieldSet) project(res *index.SearchResponse) interface{} {
+	if f == nil {
+		return res
+	}
+
+	p := &projectedResponse{
+		Limited: res.Limited,
+	}
+
+	if f.counts {
+		filesWithMatch := res.FilesWithMatch
+		p.FilesWithMatch = &filesWithMatch
+		if res.MatchCount > 0 {
+			matchCount := res.MatchCount
+			p.MatchCount = &matchCount
+		}
+	}
+
+	if f.revision {
+		p.Revision = res.Revision
+	}
+
+	if f.matches && len(res.Matches) > 0 {
+		p.Matches = res.Matches
+	} else if f.files && len(res.Matches) > 0 {
+		files := make([]*projectedFile, len(res.Matches))
+		for i, fm := range res.Matches {
+			files[i] = &projectedFile{
+				Filename:       fm.Filename,
+				Score:          fm.Score,
+				Branch:         fm.Branch,
+				AlsoOnBranches: fm.AlsoOnBranches,
+				ExactMatches:   fm.ExactMatches,
+			}
+		}
+		p.Matches = files
+	}
+
+	return p
+}
+
+// The results of every repo, projected down to f.
+func (f *fieldSet) projectAll(results map[string]*index.SearchResponse) interface{} {
+	if f == nil {
+		return results
+	}
+
+	p := make(map[string]interface{}, len(results))
+	for repo, res := range results {
+		p[repo] = f.project(res)
+	}
+	return p
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/etsy/hound/index"
+)
+
+func TestParseFields(t *testing.T) {
+	if f, err := parseFields(""); err != nil || f != nil {
+		t.Fatalf("expected no fields to be the full response, got %+v (%v)", f, err)
+	}
+
+	f, err := parseFields("counts, matches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *f != (fieldSet{counts: true, files: true, matches: true}) {
+		t.Fatalf("expected matches to take the files along, got %+v", f)
+	}
+
+	if _, err := parseFields("counts,lines"); err == nil {
+		t.Fatal("expected lines to be an invalid field")
+	}
+}
+
+func TestFieldsLimit(t *testing.T) {
+	opt := index.SearchOptions{LinesBefore: 2, LinesAfter: 2, MaxMatchesPerFile: 100}
+	(&fieldSet{counts: true}).limit(&opt, false)
+	if !opt.CountOnly {
+		t.Fatal("expected a search without files to only count")
+	}
+
+	opt = index.SearchOptions{LinesBefore: 2, LinesAfter: 2, MaxMatchesPerFile: 100, Sort: index.SortByPath}
+	(&fieldSet{files: true}).limit(&opt, false)
+	if opt.CountOnly || opt.LinesBefore != 0 || opt.LinesAfter != 0 || opt.MaxMatchesPerFile != 1 {
+		t.Fatalf("expected a search of files to collect a single line of each, got %+v", opt)
+	}
+
+	// ranking by score takes every line
+	opt = index.SearchOptions{MaxMatchesPerFile: 100, Sort: index.SortByScore}
+	(&fieldSet{files: true}).limit(&opt, false)
+	if opt.MaxMatchesPerFile != 100 {
+		t.Fatalf("expected every line to be collected, got %d", opt.MaxMatchesPerFile)
+	}
+
+	var all *fieldSet
+	opt = index.SearchOptions{LinesBefore: 2, MaxMatchesPerFile: 100}
+	all.limit(&opt, false)
+	if opt.LinesBefore != 2 || opt.MaxMatchesPerFile != 100 {
+		t.Fatalf("expected the full response to change nothing, got %+v", opt)
+	}
+}
+
+func TestFieldsProject(t *testing.T) {
+	res := &index.SearchResponse{
+		Matches: []*index.FileMatch{
+			{
+				Filename: "a.go",
+				Score:    1.5,
+				Matches:  []*index.Match{{Line: "needle", LineNumber: 3}},
+			},
+		},
+		FilesWithMatch: 1,
+		Revision:       "abc",
+	}
+
+	project := func(f *fieldSet) string {
+		b, err := json.Marshal(f.project(res))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if got := project(&fieldSet{counts: true}); got != `{"FilesWithMatch":1}` {
+		t.Fatalf("expected only the counts, got %s", got)
+	}
+
+	if got := project(&fieldSet{files: true, revision: true}); got != `{"Matches":[{"Filename":"a.go","Score":1.5}],"Revision":"abc"}` {
+		t.Fatalf("expected the files without their lines, got %s", got)
+	}
+
+	if got := project(&fieldSet{files: true, matches: true}); got != `{"Matches":[{"Filename":"a.go","Matches":[{"Line":"needle","LineNumber":3,"Before":null,"After":null}],"Score":1.5}]}` {
+		t.Fatalf("expected the files with their lines, got %s", got)
+	}
+
+	// the response may be cached, projecting leaves it alone
+	if res.Matches[0].Matches == nil {
+		t.Fatal("expected the response not to be changed")
+	}
+}
+
+// Only count only searches leave out the matches.
+func TestCountOnlyFields(t *testing.T) {
+	res := &index.SearchResponse{
+		FilesWithMatch: 2,
+		MatchCount:     5,
+		Revision:       "abc",
+	}
+
+	b, err := json.Marshal(countOnlyFields.project(res))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != `{"FilesWithMatch":2,"MatchCount":5,"Revision":"abc"}` {
+		t.Fatalf("expected only the counts and revision, got %s", got)
+	}
+
+	b, err = json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var full map[string]interface{}
+	if err := json.Unmarshal(b, &full); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := full["Matches"]; !ok {
+		t.Fatalf("expected the full response to have its matches, got %s", b)
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
+)
+
+var errUnsatisfiableRange = errors.New("Requested range not satisfiable")
+
+// Parse the Range header h of a request for a file of the given size into
+// the offset and length of the bytes asked for. Only a single range is
+// supported, ok is false if the header is missing or should be ignored
+// (which serves the whole file) and an error is returned if the range lies
+// outside the file.
+func parseByteRange(h string, size int64) (int64, int64, bool, error) {
+	if !strings.HasPrefix(h, "bytes=") {
+		return 0, 0, false, nil
+	}
+
+	spec := strings.TrimSpace(h[len("bytes="):])
+	i := strings.IndexByte(spec, '-')
+	if i < 0 || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+
+	from, to := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+
+	// -n is the last n bytes
+	if from == "" {
+		n, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false, nil
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, false, errUnsatisfiableRange
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, true, nil
+	}
+
+	start, err := strconv.ParseInt(from, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, nil
+	}
+	if start >= size {
+		return 0, 0, false, errUnsatisfiableRange
+	}
+
+	end := size - 1
+	if to != "" {
+		e, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || e < start {
+			return 0, 0, false, nil
+		}
+		if e < end {
+			end = e
+		}
+	}
+
+	return start, end - start + 1, true, nil
+}
+
+// Copy the lines in the range rv (first:last, 1-based and inclusive) of the
+// file r to w. Either end can be omitted to select from the start or to the
+// end of the file. The file is streamed, so only as much of it as it takes
+// to get to the last line is read, and never all at once.
+func fileLines(w io.Writer, r *bufio.Reader, rv string) error {
+	first, last := parseRangeValue(rv)
+	if first < 1 {
+		first = 1
+	}
+
+	for line := 1; last == 0 || line <= last; {
+		b, err := r.ReadSlice('\n')
+		if line >= first && len(b) > 0 {
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+
+		switch err {
+		case nil:
+			line++
+		case bufio.ErrBufferFull:
+			// the rest of the line is still to come
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// The charset of a text file in encoding (see index.IndexedFile.Encoding).
+func charsetOf(encoding string) string {
+	if encoding == "" || encoding == index.EncodingUTF8BOM {
+		return "utf-8"
+	}
+	return encoding
+}
+
+// Write the indexed file f (at path name and revision rev) in response to r.
+// The response is limited to the given lines, if any, or else to the byte
+// range in the request's Range header. Files that were transcoded to UTF-8
+// are served in UTF-8 unless original is true, which serves them in their
+// original encoding and ignores the Range header (it counts the bytes of
+// the UTF-8). Whatever the repos hold, the file never becomes a page on
+// hound's origin: text is served as plain text and anything else as a
+// download, neither of which the browser may sniff or run scripts in.
+func writeFile(
+	w http.ResponseWriter,
+	r *http.Request,
+	f *index.IndexedFile,
+	name,
+	rev,
+	lines string,
+	original bool) {
+
+	br := bufio.NewReader(f)
+
+	head, _ := br.Peek(512)
+	text := strings.HasPrefix(http.DetectContentType(head), "text/")
+
+	original = original && f.Encoding != ""
+
+	h := w.Header()
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Security-Policy", "sandbox")
+	if !text {
+		h.Set("Content-Type", "application/octet-stream")
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": filepath.Base(name),
+		}))
+	} else if original {
+		h.Set("Content-Type", "text/plain; charset="+charsetOf(f.Encoding))
+	} else {
+		h.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	h.Set("X-Hound-Revision", rev)
+	if f.Encoding != "" {
+		h.Set("X-Hound-Encoding", f.Encoding)
+	}
+
+	var err error
+	defer func() {
+		if err != nil {
+			logger.Warn("failed to write file", logger.Fields{
+				"path":  name,
+				"error": err,
+			})
+		}
+	}()
+
+	if original {
+		// the byte order mark goes with the first line
+		first, _ := parseRangeValue(lines)
+		out := index.NewEncodingWriter(w, f.Encoding, first <= 1)
+		if lines != "" {
+			err = fileLines(out, br, lines)
+		} else {
+			_, err = io.Copy(out, br)
+		}
+		return
+	}
+
+	if lines != "" {
+		err = fileLines(w, br, lines)
+		return
+	}
+
+	h.Set("Accept-Ranges", "bytes")
+
+	start, n, ok, rerr := parseByteRange(r.Header.Get("Range"), f.Size)
+	if rerr != nil {
+		h.Set("Content-Range", fmt.Sprintf("bytes */%d", f.Size))
+		writeError(w, errInvalidRange, rerr, http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	if !ok {
+		// lets large files skip compression, see maxGzipSize
+		h.Set("Content-Length", strconv.FormatInt(f.Size, 10))
+		_, err = io.Copy(w, br)
+		return
+	}
+
+	// the file is compressed, so the bytes before the range have to be
+	// read to get to it
+	if _, err = io.CopyN(ioutil.Discard, br, start); err != nil {
+		writeError(w, errInternal, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+n-1, f.Size))
+	h.Set("Content-Length", strconv.FormatInt(n, 10))
+	w.WriteHeader(http.StatusPartialContent)
+	_, err = io.CopyN(w, br, n)
+}
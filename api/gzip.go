@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Responses smaller than this are not worth compressing.
+	minGzipSize = 1400
+
+	// Responses that say up front (with a Content-Length) that they are
+	// larger than this, like whole files, are sent as they are. Compressing
+	// them costs more than it saves, and they keep their length.
+	maxGzipSize = 1 << 20
+)
+
+// Does the client accept gzip encoded responses?
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+
+		// gzip;q=0 means the client explicitly refuses gzip
+		for _, p := range parts[1:] {
+			if q := strings.TrimSpace(p); strings.HasPrefix(q, "q=") {
+				if v, err := strconv.ParseFloat(q[2:], 64); err == nil && v == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// A ResponseWriter that holds on to the start of the response, until it is
+// large enough to be worth compressing, and streams the rest of it through
+// gzip. If the handler flushes before then, the response is streamed
+// uncompressed from then on.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	z           *gzip.Writer
+	passthrough bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.passthrough || g.z != nil {
+		g.ResponseWriter.WriteHeader(status)
+		return
+	}
+	g.status = status
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if g.passthrough {
+		return g.ResponseWriter.Write(b)
+	}
+	if g.z != nil {
+		return g.z.Write(b)
+	}
+
+	g.buf.Write(b)
+	if g.buf.Len() >= minGzipSize {
+		if err := g.start(!g.skipsGzip()); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Does the response have to be sent as it is, however large it gets?
+func (g *gzipResponseWriter) skipsGzip() bool {
+	h := g.Header()
+	if h.Get("Content-Encoding") != "" {
+		return true
+	}
+
+	n, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64)
+	return err == nil && n > maxGzipSize
+}
+
+// Send the headers and what was held on to, and stream the rest of the
+// response through gzip if compress is true, or as it is otherwise.
+func (g *gzipResponseWriter) start(compress bool) error {
+	if compress {
+		h := g.Header()
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		g.z = gzip.NewWriter(g.ResponseWriter)
+	} else {
+		g.passthrough = true
+	}
+
+	g.ResponseWriter.WriteHeader(g.status)
+
+	var err error
+	if g.z != nil {
+		_, err = g.z.Write(g.buf.Bytes())
+	} else {
+		_, err = g.ResponseWriter.Write(g.buf.Bytes())
+	}
+	g.buf.Reset()
+	return err
+}
+
+func (g *gzipResponseWriter) Flush() {
+	if g.z != nil {
+		g.z.Flush()
+	} else if !g.passthrough {
+		g.start(false)
+	}
+
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// The ResponseWriter underneath, so that http.ResponseController can reach
+// it.
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// Write out whatever the handler left behind, which is either the end of the
+// compressed stream or a response too small to compress.
+func (g *gzipResponseWriter) finish() error {
+	if g.z != nil {
+		return g.z.Close()
+	}
+
+	if g.passthrough {
+		return nil
+	}
+
+	// these responses never have a body
+	if g.status == http.StatusNotModified || g.status == http.StatusNoContent {
+		g.ResponseWriter.WriteHeader(g.status)
+		return nil
+	}
+
+	g.Header().Set("Content-Length", strconv.Itoa(g.buf.Len()))
+	return g.start(false)
+}
+
+// Wrap the api handler so that large responses are gzip compressed for
+// clients that accept it.
+func gzipHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// a byte range is of the file, not of a compressed response
+		if !acceptsGzip(r) || r.Header.Get("Range") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+		h.ServeHTTP(gw, r)
+		gw.finish()
+	})
+}
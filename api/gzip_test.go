@@ -0,0 +1,121 @@
+package api
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func gzipRequest(body, acceptEncoding string) *httptest.ResponseRecorder {
+	h := gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		w.Write([]byte(body))
+	}))
+
+	r, _ := http.NewRequest("GET", "/api/v1/search", nil)
+	r.Header.Set("Accept-Encoding", acceptEncoding)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestGzipLargeResponse(t *testing.T) {
+	body := strings.Repeat("a", 2*minGzipSize)
+	w := gzipRequest(body, "deflate, gzip")
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("expected large response to be compressed")
+	}
+
+	if w.Header().Get("Content-Type") != "application/json;charset=utf-8" {
+		t.Fatalf("content type was not preserved: %s", w.Header().Get("Content-Type"))
+	}
+
+	z, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != body {
+		t.Fatal("decompressed body does not match")
+	}
+}
+
+func TestGzipSmallResponse(t *testing.T) {
+	w := gzipRequest("{}", "gzip")
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "{}" {
+		t.Fatal("expected small response to be left alone")
+	}
+
+	w = gzipRequest(strings.Repeat("a", 2*minGzipSize), "gzip;q=0")
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("expected gzip;q=0 to disable compression")
+	}
+}
+
+func TestGzipStreamsResponse(t *testing.T) {
+	chunk := strings.Repeat("a", minGzipSize)
+	w := httptest.NewRecorder()
+
+	h := gzipHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 10; i++ {
+			rw.Write([]byte(chunk))
+		}
+
+		// the start of the response is already on its way
+		if w.Body.Len() == 0 {
+			t.Fatal("expected the response to be streamed rather than held on to")
+		}
+	}))
+
+	r, _ := http.NewRequest("GET", "/api/v1/file", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	h.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" {
+		t.Fatalf("expected a compressed response without a length, got %v", w.Header())
+	}
+
+	z, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != strings.Repeat(chunk, 10) {
+		t.Fatal("decompressed body does not match")
+	}
+}
+
+func TestGzipSkipsLargeResponse(t *testing.T) {
+	body := strings.Repeat("a", maxGzipSize+1)
+	h := gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write([]byte(body))
+	}))
+
+	r, _ := http.NewRequest("GET", "/api/v1/file", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != body {
+		t.Fatal("expected a response larger than maxGzipSize to be sent as it is")
+	}
+	if w.Header().Get("Content-Length") != strconv.Itoa(len(body)) {
+		t.Fatalf("expected the length to be kept, got %q", w.Header().Get("Content-Length"))
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"regexp"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/searcher"
+)
+
+// The kinds of hints a search that found nothing can have.
+const (
+	// search case insensitively, with i=true
+	hintIgnoreCase = "ignoreCase"
+
+	// search for the query as it is, with mode=literal
+	hintLiteral = "literal"
+
+	// search for the tokens of the query that aren't in the repos as the
+	// closest ones that are
+	hintSpelling = "spelling"
+)
+
+// The most tokens each repo is asked for that are close to a token of the
+// query, see Searcher.Similar.
+const similarTokensPerRepo = 5
+
+// A change to a search that found nothing which finds something. The search
+// itself isn't changed, the hints only tell how to change it.
+type searchHint struct {
+	Kind string
+
+	// The query to search for, which is only different for spelling.
+	Query string
+
+	// The params to search with on top of the search's own, like i=true.
+	Params map[string]string `json:",omitempty"`
+
+	// How many files the changed search finds.
+	FilesWithMatch int
+}
+
+// Count the files the search would find with opts changed by change. The
+// matches are only counted, which is the cheapest way to search.
+func countWith(
+	query string,
+	opts *index.SearchOptions,
+	ignoreCase *bool,
+	repos []string,
+	vrepos []string,
+	within fileSets,
+	idx map[string]*searcher.Searcher,
+	change func(o *index.SearchOptions)) int {
+
+	o := *opts
+	o.CountOnly = true
+	change(&o)
+
+	res, err := searchAll(query, &o, ignoreCase, repos, vrepos, within, false, idx, &Stats{Languages: map[string]int{}})
+	if err != nil {
+		return 0
+	}
+
+	var n int
+	for _, sr := range res {
+		n += sr.FilesWithMatch
+	}
+	return n
+}
+
+// The tokens of query that none of the repos have, each with the closest
+// token that some of them do. Tokens that are only in a different case are
+// left to the ignoreCase hint.
+func respellings(query string, repos []string, idx map[string]*searcher.Searcher) map[string]string {
+	with := map[string]string{}
+	for _, token := range index.PatternTokens(query) {
+		counts := map[string]*index.SimilarToken{}
+		for _, repo := range repos {
+			s := idx[repo]
+			if s == nil {
+				continue
+			}
+
+			for _, sim := range s.Similar(token, similarTokensPerRepo) {
+				if c := counts[sim.Token]; c != nil {
+					c.Count += sim.Count
+				} else {
+					counts[sim.Token] = &index.SimilarToken{Token: sim.Token, Count: sim.Count, Edits: sim.Edits}
+				}
+			}
+		}
+
+		sims := make([]*index.SimilarToken, 0, len(counts))
+		for _, sim := range counts {
+			sims = append(sims, sim)
+		}
+		index.SortSimilarTokens(sims)
+
+		if len(sims) > 0 && sims[0].Edits > 0 {
+			with[token] = sims[0].Token
+		}
+	}
+	return with
+}
+
+// The hints for a search that found nothing, see searchHint. Only the ones
+// that find something are returned.
+func hintsFor(
+	query string,
+	opts *index.SearchOptions,
+	ignoreCase *bool,
+	repos []string,
+	vrepos []string,
+	within fileSets,
+	idx map[string]*searcher.Searcher) []*searchHint {
+
+	parsed, err := index.ParseQuery(query)
+	if err != nil {
+		return nil
+	}
+
+	var hints []*searchHint
+
+	if (ignoreCase == nil || !*ignoreCase) && !opts.CaseRank {
+		yes := true
+		if n := countWith(query, opts, &yes, repos, vrepos, within, idx, func(o *index.SearchOptions) {
+			o.IgnoreCase = true
+		}); n > 0 {
+			hints = append(hints, &searchHint{
+				Kind:           hintIgnoreCase,
+				Query:          query,
+				Params:         map[string]string{"i": "true"},
+				FilesWithMatch: n,
+			})
+		}
+	}
+
+	// a query without any regexp syntax is searched for as it is already
+	if (opts.Mode == "" || opts.Mode == index.ModeRegex) && regexp.QuoteMeta(parsed.Pattern) != parsed.Pattern {
+		if n := countWith(query, opts, ignoreCase, repos, vrepos, within, idx, func(o *index.SearchOptions) {
+			o.Mode = index.ModeLiteral
+		}); n > 0 {
+			hints = append(hints, &searchHint{
+				Kind:           hintLiteral,
+				Query:          query,
+				Params:         map[string]string{"mode": index.ModeLiteral},
+				FilesWithMatch: n,
+			})
+		}
+	}
+
+	if with := respellings(query, repos, idx); len(with) > 0 {
+		respelled := index.ReplacePatternTokens(query, with)
+		if n := countWith(respelled, opts, ignoreCase, repos, vrepos, within, idx, func(o *index.SearchOptions) {}); n > 0 {
+			hints = append(hints, &searchHint{
+				Kind:           hintSpelling,
+				Query:          respelled,
+				FilesWithMatch: n,
+			})
+		}
+	}
+
+	return hints
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/searcher"
+)
+
+func TestSearchHints(t *testing.T) {
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	src := filepath.Join(dbpath, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"a.go": "func Handler() {}\n",
+		"b.go": "x := a+b\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	suggest := true
+	s, err := searcher.New(dbpath, "a", &config.Repo{
+		Url:            "file://" + src,
+		Vcs:            "local",
+		MsBetweenPolls: 60000,
+		Suggest:        &suggest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	defer SetSearchers(gSearchers)
+	SetSearchers(map[string]*searcher.Searcher{"a": s})
+
+	m := http.NewServeMux()
+	Setup(m, nil, &config.Config{DbPath: dbpath})
+
+	hints := func(q string) []*searchHint {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/search?hints=true&"+q, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected a 200, got %d", q, w.Code)
+		}
+
+		var res struct {
+			Hints []*searchHint
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Hints
+	}
+
+	if h := hints("q=Handler"); len(h) != 0 {
+		t.Fatalf("expected a search that found something to have no hints, got %v", h)
+	}
+
+	if h := hints("q=handler&i=false"); len(h) != 1 || h[0].Kind != hintIgnoreCase || h[0].FilesWithMatch != 1 {
+		t.Fatalf("expected to search case insensitively, got %v", h)
+	}
+
+	if h := hints("q=" + url.QueryEscape("a+b")); len(h) != 1 || h[0].Kind != hintLiteral || h[0].FilesWithMatch != 1 {
+		t.Fatalf("expected to search literally, got %v", h)
+	}
+
+	if h := hints("q=" + url.QueryEscape("Hnadler()")); len(h) != 1 || h[0].Kind != hintSpelling || h[0].Query != "Handler()" {
+		t.Fatalf("expected to search for Handler(), got %v", h)
+	}
+
+	// without hints=true there are none
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/search?q=handler&i=false", nil))
+	var res map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res["Hints"]; ok {
+		t.Fatalf("expected no hints, got %v", res["Hints"])
+	}
+}
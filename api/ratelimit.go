@@ -0,0 +1,166 @@
+package api
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etsy/hound/config"
+)
+
+// The most clients the rate limiter keeps track of. Beyond that, the ones
+// seen least recently are forgotten, which only gives them a full bucket.
+const maxRateLimitedClients = 10000
+
+// A token bucket per client IP. Each search takes a token, tokens come back
+// at rate per second up to burst.
+type rateLimiter struct {
+	lck     sync.Mutex
+	rate    float64
+	burst   float64
+	allowed []*net.IPNet
+	proxies []*net.IPNet
+	size    int
+	lru     *list.List
+	buckets map[string]*list.Element
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	ip     string
+	tokens float64
+	last   time.Time
+}
+
+// Parse a list of IPs and CIDRs into networks, a single IP is a network of
+// just that IP. The config has already checked the list, see
+// config.ParseIPNets.
+func parseIPNets(list []string) []*net.IPNet {
+	nets, _ := config.ParseIPNets(list)
+	return nets
+}
+
+func newRateLimiter(cfg *config.Config) *rateLimiter {
+	return &rateLimiter{
+		rate:    cfg.SearchRateLimit,
+		burst:   float64(cfg.SearchBurst()),
+		allowed: parseIPNets(cfg.SearchRateAllowlist),
+		proxies: parseIPNets(cfg.TrustedProxies),
+		size:    maxRateLimitedClients,
+		lru:     list.New(),
+		buckets: map[string]*list.Element{},
+		now:     time.Now,
+	}
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// The IP of the client that made the request. Requests from a trusted proxy
+// are attributed to the last address in X-Forwarded-For that isn't one of
+// the trusted proxies, anyone else could put whatever they like in there.
+func (l *rateLimiter) clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(l.proxies, ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, h := range r.Header["X-Forwarded-For"] {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+
+		ip = hop
+		if !containsIP(l.proxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// Take a token from the bucket of ip. Returns 0 if there was one, otherwise
+// how long it will be until there is.
+func (l *rateLimiter) take(ip string) time.Duration {
+	l.lck.Lock()
+	defer l.lck.Unlock()
+
+	now := l.now()
+
+	var b *tokenBucket
+	if e, ok := l.buckets[ip]; ok {
+		l.lru.MoveToFront(e)
+		b = e.Value.(*tokenBucket)
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	} else {
+		b = &tokenBucket{
+			ip:     ip,
+			tokens: l.burst,
+			last:   now,
+		}
+		l.buckets[ip] = l.lru.PushFront(b)
+
+		for l.lru.Len() > l.size {
+			e := l.lru.Back()
+			l.lru.Remove(e)
+			delete(l.buckets, e.Value.(*tokenBucket).ip)
+		}
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// Wrap h so that clients going over the rate limit get a 429 instead. A nil
+// limiter doesn't limit anything.
+func limitRate(l *rateLimiter, h http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return h
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := l.clientIP(r)
+		if ip == nil || containsIP(l.allowed, ip) {
+			h(w, r)
+			return
+		}
+
+		wait := l.take(ip.String())
+		if wait == 0 {
+			h(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, errRateLimited,
+			fmt.Errorf("Too many searches, retry in %s", wait.Round(time.Millisecond)),
+			http.StatusTooManyRequests)
+	}
+}
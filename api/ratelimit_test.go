@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/etsy/hound/config"
+)
+
+func TestRateLimiterTake(t *testing.T) {
+	l := newRateLimiter(&config.Config{SearchRateLimit: 2, SearchRateBurst: 3})
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if wait := l.take("10.0.0.1"); wait != 0 {
+			t.Fatalf("expected search %d of the burst to be allowed, wait %s", i, wait)
+		}
+	}
+
+	if wait := l.take("10.0.0.1"); wait != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, got %s", wait)
+	}
+
+	// other clients have buckets of their own
+	if wait := l.take("10.0.0.2"); wait != 0 {
+		t.Fatalf("expected another client to be allowed, wait %s", wait)
+	}
+
+	now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		if wait := l.take("10.0.0.1"); wait != 0 {
+			t.Fatalf("expected search %d after a second to be allowed, wait %s", i, wait)
+		}
+	}
+	if wait := l.take("10.0.0.1"); wait == 0 {
+		t.Fatal("expected the bucket to be empty again")
+	}
+}
+
+func TestRateLimiterIsBounded(t *testing.T) {
+	l := newRateLimiter(&config.Config{SearchRateLimit: 1})
+	l.size = 10
+
+	for i := 0; i < 100; i++ {
+		l.take(fmt.Sprintf("10.0.0.%d", i))
+	}
+
+	if len(l.buckets) != 10 || l.lru.Len() != 10 {
+		t.Fatalf("expected 10 clients to be tracked, got %d", len(l.buckets))
+	}
+
+	if _, ok := l.buckets["10.0.0.99"]; !ok {
+		t.Fatal("expected the most recent client to be tracked")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	l := newRateLimiter(&config.Config{
+		SearchRateLimit: 1,
+		TrustedProxies:  []string{"10.0.0.1", "192.168.0.0/16"},
+	})
+
+	tests := []struct {
+		remote string
+		xff    string
+		ip     string
+	}{
+		{"1.2.3.4:1234", "", "1.2.3.4"},
+		{"1.2.3.4:1234", "5.6.7.8", "1.2.3.4"},
+		{"10.0.0.1:1234", "5.6.7.8", "5.6.7.8"},
+		{"10.0.0.1:1234", "9.9.9.9, 5.6.7.8, 192.168.1.1", "5.6.7.8"},
+		{"10.0.0.1:1234", "", "10.0.0.1"},
+		{"10.0.0.1:1234", "junk", "10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/search", nil)
+		r.RemoteAddr = test.remote
+		if test.xff != "" {
+			r.Header.Set("X-Forwarded-For", test.xff)
+		}
+
+		if ip := l.clientIP(r); ip.String() != test.ip {
+			t.Errorf("%s %q: expected %s, got %s", test.remote, test.xff, test.ip, ip)
+		}
+	}
+}
+
+func TestLimitRate(t *testing.T) {
+	l := newRateLimiter(&config.Config{
+		SearchRateLimit:     1,
+		SearchRateAllowlist: []string{"10.1.0.0/16"},
+	})
+
+	h := limitRate(l, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	search := func(remote string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/v1/search", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+
+	if w := search("1.2.3.4:1"); w.Code != http.StatusOK {
+		t.Fatalf("expected the first search to be allowed, got %d", w.Code)
+	}
+
+	w := search("1.2.3.4:2")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429, got %d", w.Code)
+	}
+	if ra := w.Header().Get("Retry-After"); ra != "1" {
+		t.Fatalf("expected to retry after 1s, got %q", ra)
+	}
+
+	for i := 0; i < 5; i++ {
+		if w := search("10.1.2.3:1"); w.Code != http.StatusOK {
+			t.Fatalf("expected allowlisted clients not to be limited, got %d", w.Code)
+		}
+	}
+}
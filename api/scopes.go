@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/searcher"
+)
+
+// The namespaces each caller may see, see config.Scopes. A nil scopes lets
+// everyone see every repo.
+type scopes struct {
+	// the namespaces of each identity, * standing for all of them
+	namespaces map[string]map[string]bool
+
+	// where the identity of a request comes from, see identityOf
+	header  string
+	proxies []*net.IPNet
+
+	// for the namespaces of repos that aren't running, see seesRepo
+	cfg *config.Config
+}
+
+var (
+	gScopes *scopes
+
+	// Finds the identity of a request instead of the identity-header, see
+	// SetIdentityFunc.
+	gIdentity func(r *http.Request) string
+)
+
+// Set how the identity of a request is found, for programs that embed hound
+// and authenticate requests themselves. The identity is looked up in the
+// scopes of the config, an empty one has no scopes.
+func SetIdentityFunc(fn func(r *http.Request) string) {
+	gIdentity = fn
+}
+
+func newScopes(cfg *config.Config) *scopes {
+	if len(cfg.Scopes) == 0 {
+		return nil
+	}
+
+	s := &scopes{
+		namespaces: map[string]map[string]bool{},
+		header:     cfg.IdentityHeader,
+		proxies:    parseIPNets(cfg.TrustedProxies),
+		cfg:        cfg,
+	}
+
+	for identity, namespaces := range cfg.Scopes {
+		s.namespaces[identity] = map[string]bool{}
+		for _, ns := range namespaces {
+			s.namespaces[identity][ns] = true
+		}
+	}
+	return s
+}
+
+// The identity of the caller that made the request, empty if it has none.
+// The identity header is only believed when a trusted proxy sent it.
+func (s *scopes) identityOf(r *http.Request) string {
+	if s == nil {
+		return ""
+	}
+
+	if gIdentity != nil {
+		return gIdentity(r)
+	}
+
+	if s.header == "" {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(s.proxies, ip) {
+		return ""
+	}
+	return r.Header.Get(s.header)
+}
+
+// Whether the caller of r may see every namespace, which everyone may without
+// scopes. Only such callers see what hound has beyond the repos, like the
+// whole dbpath, or change it.
+func (s *scopes) seesAll(r *http.Request) bool {
+	return s == nil || s.namespaces[s.identityOf(r)]["*"]
+}
+
+// Whether the caller of r may see the repo name, which may not be running,
+// like a repo that is still being built. Repos that are neither running nor
+// in the config are only seen by those who see everything.
+func (s *scopes) seesRepo(r *http.Request, name string) bool {
+	if s.seesAll(r) {
+		return true
+	}
+
+	var repo *config.Repo
+	if sr := GetSearchers()[name]; sr != nil {
+		repo = sr.Config()
+	} else {
+		s.cfg.RLockRepos()
+		repo = s.cfg.Repos[name]
+		s.cfg.RUnlockRepos()
+	}
+
+	return repo != nil && (repo.Namespace == "" || s.namespaces[s.identityOf(r)][repo.Namespace])
+}
+
+// The identity whose searches the caller of r may see in the analytics, nil
+// for everyone's. See seesAll.
+func (s *scopes) callerOf(r *http.Request) *string {
+	if s.seesAll(r) {
+		return nil
+	}
+
+	identity := s.identityOf(r)
+	return &identity
+}
+
+// The searchers of idx that the caller of r may see, which are all of them
+// without scopes.
+func (s *scopes) visible(r *http.Request, idx map[string]*searcher.Searcher) map[string]*searcher.Searcher {
+	if s == nil {
+		return idx
+	}
+
+	allowed := s.namespaces[s.identityOf(r)]
+	if allowed["*"] {
+		return idx
+	}
+
+	res := make(map[string]*searcher.Searcher, len(idx))
+	for name, sr := range idx {
+		if ns := sr.Config().Namespace; ns == "" || allowed[ns] {
+			res[name] = sr
+		}
+	}
+	return res
+}
+
+// The searchers the caller of r may see, see scopes.visible. Every handler
+// that serves repos to callers goes through these rather than GetSearchers,
+// so a search of every repo never touches the indexes of other namespaces.
+func visibleSearchers(r *http.Request) map[string]*searcher.Searcher {
+	return gScopes.visible(r, GetSearchers())
+}
+
+// The first of the comma separated repos in v that the caller can't see
+// (see visibleSearchers) but that hound has, which the caller is forbidden
+// from. Globs only ever match the repos the caller can see, so they are
+// never forbidden. Returns empty if the caller may see all of them.
+func forbiddenRepo(v string, visible map[string]*searcher.Searcher) string {
+	for _, repo := range parseAsList(v) {
+		if isRepoGlob(repo) {
+			continue
+		}
+
+		if s, _ := findSearcher(repo, visible); s != nil {
+			continue
+		}
+
+		if s, _ := findSearcher(repo, GetSearchers()); s != nil {
+			return repo
+		}
+	}
+	return ""
+}
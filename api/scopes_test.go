@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/searcher"
+)
+
+func TestScopes(t *testing.T) {
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	// a is team-a's, b is team-b's and c is everyone's
+	idx := map[string]*searcher.Searcher{}
+	for name, ns := range map[string]string{"a": "team-a", "b": "team-b", "c": ""} {
+		src := filepath.Join(dbpath, "src-"+name)
+		if err := os.Mkdir(src, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("needle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := searcher.New(dbpath, name, &config.Repo{
+			Url:            "file://" + src,
+			Vcs:            "local",
+			MsBetweenPolls: 60000,
+			Namespace:      ns,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+		idx[name] = s
+	}
+
+	defer SetSearchers(gSearchers)
+	SetSearchers(idx)
+
+	m := http.NewServeMux()
+	defer func() { gScopes = nil }()
+	defer SetAnalyticsSink(gAnalytics)
+	Setup(m, nil, &config.Config{
+		DbPath:              dbpath,
+		SearchAnalyticsSize: 100,
+		Scopes: map[string][]string{
+			"alice": {"team-a"},
+			"root":  {"*"},
+		},
+		IdentityHeader: "X-Hound-User",
+		TrustedProxies: []string{"192.0.2.1"},
+	})
+
+	do := func(method, path, user, remote string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("X-Hound-User", user)
+		if remote != "" {
+			r.RemoteAddr = remote
+		}
+		m.ServeHTTP(w, r)
+		return w
+	}
+
+	get := func(path, user, remote string) *httptest.ResponseRecorder {
+		return do("GET", path, user, remote)
+	}
+
+	searched := func(user, remote string) []string {
+		w := get("/api/v1/search?q=needle", user, remote)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected a 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var res struct {
+			Results map[string]interface{}
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		var repos []string
+		for repo := range res.Results {
+			repos = append(repos, repo)
+		}
+		sort.Strings(repos)
+		return repos
+	}
+
+	assertStrings(t, searched("alice", ""), "a", "c")
+	assertStrings(t, searched("root", ""), "a", "b", "c")
+	assertStrings(t, searched("bob", ""), "c")
+
+	// only a trusted proxy can say who the caller is
+	assertStrings(t, searched("root", "198.51.100.1:1234"), "c")
+
+	var repos map[string]*config.Repo
+	if err := json.Unmarshal(get("/api/v1/repos", "alice", "").Body.Bytes(), &repos); err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 2 || repos["a"] == nil || repos["c"] == nil {
+		t.Fatalf("expected only the repos of team-a and everyone's, got %v", repos)
+	}
+
+	for _, path := range []string{
+		"/api/v1/search?q=needle&repos=a,b",
+		"/api/v1/file?repo=b&path=a.txt",
+		"/api/v1/files?repo=b",
+		"/api/v1/excludes?repo=b",
+	} {
+		if w := get(path, "alice", ""); w.Code != http.StatusForbidden {
+			t.Fatalf("%s: expected a 403, got %d", path, w.Code)
+		}
+	}
+
+	if w := get("/api/v1/file?repo=a&path=a.txt", "alice", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected a repo of team-a to be open to alice, got %d", w.Code)
+	}
+
+	// without an admin address, the admin routes are on the same mux
+	var stats struct {
+		Repos  map[string]interface{}
+		DbPath interface{}
+	}
+	if err := json.Unmarshal(get("/api/v1/stats", "alice", "").Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Repos) != 2 || stats.Repos["b"] != nil || stats.DbPath != nil {
+		t.Fatalf("expected only the stats of the repos alice sees, got %v", stats)
+	}
+
+	var health struct {
+		Repos int
+	}
+	if err := json.Unmarshal(get("/api/v1/health", "alice", "").Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	if health.Repos != 2 {
+		t.Fatalf("expected alice to count 2 repos, got %d", health.Repos)
+	}
+
+	var dirs []*searcher.IndexDir
+	if err := json.Unmarshal(get("/api/v1/indexes", "alice", "").Body.Bytes(), &dirs); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		if dir.Repo != "a" && dir.Repo != "c" {
+			t.Fatalf("expected only the indexes of the repos alice sees, got one of %q", dir.Repo)
+		}
+	}
+	if len(dirs) != 2 {
+		t.Fatalf("expected the indexes of a and c, got %d", len(dirs))
+	}
+
+	// alice searched once that was allowed, everyone else did more
+	var top struct {
+		Queries []*QueryCount
+	}
+	if err := json.Unmarshal(get("/api/v1/analytics/top", "alice", "").Body.Bytes(), &top); err != nil {
+		t.Fatal(err)
+	}
+	if len(top.Queries) != 1 || top.Queries[0].Count != 1 {
+		t.Fatalf("expected only the queries of alice, got %v", top.Queries)
+	}
+	if err := json.Unmarshal(get("/api/v1/analytics/top", "", "").Body.Bytes(), &top); err != nil {
+		t.Fatal(err)
+	}
+	if len(top.Queries) != 0 {
+		t.Fatalf("expected callers without an identity to see no queries, got %v", top.Queries)
+	}
+
+	if w := do("POST", "/api/v1/config/diff", "alice", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("expected the config diff to be a 403, got %d", w.Code)
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
+	"github.com/etsy/hound/searcher"
+)
+
+// A line of a streamed search response. There is a line with the Repo and
+// its Result for every repo with matches, in the order the repos finish.
+// The last line has the Stats, or the Error and its Code if the search
+// failed part way through.
+type streamLine struct {
+	Repo   string                `json:",omitempty"`
+	Result *index.SearchResponse `json:",omitempty"`
+	Stats  *Stats                `json:",omitempty"`
+	Error  string                `json:",omitempty"`
+	Code   string                `json:",omitempty"`
+}
+
+// A line with the results of a repo, projected down to some of their fields.
+type projectedLine struct {
+	Repo   string
+	Result interface{}
+}
+
+// Writes each line of a streamed response as soon as it's ready.
+type lineWriter struct {
+	w   http.ResponseWriter
+	enc *json.Encoder
+
+	// the fields of the results to write, nil for all of them
+	fields *fieldSet
+}
+
+func newLineWriter(w http.ResponseWriter) *lineWriter {
+	w.Header().Set("Content-Type", "application/x-ndjson;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	return &lineWriter{
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (l *lineWriter) write(line *streamLine) error {
+	return l.encode(line)
+}
+
+func (l *lineWriter) encode(line interface{}) error {
+	if err := l.enc.Encode(line); err != nil {
+		return err
+	}
+
+	if f, ok := l.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// Write the results of some repos, in the order of their names.
+func (l *lineWriter) writeResults(results map[string]*index.SearchResponse) error {
+	repos := make([]string, 0, len(results))
+	for repo := range results {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	for _, repo := range repos {
+		var line interface{} = &streamLine{
+			Repo:   repo,
+			Result: results[repo],
+		}
+		if l.fields != nil {
+			line = &projectedLine{
+				Repo:   repo,
+				Result: l.fields.project(results[repo]),
+			}
+		}
+
+		if err := l.encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search like the search api does, but write the results of each repo as a
+// line of JSON as soon as it has been searched rather than all of them at
+// the end, which is the first anyone sees of them. Once the first line is
+// written, an error can only be reported in the last line.
+func streamSearch(
+	w http.ResponseWriter,
+	query string,
+	opts *index.SearchOptions,
+	ignoreCase *bool,
+	repos []string,
+	vrepos []string,
+	within fileSets,
+	rev string,
+	dedupe bool,
+	fields *fieldSet,
+	idx map[string]*searcher.Searcher,
+	stats *Stats) {
+
+	lw := newLineWriter(w)
+	lw.fields = fields
+
+	var err error
+	if rev == "" {
+		err = searchEach(query, opts, ignoreCase, repos, vrepos, within, dedupe, idx, stats, lw.writeResults)
+	} else {
+		var results map[string]*index.SearchResponse
+		if results, err = searchRev(query, opts, ignoreCase, repos[0], rev, idx, stats); err == nil {
+			err = lw.writeResults(results)
+		}
+	}
+
+	if err != nil {
+		logger.Warn("search failed", logger.Fields{
+			"event": "search",
+			"query": query,
+			"error": err,
+		})
+
+		code, _ := searchErrorCode(err)
+
+		lw.write(&streamLine{
+			Error: err.Error(),
+			Code:  code,
+		})
+		return
+	}
+
+	lw.write(&streamLine{Stats: stats})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/searcher"
+)
+
+// Decode the lines of a streamed response.
+func readLines(t *testing.T, body string) []*streamLine {
+	var lines []*streamLine
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		var line streamLine
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("line %q isn't JSON: %s", sc.Text(), err)
+		}
+		lines = append(lines, &line)
+	}
+	return lines
+}
+
+func TestLineWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	lw := newLineWriter(rec)
+
+	if err := lw.writeResults(map[string]*index.SearchResponse{
+		"b": {FilesWithMatch: 2},
+		"a": {FilesWithMatch: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lw.write(&streamLine{Stats: &Stats{FilesWithMatch: 3}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/x-ndjson") {
+		t.Fatalf("expected a content type of application/x-ndjson, got %s", ct)
+	}
+
+	if !rec.Flushed {
+		t.Fatal("expected the lines to be flushed")
+	}
+
+	lines := readLines(t, rec.Body.String())
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+
+	if lines[0].Repo != "a" || lines[1].Repo != "b" || lines[1].Result.FilesWithMatch != 2 {
+		t.Fatalf("expected the results of a and then b, got %+v and %+v", lines[0], lines[1])
+	}
+
+	if lines[2].Stats == nil || lines[2].Stats.FilesWithMatch != 3 || lines[2].Repo != "" {
+		t.Fatalf("expected the stats last, got %+v", lines[2])
+	}
+}
+
+func TestStreamSearchEndsWithStats(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stats := &Stats{Languages: map[string]int{}}
+	streamSearch(rec, "needle", &index.SearchOptions{}, nil, []string{"missing"}, nil, nil, "", false, nil,
+		map[string]*searcher.Searcher{}, stats)
+
+	lines := readLines(t, rec.Body.String())
+	if len(lines) != 1 || lines[0].Stats == nil || lines[0].Error != "" {
+		t.Fatalf("expected just the stats, got %s", rec.Body.String())
+	}
+}
+
+// A streamed search fails with the same codes as one that isn't.
+func TestSearchErrorCode(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		code string
+	}{
+		{&searcher.UnknownRevError{Rev: "nope", Err: errors.New("unknown revision")}, errInvalidParam},
+		{searcher.ErrRevNotSupported, errInvalidParam},
+		{searcher.ErrTooManyRevBuilds, errRateLimited},
+		{errors.New("disk full"), errSearchFailed},
+	} {
+		if code, _ := searchErrorCode(test.err); code != test.code {
+			t.Fatalf("%v: expected %s, got %s", test.err, test.code, code)
+		}
+	}
+}
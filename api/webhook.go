@@ -0,0 +1,235 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/logger"
+	"github.com/etsy/hound/vcs"
+)
+
+const (
+	// the largest webhook payload we are willing to read.
+	maxWebhookBodySize = 1 << 20
+
+	githubSignatureHeader = "X-Hub-Signature-256"
+	githubEventHeader     = "X-GitHub-Event"
+	gitlabTokenHeader     = "X-Gitlab-Token"
+	gitlabEventHeader     = "X-Gitlab-Event"
+)
+
+// The parts of a GitHub or GitLab push event that we care about.
+type pushEvent struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		// GitHub
+		CloneUrl string `json:"clone_url"`
+		SshUrl   string `json:"ssh_url"`
+		GitUrl   string `json:"git_url"`
+		HtmlUrl  string `json:"html_url"`
+
+		// GitLab
+		Url        string `json:"url"`
+		GitHttpUrl string `json:"git_http_url"`
+		GitSshUrl  string `json:"git_ssh_url"`
+		Homepage   string `json:"homepage"`
+	} `json:"repository"`
+}
+
+// All the urls the pushed repository is known by.
+func (e *pushEvent) urls() []string {
+	r := &e.Repository
+	var urls []string
+	for _, u := range []string{
+		r.CloneUrl, r.SshUrl, r.GitUrl, r.HtmlUrl,
+		r.Url, r.GitHttpUrl, r.GitSshUrl, r.Homepage,
+	} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// The branch that was pushed to, or "" if the push was not to a branch.
+func (e *pushEvent) branch() string {
+	if !strings.HasPrefix(e.Ref, "refs/heads/") {
+		return ""
+	}
+	return strings.TrimPrefix(e.Ref, "refs/heads/")
+}
+
+// Reduce a repo url to host/path so that the different ways of referring
+// to the same repo (https, ssh, scp style, with or without .git) compare
+// as equal.
+func normalizeRepoUrl(u string) string {
+	u = strings.TrimSpace(u)
+
+	// scp style, git@github.com:foo/bar.git
+	if !strings.Contains(u, "://") {
+		if i := strings.Index(u, ":"); i >= 0 {
+			u = "ssh://" + u[:i] + "/" + u[i+1:]
+		}
+	}
+
+	p, err := url.Parse(u)
+	if err != nil {
+		return strings.ToLower(u)
+	}
+
+	host := strings.ToLower(p.Hostname())
+	path := strings.TrimSuffix(strings.Trim(p.Path, "/"), ".git")
+	return strings.ToLower(host + "/" + path)
+}
+
+// Verify the payload against the webhook secret. GitHub signs the body with
+// an HMAC, GitLab simply echoes the secret back in a header.
+func verifyWebhook(r *http.Request, body []byte, secret string) bool {
+	if sig := r.Header.Get(githubSignatureHeader); sig != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		exp := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(sig), []byte(exp))
+	}
+
+	if tok := r.Header.Get(gitlabTokenHeader); tok != "" {
+		return hmac.Equal([]byte(tok), []byte(secret))
+	}
+
+	return false
+}
+
+// Is the event a push? Events from other sources are assumed to be pushes.
+func isPushEvent(r *http.Request) bool {
+	if ev := r.Header.Get(githubEventHeader); ev != "" {
+		return ev == "push"
+	}
+
+	if ev := r.Header.Get(gitlabEventHeader); ev != "" {
+		return ev == "Push Hook"
+	}
+
+	return true
+}
+
+// The branch that the repo tracks, or "" if the vcs has no such notion.
+func trackedBranch(repo *config.Repo) string {
+	wd, err := vcs.New(repo.Vcs, repo.VcsConfig())
+	if err != nil {
+		return ""
+	}
+
+	if git, ok := wd.Driver.(*vcs.GitDriver); ok {
+		return git.Ref
+	}
+	return ""
+}
+
+// Find the names of the repos that the push event applies to.
+func reposForPush(ev *pushEvent, repos map[string]*config.Repo) []string {
+	urls := map[string]bool{}
+	for _, u := range ev.urls() {
+		urls[normalizeRepoUrl(u)] = true
+	}
+
+	var names []string
+	for name, repo := range repos {
+		if !urls[normalizeRepoUrl(repo.Url)] {
+			continue
+		}
+
+		if br := trackedBranch(repo); br != "" && br != ev.branch() {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func setupWebhook(m *http.ServeMux, cfg *config.Config) {
+	m.HandleFunc("/api/v1/webhook", func(w http.ResponseWriter, r *http.Request) {
+		if checkReady(w) == false {
+			return
+		}
+
+		if r.Method != "POST" {
+			writeError(w, errMethodNotAllowed,
+				errors.New(http.StatusText(http.StatusMethodNotAllowed)),
+				http.StatusMethodNotAllowed)
+			return
+		}
+
+		if cfg.WebhookSecret == "" {
+			writeError(w, errNotEnabled,
+				errors.New("Webhooks are not enabled"),
+				http.StatusForbidden)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+		if err != nil {
+			writeError(w, errInvalidBody, err, http.StatusBadRequest)
+			return
+		}
+
+		if !verifyWebhook(r, body, cfg.WebhookSecret) {
+			writeError(w, errInvalidSignature,
+				errors.New("Invalid webhook signature"),
+				http.StatusUnauthorized)
+			return
+		}
+
+		if !isPushEvent(r) {
+			writeJson(w, []string{}, http.StatusAccepted)
+			return
+		}
+
+		var ev pushEvent
+		if err := json.Unmarshal(body, &ev); err != nil {
+			writeError(w, errInvalidBody, err, http.StatusBadRequest)
+			return
+		}
+
+		// a reload may be changing the repos at the same time
+		cfg.RLockRepos()
+		names := reposForPush(&ev, cfg.Repos)
+		cfg.RUnlockRepos()
+
+		idx := GetSearchers()
+		updated := []string{}
+		for _, name := range names {
+			searcher := idx[name]
+			if searcher == nil {
+				continue
+			}
+
+			if !searcher.Update() {
+				logger.Warn("webhook: push updates are not enabled", logger.Fields{
+					"event": "webhook",
+					"repo":  name,
+				})
+				continue
+			}
+
+			updated = append(updated, name)
+		}
+
+		logger.Info("webhook received", logger.Fields{
+			"event":   "webhook",
+			"ref":     ev.Ref,
+			"updated": strings.Join(updated, ","),
+		})
+
+		writeJson(w, updated, http.StatusAccepted)
+	})
+}
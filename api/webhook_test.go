@@ -0,0 +1,66 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/etsy/hound/config"
+)
+
+func TestNormalizeRepoUrl(t *testing.T) {
+	exp := "github.com/foo/bar"
+	for _, u := range []string{
+		"https://github.com/foo/bar.git",
+		"https://github.com/Foo/Bar",
+		"git@github.com:foo/bar.git",
+		"ssh://git@github.com/foo/bar.git",
+		"git://github.com/foo/bar.git",
+	} {
+		if n := normalizeRepoUrl(u); n != exp {
+			t.Fatalf("expected %s for %s, got %s", exp, u, n)
+		}
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"ref": "refs/heads/master"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+
+	r, _ := http.NewRequest("POST", "/api/v1/webhook", nil)
+	r.Header.Set(githubSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	if !verifyWebhook(r, body, "secret") {
+		t.Fatal("expected valid github signature to verify")
+	}
+
+	if verifyWebhook(r, body, "other") {
+		t.Fatal("expected signature with the wrong secret to fail")
+	}
+
+	r, _ = http.NewRequest("POST", "/api/v1/webhook", nil)
+	if verifyWebhook(r, body, "secret") {
+		t.Fatal("expected unsigned payload to fail")
+	}
+}
+
+func TestReposForPush(t *testing.T) {
+	repos := map[string]*config.Repo{
+		"master": &config.Repo{Url: "https://github.com/foo/bar.git", Vcs: "git"},
+		"custom": &config.Repo{Url: "git@github.com:foo/bar.git", Vcs: "git",
+			VcsConfigMessage: &config.SecretMessage{}},
+		"other": &config.Repo{Url: "https://github.com/foo/baz.git", Vcs: "git"},
+	}
+	*repos["custom"].VcsConfigMessage = []byte(`{"ref": "custom"}`)
+
+	var ev pushEvent
+	ev.Ref = "refs/heads/master"
+	ev.Repository.CloneUrl = "https://github.com/foo/bar.git"
+
+	names := reposForPush(&ev, repos)
+	if len(names) != 1 || names[0] != "master" {
+		t.Fatalf("expected only master to be updated, got %v", names)
+	}
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"sort"
 	"strings"
 	"syscall"
 	"path/filepath"
@@ -16,8 +19,10 @@ import (
 
 	"github.com/etsy/hound/api"
 	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/logger"
 	"github.com/etsy/hound/searcher"
 	"github.com/etsy/hound/ui"
+	"github.com/etsy/hound/vcs"
 )
 
 const gracefulShutdownSignal = syscall.SIGTERM
@@ -25,12 +30,26 @@ const gracefulShutdownSignal = syscall.SIGTERM
 type scanCallback func(path string)
 
 var (
-	info_log  *log.Logger
-	error_log *log.Logger
 	startTime = time.Now()
+
+	// The directories scanChanges skips besides .git, see -watch-exclude-dirs.
+	watchExcludeDirs []string
 )
 
-func makeAllSearchers(cfg *config.Config) (bool, error) {
+// The timeouts of the http server. Searches get their own write timeout as
+// their responses can take much longer to produce than anything else.
+type httpTimeouts struct {
+	ReadHeader  time.Duration
+	Read        time.Duration
+	Write       time.Duration
+	Idle        time.Duration
+	SearchWrite time.Duration
+}
+
+// Make searchers for all repos in the config. If noIndex is set, the searchers
+// are opened from existing indexes rather than being cloned and indexed. If
+// warmup is set, the indexes are paged into memory before any are searched.
+func makeAllSearchers(cfg *config.Config, noIndex, warmup bool) (bool, error) {
 	// Ensure we have a dbpath
 	if _, err := os.Stat(cfg.DbPath); err != nil {
 		if err := os.MkdirAll(cfg.DbPath, os.ModePerm); err != nil {
@@ -38,11 +57,25 @@ func makeAllSearchers(cfg *config.Config) (bool, error) {
 		}
 	}
 
-	searchers, errs, err := searcher.MakeAll(cfg)
+	var (
+		searchers map[string]*searcher.Searcher
+		errs      map[string]error
+		err       error
+	)
+
+	if noIndex {
+		searchers, errs, err = searcher.OpenAll(cfg)
+	} else {
+		searchers, errs, err = searcher.MakeAll(cfg)
+	}
 	if err != nil {
 		return false, err
 	}
 
+	if warmup {
+		warmupSearchers(searchers)
+	}
+
 	// set searcher list 
 	api.SetSearchers(searchers)
 
@@ -59,6 +92,54 @@ func makeAllSearchers(cfg *config.Config) (bool, error) {
 	return true, nil
 }
 
+// Put a repo that failed to start, but started when it was retried, back in
+// the config and make it searchable.
+func addRecoveredSearcher(cfg *config.Config, name string, s *searcher.Searcher) {
+	cfg.LockRepos()
+	defer cfg.UnlockRepos()
+
+	cfg.Repos[name] = s.Repo
+	api.SetSearcher(name, s)
+}
+
+// Page every index into memory so the first searches are as fast as the
+// ones that follow. An index that fails to warm up is still searchable.
+func warmupSearchers(searchers map[string]*searcher.Searcher) {
+	startedAt := time.Now()
+
+	names := make([]string, 0, len(searchers))
+	for name := range searchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var total int64
+	for i, name := range names {
+		n, err := searchers[name].Warmup()
+		if err != nil {
+			logger.Warn("failed to warm up index", logger.Fields{
+				"event": "warmup",
+				"repo":  name,
+				"error": err,
+			})
+			continue
+		}
+		total += n
+
+		logger.Info(fmt.Sprintf("warmed up index %d/%d", i+1, len(names)), logger.Fields{
+			"event": "warmup",
+			"repo":  name,
+			"bytes": n,
+		})
+	}
+
+	logger.Info("all indexes warmed up", logger.Fields{
+		"event":      "warmup",
+		"bytes":      total,
+		"durationMs": int(time.Since(startedAt) / time.Millisecond),
+	})
+}
+
 func makeSearchers(cfg *config.Config) (map[string]*searcher.Searcher, bool, error) {
 	// Ensure we have a dbpath
 	if _, err := os.Stat(cfg.DbPath); err != nil {
@@ -79,9 +160,64 @@ func makeSearchers(cfg *config.Config) (map[string]*searcher.Searcher, bool, err
 	return searchers, true, nil
 }
 
+// Load the config in filename, and make sure that every repo's vcs exists
+// and accepts its vcs-config, which the config can't check by itself.
+func loadConfig(filename string, cfg *config.Config) error {
+	if err := cfg.LoadFromFile(filename); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(cfg.Repos))
+	for name := range cfg.Repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		repo := cfg.Repos[name]
+		if _, err := vcs.New(repo.Vcs, repo.VcsConfig()); err != nil {
+			return fmt.Errorf("%s: repo %s: %s", filename, name, err)
+		}
+	}
+	return nil
+}
+
+// Validate every repo in the config and print a report of the problems
+// found. Returns the exit code, which is non-zero if any repo is invalid.
+func checkConfig(cfg *config.Config) int {
+	names := make([]string, 0, len(cfg.Repos))
+	for name := range cfg.Repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	code := 0
+	for _, name := range names {
+		repo := cfg.Repos[name]
+		errs := repo.Validate()
+
+		if _, err := vcs.New(repo.Vcs, repo.VcsConfig()); err != nil {
+			errs = append(errs, err)
+		}
+
+		if len(errs) == 0 {
+			fmt.Printf("ok    %s\n", name)
+			continue
+		}
+
+		code = 1
+		fmt.Printf("FAIL  %s\n", name)
+		for _, err := range errs {
+			fmt.Printf("        %s\n", err)
+		}
+	}
+
+	return code
+}
+
 func handleShutdown(shutdownCh <-chan os.Signal) {
 	<-shutdownCh
-	info_log.Printf("Graceful shutdown requested...")
+	logger.Info("graceful shutdown requested...", nil)
 	for _, s := range api.GetSearchers() {
 		s.Stop()
 	}
@@ -106,7 +242,9 @@ func makeTemplateData(cfg *config.Config) (interface{}, error) {
 
 	res := map[string]*config.Repo{}
 	for name, repo := range cfg.Repos {
-		res[name] = repo
+		if repo.IsEnabled() {
+			res[name] = repo
+		}
 	}
 
 	b, err := json.Marshal(res)
@@ -118,10 +256,63 @@ func makeTemplateData(cfg *config.Config) (interface{}, error) {
 	return &data, nil
 }
 
+// A tls config that only allows TLS 1.2+ and modern cipher suites.
+func makeTlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:               tls.VersionTLS12,
+		PreferServerCipherSuites: true,
+		CurvePreferences: []tls.CurveID{
+			tls.X25519,
+			tls.CurveP256,
+		},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+	}
+}
+
+// Both or neither of the tls cert and key must be given.
+func checkTls(certFile, keyFile string) error {
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("both a tls cert and a tls key are required to serve https")
+	}
+	return nil
+}
+
+// Replace the server's write timeout on searches, and batches of them, with
+// the search write timeout, 0 meaning searches have none.
+func withSearchWriteTimeout(h http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/search" || r.URL.Path == "/api/v1/search/batch" {
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
+
+			if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+				logger.Warn("failed to set the search write timeout", logger.Fields{
+					"event": "search",
+					"error": err,
+				})
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
 func runHttp(
-	m *http.ServeMux,
+	m,
+	admin *http.ServeMux,
 	addr string,
 	dev bool,
+	certFile,
+	keyFile string,
+	timeouts *httpTimeouts,
 	cfg *config.Config) error {
 
 	h, err := ui.Content(dev, cfg)
@@ -130,44 +321,101 @@ func runHttp(
 	}
 
 	m.Handle("/", h)
-	api.Setup(m)
-	return http.ListenAndServe(addr, m)
+	api.Setup(m, admin, cfg)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           withSearchWriteTimeout(m, timeouts.SearchWrite),
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+
+	if certFile == "" {
+		return srv.ListenAndServe()
+	}
+
+	srv.TLSConfig = makeTlsConfig()
+	return srv.ListenAndServeTLS(certFile, keyFile)
+}
+
+// Serve the admin routes on their own address, which is expected to be one
+// that only operators can reach (e.g. localhost).
+func runAdminHttp(admin *http.ServeMux, addr string, timeouts *httpTimeouts) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           admin,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+	return srv.ListenAndServe()
 }
 
 func scanChanges(
 	watchPath string, 
 	allFiles bool, cb scanCallback) {
 	for {
-		filepath.Walk(watchPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
-			}
+		if path := scanOnce(watchPath, allFiles, watchExcludeDirs, startTime); path != "" {
+			cb(path)
+			startTime = time.Now()
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
 
-			if path == ".git" && info.IsDir() {
-				return filepath.SkipDir
-			}
-			/*
-			for _, x := range excludeDirs {
-				if x == path {
-					return filepath.SkipDir
-				}
-			}
-			*/
-			// ignore hidden files
-			if filepath.Base(path)[0] == '.' {
-				return nil
-			}
+// Walk watchPath once and return the first file (any file if allFiles is
+// set, otherwise a .go file) that was modified after since, or "" if there
+// is none. .git directories are never walked, nor are the directories in
+// excludeDirs, which are names (vendor) or paths relative to watchPath
+// (ui/build).
+func scanOnce(watchPath string, allFiles bool, excludeDirs []string, since time.Time) string {
+	var changed string
+	filepath.Walk(watchPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
 
-			if (allFiles || filepath.Ext(path) == ".go") && info.ModTime().After(startTime) {
-				cb(path)
-				startTime = time.Now()
-				return errors.New("done")
-			}
+		if info.IsDir() && path != watchPath && isExcludedDir(watchPath, path, excludeDirs) {
+			return filepath.SkipDir
+		}
 
+		// ignore hidden files
+		if filepath.Base(path)[0] == '.' {
 			return nil
-		})
-		time.Sleep(500 * time.Millisecond)
+		}
+
+		if (allFiles || filepath.Ext(path) == ".go") && info.ModTime().After(since) {
+			changed = path
+			return errors.New("done")
+		}
+
+		return nil
+	})
+	return changed
+}
+
+// Is the directory at path, under root, one that scanChanges skips?
+func isExcludedDir(root, path string, excludeDirs []string) bool {
+	name := filepath.Base(path)
+	if name == ".git" {
+		return true
 	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	for _, x := range excludeDirs {
+		x = filepath.Clean(x)
+		if x == name || x == rel {
+			return true
+		}
+	}
+	return false
 }
 
 func checkConfigChange(
@@ -177,100 +425,262 @@ func checkConfigChange(
 	go func() {
 		scanChanges(filename, true, func(path string) {
 			var cfgn config.Config
-			if err := cfgn.LoadFromFile(path); err != nil {
-				// ignore the error as we might in the middle of the changing 
+			if err := loadConfig(path, &cfgn); err != nil {
+				// we might be in the middle of the change, the running
+				// config is kept until the file is fixed
+				logger.Error("config is invalid, not reloading", logger.Fields{
+					"event":  "reload",
+					"config": path,
+					"error":  err,
+				})
 				return 
 			}
 
-			deleted := map[string]string{}
-			// remove not changed repo 
-			for name, repo := range cfg.Repos {
-				repo1, ok := cfgn.Repos[name]
-
-				// filter out ms-between-poll as it the value can be dynamic 
-				repo1.MsBetweenPolls = repo.MsBetweenPolls
-				// can have anything else which we use to trigger hot reload 
-
-				if ok && repo.ToJsonString() == repo1.ToJsonString() {
-					info_log.Println("no change for: ", name)
-					// no change 
-					delete(cfgn.Repos, name)
-				} else if ok {
-					info_log.Println("config json: ",  repo.ToJsonString())
-					info_log.Println("config json: ",  repo1.ToJsonString())
-					// the config is udpated, need to restart 
-					info_log.Println("config is altered, will restart: ", name)
-					deleted[name] = name
-				} else {
-					// not found. this was removed from config file 
-					// need to stop it 
-					info_log.Println("deleted, remove from cfg: ", name)
-					delete(cfg.Repos,  name)
-					deleted[name] = name
-				}
-			}
+			reloadConfig(cfg, &cfgn)
+		})
+	}()
+}
 
-			// add new config back into cfg.Repos for next loop
-			for name, repo := range cfgn.Repos {
-				_, ok := cfg.Repos[name];
-				if !ok {
-					cfg.Repos[name] = repo
-				} else if _, ok = deleted[name]; ok {
-					// in cfg.Repos but also in deleted for restart, then its config 
-					// has been updated, so add it cfg.Repos for next loop 
-					cfg.Repos[name] = repo
-				}
-			}
+// Apply the changes in the repos of cfgn, the config that was just loaded,
+// to cfg and the live searchers. Repos that were removed or disabled are
+// stopped, new and enabled ones are started and changed ones restarted.
+func reloadConfig(cfg, cfgn *config.Config) {
+	// the repos are only locked while cfg.Repos changes, requests that read
+	// them shouldn't wait for the searchers to be stopped and rebuilt
+	cfg.LockRepos()
+
+	diff := cfg.Diff(cfgn)
+
+	for _, name := range diff.Unchanged {
+		logger.Debug("no change for repo", logger.Fields{
+			"event": "reload",
+			"repo":  name,
+		})
+
+		// the changes only affect which repos are searched, so the
+		// running searcher takes a copy of the new config instead of
+		// restarting. The live repo is shared with requests and is never
+		// changed in place.
+		repo := *cfgn.Repos[name]
+		repo.MsBetweenPolls = cfg.Repos[name].MsBetweenPolls
+		cfg.Repos[name] = &repo
+		if s := api.GetSearchers()[name]; s != nil {
+			s.Reconfigure(&repo)
+		}
+		delete(cfgn.Repos, name)
+	}
+
+	deleted := map[string]string{}
+	removed := map[string]bool{}
+	for _, name := range diff.Restarted {
+		logger.Debug("config json", logger.Fields{
+			"repo": name,
+			"old":  cfg.Repos[name].ToJsonString(),
+			"new":  cfgn.Repos[name].ToJsonString(),
+		})
+		// the config is udpated, need to restart 
+		if cfgn.Repos[name].IsEnabled() {
+			logger.Info("config is altered, will restart", logger.Fields{
+				"event": "reload",
+				"repo":  name,
+			})
+		} else {
+			logger.Info("disabled, will stop", logger.Fields{
+				"event": "reload",
+				"repo":  name,
+			})
+		}
+		deleted[name] = name
+	}
 
+	for _, name := range diff.Removed {
+		// not found. this was removed from config file 
+		// need to stop it 
+		logger.Info("deleted, remove from cfg", logger.Fields{
+			"event": "reload",
+			"repo":  name,
+		})
+		delete(cfg.Repos,  name)
+		deleted[name] = name
+		removed[name] = true
+	}
+
+	// add new and restarted repos into cfg.Repos for next loop, any
+	// that failed before are started anew rather than retried
+	for name, repo := range cfgn.Repos {
+		cfg.Repos[name] = repo
+		searcher.ForgetFailed(name)
+	}
+	for name := range deleted {
+		searcher.ForgetFailed(name)
+	}
 
-			// getCurrent searchers which is a reference to api gSearchers object 
-			searchers := api.GetSearchers()
-			// disable deleted repos
-			if len(deleted) > 0 {
-				for name, s := range searchers {
-					if  _, ok :=  deleted[name]; ok {
-						info_log.Println("searcher stopped: " , name)
-						s.Stop()
-						s.Wait()
-						delete(searchers, name)
-					}
+	cfg.UnlockRepos()
+
+	// the searchers as they were, the api's are only changed through
+	// api.SetSearcher and api.RemoveSearcher since requests are using them
+	searchers := api.GetSearchers()
+	// disable deleted repos
+	if len(deleted) > 0 {
+		for name, s := range searchers {
+			if  _, ok :=  deleted[name]; ok {
+				logger.Info("searcher stopped", logger.Fields{
+					"event": "stop",
+					"repo":  name,
+				})
+				api.RemoveSearcher(name)
+				s.Stop()
+				s.Wait()
+
+				if removed[name] && cfgn.CleanupOnRemove {
+					cfg.RLockRepos()
+					cleanupRemoved(cfg, name, s, api.GetSearchers())
+					cfg.RUnlockRepos()
 				}
 			}
+		}
+	}
 
-			// create new searchers with new config 
-			idxn, ok, err := makeSearchers(&cfgn)
-			if err != nil {
-				log.Panic(err)
-			}
-			if !ok {
-				info_log.Println("Some repos failed to index, see output above")
-			} else {
-				info_log.Println("All indexes are rebuilt!")
-			}
+	// create new searchers with new config 
+	idxn, ok, err := makeSearchers(cfgn)
+	if err != nil {
+		log.Panic(err)
+	}
+	if !ok {
+		logger.Warn("some repos failed to index, see output above", nil)
+	} else {
+		logger.Info("all indexes are rebuilt!", nil)
+	}
 
-			// add back to global searchers 
-			for name, s := range idxn {
-				searchers[name] = s
-			}
+	// add back to global searchers 
+	for name, s := range idxn {
+		api.SetSearcher(name, s)
+	}
+}
 
+// Remove what the stopped searcher of the repo name, which was removed from
+// cfg, leaves in the dbpath. searchers are the ones still running.
+func cleanupRemoved(cfg *config.Config, name string, s *searcher.Searcher, searchers map[string]*searcher.Searcher) {
+	dirs, err := s.Cleanup(cfg, searchers)
+	for _, dir := range dirs {
+		logger.Info("removed the directory of a removed repo", logger.Fields{
+			"event": "cleanup",
+			"repo":  name,
+			"dir":   dir,
 		})
-	}()
+	}
+	if err != nil {
+		logger.Error("failed to clean up after a removed repo", logger.Fields{
+			"event": "cleanup",
+			"repo":  name,
+			"error": err,
+		})
+	}
+}
+
+// Starts watching the config file for changes, replaced in tests.
+var watchConfig = checkConfigChange
+
+// Watch the config file for changes unless noWatch is set, in which case
+// the config that was loaded at startup is the one used until hound exits.
+// Returns whether a watcher was started.
+func startConfigWatcher(filename string, cfg *config.Config, noWatch bool) bool {
+	if noWatch {
+		logger.Info("not watching the config for changes", logger.Fields{
+			"config": filename,
+		})
+		return false
+	}
+
+	watchConfig(filename, cfg)
+	return true
 }
 
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
-	info_log = log.New(os.Stdout, "", log.LstdFlags)
-	error_log = log.New(os.Stderr, "", log.LstdFlags)
 
 	flagConf := flag.String("conf", "config.json", "")
 	flagAddr := flag.String("addr", ":6080", "")
+	flagAdminAddr := flag.String("admin-addr", "", "serve the admin routes (update, stats, builds, ...) on this address rather than on -addr")
 	flagDev := flag.Bool("dev", false, "")
+	flagLogLevel := flag.String("log-level", "info", "debug, info, warn or error")
+	flagLogFormat := flag.String("log-format", "text", "text or json")
+	flagTlsCert := flag.String("tls-cert", "", "serve https using this certificate")
+	flagTlsKey := flag.String("tls-key", "", "serve https using this private key")
+	flagCheckConfig := flag.Bool("check-config", false, "validate the config and exit")
+	flagBuildOnly := flag.Bool("build-only", false, "build all indexes in the dbpath and exit")
+	flagNoIndex := flag.Bool("no-index", false, "serve the existing indexes in the dbpath without cloning or indexing")
+	flagWarmup := flag.Bool("warmup", false, "page all indexes into memory before serving searches")
+	flagDebugMem := flag.Bool("debug-mem", false, "log the size of the heap after every reindex")
+	flagGCAfterReindex := flag.Bool("gc-after-reindex", true, "force a garbage collection after every reindex")
+	flagGCInterval := flag.Duration("gc-interval", 30*time.Second, "collect garbage after reindexes at most this often, in the background, 0 collects after each one")
+	flagNoWatch := flag.Bool("no-watch", false, "load the config once and never reload it, recommended for production")
+	flagWatchExcludeDirs := flag.String("watch-exclude-dirs", "node_modules,vendor", "comma separated directories (names or relative paths) that aren't watched for changes")
+
+	var timeouts httpTimeouts
+	flag.DurationVar(&timeouts.ReadHeader, "read-header-timeout", 10*time.Second, "how long a client has to send the request headers")
+	flag.DurationVar(&timeouts.Read, "read-timeout", 30*time.Second, "how long a client has to send the whole request")
+	flag.DurationVar(&timeouts.Write, "write-timeout", time.Minute, "how long a response may take to write, except for searches")
+	flag.DurationVar(&timeouts.Idle, "idle-timeout", 2*time.Minute, "how long an idle keep-alive connection is kept open")
+	flag.DurationVar(&timeouts.SearchWrite, "search-write-timeout", 5*time.Minute, "how long a search response may take to write, 0 for no limit")
 
 	flag.Parse()
 
+	logLevel, err := logger.ParseLevel(*flagLogLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger.SetLevel(logLevel)
+
+	logFormat, err := logger.ParseFormat(*flagLogFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger.SetFormat(logFormat)
+
+	searcher.SetReportMemory(*flagDebugMem)
+	searcher.SetGCAfterReindex(*flagGCAfterReindex)
+	searcher.SetGCInterval(*flagGCInterval)
+
 	var cfg config.Config
-	if err := cfg.LoadFromFile(*flagConf); err != nil {
-		panic(err)
+	if *flagCheckConfig {
+		// every repo is reported on, not just the first that's wrong
+		if err := cfg.LoadFromFile(*flagConf); err != nil {
+			fmt.Printf("FAIL  %s\n        %s\n", *flagConf, err)
+			os.Exit(1)
+		}
+		os.Exit(checkConfig(&cfg))
+	}
+
+	if err := loadConfig(*flagConf, &cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	if *flagBuildOnly && *flagNoIndex {
+		log.Fatal("-build-only and -no-index can't be used together")
+	}
+
+	if *flagBuildOnly {
+		ok, err := makeAllSearchers(&cfg, false, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !ok {
+			logger.Error("some repos failed to index, see output above", nil)
+			os.Exit(1)
+		}
+		logger.Info("all indexes built!", nil)
+		os.Exit(0)
+	}
+
+	// flags take precedence over the config
+	certFile, keyFile := cfg.TlsCert, cfg.TlsKey
+	if *flagTlsCert != "" || *flagTlsKey != "" {
+		certFile, keyFile = *flagTlsCert, *flagTlsKey
+	}
+
+	if err := checkTls(certFile, keyFile); err != nil {
+		log.Fatal(err)
 	}
 
 	// start server first 
@@ -279,13 +689,37 @@ func main() {
 		host = "localhost" + host
 	}
 
-	info_log.Printf("running server at http://%s...\n", host)
+	scheme := "http"
+	if certFile != "" {
+		scheme = "https"
+	}
+
+	logger.Info("running server at "+scheme+"://"+host+"...", logger.Fields{
+		"event": "listen",
+		"addr":  *flagAddr,
+	})
 
 	// create http default handler to start server in different thread
 	m := http.DefaultServeMux
 
+	var admin *http.ServeMux
+	if *flagAdminAddr != "" {
+		admin = http.NewServeMux()
+
+		logger.Info("running admin server at http://"+*flagAdminAddr+"...", logger.Fields{
+			"event": "listen",
+			"addr":  *flagAdminAddr,
+		})
+
+		go func() {
+			if err := runAdminHttp(admin, *flagAdminAddr, &timeouts); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
 	go func() {
-		if err := runHttp(m, *flagAddr, *flagDev, &cfg); err != nil {
+		if err := runHttp(m, admin, *flagAddr, *flagDev, certFile, keyFile, &timeouts, &cfg); err != nil {
 			panic(err)
 		}
 	}()
@@ -293,18 +727,34 @@ func main() {
 	// It's not safe to be killed during makeAllSearchers, so register the
 	// shutdown signal here and defer processing it until we are ready.
 	shutdownCh := registerShutdownSignal()
-	ok, err := makeAllSearchers(&cfg)
+	ok, err := makeAllSearchers(&cfg, *flagNoIndex, *flagWarmup)
 	if err != nil {
 		log.Panic(err)
 	}
 	if !ok {
-		info_log.Println("Some repos failed to index, see output above")
+		logger.Warn("some repos failed to index, see output above", nil)
 	} else {
-		info_log.Println("All indexes built!")
+		logger.Info("all indexes built!", nil)
 	}
 
-	// enable hot-reload
-	checkConfigChange(*flagConf, &cfg)
+	// enable hot-reload and sweeping of old indexes, both of which
+	// manage the dbpath so they make no sense when serving prebuilt
+	// indexes.
+	if !*flagNoIndex {
+		for _, dir := range strings.Split(*flagWatchExcludeDirs, ",") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				watchExcludeDirs = append(watchExcludeDirs, dir)
+			}
+		}
+		startConfigWatcher(*flagConf, &cfg, *flagNoWatch)
+		searcher.StartSweeper(&cfg, api.GetSearchers)
+
+		// repos that failed to start (e.g. a clone that timed out) are
+		// retried rather than dropped until the next restart
+		searcher.RetryFailed(func(name string, s *searcher.Searcher) {
+			addRecoveredSearcher(&cfg, name, s)
+		})
+	}
 
 	// handle graceful shutdown 
 	handleShutdown(shutdownCh)
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etsy/hound/api"
+	"github.com/etsy/hound/config"
+)
+
+func TestNoWatch(t *testing.T) {
+	defer func(f func(string, *config.Config)) {
+		watchConfig = f
+	}(watchConfig)
+
+	started := 0
+	watchConfig = func(filename string, cfg *config.Config) {
+		started++
+	}
+
+	var cfg config.Config
+	if startConfigWatcher("config.json", &cfg, true) || started != 0 {
+		t.Fatalf("expected no watcher with -no-watch, %d were started", started)
+	}
+
+	if !startConfigWatcher("config.json", &cfg, false) || started != 1 {
+		t.Fatalf("expected a watcher without -no-watch, %d were started", started)
+	}
+}
+
+func TestScanOnceSkipsExcludedDirs(t *testing.T) {
+	root, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	write := func(rel string) {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a.go")
+	since := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	// changes in a nested .git and in excluded dirs are ignored
+	write("sub/.git/objects/x.go")
+	write("sub/vendor/lib/y.go")
+	write("ui/build/z.go")
+
+	excluded := []string{"vendor", "ui/build"}
+	if path := scanOnce(root, false, excluded, since); path != "" {
+		t.Fatalf("expected no changes, got %s", path)
+	}
+
+	// without ui/build in the list, it's watched
+	if path := scanOnce(root, false, []string{"vendor"}, since); path != filepath.Join(root, "ui", "build", "z.go") {
+		t.Fatalf("expected a change to ui/build/z.go, got %s", path)
+	}
+
+	write("sub/b.go")
+	if path := scanOnce(root, false, excluded, since); path != filepath.Join(root, "sub", "b.go") {
+		t.Fatalf("expected a change to sub/b.go, got %s", path)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+	}
+}
+
+func TestReloadEnabled(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	runGit(t, "", "init", "-q", src)
+	if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, src, "add", "a.txt")
+	runGit(t, src, "-c", "user.name=hound", "-c", "user.email=hound@example.com",
+		"commit", "-q", "-m", "a")
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	load := func(enabled bool) *config.Config {
+		b, err := json.Marshal(map[string]interface{}{
+			"dbpath": dbpath,
+			"repos": map[string]interface{}{
+				"a": map[string]interface{}{"url": "file://" + src},
+				// a url of its own, so that it has a working dir of its own
+				"b": map[string]interface{}{
+					"url":     "file://" + src + "/",
+					"enabled": enabled,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var cfg config.Config
+		if err := cfg.LoadFromBytes(b, false); err != nil {
+			t.Fatal(err)
+		}
+		return &cfg
+	}
+
+	cfg := load(false)
+	ok, err := makeAllSearchers(cfg, false, false)
+	if err != nil || !ok {
+		t.Fatalf("expected the searchers to start, got %v", err)
+	}
+	defer func() {
+		for _, s := range api.GetSearchers() {
+			s.Stop()
+			s.Wait()
+		}
+		api.SetSearchers(nil)
+	}()
+
+	running := func() bool {
+		_, ok := api.GetSearchers()["b"]
+		return ok
+	}
+
+	// a disabled repo isn't started, but stays in the config
+	if running() || api.GetSearchers()["a"] == nil {
+		t.Fatalf("expected only a to be running, got %v", api.GetSearchers())
+	}
+	if _, ok := cfg.Repos["b"]; !ok {
+		t.Fatal("expected b to stay in the config")
+	}
+
+	reloadConfig(cfg, load(true))
+	if !running() {
+		t.Fatal("expected b to start once it's enabled")
+	}
+
+	a := api.GetSearchers()["a"]
+	reloadConfig(cfg, load(false))
+	if running() {
+		t.Fatal("expected b to stop once it's disabled")
+	}
+	if api.GetSearchers()["a"] != a {
+		t.Fatal("expected a to be left running")
+	}
+
+	reloadConfig(cfg, load(true))
+	if !running() {
+		t.Fatal("expected b to start again once it's enabled")
+	}
+}
+
+func TestReloadNamespace(t *testing.T) {
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	load := func(ns string) *config.Config {
+		b, err := json.Marshal(map[string]interface{}{
+			"dbpath": dbpath,
+			"repos": map[string]interface{}{
+				"a": map[string]interface{}{
+					"url":       "file://" + src,
+					"vcs":       "local",
+					"namespace": ns,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var cfg config.Config
+		if err := cfg.LoadFromBytes(b, false); err != nil {
+			t.Fatal(err)
+		}
+		return &cfg
+	}
+
+	cfg := load("team-a")
+	ok, err := makeAllSearchers(cfg, false, false)
+	if err != nil || !ok {
+		t.Fatalf("expected the searchers to start, got %v", err)
+	}
+	defer func() {
+		for _, s := range api.GetSearchers() {
+			s.Stop()
+			s.Wait()
+		}
+		api.SetSearchers(nil)
+	}()
+
+	a := api.GetSearchers()["a"]
+	live := a.Config()
+
+	reloadConfig(cfg, load("team-b"))
+	if api.GetSearchers()["a"] != a {
+		t.Fatal("expected a to be left running")
+	}
+	if ns := a.Config().Namespace; ns != "team-b" {
+		t.Fatalf("expected the namespace to change to team-b, got %s", ns)
+	}
+	if cfg.Repos["a"].Namespace != "team-b" {
+		t.Fatalf("expected the config to have the new namespace, got %s", cfg.Repos["a"].Namespace)
+	}
+
+	// the config requests were using is left as it was
+	if live.Namespace != "team-a" {
+		t.Fatalf("expected the old config to be left alone, got %s", live.Namespace)
+	}
+}
+
+func TestLoadConfigChecksVcs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "config.json")
+	write := func(vcs string) {
+		if err := ioutil.WriteFile(filename, []byte(`{
+			"dbpath" : "db",
+			"repos" : {
+				"a" : { "url" : "https://github.com/a/a.git", "vcs" : "`+vcs+`" }
+			}
+		}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("git")
+	var cfg config.Config
+	if err := loadConfig(filename, &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	write("gti")
+	if err := loadConfig(filename, &config.Config{}); err == nil || !strings.Contains(err.Error(), "repo a") {
+		t.Fatalf("expected repo a's vcs to be rejected, got %v", err)
+	}
+}
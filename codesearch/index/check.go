@@ -0,0 +1,130 @@
+package index
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// Check verifies the structure of the index in file: the magic numbers, the
+// section offsets in the trailer and every posting list, which must decode
+// to exactly the number of file IDs recorded for it. Open treats a damaged
+// index as a fatal error, so Check should be used first on any index that
+// may have been left half written.
+func Check(file string) error {
+	ix, err := OpenChecked(file)
+	if err != nil {
+		return err
+	}
+	return ix.Close()
+}
+
+// OpenChecked is Check followed by Open, but maps the file only once.
+func OpenChecked(file string) (*Index, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty file can't be mapped, and is too short anyway
+	st, err := f.Stat()
+	if err != nil || st.Size() == 0 {
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		return nil, check(nil)
+	}
+
+	mm := mmapFile(f)
+	if err := check(mm.d); err != nil {
+		mm.close()
+		return nil, err
+	}
+	return open(mm), nil
+}
+
+func check(d []byte) error {
+	if len(d) < len(magic)+5*4+len(trailerMagic) {
+		return fmt.Errorf("index is too short (%d bytes)", len(d))
+	}
+
+	if string(d[:len(magic)]) != magic {
+		return fmt.Errorf("bad magic at start of index")
+	}
+
+	if string(d[len(d)-len(trailerMagic):]) != trailerMagic {
+		return fmt.Errorf("bad magic at end of index")
+	}
+
+	n := len(d) - len(trailerMagic) - 5*4
+	u := func(off int) int {
+		return int(binary.BigEndian.Uint32(d[off:]))
+	}
+
+	var (
+		pathData  = u(n)
+		nameData  = u(n + 4)
+		postData  = u(n + 8)
+		nameIndex = u(n + 12)
+		postIndex = u(n + 16)
+	)
+
+	if pathData != len(magic) || nameData < pathData || postData < nameData ||
+		nameIndex < postData || postIndex < nameIndex || n < postIndex {
+		return fmt.Errorf("section offsets are out of order")
+	}
+
+	if (postIndex-nameIndex)%4 != 0 || postIndex-nameIndex < 4 || (n-postIndex)%postEntrySize != 0 {
+		return fmt.Errorf("name or posting list index has a bad size")
+	}
+
+	// the name index has an entry for the end of the list too
+	numName := (postIndex-nameIndex)/4 - 1
+	prev := 0
+	for i := 0; i <= numName; i++ {
+		off := u(nameIndex + 4*i)
+		if off < prev || nameData+off > postData {
+			return fmt.Errorf("name %d is out of range", i)
+		}
+		prev = off
+	}
+
+	numPost := (n - postIndex) / postEntrySize
+	prevTrigram := -1
+	for i := 0; i < numPost; i++ {
+		e := d[postIndex+i*postEntrySize:]
+		trigram := int(e[0])<<16 | int(e[1])<<8 | int(e[2])
+		count := int(binary.BigEndian.Uint32(e[3:]))
+		start := postData + int(binary.BigEndian.Uint32(e[3+4:]))
+
+		if trigram <= prevTrigram {
+			return fmt.Errorf("posting list index is not sorted at %q", e[:3])
+		}
+		prevTrigram = trigram
+
+		if start+3 > nameIndex || string(d[start:start+3]) != string(e[:3]) {
+			return fmt.Errorf("posting list for %q is out of place", e[:3])
+		}
+
+		p, fileid := start+3, -1
+		for j := 0; j < count; j++ {
+			delta, m := binary.Uvarint(d[p:nameIndex])
+			if m <= 0 || delta == 0 || delta > uint64(numName) {
+				return fmt.Errorf("posting list for %q is damaged", e[:3])
+			}
+			p += m
+			fileid += int(delta)
+			if fileid >= numName {
+				return fmt.Errorf("posting list for %q refers to file %d of %d",
+					e[:3], fileid, numName)
+			}
+		}
+
+		if p >= nameIndex || d[p] != 0 {
+			return fmt.Errorf("posting list for %q has the wrong length", e[:3])
+		}
+	}
+
+	return nil
+}
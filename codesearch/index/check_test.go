@@ -0,0 +1,59 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	if err := check([]byte(trivialIndex)); err != nil {
+		t.Fatalf("expected the trivial index to be valid, got %s", err)
+	}
+
+	if err := check([]byte(trivialIndex[:len(trivialIndex)/2])); err == nil {
+		t.Fatal("expected a truncated index to be rejected")
+	}
+
+	// make the posting list for "abc" one file too long
+	d := []byte(trivialIndex)
+	i := strings.Index(trivialIndex, "abc"+fileList(0, 3))
+	d[i+3+2] = 1
+	if err := check(d); err == nil {
+		t.Fatal("expected a damaged posting list to be rejected")
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "index-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	// empty, like an index that was never written
+	if err := Check(f.Name()); err == nil {
+		t.Fatal("expected an empty index to be rejected")
+	}
+
+	if err := ioutil.WriteFile(f.Name(), []byte(trivialIndex), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := OpenChecked(f.Name())
+	if err != nil {
+		t.Fatalf("expected the trivial index to be valid, got %s", err)
+	}
+	if n := ix.NumNames(); n != len(trivialFiles) {
+		t.Fatalf("expected %d names, got %d", len(trivialFiles), n)
+	}
+	ix.Close()
+
+	if err := ioutil.WriteFile(f.Name(), []byte(trivialIndex[:len(trivialIndex)/2]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenChecked(f.Name()); err == nil {
+		t.Fatal("expected a truncated index to be rejected")
+	}
+}
@@ -108,11 +108,8 @@ func Merge(dst, src1, src2 string) {
 	}
 	numName := new
 
-	ix3 := bufCreate(dst)
-	ix3.writeString(magic)
-
 	// Merged list of paths.
-	pathData := ix3.offset()
+	var paths []string
 	mi1 := 0
 	mi2 := 0
 	last := "\x00" // not a prefix of anything
@@ -129,6 +126,21 @@ func Merge(dst, src1, src2 string) {
 			continue
 		}
 		last = p
+		paths = append(paths, p)
+	}
+
+	writeMerged(dst, ix1, ix2, map1, map2, paths, numName)
+}
+
+// Write the index in dst that holds the given paths and the files of ix1
+// and ix2, renumbered according to map1 and map2. Between them, the maps
+// must cover every docid in [0, numName) exactly once.
+func writeMerged(dst string, ix1, ix2 *Index, map1, map2 []idrange, paths []string, numName uint32) {
+	ix3 := bufCreate(dst)
+	ix3.writeString(magic)
+
+	pathData := ix3.offset()
+	for _, p := range paths {
 		ix3.writeString(p)
 		ix3.writeString("\x00")
 	}
@@ -137,9 +149,9 @@ func Merge(dst, src1, src2 string) {
 	// Merged list of names.
 	nameData := ix3.offset()
 	nameIndexFile := bufCreate("")
-	new = 0
-	mi1 = 0
-	mi2 = 0
+	new := uint32(0)
+	mi1 := 0
+	mi2 := 0
 	for new < numName {
 		if mi1 < len(map1) && map1[mi1].new == new {
 			for i := map1[mi1].lo; i < map1[mi1].hi; i++ {
@@ -166,7 +178,8 @@ func Merge(dst, src1, src2 string) {
 	if new*4 != nameIndexFile.offset() {
 		panic("merge: inconsistent index")
 	}
-	nameIndexFile.writeUint32(ix3.offset())
+	// the end of the last name, relative to nameData like the others
+	nameIndexFile.writeUint32(ix3.offset() - nameData)
 
 	// Merged list of posting lists.
 	postData := ix3.offset()
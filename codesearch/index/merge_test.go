@@ -102,3 +102,32 @@ func TestMerge(t *testing.T) {
 	check(ix3, "now", 3, 4, 6)
 	check(ix3, "pot", 4, 5, 7)
 }
+
+// The name index ends with the offset just past the last name, relative to
+// the start of the names like every other entry. The paths come before the
+// names, so an absolute offset there points past the names.
+func TestMergeNameIndex(t *testing.T) {
+	f1, _ := ioutil.TempFile("", "index-test")
+	f2, _ := ioutil.TempFile("", "index-test")
+	f3, _ := ioutil.TempFile("", "index-test")
+	defer os.Remove(f1.Name())
+	defer os.Remove(f2.Name())
+	defer os.Remove(f3.Name())
+
+	buildIndex(t, f1.Name(), mergePaths1, mergeFiles1)
+	buildIndex(t, f2.Name(), mergePaths2, mergeFiles2)
+
+	Merge(f3.Name(), f1.Name(), f2.Name())
+
+	if err := Check(f3.Name()); err != nil {
+		t.Fatalf("expected the merged index to be valid, got %s", err)
+	}
+
+	ix := Open(f3.Name())
+	defer ix.Close()
+
+	end := ix.uint32(ix.nameIndex + 4*uint32(ix.numName))
+	if want := ix.postData - ix.nameData; end != want {
+		t.Fatalf("expected the name index to end at %d, got %d", want, end)
+	}
+}
@@ -97,7 +97,10 @@ type Index struct {
 const postEntrySize = 3 + 4 + 4
 
 func Open(file string) *Index {
-	mm := mmap(file)
+	return open(mmap(file))
+}
+
+func open(mm mmapData) *Index {
 	if len(mm.d) < 4*4+len(trailerMagic) || string(mm.d[len(mm.d)-len(trailerMagic):]) != trailerMagic {
 		corrupt()
 	}
@@ -155,6 +158,12 @@ func (ix *Index) Paths() []string {
 	return x
 }
 
+// NumNames returns the number of indexed files, fileids range from 0 to
+// NumNames()-1.
+func (ix *Index) NumNames() int {
+	return ix.numName
+}
+
 // NameBytes returns the name corresponding to the given fileid.
 func (ix *Index) NameBytes(fileid uint32) []byte {
 	off := ix.uint32(ix.nameIndex + 4*fileid)
@@ -195,6 +204,12 @@ func (ix *Index) dumpPosting() {
 	}
 }
 
+// PostingListLen returns the number of files that contain the trigram.
+func (ix *Index) PostingListLen(trigram uint32) int {
+	count, _ := ix.findList(trigram)
+	return count
+}
+
 func (ix *Index) findList(trigram uint32) (count int, offset uint32) {
 	// binary search
 	d := ix.slice(ix.postIndex, postEntrySize*ix.numPost)
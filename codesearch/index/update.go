@@ -0,0 +1,74 @@
+package index
+
+import (
+	"os"
+	"strings"
+)
+
+// Update creates a new index in the file dst that holds the files of the
+// index src1 updated with those of src2: the files named in remove are left
+// out, the files in src2 replace any of the same name in src1 and the rest
+// are added. This lets an index be updated with only the files that changed
+// rather than building it again from scratch.
+//
+// Unlike Merge, which works on the sorted path lists of the two indexes,
+// Update expects the names in both to be in the order that filepath.Walk
+// visits them, which is the order they are given in dst. The paths of dst
+// are those of src1.
+func Update(dst, src1, src2 string, remove []string) {
+	ix1 := Open(src1)
+	defer ix1.Close()
+	ix2 := Open(src2)
+	defer ix2.Close()
+
+	drop := map[string]bool{}
+	for _, name := range remove {
+		drop[name] = true
+	}
+	for i := 0; i < ix2.numName; i++ {
+		drop[ix2.Name(uint32(i))] = true
+	}
+
+	var i1, i2, new uint32
+	var map1, map2 []idrange
+	for int(i1) < ix1.numName || int(i2) < ix2.numName {
+		if int(i1) < ix1.numName && drop[ix1.Name(i1)] {
+			i1++
+			continue
+		}
+
+		if int(i2) >= ix2.numName || int(i1) < ix1.numName && WalkLess(ix1.Name(i1), ix2.Name(i2)) {
+			map1 = addToRange(map1, i1, new)
+			i1++
+		} else {
+			map2 = addToRange(map2, i2, new)
+			i2++
+		}
+		new++
+	}
+
+	writeMerged(dst, ix1, ix2, map1, map2, ix1.Paths(), new)
+}
+
+// Record that docid id maps to new, growing the last range if it can.
+func addToRange(m []idrange, id, new uint32) []idrange {
+	if n := len(m); n > 0 && m[n-1].hi == id && m[n-1].new+id-m[n-1].lo == new {
+		m[n-1].hi++
+		return m
+	}
+	return append(m, idrange{id, id + 1, new})
+}
+
+// WalkLess reports whether filepath.Walk visits the file a before the file
+// b. Each directory's entries are visited in order, so the names compare
+// element by element.
+func WalkLess(a, b string) bool {
+	ea := strings.Split(a, string(os.PathSeparator))
+	eb := strings.Split(b, string(os.PathSeparator))
+	for i := 0; i < len(ea) && i < len(eb); i++ {
+		if ea[i] != eb[i] {
+			return ea[i] < eb[i]
+		}
+	}
+	return len(ea) < len(eb)
+}
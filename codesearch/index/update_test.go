@@ -0,0 +1,85 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	f1, _ := ioutil.TempFile("", "index-test")
+	f2, _ := ioutil.TempFile("", "index-test")
+	f3, _ := ioutil.TempFile("", "index-test")
+	defer os.Remove(f1.Name())
+	defer os.Remove(f2.Name())
+	defer os.Remove(f3.Name())
+
+	buildIndex(t, f1.Name(), []string{"/src"}, map[string]string{
+		"a/x": "hello world",
+		"a/y": "goodbye world",
+		"b":   "now is the time",
+		"c/z": "give me death",
+	})
+	buildIndex(t, f2.Name(), []string{"/other"}, map[string]string{
+		"a/w": "hello moon",
+		"a/y": "goodbye moon",
+		"d":   "world peace",
+	})
+
+	Update(f3.Name(), f1.Name(), f2.Name(), []string{"c/z"})
+
+	ix := Open(f3.Name())
+	defer ix.Close()
+
+	if err := Check(f3.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"a/w", "a/x", "a/y", "b", "d"}
+	if ix.NumNames() != len(names) {
+		t.Fatalf("expected %d names, got %d", len(names), ix.NumNames())
+	}
+	for i, name := range names {
+		if n := ix.Name(uint32(i)); n != name {
+			t.Errorf("Name(%d) = %s, want %s", i, n, name)
+		}
+	}
+
+	if paths := ix.Paths(); len(paths) != 1 || paths[0] != "/src" {
+		t.Errorf("expected the paths of the original index, got %v", paths)
+	}
+
+	tests := []struct {
+		tri   uint32
+		files []uint32
+	}{
+		{tri('w', 'o', 'r'), []uint32{1, 4}},
+		{tri('m', 'o', 'o'), []uint32{0, 2}},
+		{tri('h', 'e', 'l'), []uint32{0, 1}},
+		{tri('t', 'i', 'm'), []uint32{3}},
+		{tri('d', 'e', 'a'), nil},
+	}
+	for _, test := range tests {
+		if l := ix.PostingList(test.tri); !equalList(l, test.files) {
+			t.Errorf("PostingList(%x) = %v, want %v", test.tri, l, test.files)
+		}
+	}
+}
+
+func TestWalkLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{"a", "b", true},
+		{"a/b", "a.txt", true},
+		{"a.txt", "a/b", false},
+		{"a/b/c", "a/c", true},
+		{"z", "a/b", false},
+	}
+	for _, test := range tests {
+		if less := WalkLess(test.a, test.b); less != test.less {
+			t.Errorf("WalkLess(%q, %q) = %t, want %t", test.a, test.b, less, test.less)
+		}
+	}
+}
@@ -56,6 +56,11 @@ type IndexWriter struct {
 	inbuf []byte     // input buffer
 	main  *bufWriter // main index file
 
+	// scratch space for sortPost, kept per writer so that several
+	// indexes can be written at once
+	sortTmp []postEntry
+	sortN   [1 << sortK]int
+
 	MaxFileLen      int64
 	MaxLineLen      int
 	MaxTextTrigrams int
@@ -67,13 +72,27 @@ const npost = 64 << 20 / 8 // 64 MB worth of post entries
 
 // Create returns a new IndexWriter that will write the index to file.
 func Create(file string) *IndexWriter {
+	return CreateWithPostBuffer(file, npost*8)
+}
+
+// CreateWithPostBuffer is like Create, but holds at most size bytes worth of
+// post entries in memory before flushing them to a temporary file, rather
+// than 64 MB worth. size is capped at 64 MB.
+func CreateWithPostBuffer(file string, size int) *IndexWriter {
+	n := size / 8
+	if n > npost {
+		n = npost
+	} else if n < 1 {
+		n = 1
+	}
+
 	return &IndexWriter{
 		trigram:             sparse.NewSet(1 << 24),
 		nameData:            bufCreate(""),
 		nameIndex:           bufCreate(""),
 		postIndex:           bufCreate(""),
 		main:                bufCreate(file),
-		post:                make([]postEntry, 0, npost),
+		post:                make([]postEntry, 0, n),
 		inbuf:               make([]byte, 16384),
 		MaxFileLen:          1 << 30,
 		MaxLineLen:          2000,
@@ -295,7 +314,7 @@ func (ix *IndexWriter) flushPost() {
 	if ix.Verbose {
 		log.Printf("flush %d entries to %s", len(ix.post), w.Name())
 	}
-	sortPost(ix.post)
+	ix.sortPost(ix.post)
 
 	// Write the raw ix.post array to disk as is.
 	// This process is the one reading it back in, so byte order is not a concern.
@@ -321,7 +340,7 @@ func (ix *IndexWriter) mergePost(out *bufWriter) {
 	for _, f := range ix.postFile {
 		h.addFile(f)
 	}
-	sortPost(ix.post)
+	ix.sortPost(ix.post)
 	h.addMem(ix.post)
 
 	npost := 0
@@ -632,14 +651,12 @@ func validUTF8(c1, c2 byte) bool {
 // 24 bits to sort.  Run two rounds of 12-bit radix sort.
 const sortK = 12
 
-var sortTmp []postEntry
-var sortN [1 << sortK]int
-
-func sortPost(post []postEntry) {
-	if len(post) > len(sortTmp) {
-		sortTmp = make([]postEntry, len(post))
+func (ix *IndexWriter) sortPost(post []postEntry) {
+	if len(post) > len(ix.sortTmp) {
+		ix.sortTmp = make([]postEntry, len(post))
 	}
-	tmp := sortTmp[:len(post)]
+	tmp := ix.sortTmp[:len(post)]
+	sortN := &ix.sortN
 
 	const k = sortK
 	for i := range sortN {
@@ -167,6 +167,32 @@ func TestTrivialWriteDisk(t *testing.T) {
 	testTrivialWrite(t, true)
 }
 
+func TestTrivialWriteSmallBuffer(t *testing.T) {
+	f, _ := ioutil.TempFile("", "index-test")
+	defer os.Remove(f.Name())
+
+	// two entries at a time, so the posts are flushed over and over
+	ix := CreateWithPostBuffer(f.Name(), 16)
+	var files []string
+	for name := range trivialFiles {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		r := strings.NewReader(trivialFiles[name])
+		ix.Add(name, r, int64(r.Len()))
+	}
+	ix.Flush()
+
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte(trivialIndex)) {
+		t.Fatalf("wrong index:\nhave: %q\nwant: %q", data, trivialIndex)
+	}
+}
+
 func TestHeap(t *testing.T) {
 	h := &postHeap{}
 	es := []postEntry{7, 4, 3, 2, 4}
@@ -3,36 +3,152 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	defaultMsBetweenPoll         = 30000
+	defaultPullAttempts          = 3
+	defaultMsBetweenPullRetries  = 1000
+	defaultMsPullTimeout         = 10 * 60 * 1000
+	defaultPullFailuresToBackOff = 5
+	defaultMsMaxPollBackoff      = 60 * 60 * 1000
 	defaultMaxConcurrentIndexers = 2
+	defaultIndexWeight           = 1
 	defaultPushEnabled           = false
 	defaultPollEnabled           = true
+	defaultExcludeDotFiles       = false
+	defaultFollowSymlinks        = false
 	defaultVcs                   = "git"
 	defaultBaseUrl               = "{url}/blob/{rev}/{path}{anchor}"
 	defaultAnchor                = "#L{line}"
+	defaultRangeAnchor           = "#L{line}-L{lineEnd}"
+	defaultMaxFileSize           = 10 << 20
+	defaultMsSearchCacheTtl      = 60000
+	defaultLinesOfContext        = 2
+	defaultMaxQueryLength        = 1000
+	defaultMinQueryLength        = 3
+	defaultShortQueryMaxFiles    = 1000
+	defaultMsReadyGracePeriod    = 5000
+	defaultBatchConcurrency      = 4
+	maxIndexShards               = 64
 )
 
+// How to link to a file in a repo's web UI. BaseUrl can use {url}, {rev},
+// {path}, {anchor} and {reponame}, Anchor links a single line with {line}
+// and {filename} and RangeAnchor a range of lines from {line} to {lineEnd}.
+// Whatever isn't set comes from the preset for Host (see the Host
+// constants), or GitHub's if there is no Host.
 type UrlPattern struct {
-	BaseUrl string `json:"base-url"`
-	Anchor  string `json:"anchor"`
+	Host        string `json:"host,omitempty"`
+	BaseUrl     string `json:"base-url"`
+	Anchor      string `json:"anchor"`
+	RangeAnchor string `json:"range-anchor"`
 }
 
 type Repo struct {
-	Url               string         `json:"url"`
-	MsBetweenPolls    int            `json:"ms-between-poll"`
-	Vcs               string         `json:"vcs"`
-	VcsConfigMessage  *SecretMessage `json:"vcs-config"`
-	UrlPattern        *UrlPattern    `json:"url-pattern"`
-	ExcludeDotFiles   bool           `json:"exclude-dot-files"`
-	EnablePollUpdates *bool          `json:"enable-poll-updates"`
-	EnablePushUpdates *bool          `json:"enable-push-updates"`
-	Hidden            bool           `json:"hidden"`
-	Revision          string         `json:"-"` // use - to ignore from json.Marshal
+	Url              string `json:"url"`
+	MsBetweenPolls   int    `json:"ms-between-poll"`
+	PullAttempts     int    `json:"pull-attempts"`
+	MsBetweenRetries int    `json:"ms-between-pull-retries"`
+
+	// How long a pull (or clone) may take before its commands are killed
+	// and it's retried like any other transient failure. A negative
+	// timeout lets it take as long as it takes.
+	MsPullTimeout int `json:"ms-pull-timeout"`
+
+	// After this many pulls in a row have failed, the repo is polled less
+	// and less often, the time between polls doubling after every failure
+	// up to MsMaxPollBackoff, until a pull succeeds again (see
+	// Searcher.Circuit). When not set, the config's defaults are used.
+	PullFailuresToBackOff int            `json:"pull-failures-to-back-off"`
+	MsMaxPollBackoff      int            `json:"ms-max-poll-backoff"`
+	Vcs                   string         `json:"vcs"`
+	VcsConfigMessage      *SecretMessage `json:"vcs-config"`
+	UrlPattern            *UrlPattern    `json:"url-pattern"`
+	ExcludeDotFiles       *bool          `json:"exclude-dot-files"`
+	EnablePollUpdates     *bool          `json:"enable-poll-updates"`
+	EnablePushUpdates     *bool          `json:"enable-push-updates"`
+	Hidden                bool           `json:"hidden"`
+	Tags                  []string       `json:"tags"`
+
+	// Other names the repo can be searched by, like the ones it had before
+	// it was renamed, so that links to those keep working.
+	Aliases     []string `json:"aliases,omitempty"`
+	MaxFileSize *int64   `json:"max-file-size"`
+	Revision    string   `json:"-"` // use - to ignore from json.Marshal
+
+	// Directories that are never indexed, on top of the vcs's own (like
+	// .git). A name matches directories of that name anywhere in the repo,
+	// a path with slashes matches that one directory from the top of the
+	// repo. A name starting with ! is indexed even if the vcs would skip
+	// it. When not set, the config's default-exclude-dirs are used.
+	ExcludeDirs []string `json:"exclude-dirs"`
+
+	// Leave the repo out of searches of every repo (and of globs), it's
+	// only searched when asked for by name or through one of its tags.
+	ExcludeFromWildcard bool `json:"exclude-from-wildcard"`
+
+	// The namespace (like the team that owns it) the repo is in. While the
+	// config has scopes, a repo in a namespace is only seen by the callers
+	// whose scopes include it. Repos without one are seen by everyone.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Index the files that symbolic links in the repo point to, as long as
+	// they are in the repo too. Links are excluded by default.
+	FollowSymlinks *bool `json:"follow-symlinks"`
+
+	// Split the repo's index into this many shards, which are built and
+	// searched in parallel. Only worth it for very large repos, 0 or 1
+	// keeps a single index.
+	IndexShards int `json:"index-shards"`
+
+	// How many of the repo's files are read at once while it's indexed,
+	// which speeds up repos with lots of files. 0 uses every CPU. When not
+	// set, the config's default-index-workers is used.
+	IndexWorkers int `json:"index-workers"`
+
+	// How much to compress the repo's index on disk: off (the default),
+	// fast or max. Compressed indexes are decompressed again when they're
+	// opened. When not set, the config's default-index-compression is used.
+	IndexCompression string `json:"index-compression"`
+
+	// Rebuild an index that was updated with the changes to the repo from
+	// scratch once the repo has gone this long without being searched or
+	// reindexed, which undoes the wear of the updates. 0 never does. When
+	// not set, the config's default-ms-idle-before-compaction is used.
+	MsIdleBeforeCompaction int `json:"ms-idle-before-compaction"`
+
+	// How many of the max-concurrent-indexers the repo takes up while it's
+	// being indexed, so that fewer huge repos are indexed at once than
+	// small ones. Defaults to 1.
+	IndexWeight int `json:"index-weight"`
+
+	// Only index files with these extensions (like "go" or ".go"), every
+	// other file is listed as excluded. The exclude settings still apply
+	// to the files that are included. Empty indexes every file.
+	IncludeExtensions []string `json:"include-extensions"`
+
+	// Search the repo case insensitively unless a search says otherwise.
+	// When not set, the config's default-ignore-case is used.
+	IgnoreCase *bool `json:"ignore-case"`
+
+	// Count the tokens in the repo's files when indexing, so that they can
+	// be suggested by /api/v1/suggest. Off by default since they take up
+	// memory. When not set, the config's default-suggest is used.
+	Suggest *bool `json:"suggest"`
+
+	// A disabled repo keeps its settings (and its index) but isn't
+	// polled or searched until it's enabled again. Enabled by default.
+	Enabled *bool `json:"enabled"`
 }
 
 // Used for interpreting the config value for fields that use *bool. If a value
@@ -44,6 +160,28 @@ func optionToBool(val *bool, def bool) bool {
 	return *val
 }
 
+// How long a pull may take, 0 if there's no limit.
+func (r *Repo) PullTimeout() time.Duration {
+	if r.MsPullTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(r.MsPullTimeout) * time.Millisecond
+}
+
+// The longest the time between polls of a repo whose pulls keep failing
+// gets, 0 if it's never backed off.
+func (r *Repo) MaxPollBackoff() time.Duration {
+	if r.MsMaxPollBackoff <= 0 {
+		return 0
+	}
+	return time.Duration(r.MsMaxPollBackoff) * time.Millisecond
+}
+
+// Is the repo polled and searched?
+func (r *Repo) IsEnabled() bool {
+	return optionToBool(r.Enabled, true)
+}
+
 // Are polling based updates enabled on this repo?
 func (r *Repo) PollUpdatesEnabled() bool {
 	return optionToBool(r.EnablePollUpdates, defaultPollEnabled)
@@ -54,11 +192,157 @@ func (r *Repo) PushUpdatesEnabled() bool {
 	return optionToBool(r.EnablePushUpdates, defaultPushEnabled)
 }
 
-// Is Repo hidden 
+// Are files and directories whose names start with a dot left out of the
+// index?
+func (r *Repo) DotFilesExcluded() bool {
+	return optionToBool(r.ExcludeDotFiles, defaultExcludeDotFiles)
+}
+
+// Are symbolic links followed when indexing?
+func (r *Repo) SymlinksFollowed() bool {
+	return optionToBool(r.FollowSymlinks, defaultFollowSymlinks)
+}
+
+// Is the repo searched case insensitively by searches that don't say?
+func (r *Repo) CaseIgnored() bool {
+	return optionToBool(r.IgnoreCase, false)
+}
+
+// Are the tokens in the repo's files suggested?
+func (r *Repo) SuggestionsEnabled() bool {
+	return optionToBool(r.Suggest, false)
+}
+
+// The size in bytes of the largest file that will be indexed, 0 means
+// there is no limit.
+func (r *Repo) FileSizeLimit() int64 {
+	if r.MaxFileSize == nil {
+		return defaultMaxFileSize
+	}
+	return *r.MaxFileSize
+}
+
+// Split exclude-dirs into the directories to skip and the names of the vcs's
+// special directories that should be indexed after all.
+func (r *Repo) ExcludedDirs() ([]string, []string) {
+	var exclude, include []string
+	for _, dir := range r.ExcludeDirs {
+		if strings.HasPrefix(dir, "!") {
+			include = append(include, dir[1:])
+		} else {
+			exclude = append(exclude, strings.Trim(dir, "/"))
+		}
+	}
+	return exclude, include
+}
+
+// Is Repo hidden
 func (r *Repo) IsHidden() bool {
 	return optionToBool(&r.Hidden, false)
 }
 
+// Check the repo's config for mistakes that would keep it from being
+// indexed. This returns an error for every problem found, so a nil return
+// means the repo looks good. Note that the vcs itself is not checked here
+// since that requires the vcs registry.
+func (r *Repo) Validate() []error {
+	errs := r.validateSettings()
+
+	if strings.HasPrefix(r.Url, "file://") {
+		path := strings.TrimPrefix(r.Url, "file://")
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Errorf("local path is not accessible: %s", err))
+		}
+	}
+
+	return errs
+}
+
+// The part of Validate that only looks at the settings themselves, which
+// every config that is loaded has to pass. A local path that isn't there
+// yet may turn up later, so it doesn't keep a config from loading.
+func (r *Repo) validateSettings() []error {
+	var errs []error
+
+	if r.Url == "" {
+		errs = append(errs, errors.New("url is required"))
+	}
+
+	if r.MsBetweenPolls < 0 {
+		errs = append(errs, fmt.Errorf("ms-between-poll must be positive, got %d", r.MsBetweenPolls))
+	}
+
+	if r.PullAttempts < 0 {
+		errs = append(errs, fmt.Errorf("pull-attempts must be positive, got %d", r.PullAttempts))
+	}
+
+	if r.MsBetweenRetries < 0 {
+		errs = append(errs, fmt.Errorf("ms-between-pull-retries must be positive, got %d", r.MsBetweenRetries))
+	}
+
+	if r.PullFailuresToBackOff < 0 {
+		errs = append(errs, fmt.Errorf("pull-failures-to-back-off must be positive, got %d", r.PullFailuresToBackOff))
+	}
+
+	if r.MsMaxPollBackoff < 0 {
+		errs = append(errs, fmt.Errorf("ms-max-poll-backoff must be positive, got %d", r.MsMaxPollBackoff))
+	}
+
+	if r.MaxFileSize != nil && *r.MaxFileSize < 0 {
+		errs = append(errs, fmt.Errorf("max-file-size must be positive, got %d", *r.MaxFileSize))
+	}
+
+	if r.IndexShards < 0 || r.IndexShards > maxIndexShards {
+		errs = append(errs, fmt.Errorf("index-shards must be between 0 and %d, got %d", maxIndexShards, r.IndexShards))
+	}
+
+	if r.IndexWorkers < 0 {
+		errs = append(errs, fmt.Errorf("index-workers must be positive, got %d", r.IndexWorkers))
+	}
+
+	if !isIndexCompression(r.IndexCompression) {
+		errs = append(errs, fmt.Errorf("index-compression must be %s, %s or %s, got %s",
+			CompressionOff, CompressionFast, CompressionMax, r.IndexCompression))
+	}
+
+	if r.MsIdleBeforeCompaction < 0 {
+		errs = append(errs, fmt.Errorf("ms-idle-before-compaction must be positive, got %d", r.MsIdleBeforeCompaction))
+	}
+
+	if r.IndexWeight < 0 {
+		errs = append(errs, fmt.Errorf("index-weight must be positive, got %d", r.IndexWeight))
+	}
+
+	for _, dir := range r.ExcludeDirs {
+		name := strings.TrimPrefix(dir, "!")
+		switch {
+		case strings.Trim(name, "/") == "":
+			errs = append(errs, fmt.Errorf("exclude-dirs can't contain an empty name"))
+		case name != dir && strings.Contains(name, "/"):
+			errs = append(errs, fmt.Errorf("exclude-dirs can only index names, not paths: %s", dir))
+		case containsDotDot(name):
+			errs = append(errs, fmt.Errorf("exclude-dirs can't refer outside of the repo: %s", dir))
+		}
+	}
+
+	for _, ext := range r.IncludeExtensions {
+		if e := strings.TrimPrefix(ext, "."); e == "" || strings.ContainsAny(e, "/.") {
+			errs = append(errs, fmt.Errorf("include-extensions must be plain extensions, got %q", ext))
+		}
+	}
+
+	return errs
+}
+
+func containsDotDot(path string) bool {
+	for _, part := range strings.Split(path, "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Repo) ToJsonString() string {
 	b, err := json.Marshal(r)
 	if err != nil {
@@ -69,9 +353,246 @@ func (r *Repo) ToJsonString() string {
 }
 
 type Config struct {
-	DbPath                string           `json:"dbpath"`
-	Repos                 map[string]*Repo `json:"repos"`
-	MaxConcurrentIndexers int              `json:"max-concurrent-indexers"`
+	DbPath                string                    `json:"dbpath"`
+	Repos                 map[string]*Repo          `json:"repos"`
+	MaxConcurrentIndexers int                       `json:"max-concurrent-indexers"`
+	VcsConfigDefaults     map[string]*SecretMessage `json:"vcs-config-defaults"`
+	WebhookSecret         string                    `json:"webhook-secret"`
+	TlsCert               string                    `json:"tls-cert"`
+	TlsKey                string                    `json:"tls-key"`
+	AllowedOrigins        []string                  `json:"allowed-origins"`
+
+	// The bearer token the admin routes that remove things (like index gc)
+	// need in their Authorization header. Without one, those routes are
+	// only served on the admin address.
+	AdminToken string `json:"admin-token"`
+
+	// The number of search results to cache, 0 (the default) disables the
+	// cache. Cached results expire after MsSearchCacheTtl.
+	SearchCacheSize  int `json:"search-cache-size"`
+	MsSearchCacheTtl int `json:"ms-search-cache-ttl"`
+
+	// Defaults for the repos that don't set these themselves.
+	DefaultMsBetweenPolls   int `json:"default-ms-between-poll"`
+	DefaultPullAttempts     int `json:"default-pull-attempts"`
+	DefaultMsBetweenRetries int `json:"default-ms-between-pull-retries"`
+	DefaultMsPullTimeout    int `json:"default-ms-pull-timeout"`
+
+	// The pull-failures-to-back-off and ms-max-poll-backoff of the repos
+	// that don't set their own, 5 failures and an hour by default.
+	DefaultPullFailuresToBackOff int `json:"default-pull-failures-to-back-off"`
+	DefaultMsMaxPollBackoff      int `json:"default-ms-max-poll-backoff"`
+
+	// The ms-idle-before-compaction of the repos that don't set their own,
+	// 0 (the default) never compacts them.
+	DefaultMsIdleBeforeCompaction int `json:"default-ms-idle-before-compaction"`
+
+	// The number of lines of context around matches when a search doesn't
+	// ask for a specific number, see LinesOfContext.
+	DefaultLinesOfContext *int `json:"default-lines-of-context"`
+
+	// The exclude-dirs of the repos that don't set their own.
+	DefaultExcludeDirs []string `json:"default-exclude-dirs"`
+
+	// The index options of the repos that don't set their own, see the
+	// repo settings of the same names.
+	DefaultExcludeDotFiles  *bool  `json:"default-exclude-dot-files"`
+	DefaultFollowSymlinks   *bool  `json:"default-follow-symlinks"`
+	DefaultMaxFileSize      *int64 `json:"default-max-file-size"`
+	DefaultIndexShards      int    `json:"default-index-shards"`
+	DefaultIndexWorkers     int    `json:"default-index-workers"`
+	DefaultIndexCompression string `json:"default-index-compression"`
+
+	// The ignore-case of the repos that don't set their own.
+	DefaultIgnoreCase *bool `json:"default-ignore-case"`
+
+	// The suggest of the repos that don't set their own.
+	DefaultSuggest *bool `json:"default-suggest"`
+
+	// The number of bytes the dbpath should stay under, 0 means there is
+	// no limit. Going over the limit is reported but nothing is evicted.
+	MaxDbPathSize int64 `json:"max-dbpath-size"`
+
+	// Remove the working directory and the indexes of a repo that is
+	// removed from the config on a reload, rather than leaving them in the
+	// dbpath. Directories that aren't clones hound made are never removed.
+	CleanupOnRemove bool `json:"cleanup-on-remove"`
+
+	// The number of recent searches to keep for /api/v1/analytics/top, 0
+	// (the default) records nothing. SearchAnalyticsQueries says how the
+	// queries are kept, see the QueriesAs constants.
+	SearchAnalyticsSize    int    `json:"search-analytics-size"`
+	SearchAnalyticsQueries string `json:"search-analytics-queries"`
+
+	// The number of searches a second each client may make, 0 (the
+	// default) doesn't limit them. SearchRateBurst is how many a client
+	// can make at once, which defaults to the rate (and at least 1).
+	// Clients are told apart by IP, which for requests from one of the
+	// TrustedProxies (IPs or CIDRs) comes from X-Forwarded-For. Clients in
+	// SearchRateAllowlist are never limited.
+	SearchRateLimit     float64  `json:"search-rate-limit"`
+	SearchRateBurst     int      `json:"search-rate-burst"`
+	SearchRateAllowlist []string `json:"search-rate-allowlist"`
+	TrustedProxies      []string `json:"trusted-proxies"`
+
+	// How many queries of all batches (see /api/v1/search/batch) are
+	// searched at the same time (4 by default). The rate limit counts each
+	// query of a batch but is per client, this bounds how much of the
+	// server batches take up between them, since each one can carry many
+	// queries that would otherwise all be searched at once.
+	BatchConcurrency int `json:"batch-concurrency"`
+
+	// The longest query, in bytes, a search may have (1000 by default).
+	// MaxCandidateFiles is the most files a search may have to open across
+	// all of the repos it searches, 0 (the default) doesn't limit them. The
+	// files are counted from the trigram index, before any file filters.
+	MaxQueryLength    int `json:"max-query-length"`
+	MaxCandidateFiles int `json:"max-candidate-files"`
+
+	// Patterns shorter than MinQueryLength characters (3 by default) are
+	// too short to look up in the trigram index, so every file would be
+	// opened. ShortQueries says what happens to them, see the
+	// ShortQueries constants. Searches that filter by path are left alone.
+	MinQueryLength     int    `json:"min-query-length"`
+	ShortQueries       string `json:"short-queries"`
+	ShortQueryMaxFiles int    `json:"short-query-max-files"`
+
+	// How long an api request that comes in while the repos are still
+	// being indexed at startup waits for them before it fails as not ready
+	// (5000 by default). A negative period fails right away.
+	MsReadyGracePeriod int `json:"ms-ready-grace-period"`
+
+	// Send errors from the search api with a 200 status (and the error in
+	// the body) as older versions did, for clients that depend on it.
+	LegacyErrorStatus bool `json:"legacy-error-status"`
+
+	// The namespaces (see Repo.Namespace) that each caller may see, by
+	// their identity, with * for all of them. Callers without an identity,
+	// or with one that isn't listed, only see the repos without a
+	// namespace. No scopes (the default) lets everyone see every repo. The
+	// identity is the value of IdentityHeader, which is only taken from
+	// the TrustedProxies, since anyone else could send whatever they like.
+	// The proxy that authenticates callers has to set it on every request.
+	Scopes         map[string][]string `json:"scopes"`
+	IdentityHeader string              `json:"identity-header"`
+
+	// Held while Repos changes, see LockRepos.
+	reposLck sync.RWMutex
+}
+
+// How much indexes are compressed on disk.
+const (
+	// Only the stored files are compressed.
+	CompressionOff = "off"
+
+	// The trigram index is compressed too, quickly.
+	CompressionFast = "fast"
+
+	// Everything is compressed as much as possible.
+	CompressionMax = "max"
+)
+
+func isIndexCompression(c string) bool {
+	switch c {
+	case "", CompressionOff, CompressionFast, CompressionMax:
+		return true
+	}
+	return false
+}
+
+// How search analytics keep queries.
+const (
+	// As they were typed (after normalizing white space).
+	QueriesAsPlain = "plain"
+
+	// As a SHA-256 hash, so that counts of the same query add up without
+	// the query itself being kept.
+	QueriesAsHash = "hash"
+
+	// Not at all, only the rest of the search is kept.
+	QueriesAsRedacted = "redact"
+)
+
+// What happens to searches for patterns shorter than MinQueryLength.
+const (
+	// They are rejected, which is the default.
+	ShortQueriesReject = "reject"
+
+	// At most ShortQueryMaxFiles files (1000 by default) are opened
+	// across the repos searched, and the results are marked as limited.
+	ShortQueriesLimit = "limit"
+)
+
+// The number of searches a client can make at once, see SearchRateLimit.
+func (c *Config) SearchBurst() int {
+	if c.SearchRateBurst > 0 {
+		return c.SearchRateBurst
+	}
+	return int(math.Max(1, math.Ceil(c.SearchRateLimit)))
+}
+
+// The number of queries of all batches that are searched at the same time,
+// see BatchConcurrency.
+func (c *Config) BatchSlots() int {
+	if c.BatchConcurrency > 0 {
+		return c.BatchConcurrency
+	}
+	return defaultBatchConcurrency
+}
+
+// Parse a list of IPs and CIDRs into networks, a single IP is a network of
+// just that IP.
+func ParseIPNets(list []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, s := range list {
+		if strings.Contains(s, "/") {
+			_, n, err := net.ParseCIDR(s)
+			if err != nil {
+				return nil, err
+			}
+			nets = append(nets, n)
+			continue
+		}
+
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address: %s", s)
+		}
+
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
+}
+
+// Lock Repos while it changes, like on a reload or when a repo that failed
+// to start is put back. Anything that reads Repos while hound is running,
+// rather than only at startup, holds RLockRepos.
+func (c *Config) LockRepos() {
+	c.reposLck.Lock()
+}
+
+func (c *Config) UnlockRepos() {
+	c.reposLck.Unlock()
+}
+
+func (c *Config) RLockRepos() {
+	c.reposLck.RLock()
+}
+
+func (c *Config) RUnlockRepos() {
+	c.reposLck.RUnlock()
+}
+
+// The number of lines of context to show around matches by default.
+func (c *Config) LinesOfContext() int {
+	if c.DefaultLinesOfContext == nil {
+		return defaultLinesOfContext
+	}
+	return *c.DefaultLinesOfContext
 }
 
 // SecretMessage is just like json.RawMessage but it will not
@@ -102,10 +623,75 @@ func (r *Repo) VcsConfig() []byte {
 	return *r.VcsConfigMessage
 }
 
-// Populate missing config values with default values.
-func initRepo(r *Repo) {
+// Populate missing config values with the defaults from the config, which
+// must already have been initialized.
+func initRepo(r *Repo, c *Config) {
 	if r.MsBetweenPolls == 0 {
-		r.MsBetweenPolls = defaultMsBetweenPoll
+		r.MsBetweenPolls = c.DefaultMsBetweenPolls
+	}
+
+	if r.PullAttempts == 0 {
+		r.PullAttempts = c.DefaultPullAttempts
+	}
+
+	if r.MsBetweenRetries == 0 {
+		r.MsBetweenRetries = c.DefaultMsBetweenRetries
+	}
+
+	if r.MsPullTimeout == 0 {
+		r.MsPullTimeout = c.DefaultMsPullTimeout
+	}
+
+	if r.PullFailuresToBackOff == 0 {
+		r.PullFailuresToBackOff = c.DefaultPullFailuresToBackOff
+	}
+
+	if r.MsMaxPollBackoff == 0 {
+		r.MsMaxPollBackoff = c.DefaultMsMaxPollBackoff
+	}
+
+	if r.ExcludeDirs == nil {
+		r.ExcludeDirs = c.DefaultExcludeDirs
+	}
+
+	if r.ExcludeDotFiles == nil {
+		r.ExcludeDotFiles = c.DefaultExcludeDotFiles
+	}
+
+	if r.FollowSymlinks == nil {
+		r.FollowSymlinks = c.DefaultFollowSymlinks
+	}
+
+	if r.MaxFileSize == nil {
+		r.MaxFileSize = c.DefaultMaxFileSize
+	}
+
+	if r.IndexShards == 0 {
+		r.IndexShards = c.DefaultIndexShards
+	}
+
+	if r.IndexWorkers == 0 {
+		r.IndexWorkers = c.DefaultIndexWorkers
+	}
+
+	if r.IndexCompression == "" {
+		r.IndexCompression = c.DefaultIndexCompression
+	}
+
+	if r.IndexWeight == 0 {
+		r.IndexWeight = defaultIndexWeight
+	}
+
+	if r.MsIdleBeforeCompaction == 0 {
+		r.MsIdleBeforeCompaction = c.DefaultMsIdleBeforeCompaction
+	}
+
+	if r.IgnoreCase == nil {
+		r.IgnoreCase = c.DefaultIgnoreCase
+	}
+
+	if r.Suggest == nil {
+		r.Suggest = c.DefaultSuggest
 	}
 
 	if r.Vcs == "" {
@@ -113,19 +699,42 @@ func initRepo(r *Repo) {
 	}
 
 	if r.UrlPattern == nil {
-		r.UrlPattern = &UrlPattern{
-			BaseUrl: defaultBaseUrl,
-			Anchor:  defaultAnchor,
-		}
-	} else {
-		if r.UrlPattern.BaseUrl == "" {
-			r.UrlPattern.BaseUrl = defaultBaseUrl
-		}
+		r.UrlPattern = &UrlPattern{}
+	}
+	r.UrlPattern.resolve()
+}
 
-		if r.UrlPattern.Anchor == "" {
-			r.UrlPattern.Anchor = defaultAnchor
-		}
+// Merge the default vcs-config for the repo's vcs into the repo's own
+// vcs-config. Values declared on the repo take precedence.
+func initRepoVcsConfig(r *Repo, defaults map[string]*SecretMessage) error {
+	def := defaults[r.Vcs]
+	if def == nil {
+		return nil
 	}
+
+	if r.VcsConfigMessage == nil {
+		msg := append(SecretMessage{}, *def...)
+		r.VcsConfigMessage = &msg
+		return nil
+	}
+
+	merged := map[string]json.RawMessage{}
+	if err := json.Unmarshal(*def, &merged); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(*r.VcsConfigMessage, &merged); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	msg := SecretMessage(b)
+	r.VcsConfigMessage = &msg
+	return nil
 }
 
 // Populate missing config values with default values.
@@ -133,34 +742,221 @@ func initConfig(c *Config) {
 	if c.MaxConcurrentIndexers == 0 {
 		c.MaxConcurrentIndexers = defaultMaxConcurrentIndexers
 	}
+
+	if c.MsSearchCacheTtl == 0 {
+		c.MsSearchCacheTtl = defaultMsSearchCacheTtl
+	}
+
+	if c.MaxQueryLength == 0 {
+		c.MaxQueryLength = defaultMaxQueryLength
+	}
+
+	if c.MinQueryLength == 0 {
+		c.MinQueryLength = defaultMinQueryLength
+	}
+
+	if c.ShortQueries == "" {
+		c.ShortQueries = ShortQueriesReject
+	}
+
+	if c.ShortQueryMaxFiles == 0 {
+		c.ShortQueryMaxFiles = defaultShortQueryMaxFiles
+	}
+
+	if c.MsReadyGracePeriod == 0 {
+		c.MsReadyGracePeriod = defaultMsReadyGracePeriod
+	}
+
+	if c.DefaultMsBetweenPolls == 0 {
+		c.DefaultMsBetweenPolls = defaultMsBetweenPoll
+	}
+
+	if c.DefaultPullAttempts == 0 {
+		c.DefaultPullAttempts = defaultPullAttempts
+	}
+
+	if c.DefaultMsBetweenRetries == 0 {
+		c.DefaultMsBetweenRetries = defaultMsBetweenPullRetries
+	}
+
+	if c.DefaultMsPullTimeout == 0 {
+		c.DefaultMsPullTimeout = defaultMsPullTimeout
+	}
+
+	if c.DefaultPullFailuresToBackOff == 0 {
+		c.DefaultPullFailuresToBackOff = defaultPullFailuresToBackOff
+	}
+
+	if c.DefaultMsMaxPollBackoff == 0 {
+		c.DefaultMsMaxPollBackoff = defaultMsMaxPollBackoff
+	}
 }
 
+// Is the file a YAML config rather than a JSON one?
+func isYaml(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Load the config from a JSON file or, if the file has a .yaml or .yml
+// extension, a YAML file.
 func (c *Config) LoadFromFile(filename string) error {
-	r, err := os.Open(filename)
+	b, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return err
 	}
-	defer r.Close()
 
-	if err := json.NewDecoder(r).Decode(c); err != nil {
+	if err := c.load(b, isYaml(filename), filepath.Dir(filename)); err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+	return nil
+}
+
+// Load a config that isn't in a file, relative paths in it are relative to
+// the working directory.
+func (c *Config) LoadFromBytes(b []byte, asYaml bool) error {
+	return c.load(b, asYaml, ".")
+}
+
+// Load the config in b, relative paths in it are relative to dir.
+func (c *Config) load(b []byte, asYaml bool, dir string) error {
+	if asYaml {
+		var err error
+		if b, err = yamlToJson(b); err != nil {
+			return err
+		}
+	}
+
+	// the locations in a YAML config's JSON aren't where they are in the file
+	if err := decodeStrict(b, c, !asYaml); err != nil {
 		return err
 	}
 
+	if err := c.expandEnv(); err != nil {
+		return err
+	}
+
+	if c.MaxConcurrentIndexers < 0 {
+		return fmt.Errorf("max-concurrent-indexers must not be negative, got %d",
+			c.MaxConcurrentIndexers)
+	}
+
+	if !isIndexCompression(c.DefaultIndexCompression) {
+		return fmt.Errorf("default-index-compression must be %s, %s or %s, got %s",
+			CompressionOff, CompressionFast, CompressionMax, c.DefaultIndexCompression)
+	}
+
+	switch c.SearchAnalyticsQueries {
+	case "", QueriesAsPlain, QueriesAsHash, QueriesAsRedacted:
+	default:
+		return fmt.Errorf("search-analytics-queries must be %s, %s or %s, got %s",
+			QueriesAsPlain, QueriesAsHash, QueriesAsRedacted, c.SearchAnalyticsQueries)
+	}
+
+	if c.MaxQueryLength < 0 || c.MaxCandidateFiles < 0 {
+		return fmt.Errorf("max-query-length and max-candidate-files must not be negative, got %d and %d",
+			c.MaxQueryLength, c.MaxCandidateFiles)
+	}
+
+	if c.MinQueryLength < 0 || c.ShortQueryMaxFiles < 0 {
+		return fmt.Errorf("min-query-length and short-query-max-files must not be negative, got %d and %d",
+			c.MinQueryLength, c.ShortQueryMaxFiles)
+	}
+
+	switch c.ShortQueries {
+	case "", ShortQueriesReject, ShortQueriesLimit:
+	default:
+		return fmt.Errorf("short-queries must be %s or %s, got %s",
+			ShortQueriesReject, ShortQueriesLimit, c.ShortQueries)
+	}
+
+	if c.SearchRateLimit < 0 || c.SearchRateBurst < 0 {
+		return fmt.Errorf("search-rate-limit and search-rate-burst must not be negative, got %g and %d",
+			c.SearchRateLimit, c.SearchRateBurst)
+	}
+
+	if _, err := ParseIPNets(c.SearchRateAllowlist); err != nil {
+		return fmt.Errorf("search-rate-allowlist: %s", err)
+	}
+
+	if _, err := ParseIPNets(c.TrustedProxies); err != nil {
+		return fmt.Errorf("trusted-proxies: %s", err)
+	}
+
+	for identity, namespaces := range c.Scopes {
+		for _, ns := range namespaces {
+			if ns == "" {
+				return fmt.Errorf("scopes: empty namespace for %s", identity)
+			}
+		}
+	}
+
 	if !filepath.IsAbs(c.DbPath) {
 		path, err := filepath.Abs(
-			filepath.Join(filepath.Dir(filename), c.DbPath))
+			filepath.Join(dir, c.DbPath))
 		if err != nil {
 			return err
 		}
 		c.DbPath = path
 	}
 
-	for _, repo := range c.Repos {
-		initRepo(repo)
+	// tls files are also relative to the config file
+	for _, p := range []*string{&c.TlsCert, &c.TlsKey} {
+		if *p == "" || filepath.IsAbs(*p) {
+			continue
+		}
+
+		path, err := filepath.Abs(
+			filepath.Join(dir, *p))
+		if err != nil {
+			return err
+		}
+		*p = path
 	}
 
+	// repos inherit from the config, so it goes first
 	initConfig(c)
 
+	for name, repo := range c.Repos {
+		initRepo(repo, c)
+
+		if err := repo.UrlPattern.validate(); err != nil {
+			return fmt.Errorf("repo %s: %s", name, err)
+		}
+
+		if errs := repo.validateSettings(); len(errs) > 0 {
+			msgs := make([]string, len(errs))
+			for i, err := range errs {
+				msgs[i] = err.Error()
+			}
+			return fmt.Errorf("repo %s: %s", name, strings.Join(msgs, "; "))
+		}
+
+		if err := initRepoVcsConfig(repo, c.VcsConfigDefaults); err != nil {
+			return err
+		}
+	}
+
+	return c.validateAliases()
+}
+
+// Check that every alias stands for a single repo and can be used wherever
+// a repo name can.
+func (c *Config) validateAliases() error {
+	owners := map[string]string{}
+	for name, repo := range c.Repos {
+		for _, alias := range repo.Aliases {
+			switch {
+			case alias == "" || strings.ContainsAny(alias, ",*?["):
+				return fmt.Errorf("repo %s: invalid alias %q", name, alias)
+			case c.Repos[alias] != nil:
+				return fmt.Errorf("repo %s: alias %s is the name of a repo", name, alias)
+			case owners[alias] != "" && owners[alias] != name:
+				return fmt.Errorf("repo %s: alias %s is also an alias of %s", name, alias, owners[alias])
+			}
+			owners[alias] = name
+		}
+	}
 	return nil
 }
 
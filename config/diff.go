@@ -0,0 +1,63 @@
+package config
+
+import "sort"
+
+// How the repos of one config differ from those of another, each list is
+// sorted by name.
+type Diff struct {
+	// Repos that are only in the new config.
+	Added []string
+
+	// Repos that are only in the old config.
+	Removed []string
+
+	// Repos whose config changed in a way that needs a restart.
+	Restarted []string
+
+	// Repos whose config is the same, apart from what can be changed on a
+	// running repo (see liveRepoChanges).
+	Unchanged []string
+}
+
+// Undo the changes in next that can be applied to a running repo, so that
+// comparing it with repo only finds the changes that need a restart. Tags,
+// aliases, exclude-from-wildcard and namespace only affect which repos a
+// search covers and ms-between-poll is left as it was.
+func liveRepoChanges(repo, next *Repo) *Repo {
+	r := *next
+	r.MsBetweenPolls = repo.MsBetweenPolls
+	r.Tags = repo.Tags
+	r.Aliases = repo.Aliases
+	r.ExcludeFromWildcard = repo.ExcludeFromWildcard
+	r.Namespace = repo.Namespace
+	return &r
+}
+
+// Compare the repos in c with those in next.
+func (c *Config) Diff(next *Config) *Diff {
+	d := &Diff{}
+
+	for name, repo := range c.Repos {
+		nrepo, ok := next.Repos[name]
+		switch {
+		case !ok:
+			d.Removed = append(d.Removed, name)
+		case repo.ToJsonString() == liveRepoChanges(repo, nrepo).ToJsonString():
+			d.Unchanged = append(d.Unchanged, name)
+		default:
+			d.Restarted = append(d.Restarted, name)
+		}
+	}
+
+	for name := range next.Repos {
+		if _, ok := c.Repos[name]; !ok {
+			d.Added = append(d.Added, name)
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Restarted)
+	sort.Strings(d.Unchanged)
+	return d
+}
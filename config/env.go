@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Matches ${VAR} references, along with the $${VAR} escape for a literal
+// ${VAR}. A bare $ is left alone so values like regexes are not mangled.
+var envVarPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Replace the ${VAR} references in s with the values of the environment
+// variables, escape is applied to each value before it is substituted. It
+// is an error to refer to a variable that is not set.
+func expandEnvWith(s string, escape func(string) string) (string, error) {
+	var err error
+	res := envVarPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if m[1] == '$' {
+			return m[1:]
+		}
+
+		name := m[2 : len(m)-1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("environment variable %s is not set", name)
+			}
+			return m
+		}
+		return escape(v)
+	})
+	return res, err
+}
+
+func expandEnv(s string) (string, error) {
+	return expandEnvWith(s, func(v string) string {
+		return v
+	})
+}
+
+// Expand the ${VAR} references in the strings of a raw JSON message, the
+// values are escaped so the message stays valid JSON.
+func expandEnvJson(msg *SecretMessage) error {
+	if msg == nil {
+		return nil
+	}
+
+	s, err := expandEnvWith(string(*msg), func(v string) string {
+		b, _ := json.Marshal(v)
+		return string(b[1 : len(b)-1])
+	})
+	if err != nil {
+		return err
+	}
+
+	*msg = SecretMessage(s)
+	return nil
+}
+
+// Expand environment variables in the values that support them: dbpath,
+// tls-cert, tls-key, webhook-secret, admin-token, vcs-config-defaults and the
+// url and vcs-config of each repo. Nothing else is expanded, in particular not the
+// url-pattern.
+func (c *Config) expandEnv() error {
+	for name, p := range map[string]*string{
+		"dbpath":         &c.DbPath,
+		"tls-cert":       &c.TlsCert,
+		"tls-key":        &c.TlsKey,
+		"webhook-secret": &c.WebhookSecret,
+		"admin-token":    &c.AdminToken,
+	} {
+		v, err := expandEnv(*p)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		*p = v
+	}
+
+	for vcs, msg := range c.VcsConfigDefaults {
+		if err := expandEnvJson(msg); err != nil {
+			return fmt.Errorf("vcs-config-defaults for %s: %s", vcs, err)
+		}
+	}
+
+	for name, repo := range c.Repos {
+		url, err := expandEnv(repo.Url)
+		if err != nil {
+			return fmt.Errorf("repo %s: url: %s", name, err)
+		}
+		repo.Url = url
+
+		if err := expandEnvJson(repo.VcsConfigMessage); err != nil {
+			return fmt.Errorf("repo %s: vcs-config: %s", name, err)
+		}
+	}
+
+	return nil
+}
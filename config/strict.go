@@ -0,0 +1,83 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The errors of the json package for keys the config doesn't know about.
+var unknownFieldPattern = regexp.MustCompile(`^json: unknown field "(.*)"$`)
+
+// Decode the config in b into c, rejecting any key that isn't a setting (so
+// that a typo isn't silently ignored). When located is set, errors say
+// where in b the problem is.
+func decodeStrict(b []byte, c *Config, located bool) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(c); err != nil {
+		return locateError(b, err, located)
+	}
+
+	if dec.More() {
+		return locate(b, int(dec.InputOffset()), located,
+			fmt.Errorf("unexpected data after the config"))
+	}
+	return nil
+}
+
+// Rewrite an error from decoding b to name the key at fault and where it is.
+func locateError(b []byte, err error, located bool) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		// the offset is just past the character at fault
+		return locate(b, int(e.Offset)-1, located, e)
+
+	case *json.UnmarshalTypeError:
+		// the offset is just past the value, which is close enough
+		return locate(b, int(e.Offset), located,
+			fmt.Errorf("%s must be %s, got %s", e.Field, typeName(e.Type.String()), e.Value))
+	}
+
+	if m := unknownFieldPattern.FindStringSubmatch(err.Error()); m != nil {
+		// the decoder doesn't say where the key is, so it's the first key
+		// with that name
+		off := -1
+		if loc := regexp.MustCompile(regexp.QuoteMeta(`"`+m[1]+`"`) + `\s*:`).FindIndex(b); loc != nil {
+			off = loc[0]
+		}
+		return locate(b, off, located, fmt.Errorf("unknown setting %q", m[1]))
+	}
+
+	return err
+}
+
+// The name of the kind of value a Go type holds, for errors.
+func typeName(t string) string {
+	switch {
+	case strings.HasPrefix(t, "int"), strings.HasPrefix(t, "uint"), strings.HasPrefix(t, "float"):
+		return "a number"
+	case t == "bool":
+		return "true or false"
+	case t == "string":
+		return "a string"
+	case strings.HasPrefix(t, "[]"):
+		return "a list"
+	}
+	return "an object"
+}
+
+// Prefix err with the line and column of offset off in b, unless the
+// location isn't known or wanted.
+func locate(b []byte, off int, located bool, err error) error {
+	if !located || off < 0 || off > len(b) {
+		return err
+	}
+
+	line := bytes.Count(b[:off], []byte("\n")) + 1
+	col := off - bytes.LastIndexByte(b[:off], '\n')
+	return fmt.Errorf("line %d, column %d: %s", line, col, err)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The code hosts that url-pattern has presets for.
+const (
+	HostGitHub    = "github"
+	HostGitLab    = "gitlab"
+	HostBitbucket = "bitbucket"
+	HostGitea     = "gitea"
+)
+
+var urlPresets = map[string]*UrlPattern{
+	HostGitHub: {
+		BaseUrl:     "{url}/blob/{rev}/{path}{anchor}",
+		Anchor:      "#L{line}",
+		RangeAnchor: "#L{line}-L{lineEnd}",
+	},
+	HostGitLab: {
+		BaseUrl:     "{url}/-/blob/{rev}/{path}{anchor}",
+		Anchor:      "#L{line}",
+		RangeAnchor: "#L{line}-{lineEnd}",
+	},
+	HostBitbucket: {
+		BaseUrl:     "{url}/src/{rev}/{path}{anchor}",
+		Anchor:      "#lines-{line}",
+		RangeAnchor: "#lines-{line}:{lineEnd}",
+	},
+	HostGitea: {
+		BaseUrl:     "{url}/src/commit/{rev}/{path}{anchor}",
+		Anchor:      "#L{line}",
+		RangeAnchor: "#L{line}-L{lineEnd}",
+	},
+}
+
+// The placeholders each part of a url-pattern can use.
+var (
+	baseUrlVars     = []string{"url", "rev", "path", "anchor", "reponame"}
+	anchorVars      = []string{"line", "filename"}
+	rangeAnchorVars = []string{"line", "lineEnd", "filename"}
+)
+
+var placeholderRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// The names of the {placeholders} in pattern, leaving out any ${VAR} which
+// is there for the environment.
+func placeholders(pattern string) []string {
+	var names []string
+	for _, m := range placeholderRe.FindAllStringSubmatchIndex(pattern, -1) {
+		if m[0] > 0 && pattern[m[0]-1] == '$' {
+			continue
+		}
+		names = append(names, pattern[m[2]:m[3]])
+	}
+	return names
+}
+
+// Fill in the parts of the pattern that aren't set from the preset for its
+// host, or from the defaults (which are GitHub's) if it has none.
+func (p *UrlPattern) resolve() {
+	preset := urlPresets[p.Host]
+	if preset == nil {
+		preset = &UrlPattern{
+			BaseUrl:     defaultBaseUrl,
+			Anchor:      defaultAnchor,
+			RangeAnchor: defaultRangeAnchor,
+		}
+	}
+
+	if p.BaseUrl == "" {
+		p.BaseUrl = preset.BaseUrl
+	}
+
+	if p.Anchor == "" {
+		p.Anchor = preset.Anchor
+	}
+
+	if p.RangeAnchor == "" {
+		p.RangeAnchor = preset.RangeAnchor
+	}
+}
+
+// Check that the host is one there's a preset for and that every part of
+// the pattern only uses the placeholders that will be filled in.
+func (p *UrlPattern) validate() error {
+	if p.Host != "" && urlPresets[p.Host] == nil {
+		hosts := make([]string, 0, len(urlPresets))
+		for host := range urlPresets {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+
+		return fmt.Errorf("unknown url-pattern host %s, must be one of %s",
+			p.Host, strings.Join(hosts, ", "))
+	}
+
+	parts := []struct {
+		name    string
+		pattern string
+		vars    []string
+	}{
+		{"base-url", p.BaseUrl, baseUrlVars},
+		{"anchor", p.Anchor, anchorVars},
+		{"range-anchor", p.RangeAnchor, rangeAnchorVars},
+	}
+
+	for _, part := range parts {
+		for _, name := range placeholders(part.pattern) {
+			if !containsString(part.vars, name) {
+				return fmt.Errorf("unknown placeholder {%s} in url-pattern %s, must be one of {%s}",
+					name, part.name, strings.Join(part.vars, "}, {"))
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
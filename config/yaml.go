@@ -0,0 +1,447 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Hound reads YAML configs by converting them to JSON, so that both formats
+// go through exactly the same decoding (and vcs-config stays raw JSON). Only
+// the parts of YAML that a config needs are supported: block mappings and
+// sequences, flow mappings and sequences, plain and quoted scalars and
+// comments. Anchors, tags, block scalars and multiple documents are not.
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// Convert a YAML document to JSON.
+func yamlToJson(b []byte) ([]byte, error) {
+	lines, err := yamlLines(string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return []byte("{}"), nil
+	}
+
+	p := &yamlParser{lines: lines}
+	v, err := p.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.lines) {
+		return nil, p.errorf(p.lines[p.pos], "unexpected indentation")
+	}
+
+	return json.Marshal(v)
+}
+
+// Split the document into lines with their indentation, leaving out blank
+// lines, comments and document markers.
+func yamlLines(doc string) ([]yamlLine, error) {
+	var lines []yamlLine
+	for i, line := range strings.Split(doc, "\n") {
+		line = strings.TrimRight(stripYamlComment(strings.TrimRight(line, "\r")), " \t")
+		text := strings.TrimLeft(line, " ")
+		if text == "" {
+			continue
+		}
+
+		if text[0] == '\t' {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed for indentation", i+1)
+		}
+
+		indent := len(line) - len(text)
+		if indent == 0 && (text == "---" || text == "...") {
+			continue
+		}
+
+		lines = append(lines, yamlLine{i + 1, indent, text})
+	}
+	return lines, nil
+}
+
+// Remove the comment, if any, from the end of the line. A # only starts a
+// comment outside of quotes and at the start of the line or after a space.
+func stripYamlComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.IndexByte(" \t[{,:", line[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#':
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func (p *yamlParser) errorf(l yamlLine, format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", l.num, fmt.Sprintf(format, args...))
+}
+
+func isYamlSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// Split a mapping entry into its key and (possibly empty) value. Returns
+// false if the text isn't a mapping entry.
+func splitYamlEntry(text string) (string, string, bool, error) {
+	if text[0] == '[' || text[0] == '{' {
+		return "", "", false, nil
+	}
+
+	var key, rest string
+	if text[0] == '"' || text[0] == '\'' {
+		end := quotedYamlEnd(text)
+		if end < 0 {
+			return "", "", false, fmt.Errorf("unterminated string")
+		}
+
+		rest = strings.TrimLeft(text[end:], " ")
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false, nil
+		}
+
+		k, err := parseYamlScalar(text[:end])
+		if err != nil {
+			return "", "", false, err
+		}
+		key = fmt.Sprint(k)
+		rest = rest[1:]
+	} else {
+		i := strings.Index(text, ": ")
+		if i < 0 {
+			if !strings.HasSuffix(text, ":") {
+				return "", "", false, nil
+			}
+			i = len(text) - 1
+		}
+		key = strings.TrimRight(text[:i], " ")
+		rest = text[i+1:]
+	}
+
+	if rest != "" && rest[0] != ' ' {
+		return "", "", false, nil
+	}
+
+	return key, strings.TrimSpace(rest), true, nil
+}
+
+// The index just past the closing quote of the string that text starts
+// with, -1 if it isn't terminated.
+func quotedYamlEnd(text string) int {
+	q := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case q == '"' && text[i] == '\\':
+			i++
+		case text[i] == q:
+			if q == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// Parse the block (mapping or sequence) starting at the current line.
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYamlSeqItem(p.lines[p.pos].text) {
+		return p.parseSeq(indent)
+	}
+
+	if _, _, ok, _ := splitYamlEntry(p.lines[p.pos].text); ok {
+		return p.parseMap(indent)
+	}
+
+	// a lone scalar (possibly a flow collection)
+	l := p.lines[p.pos]
+	p.pos++
+	v, err := parseYamlScalar(l.text)
+	if err != nil {
+		return nil, p.errorf(l, "%s", err)
+	}
+	return v, nil
+}
+
+// Parse the value of a key or sequence item that has nothing after it on
+// its own line, which is whatever is indented more deeply beneath it.
+func (p *yamlParser) parseNested(indent int) (interface{}, error) {
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return p.parseBlock(p.lines[p.pos].indent)
+	}
+	return nil, nil
+}
+
+func (p *yamlParser) parseSeq(indent int) (interface{}, error) {
+	seq := []interface{}{}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent || !isYamlSeqItem(l.text) {
+			break
+		}
+
+		if l.indent > indent {
+			return nil, p.errorf(l, "unexpected indentation")
+		}
+
+		rest := strings.TrimLeft(l.text[1:], " ")
+
+		var v interface{}
+		var err error
+		if rest == "" {
+			p.pos++
+			v, err = p.parseNested(indent)
+		} else {
+			// the item's content is parsed as if it started on its own
+			// line, indented past the dash.
+			p.lines[p.pos] = yamlLine{l.num, l.indent + len(l.text) - len(rest), rest}
+			v, err = p.parseBlock(p.lines[p.pos].indent)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+	}
+	return seq, nil
+}
+
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+	m := map[string]interface{}{}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+
+		if l.indent > indent {
+			return nil, p.errorf(l, "unexpected indentation")
+		}
+
+		key, val, ok, err := splitYamlEntry(l.text)
+		if err != nil {
+			return nil, p.errorf(l, "%s", err)
+		}
+
+		if !ok {
+			return nil, p.errorf(l, "expected a key")
+		}
+
+		if _, dup := m[key]; dup {
+			return nil, p.errorf(l, "duplicate key %q", key)
+		}
+
+		p.pos++
+
+		var v interface{}
+		switch {
+		case val != "":
+			v, err = parseYamlScalar(val)
+			if err != nil {
+				return nil, p.errorf(l, "%s", err)
+			}
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYamlSeqItem(p.lines[p.pos].text):
+			// a sequence may sit at the same indentation as its key
+			v, err = p.parseSeq(indent)
+		default:
+			v, err = p.parseNested(indent)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// Parse a scalar or a flow collection that takes up all of text.
+func parseYamlScalar(text string) (interface{}, error) {
+	f := &yamlFlow{s: text}
+	v, err := f.value("")
+	if err != nil {
+		return nil, err
+	}
+
+	f.skipSpace()
+	if f.pos < len(f.s) {
+		return nil, fmt.Errorf("unexpected %q", f.s[f.pos:])
+	}
+	return v, nil
+}
+
+// A parser for values on a single line, including flow collections like
+// [a, b] and {a: 1}.
+type yamlFlow struct {
+	s   string
+	pos int
+}
+
+func (f *yamlFlow) skipSpace() {
+	for f.pos < len(f.s) && f.s[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+// Parse the value at the current position, plain scalars end at any of the
+// stop characters.
+func (f *yamlFlow) value(stop string) (interface{}, error) {
+	f.skipSpace()
+	if f.pos >= len(f.s) {
+		return nil, nil
+	}
+
+	switch c := f.s[f.pos]; c {
+	case '[':
+		return f.seq()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		end := quotedYamlEnd(f.s[f.pos:])
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+
+		q := f.s[f.pos : f.pos+end]
+		f.pos += end
+		if c == '\'' {
+			return strings.Replace(q[1:len(q)-1], "''", "'", -1), nil
+		}
+		return strconv.Unquote(q)
+	case '&', '*', '!', '|', '>', '%', '@', '`':
+		return nil, fmt.Errorf("unsupported yaml syntax %q", c)
+	}
+
+	start := f.pos
+	for f.pos < len(f.s) && strings.IndexByte(stop, f.s[f.pos]) < 0 {
+		f.pos++
+	}
+	return plainYamlScalar(strings.TrimSpace(f.s[start:f.pos])), nil
+}
+
+func (f *yamlFlow) seq() (interface{}, error) {
+	f.pos++
+	seq := []interface{}{}
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.s) {
+			return nil, fmt.Errorf("unterminated sequence")
+		}
+
+		if f.s[f.pos] == ']' {
+			f.pos++
+			return seq, nil
+		}
+
+		v, err := f.value(",]")
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+
+		if err := f.next(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *yamlFlow) mapping() (interface{}, error) {
+	f.pos++
+	m := map[string]interface{}{}
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.s) {
+			return nil, fmt.Errorf("unterminated mapping")
+		}
+
+		if f.s[f.pos] == '}' {
+			f.pos++
+			return m, nil
+		}
+
+		k, err := f.value(":,}")
+		if err != nil {
+			return nil, err
+		}
+
+		f.skipSpace()
+		if f.pos >= len(f.s) || f.s[f.pos] != ':' {
+			return nil, fmt.Errorf("expected : after key %v", k)
+		}
+		f.pos++
+
+		v, err := f.value(",}")
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+
+		if err := f.next('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Move past the separator after an entry in a flow collection.
+func (f *yamlFlow) next(end byte) error {
+	f.skipSpace()
+	switch {
+	case f.pos >= len(f.s):
+		return fmt.Errorf("unterminated collection")
+	case f.s[f.pos] == ',':
+		f.pos++
+	case f.s[f.pos] != end:
+		return fmt.Errorf("expected , or %c", end)
+	}
+	return nil
+}
+
+// The value of an unquoted scalar, which is a bool, a number, null or
+// otherwise a string.
+func plainYamlScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.IndexAny(s, "0123456789") >= 0 {
+		return f
+	}
+
+	return s
+}
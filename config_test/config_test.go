@@ -1,8 +1,12 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/etsy/hound/config"
@@ -36,3 +40,758 @@ func TestExampleConfigsAreValid(t *testing.T) {
 		}
 	}
 }
+
+// Test that the global vcs-config defaults are merged into the vcs-config
+// of each repo without clobbering the repo's own values.
+func TestVcsConfigDefaults(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromFile(filepath.Join(rootDir(), exampleConfigFile)); err != nil {
+		t.Fatalf("Unable to parse %s: %s", exampleConfigFile, err)
+	}
+
+	wd, err := vcs.New("git", cfg.Repos["PrivateGitRepo"].VcsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	git := wd.Driver.(*vcs.GitDriver)
+	if git.SshKey != "/absolute/path/to/id_rsa" {
+		t.Fatalf("expected default ssh-key, got %s", git.SshKey)
+	}
+
+	if git.Token != "token_for_ro_account" {
+		t.Fatalf("expected repo token, got %s", git.Token)
+	}
+}
+
+func TestRepoValidate(t *testing.T) {
+	repo := config.Repo{
+		Url:            "",
+		MsBetweenPolls: -1,
+	}
+
+	if errs := repo.Validate(); len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+
+	repo = config.Repo{
+		Url: "file:///this/path/does/not/exist",
+	}
+
+	if errs := repo.Validate(); len(errs) != 1 {
+		t.Fatalf("expected missing local path to be reported, got %v", errs)
+	}
+
+	repo = config.Repo{
+		Url: "https://github.com/etsy/hound.git",
+	}
+
+	if errs := repo.Validate(); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	for _, exts := range [][]string{{""}, {"."}, {"tar.gz"}, {"a/b"}} {
+		repo = config.Repo{Url: "https://github.com/etsy/hound.git", IncludeExtensions: exts}
+		if errs := repo.Validate(); len(errs) != 1 {
+			t.Fatalf("expected include-extensions %q to be invalid, got %v", exts, errs)
+		}
+	}
+
+	for _, shards := range []int{-1, 65} {
+		repo = config.Repo{Url: "https://github.com/etsy/hound.git", IndexShards: shards}
+		if errs := repo.Validate(); len(errs) != 1 {
+			t.Fatalf("expected index-shards %d to be invalid, got %v", shards, errs)
+		}
+	}
+}
+
+// Test that repos inherit the top level defaults unless they set their own
+// values, and that an inherited value is indistinguishable from the same
+// value set explicitly.
+func TestRepoDefaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(filename, []byte(`{
+		"default-ms-between-poll" : 60000,
+		"default-lines-of-context" : 0,
+		"repos" : {
+			"inherits" : { "url" : "https://github.com/etsy/hound.git" },
+			"explicit" : { "url" : "https://github.com/etsy/hound.git", "ms-between-poll" : 60000 },
+			"overrides" : { "url" : "https://github.com/etsy/hound.git", "ms-between-poll" : 5000 }
+		}
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromFile(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := cfg.Repos["inherits"].MsBetweenPolls; n != 60000 {
+		t.Fatalf("expected inherited ms-between-poll of 60000, got %d", n)
+	}
+
+	if n := cfg.Repos["overrides"].MsBetweenPolls; n != 5000 {
+		t.Fatalf("expected ms-between-poll of 5000, got %d", n)
+	}
+
+	if cfg.Repos["inherits"].ToJsonString() != cfg.Repos["explicit"].ToJsonString() {
+		t.Fatal("expected inherited and explicit values to compare equal")
+	}
+
+	if n := cfg.LinesOfContext(); n != 0 {
+		t.Fatalf("expected 0 lines of context, got %d", n)
+	}
+}
+
+// Test that a YAML config loads into the same config as the equivalent JSON
+// one, including the raw vcs-config.
+func TestYamlConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"config.json": `{
+			"dbpath" : "data",
+			"max-concurrent-indexers" : 4,
+			"allowed-origins" : ["https://a.example.com", "https://b.example.com"],
+			"repos" : {
+				"SomeGitRepo" : {
+					"url" : "https://github.com/etsy/hound.git",
+					"ms-between-poll" : 10000,
+					"exclude-dot-files" : true,
+					"tags" : ["backend", "go"],
+					"vcs-config" : { "ref" : "main", "submodules" : true }
+				},
+				"Subversion" : {
+					"url" : "http://my-svn.com/repo",
+					"vcs" : "svn",
+					"url-pattern" : { "base-url" : "{url}/{path}{anchor}" }
+				}
+			}
+		}`,
+		"config.yaml": `
+# comments are what YAML is for
+dbpath: data
+max-concurrent-indexers: 4
+allowed-origins:
+- https://a.example.com
+- "https://b.example.com"  # quoted
+repos:
+  SomeGitRepo:
+    url: https://github.com/etsy/hound.git
+    ms-between-poll: 10000
+    exclude-dot-files: true
+    tags: [backend, go]
+    vcs-config:
+      ref: 'main'
+      submodules: true
+  Subversion:
+    url: http://my-svn.com/repo
+    vcs: svn
+    url-pattern: {base-url: "{url}/{path}{anchor}"}
+`,
+	}
+
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cfgJson, cfgYaml config.Config
+	if err := cfgJson.LoadFromFile(filepath.Join(dir, "config.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfgYaml.LoadFromFile(filepath.Join(dir, "config.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cfgJson.AllowedOrigins, cfgYaml.AllowedOrigins) ||
+		cfgJson.DbPath != cfgYaml.DbPath ||
+		cfgJson.MaxConcurrentIndexers != cfgYaml.MaxConcurrentIndexers {
+		t.Fatalf("expected %+v, got %+v", &cfgJson, &cfgYaml)
+	}
+
+	for name, repo := range cfgJson.Repos {
+		if got := cfgYaml.Repos[name]; got == nil || got.ToJsonString() != repo.ToJsonString() {
+			t.Fatalf("expected repo %s to be %s, got %v", name, repo.ToJsonString(), got)
+		}
+	}
+
+	wd, err := vcs.New("git", cfgYaml.Repos["SomeGitRepo"].VcsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	git := wd.Driver.(*vcs.GitDriver)
+	if git.Ref != "main" || !git.Submodules {
+		t.Fatalf("expected the vcs-config to round trip, got %+v", git)
+	}
+}
+
+func TestYamlConfigErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, data := range []string{
+		"repos:\n  a:\n    url: x\n   vcs: git\n",
+		"repos:\n  a: [x, y\n",
+		"dbpath: a\ndbpath: b\n",
+		"repos:\n\ta: {}\n",
+		"dbpath: &anchor data\n",
+	} {
+		filename := filepath.Join(dir, "config.yml")
+		if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var cfg config.Config
+		if err := cfg.LoadFromFile(filename); err == nil {
+			t.Fatalf("expected an error for %q", data)
+		}
+	}
+}
+
+func TestEnvExpansion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("HOUND_TEST_DBPATH", "/var/hound")
+	os.Setenv("HOUND_TEST_TOKEN", `se"cret`)
+	defer os.Unsetenv("HOUND_TEST_DBPATH")
+	defer os.Unsetenv("HOUND_TEST_TOKEN")
+
+	filename := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(filename, []byte(`{
+		"dbpath" : "${HOUND_TEST_DBPATH}/data",
+		"repos" : {
+			"a" : {
+				"url" : "https://github.com/etsy/$${NOT_EXPANDED}.git",
+				"url-pattern" : { "base-url" : "{url}/${HOUND_TEST_DBPATH}" },
+				"vcs-config" : { "token" : "${HOUND_TEST_TOKEN}" }
+			}
+		}
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromFile(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.DbPath != "/var/hound/data" {
+		t.Fatalf("expected dbpath to be expanded, got %s", cfg.DbPath)
+	}
+
+	repo := cfg.Repos["a"]
+	if repo.Url != "https://github.com/etsy/${NOT_EXPANDED}.git" {
+		t.Fatalf("expected the escaped reference to be kept, got %s", repo.Url)
+	}
+
+	if repo.UrlPattern.BaseUrl != "{url}/${HOUND_TEST_DBPATH}" {
+		t.Fatalf("expected url-pattern not to be expanded, got %s", repo.UrlPattern.BaseUrl)
+	}
+
+	wd, err := vcs.New("git", repo.VcsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token := wd.Driver.(*vcs.GitDriver).Token; token != `se"cret` {
+		t.Fatalf("expected the token to be expanded, got %s", token)
+	}
+
+	if err := ioutil.WriteFile(filename, []byte(`{
+		"dbpath" : "${HOUND_TEST_UNSET}",
+		"repos" : {}
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.LoadFromFile(filename); err == nil {
+		t.Fatal("expected an undefined variable to fail")
+	}
+}
+
+// Test that repos get the default exclude-dirs unless they set their own.
+func TestExcludeDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(filename, []byte(`{
+		"default-exclude-dirs" : ["node_modules"],
+		"repos" : {
+			"inherits" : { "url" : "https://github.com/etsy/hound.git" },
+			"overrides" : {
+				"url" : "https://github.com/etsy/hound.git",
+				"exclude-dirs" : ["vendor", "/third_party/big/", "!.hg"]
+			}
+		}
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromFile(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	exclude, include := cfg.Repos["inherits"].ExcludedDirs()
+	if !reflect.DeepEqual(exclude, []string{"node_modules"}) || len(include) != 0 {
+		t.Fatalf("expected the default exclude-dirs, got %v and %v", exclude, include)
+	}
+
+	exclude, include = cfg.Repos["overrides"].ExcludedDirs()
+	if !reflect.DeepEqual(exclude, []string{"vendor", "third_party/big"}) {
+		t.Fatalf("expected the repo's own exclude-dirs, got %v", exclude)
+	}
+
+	if !reflect.DeepEqual(include, []string{".hg"}) {
+		t.Fatalf("expected .hg to be included, got %v", include)
+	}
+
+	for _, dirs := range [][]string{{""}, {"!a/b"}, {"../up"}} {
+		repo := config.Repo{Url: "https://github.com/etsy/hound.git", ExcludeDirs: dirs}
+		if errs := repo.Validate(); len(errs) == 0 {
+			t.Fatalf("expected exclude-dirs %q to be invalid", dirs)
+		}
+	}
+}
+
+// Test that the repos of two configs are sorted into the right categories.
+func TestConfigDiff(t *testing.T) {
+	load := func(s string) *config.Config {
+		var cfg config.Config
+		if err := cfg.LoadFromBytes([]byte(s), false); err != nil {
+			t.Fatal(err)
+		}
+		return &cfg
+	}
+
+	old := load(`{"repos" : {
+		"same"    : { "url" : "https://github.com/etsy/same.git" },
+		"retag"   : { "url" : "https://github.com/etsy/retag.git", "tags" : ["a"] },
+		"changed" : { "url" : "https://github.com/etsy/changed.git" },
+		"removed" : { "url" : "https://github.com/etsy/removed.git" }
+	}}`)
+
+	next := load(`{"repos" : {
+		"same"    : { "url" : "https://github.com/etsy/same.git" },
+		"retag"   : { "url" : "https://github.com/etsy/retag.git", "tags" : ["b"], "ms-between-poll" : 5000, "aliases" : ["tag"], "namespace" : "team-b" },
+		"changed" : { "url" : "https://github.com/etsy/changed.git", "exclude-dot-files" : true },
+		"added"   : { "url" : "https://github.com/etsy/added.git" }
+	}}`)
+
+	diff := old.Diff(next)
+	expected := &config.Diff{
+		Added:     []string{"added"},
+		Removed:   []string{"removed"},
+		Restarted: []string{"changed"},
+		Unchanged: []string{"retag", "same"},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Fatalf("expected diff %+v, got %+v", expected, diff)
+	}
+
+	// comparing doesn't change either config
+	if old.Repos["retag"].Tags[0] != "a" || next.Repos["retag"].MsBetweenPolls != 5000 {
+		t.Fatal("expected the configs to be left alone")
+	}
+}
+
+func TestMaxConcurrentIndexers(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{"repos": {}}`), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.MaxConcurrentIndexers < 1 {
+		t.Fatalf("expected a default max-concurrent-indexers, got %d", cfg.MaxConcurrentIndexers)
+	}
+
+	cfg = config.Config{}
+	if err := cfg.LoadFromBytes([]byte(`{"max-concurrent-indexers": -1}`), false); err == nil {
+		t.Fatal("expected an error for a negative max-concurrent-indexers")
+	}
+}
+
+func TestSearchAnalyticsQueries(t *testing.T) {
+	for _, keep := range []string{"plain", "hash", "redact"} {
+		var cfg config.Config
+		if err := cfg.LoadFromBytes([]byte(`{"search-analytics-queries": "`+keep+`"}`), false); err != nil {
+			t.Fatalf("%s: %s", keep, err)
+		}
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{"search-analytics-queries": "shuffle"}`), false); err == nil {
+		t.Fatal("expected an error for an unknown search-analytics-queries")
+	}
+}
+
+// Test that a repo's index options come from the repo, then from the
+// config's defaults and only then from the built-in defaults.
+func TestIndexOptionPrecedence(t *testing.T) {
+	load := func(s string) *config.Config {
+		var cfg config.Config
+		if err := cfg.LoadFromBytes([]byte(s), false); err != nil {
+			t.Fatal(err)
+		}
+		return &cfg
+	}
+
+	cfg := load(`{
+		"default-exclude-dot-files" : true,
+		"default-max-file-size" : 100,
+		"default-index-shards" : 4,
+		"repos" : {
+			"default"  : { "url" : "https://github.com/etsy/default.git" },
+			"override" : { "url" : "https://github.com/etsy/override.git",
+				"exclude-dot-files" : false, "follow-symlinks" : true, "max-file-size" : 0,
+				"index-shards" : 1 }
+	}}`)
+
+	if n := cfg.Repos["default"].IndexShards; n != 4 {
+		t.Fatalf("expected the default of 4 index shards, got %d", n)
+	}
+
+	if n := cfg.Repos["override"].IndexShards; n != 1 {
+		t.Fatalf("expected the repo's 1 index shard, got %d", n)
+	}
+
+	def := cfg.Repos["default"]
+	if !def.DotFilesExcluded() || def.SymlinksFollowed() || def.FileSizeLimit() != 100 {
+		t.Fatalf("expected the config's defaults, got %v %v %d",
+			def.DotFilesExcluded(), def.SymlinksFollowed(), def.FileSizeLimit())
+	}
+
+	over := cfg.Repos["override"]
+	if over.DotFilesExcluded() || !over.SymlinksFollowed() || over.FileSizeLimit() != 0 {
+		t.Fatalf("expected the repo's own options, got %v %v %d",
+			over.DotFilesExcluded(), over.SymlinksFollowed(), over.FileSizeLimit())
+	}
+
+	none := load(`{"repos" : { "a" : { "url" : "https://github.com/etsy/a.git" }}}`).Repos["a"]
+	if none.DotFilesExcluded() || none.SymlinksFollowed() || none.FileSizeLimit() != 10<<20 {
+		t.Fatalf("expected the built-in defaults, got %v %v %d",
+			none.DotFilesExcluded(), none.SymlinksFollowed(), none.FileSizeLimit())
+	}
+
+	// a change to a default restarts just the repos it applies to
+	next := load(`{
+		"default-exclude-dot-files" : false,
+		"default-max-file-size" : 100,
+		"default-index-shards" : 4,
+		"repos" : {
+			"default"  : { "url" : "https://github.com/etsy/default.git" },
+			"override" : { "url" : "https://github.com/etsy/override.git",
+				"exclude-dot-files" : false, "follow-symlinks" : true, "max-file-size" : 0,
+				"index-shards" : 1 }
+	}}`)
+
+	diff := cfg.Diff(next)
+	if !reflect.DeepEqual(diff.Restarted, []string{"default"}) ||
+		!reflect.DeepEqual(diff.Unchanged, []string{"override"}) {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+}
+
+// Test that url-patterns are filled in from the preset for their host and
+// that unknown hosts and placeholders are rejected.
+func TestUrlPatternHosts(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{
+		"repos" : {
+			"default" : { "url" : "https://github.com/etsy/default.git" },
+			"gitlab"  : { "url" : "https://gitlab.com/etsy/gitlab.git",
+				"url-pattern" : { "host" : "gitlab" } },
+			"custom"  : { "url" : "https://bitbucket.org/etsy/custom.git",
+				"url-pattern" : { "host" : "bitbucket", "base-url" : "{url}/browse/{path}{anchor}" } }
+	}}`), false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]config.UrlPattern{
+		"default": {
+			BaseUrl:     "{url}/blob/{rev}/{path}{anchor}",
+			Anchor:      "#L{line}",
+			RangeAnchor: "#L{line}-L{lineEnd}",
+		},
+		"gitlab": {
+			Host:        "gitlab",
+			BaseUrl:     "{url}/-/blob/{rev}/{path}{anchor}",
+			Anchor:      "#L{line}",
+			RangeAnchor: "#L{line}-{lineEnd}",
+		},
+		"custom": {
+			Host:        "bitbucket",
+			BaseUrl:     "{url}/browse/{path}{anchor}",
+			Anchor:      "#lines-{line}",
+			RangeAnchor: "#lines-{line}:{lineEnd}",
+		},
+	}
+
+	for name, pattern := range expected {
+		if got := *cfg.Repos[name].UrlPattern; got != pattern {
+			t.Fatalf("%s: expected %+v, got %+v", name, pattern, got)
+		}
+	}
+
+	for _, pattern := range []string{
+		`{ "host" : "sourceforge" }`,
+		`{ "base-url" : "{url}/{file}" }`,
+		`{ "anchor" : "#L{lineEnd}" }`,
+		`{ "range-anchor" : "#L{start}-{end}" }`,
+	} {
+		var cfg config.Config
+		err := cfg.LoadFromBytes([]byte(`{ "repos" : { "a" : {
+			"url" : "https://github.com/etsy/a.git", "url-pattern" : `+pattern+` } } }`), false)
+		if err == nil {
+			t.Fatalf("expected url-pattern %s to be invalid", pattern)
+		}
+	}
+}
+
+// Test that the rate limit settings are checked when the config is loaded.
+func TestSearchRateLimit(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{
+		"search-rate-limit" : 2.5,
+		"search-rate-allowlist" : ["10.0.0.1", "192.168.0.0/16", "::1"],
+		"trusted-proxies" : ["127.0.0.1"]
+	}`), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := cfg.SearchBurst(); n != 3 {
+		t.Fatalf("expected a burst of 3, got %d", n)
+	}
+
+	for _, s := range []string{
+		`{ "search-rate-limit" : -1 }`,
+		`{ "search-rate-limit" : 1, "search-rate-burst" : -1 }`,
+		`{ "search-rate-allowlist" : ["10.0.0.300"] }`,
+		`{ "trusted-proxies" : ["10.0.0.0/33"] }`,
+	} {
+		var cfg config.Config
+		if err := cfg.LoadFromBytes([]byte(s), false); err == nil {
+			t.Fatalf("expected %s to be invalid", s)
+		}
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{"repos": {}}`), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.MaxQueryLength != 1000 || cfg.MaxCandidateFiles != 0 {
+		t.Fatalf("expected a max-query-length of 1000 and no max-candidate-files, got %d and %d",
+			cfg.MaxQueryLength, cfg.MaxCandidateFiles)
+	}
+
+	if cfg.MinQueryLength != 3 || cfg.ShortQueries != config.ShortQueriesReject || cfg.ShortQueryMaxFiles != 1000 {
+		t.Fatalf("expected short queries under 3 characters to be rejected, got %d, %s and %d",
+			cfg.MinQueryLength, cfg.ShortQueries, cfg.ShortQueryMaxFiles)
+	}
+
+	for _, s := range []string{
+		`{ "max-query-length" : -1 }`,
+		`{ "max-candidate-files" : -1 }`,
+		`{ "min-query-length" : -1 }`,
+		`{ "short-query-max-files" : -1 }`,
+		`{ "short-queries" : "scan" }`,
+	} {
+		var cfg config.Config
+		if err := cfg.LoadFromBytes([]byte(s), false); err == nil {
+			t.Fatalf("expected %s to be invalid", s)
+		}
+	}
+}
+
+func TestIgnoreCaseDefault(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{
+		"default-ignore-case" : true,
+		"repos" : {
+			"a" : { "url" : "https://github.com/a/a.git" },
+			"b" : { "url" : "https://github.com/b/b.git", "ignore-case" : false }
+		}
+	}`), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.Repos["a"].CaseIgnored() || cfg.Repos["b"].CaseIgnored() {
+		t.Fatalf("expected a to ignore case and b not to, got %t and %t",
+			cfg.Repos["a"].CaseIgnored(), cfg.Repos["b"].CaseIgnored())
+	}
+}
+
+func TestRepoEnabled(t *testing.T) {
+	load := func(b string) *config.Config {
+		var cfg config.Config
+		if err := cfg.LoadFromBytes([]byte(b), false); err != nil {
+			t.Fatal(err)
+		}
+		return &cfg
+	}
+
+	cfg := load(`{"repos" : {"a" : { "url" : "https://github.com/a/a.git" }}}`)
+	if !cfg.Repos["a"].IsEnabled() {
+		t.Fatal("expected repos to be enabled by default")
+	}
+
+	// disabling a repo restarts it, which stops it
+	next := load(`{"repos" : {"a" : { "url" : "https://github.com/a/a.git", "enabled" : false }}}`)
+	if next.Repos["a"].IsEnabled() {
+		t.Fatal("expected a to be disabled")
+	}
+	if d := cfg.Diff(next); len(d.Restarted) != 1 {
+		t.Fatalf("expected a to be restarted, got %+v", d)
+	}
+}
+
+func TestIndexCompression(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{
+		"default-index-compression" : "fast",
+		"repos" : {
+			"a" : { "url" : "https://github.com/a/a.git" },
+			"b" : { "url" : "https://github.com/b/b.git", "index-compression" : "max" }
+		}
+	}`), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if c := cfg.Repos["a"].IndexCompression; c != config.CompressionFast {
+		t.Fatalf("expected a to use the default compression, got %s", c)
+	}
+	if c := cfg.Repos["b"].IndexCompression; c != config.CompressionMax {
+		t.Fatalf("expected b to keep its own compression, got %s", c)
+	}
+
+	for _, b := range []string{
+		`{"default-index-compression" : "zstd"}`,
+		`{"repos" : {"a" : { "url" : "https://github.com/a/a.git", "index-compression" : "on" }}}`,
+	} {
+		var cfg config.Config
+		if err := cfg.LoadFromBytes([]byte(b), false); err == nil || !strings.Contains(err.Error(), "must be off, fast or max") {
+			t.Fatalf("expected an unknown compression to be rejected, got %v", err)
+		}
+	}
+}
+
+func TestIndexWeight(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{
+		"repos" : {
+			"a" : { "url" : "https://github.com/a/a.git" },
+			"b" : { "url" : "https://github.com/b/b.git", "index-weight" : 3 }
+		}
+	}`), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := cfg.Repos["a"].IndexWeight; w != 1 {
+		t.Fatalf("expected a to weigh 1, got %d", w)
+	}
+	if w := cfg.Repos["b"].IndexWeight; w != 3 {
+		t.Fatalf("expected b to weigh 3, got %d", w)
+	}
+
+	if err := cfg.LoadFromBytes([]byte(`{
+		"repos" : { "a" : { "url" : "https://github.com/a/a.git", "index-weight" : -1 } }
+	}`), false); err == nil || !strings.Contains(err.Error(), "index-weight must be positive") {
+		t.Fatalf("expected a negative weight to be rejected, got %v", err)
+	}
+}
+
+func TestRepoAliases(t *testing.T) {
+	var cfg config.Config
+	if err := cfg.LoadFromBytes([]byte(`{
+		"repos" : {
+			"new" : { "url" : "https://github.com/a/new.git", "aliases" : ["old", "older"] }
+		}
+	}`), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if a := cfg.Repos["new"].Aliases; len(a) != 2 || a[0] != "old" || a[1] != "older" {
+		t.Fatalf("expected the aliases old and older, got %v", a)
+	}
+
+	for _, test := range []struct {
+		config string
+		err    string
+	}{
+		{`{"repos" : {
+			"a" : { "url" : "https://github.com/a/a.git", "aliases" : ["b"] },
+			"b" : { "url" : "https://github.com/b/b.git" }
+		}}`, "alias b is the name of a repo"},
+		{`{"repos" : {
+			"a" : { "url" : "https://github.com/a/a.git", "aliases" : ["old"] },
+			"b" : { "url" : "https://github.com/b/b.git", "aliases" : ["old"] }
+		}}`, "alias old is also an alias of"},
+		{`{"repos" : {
+			"a" : { "url" : "https://github.com/a/a.git", "aliases" : ["a-*"] }
+		}}`, "invalid alias"},
+	} {
+		var cfg config.Config
+		if err := cfg.LoadFromBytes([]byte(test.config), false); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("expected an error containing %q, got %v", test.err, err)
+		}
+	}
+}
+
+func TestStrictConfig(t *testing.T) {
+	for _, test := range []struct {
+		config string
+		err    string
+	}{
+		// a typo'd setting isn't ignored
+		{"{\n  \"mx-concurrent-indexers\" : 2\n}", `line 2, column 3: unknown setting "mx-concurrent-indexers"`},
+		{"{\n  \"repos\" : {\n    \"a\" : { \"url\" : \"https://github.com/a/a.git\", \"poll\" : 1 }\n  }\n}",
+			`line 3, column 51: unknown setting "poll"`},
+		{"{\n  \"repos\" : {\n    \"a\" : { \"ms-between-poll\" : \"10\" }\n  }\n}",
+			"line 3, column 37: repos.a.ms-between-poll must be a number, got string"},
+		{"{\n  \"dbpath\" : \"db\",\n}", "line 3, column 1: invalid character '}'"},
+		// the repos' settings are checked too
+		{`{"repos" : {"a" : { "ms-between-poll" : -1 }}}`, "repo a: url is required; ms-between-poll must be positive"},
+	} {
+		var cfg config.Config
+		err := cfg.LoadFromBytes([]byte(test.config), false)
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Fatalf("expected an error starting with %q, got %v", test.err, err)
+		}
+	}
+
+	// a YAML config has no location for the JSON it's turned into
+	var cfg config.Config
+	err := cfg.LoadFromBytes([]byte("mx-concurrent-indexers: 2\n"), true)
+	if err == nil || err.Error() != `unknown setting "mx-concurrent-indexers"` {
+		t.Fatalf("expected an unknown setting, got %v", err)
+	}
+}
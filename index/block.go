@@ -0,0 +1,327 @@
+package index
+
+import (
+	"strings"
+)
+
+// The languages whose blocks are delimited by braces, see EnclosingBlock.
+var braceLanguages = map[string]bool{
+	"c":          true,
+	"cpp":        true,
+	"csharp":     true,
+	"css":        true,
+	"go":         true,
+	"java":       true,
+	"javascript": true,
+	"kotlin":     true,
+	"objc":       true,
+	"perl":       true,
+	"php":        true,
+	"rust":       true,
+	"scala":      true,
+	"swift":      true,
+	"typescript": true,
+}
+
+// The words a block that only belongs to a statement, rather than to a
+// function or a class, starts with. EnclosingBlock looks past those.
+var statementWords = []string{
+	"if", "else", "for", "foreach", "while", "do", "switch", "case",
+	"default", "try", "catch", "finally", "select", "loop", "match",
+	"unless", "until", "with", "defer", "go", "return",
+}
+
+// The words that start (or are part of) the declaration of a function, class
+// or type in one of the braceLanguages, see isDeclaration.
+var declarationWords = []string{
+	"func", "function", "fn", "fun", "def", "sub", "class", "struct",
+	"interface", "enum", "union", "trait", "impl", "object", "type",
+	"namespace", "module", "protocol", "extension", "record", "package",
+}
+
+// The declarationWords that declare functions, which can also be assigned
+// to a variable, like x := func() { or var f = function() {.
+var functionWords = []string{"func", "function", "fn", "fun", "sub"}
+
+// The longest block EnclosingBlock returns, anything longer isn't much of a
+// context for a line.
+const maxBlockLines = 1000
+
+// Find the smallest function, class or type declaration around line
+// (counting from 1) of the file name, whose contents are buf. Blocks are
+// found by their braces (ignoring the ones in comments and strings) or, in
+// Python, by their indentation. A line that is only in statements, like an
+// if or a loop, gets the outermost of them, and literals, like a struct or
+// an object, are never blocks of their own. In CSS every rule is a block.
+// Returns the first and last lines of the block, or false if the language
+// isn't known or the line isn't in a block.
+func EnclosingBlock(name string, buf []byte, line int) (int, int, bool) {
+	lines := strings.Split(string(buf), "\n")
+	if line < 1 || line > len(lines) {
+		return 0, 0, false
+	}
+
+	var start, end int
+	switch lang := LanguageOf(name); {
+	case braceLanguages[lang]:
+		start, end = braceBlock(syntaxes[lang], lines, line, lang == "css")
+	case lang == "python":
+		start, end = indentBlock(lines, line)
+	}
+
+	if start == 0 || end-start+1 > maxBlockLines {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// The lines a pair of braces opens and closes on.
+type bracePair struct {
+	open, close int
+}
+
+// The braces of lines that are in code, paired up with each other. Pairs
+// are in the order they close, so inner ones come before outer ones.
+func bracePairs(syn *syntax, lines []string) []bracePair {
+	var (
+		pairs []bracePair
+		open  []int
+		st    lexState
+	)
+
+	for i, line := range lines {
+		b := []byte(line)
+		kinds := make([]lexKind, len(b))
+		st = syn.lexLine(b, st, kinds)
+
+		for j, c := range b {
+			if kinds[j] != lexCode {
+				continue
+			}
+
+			switch c {
+			case '{':
+				open = append(open, i+1)
+			case '}':
+				if len(open) > 0 {
+					pairs = append(pairs, bracePair{open[len(open)-1], i + 1})
+					open = open[:len(open)-1]
+				}
+			}
+		}
+	}
+	return pairs
+}
+
+// The smallest block around line that belongs to a declaration (or with
+// anyBlock, that isn't a statement), see EnclosingBlock.
+func braceBlock(syn *syntax, lines []string, line int, anyBlock bool) (int, int) {
+	var outer *bracePair
+	for _, p := range bracePairs(syn, lines) {
+		if p.open > line || p.close < line {
+			continue
+		}
+
+		start := headerLine(lines, p.open)
+		header := headerBefore(lines[start-1], p.open == start)
+		if isStatement(header) {
+			// pairs close inner first, so the last one seen is the
+			// outermost
+			p := p
+			outer = &p
+			continue
+		}
+
+		if anyBlock || isDeclaration(header) {
+			return start, p.close
+		}
+	}
+
+	// the line is only in statements, the outermost of them will do
+	if outer != nil {
+		return headerLine(lines, outer.open), outer.close
+	}
+	return 0, 0
+}
+
+// The line that starts what the brace on line open belongs to, which is the
+// line before when the brace is on a line of its own.
+func headerLine(lines []string, open int) int {
+	if strings.TrimSpace(lines[open-1]) != "{" {
+		return open
+	}
+
+	for i := open - 1; i >= 1; i-- {
+		if strings.TrimSpace(lines[i-1]) != "" {
+			return i
+		}
+	}
+	return open
+}
+
+// The part of the header line of a block that comes before its brace, if the
+// brace is on it.
+func headerBefore(line string, hasBrace bool) string {
+	if hasBrace {
+		if i := strings.LastIndex(line, "{"); i >= 0 {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// The words (identifiers and keywords) of line.
+func wordsOf(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+// Does the line assign to something, with an = that isn't part of a
+// comparison or an arrow?
+func hasAssignment(line string) bool {
+	for i := 0; i < len(line); i++ {
+		if line[i] != '=' {
+			continue
+		}
+		if i+1 < len(line) && (line[i+1] == '=' || line[i+1] == '>') {
+			i++
+			continue
+		}
+		if i > 0 && strings.IndexByte("=!<>", line[i-1]) >= 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// Does header, the part of a line before a block's brace, declare a
+// function, class or type? Either it has one of the declarationWords, or it
+// looks like the signature of a method, like int main(void) or foo() throws
+// E. Literals, like x := T{, f({ or key: {, don't.
+func isDeclaration(header string) bool {
+	header = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(header), "}"))
+	if header == "" || strings.ContainsAny(header[len(header)-1:], "=:,([") {
+		return false
+	}
+
+	words := wordsOf(header)
+	if hasAssignment(header) {
+		// only a function can be assigned and still be a block of its own
+		return strings.Contains(header, "=>") || hasAnyWord(words, functionWords)
+	}
+	if hasAnyWord(words, declarationWords) {
+		return true
+	}
+
+	// a signature has a name right before its parameters, and after them
+	// only words like const or throws E
+	open, close := strings.Index(header, "("), strings.LastIndex(header, ")")
+	if open < 1 || close < open {
+		return false
+	}
+	if !isWordRune(rune(header[open-1])) {
+		return false
+	}
+	return strings.TrimFunc(header[close+1:], func(r rune) bool {
+		return isWordRune(r) || strings.ContainsRune(" \t:<>,.-", r)
+	}) == ""
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+func hasAnyWord(words, any []string) bool {
+	for _, w := range words {
+		for _, a := range any {
+			if w == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Does the line start a statement, see statementWords?
+func isStatement(line string) bool {
+	word := wordsOf(strings.TrimLeft(strings.TrimSpace(line), "}"))
+	if len(word) == 0 {
+		return false
+	}
+
+	for _, w := range statementWords {
+		if word[0] == w {
+			return true
+		}
+	}
+	return false
+}
+
+// The width of the indentation of line, with tabs counting as one.
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// Python blocks start with def or class and go on for as long as the lines
+// after them are indented further. Decorators are part of the block.
+func indentBlock(lines []string, line int) (int, int) {
+	isBlank := func(i int) bool {
+		return strings.TrimSpace(lines[i-1]) == ""
+	}
+
+	// the block's header is indented less than this, which goes down with
+	// every line above that is indented less
+	limit := indentOf(lines[line-1]) + 1
+	if isBlank(line) {
+		limit = 1 << 30
+	}
+
+	for start := line; start >= 1; start-- {
+		if isBlank(start) {
+			continue
+		}
+
+		in := indentOf(lines[start-1])
+		if in >= limit {
+			continue
+		}
+		limit = in
+
+		header := strings.TrimSpace(lines[start-1])
+		if !hasAnyPrefix(header, "def ", "class ", "async def ") {
+			continue
+		}
+
+		end := start
+		for i := start + 1; i <= len(lines); i++ {
+			if isBlank(i) {
+				continue
+			}
+			if indentOf(lines[i-1]) <= in {
+				break
+			}
+			end = i
+		}
+
+		if end < line {
+			return 0, 0
+		}
+
+		for start > 1 && strings.HasPrefix(strings.TrimSpace(lines[start-2]), "@") {
+			start--
+		}
+		return start, end
+	}
+	return 0, 0
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package index
+
+import (
+	"testing"
+)
+
+func TestEnclosingBlock(t *testing.T) {
+	goSrc := `package main
+
+// Handle { this brace is in a comment
+func handle(a int) int {
+	s := "}"
+	if a > 0 {
+		return a
+	}
+	return 0
+}
+
+func other()
+{
+	go func() {
+		work()
+	}()
+}
+`
+
+	// literals aren't blocks, the declarations around them are
+	literalSrc := `package main
+
+var defaults = Config{
+	Name: "a",
+}
+
+func config() *Config {
+	return &Config{
+		Name: "b",
+		Tags: []string{
+			"c",
+		},
+	}
+}
+`
+
+	jsSrc := `const handlers = {
+  open: function(e) {
+    return {
+      id: e.id,
+    };
+  },
+};
+
+const close = (e) => {
+  send({
+    id: e.id,
+  });
+};
+`
+
+	javaSrc := `class A {
+    public void run(int n) throws IOException {
+        int[] xs = new int[] {
+            n,
+        };
+    }
+}
+`
+
+	pySrc := `import os
+
+class C:
+    @property
+    def name(self):
+        if self.x:
+            return "a"
+
+        return "b"
+
+    def other(self):
+        pass
+`
+
+	tests := []struct {
+		name       string
+		src        string
+		line       int
+		start, end int
+		ok         bool
+	}{
+		// the if is a statement, so the function is the block
+		{"a.go", goSrc, 7, 4, 10, true},
+		{"a.go", goSrc, 5, 4, 10, true},
+		{"a.go", goSrc, 4, 4, 10, true},
+
+		// braces on a line of their own belong to the line before
+		{"a.go", goSrc, 15, 12, 17, true},
+
+		// not in a block
+		{"a.go", goSrc, 1, 0, 0, false},
+
+		{"a.go", literalSrc, 4, 0, 0, false},
+		{"a.go", literalSrc, 12, 7, 14, true},
+		{"a.go", literalSrc, 9, 7, 14, true},
+		{"a.js", jsSrc, 4, 2, 6, true},
+		{"a.js", jsSrc, 11, 9, 13, true},
+		{"a.java", javaSrc, 4, 2, 6, true},
+		{"a.java", javaSrc, 1, 1, 7, true},
+
+		{"a.py", pySrc, 7, 4, 9, true},
+		{"a.py", pySrc, 5, 4, 9, true},
+		{"a.py", pySrc, 12, 11, 12, true},
+		{"a.py", pySrc, 4, 3, 12, true},
+		{"a.py", pySrc, 1, 0, 0, false},
+
+		// unknown languages have no blocks
+		{"a.txt", goSrc, 7, 0, 0, false},
+		{"a.go", goSrc, 100, 0, 0, false},
+	}
+
+	for _, test := range tests {
+		start, end, ok := EnclosingBlock(test.name, []byte(test.src), test.line)
+		if start != test.start || end != test.end || ok != test.ok {
+			t.Errorf("%s:%d: expected %d-%d %t, got %d-%d %t",
+				test.name, test.line, test.start, test.end, test.ok, start, end, ok)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package index
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	goregexp "regexp"
+)
+
+// A quote that starts and ends a string literal.
+type quote struct {
+	delim string
+
+	// Raw strings have no escapes.
+	raw bool
+
+	// Whether the string can run on past the end of its line.
+	multiline bool
+}
+
+// How comments and strings are written in a language. This is only enough to
+// tell code from comments and strings, it's not a parser.
+type syntax struct {
+	lineComments  []string
+	blockComments [][2]string
+
+	// Longer delimiters have to come before their prefixes.
+	quotes []quote
+}
+
+var (
+	cComments = [][2]string{{"/*", "*/"}}
+	cQuotes   = []quote{{`"`, false, false}, {`'`, false, false}}
+
+	cLike = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cComments,
+		quotes:        cQuotes,
+	}
+
+	jsLike = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cComments,
+		quotes:        append([]quote{{"`", false, true}}, cQuotes...),
+	}
+
+	hashLike = &syntax{
+		lineComments: []string{"#"},
+		quotes:       cQuotes,
+	}
+)
+
+// The syntax of each of the languages (see languages) that matches can be
+// told apart in, the others are never filtered.
+var syntaxes = map[string]*syntax{
+	"c":          cLike,
+	"cpp":        cLike,
+	"csharp":     cLike,
+	"java":       cLike,
+	"kotlin":     cLike,
+	"objc":       cLike,
+	"scala":      cLike,
+	"swift":      cLike,
+	"javascript": jsLike,
+	"typescript": jsLike,
+	"go": {
+		lineComments:  []string{"//"},
+		blockComments: cComments,
+		quotes:        append([]quote{{"`", true, true}}, cQuotes...),
+	},
+	// 'a is a lifetime rather than a string
+	"rust": {
+		lineComments:  []string{"//"},
+		blockComments: cComments,
+		quotes:        []quote{{`"`, false, true}},
+	},
+	"php": {
+		lineComments:  []string{"//", "#"},
+		blockComments: cComments,
+		quotes:        cQuotes,
+	},
+	"css": {
+		blockComments: cComments,
+		quotes:        cQuotes,
+	},
+	"python": {
+		lineComments: []string{"#"},
+		quotes: append([]quote{
+			{`"""`, false, true},
+			{`'''`, false, true},
+		}, cQuotes...),
+	},
+	"perl":  hashLike,
+	"ruby":  hashLike,
+	"shell": hashLike,
+	"yaml":  hashLike,
+	"sql": {
+		lineComments:  []string{"--"},
+		blockComments: cComments,
+		quotes:        []quote{{`'`, false, false}, {`"`, false, false}},
+	},
+}
+
+// What part of a line a byte is in.
+type lexKind uint8
+
+const (
+	lexCode lexKind = iota
+	lexComment
+	lexString
+)
+
+// Where the lexer is at the start of a line, i is the block comment or quote
+// it's in.
+type lexState struct {
+	kind lexKind
+	i    int
+}
+
+// Lex a line starting in state st, setting the kind of each of its bytes in
+// kinds (if it isn't nil), and return the state the next line starts in.
+func (s *syntax) lexLine(line []byte, st lexState, kinds []lexKind) lexState {
+	mark := func(from, to int, kind lexKind) {
+		if kinds == nil {
+			return
+		}
+		for ; from < to && from < len(kinds); from++ {
+			kinds[from] = kind
+		}
+	}
+
+	for i := 0; i < len(line); {
+		rest := line[i:]
+
+		switch st.kind {
+		case lexCode:
+			if s.startsLineComment(rest) {
+				mark(i, len(line), lexComment)
+				return st
+			}
+
+			if j, n := s.startsBlockComment(rest); n > 0 {
+				mark(i, i+n, lexComment)
+				st = lexState{lexComment, j}
+				i += n
+				continue
+			}
+
+			if j, n := s.startsString(rest); n > 0 {
+				mark(i, i+n, lexString)
+				st = lexState{lexString, j}
+				i += n
+				continue
+			}
+
+			mark(i, i+1, lexCode)
+			i++
+
+		case lexComment:
+			end := s.blockComments[st.i][1]
+			if bytes.HasPrefix(rest, []byte(end)) {
+				mark(i, i+len(end), lexComment)
+				st = lexState{}
+				i += len(end)
+				continue
+			}
+
+			mark(i, i+1, lexComment)
+			i++
+
+		case lexString:
+			q := s.quotes[st.i]
+			if !q.raw && rest[0] == '\\' {
+				mark(i, i+2, lexString)
+				i += 2
+				continue
+			}
+
+			if bytes.HasPrefix(rest, []byte(q.delim)) {
+				mark(i, i+len(q.delim), lexString)
+				st = lexState{}
+				i += len(q.delim)
+				continue
+			}
+
+			mark(i, i+1, lexString)
+			i++
+		}
+	}
+
+	if st.kind == lexString && !s.quotes[st.i].multiline {
+		return lexState{}
+	}
+	return st
+}
+
+func (s *syntax) startsLineComment(b []byte) bool {
+	for _, c := range s.lineComments {
+		if bytes.HasPrefix(b, []byte(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *syntax) startsBlockComment(b []byte) (int, int) {
+	for i, c := range s.blockComments {
+		if bytes.HasPrefix(b, []byte(c[0])) {
+			return i, len(c[0])
+		}
+	}
+	return 0, 0
+}
+
+func (s *syntax) startsString(b []byte) (int, int) {
+	for i, q := range s.quotes {
+		if bytes.HasPrefix(b, []byte(q.delim)) {
+			return i, len(q.delim)
+		}
+	}
+	return 0, 0
+}
+
+// Lex all of buf and return the state each of its lines starts in.
+func (s *syntax) lineStates(buf []byte) []lexState {
+	var (
+		states []lexState
+		st     lexState
+	)
+
+	for {
+		states = append(states, st)
+
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return states
+		}
+
+		st = s.lexLine(buf[:i], st, nil)
+		buf = buf[i+1:]
+	}
+}
+
+// Drops the matches that are only in comments or strings, for the files it
+// knows the syntax of.
+type codeFilter struct {
+	// the index's regexps can only tell where a match ends
+	re *goregexp.Regexp
+
+	filename string
+	syn      *syntax
+	states   []lexState
+}
+
+func newCodeFilter(expr string) *codeFilter {
+	re, err := goregexp.Compile(expr)
+	if err != nil {
+		return nil
+	}
+	return &codeFilter{re: re}
+}
+
+// Get ready to filter the matches in the raw file filename, whose name in
+// the index is name. Files in a language without a syntax aren't filtered.
+// The file is only lexed once it has a match.
+func (f *codeFilter) open(filename, name string) {
+	if f == nil {
+		return
+	}
+
+	f.filename = filename
+	f.syn = syntaxes[LanguageOf(name)]
+	f.states = nil
+}
+
+func (f *codeFilter) lex() error {
+	r, err := os.Open(f.filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	c, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	buf, err := ioutil.ReadAll(c)
+	if err != nil {
+		return err
+	}
+
+	f.states = f.syn.lineStates(buf)
+	return nil
+}
+
+// Does the matched line (counting from 1) of the open file match in code?
+// Lines that can't be told apart are kept.
+func (f *codeFilter) inCode(line []byte, lineno int) (bool, error) {
+	if f == nil || f.syn == nil {
+		return true, nil
+	}
+
+	if f.states == nil {
+		if err := f.lex(); err != nil {
+			return false, err
+		}
+	}
+
+	if lineno < 1 || lineno > len(f.states) {
+		return true, nil
+	}
+
+	locs := f.re.FindAllIndex(line, -1)
+	if locs == nil {
+		return true, nil
+	}
+
+	kinds := make([]lexKind, len(line))
+	f.syn.lexLine(line, f.states[lineno-1], kinds)
+	for _, loc := range locs {
+		if loc[0] == len(line) || kinds[loc[0]] == lexCode {
+			return true, nil
+		}
+	}
+	return false, nil
+}
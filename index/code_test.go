@@ -0,0 +1,111 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLexLine(t *testing.T) {
+	syn := syntaxes["go"]
+	line := []byte(`x := "a // b" + y // c`)
+	kinds := make([]lexKind, len(line))
+	if st := syn.lexLine(line, lexState{}, kinds); st.kind != lexCode {
+		t.Fatalf("expected the next line to start in code, got %v", st)
+	}
+
+	for i, want := range map[int]lexKind{
+		0:  lexCode,
+		7:  lexString,
+		16: lexCode,
+		19: lexComment,
+	} {
+		if kinds[i] != want {
+			t.Fatalf("expected byte %d (%c) to be %d, got %d", i, line[i], want, kinds[i])
+		}
+	}
+
+	// block comments and raw strings run on to the next line
+	if st := syn.lexLine([]byte("a /* b"), lexState{}, nil); st.kind != lexComment {
+		t.Fatalf("expected to be in a comment, got %v", st)
+	}
+	if st := syn.lexLine([]byte("a := `b"), lexState{}, nil); st.kind != lexString {
+		t.Fatalf("expected to be in a string, got %v", st)
+	}
+
+	// which have no escapes
+	if st := syn.lexLine([]byte("a := `b\\`"), lexState{}, nil); st.kind != lexCode {
+		t.Fatalf("expected to be in code, got %v", st)
+	}
+
+	// but other strings don't
+	if st := syn.lexLine([]byte(`a := "b`), lexState{}, nil); st.kind != lexCode {
+		t.Fatalf("expected to be in code, got %v", st)
+	}
+}
+
+func TestCodeOnly(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"a.go": "package a\n" +
+			"// Handler handles\n" +
+			"func Handler() {}\n" +
+			"/*\n" +
+			"Handler again\n" +
+			"*/\n" +
+			"var s = \"Handler\"\n" +
+			"var r = `\n" +
+			"Handler`\n",
+		"b.py":  "# Handler\ns = '''\nHandler\n'''\nHandler()\n",
+		"c.txt": "Handler\n",
+	})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	ref, err := Build(&IndexOptions{}, filepath.Join(dbpath, "idx"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	res, err := idx.Search("Handler", &SearchOptions{CodeOnly: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := map[string][]int{}
+	for _, fm := range res.Matches {
+		for _, m := range fm.Matches {
+			lines[fm.Filename] = append(lines[fm.Filename], m.LineNumber)
+		}
+	}
+
+	// unknown languages aren't filtered
+	if len(lines) != 3 || len(lines["a.go"]) != 1 || lines["a.go"][0] != 3 ||
+		len(lines["b.py"]) != 1 || lines["b.py"][0] != 5 || len(lines["c.txt"]) != 1 {
+		t.Fatalf("expected only the matches in code, got %v", lines)
+	}
+
+	res, err = idx.Search("Handler", &SearchOptions{CodeOnly: true, CountOnly: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.MatchCount != 3 {
+		t.Fatalf("expected 3 matches, got %d", res.MatchCount)
+	}
+}
@@ -0,0 +1,143 @@
+package index
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/etsy/hound/codesearch/index"
+	"github.com/etsy/hound/config"
+)
+
+// Is the trigram index compressed at compression c? See
+// IndexOptions.Compression.
+func isCompressed(c string) bool {
+	return c == config.CompressionFast || c == config.CompressionMax
+}
+
+// The gzip level of the stored files at compression c.
+func rawCompressionLevel(c string) int {
+	if c == config.CompressionMax {
+		return gzip.BestCompression
+	}
+	return gzip.DefaultCompression
+}
+
+// The gzip level of the trigram index at compression c.
+func triCompressionLevel(c string) int {
+	if c == config.CompressionMax {
+		return gzip.BestCompression
+	}
+	return gzip.BestSpeed
+}
+
+// The name of the file that holds filename once it's compressed.
+func compressedFilename(filename string) string {
+	return filename + ".gz"
+}
+
+// Replace filename with a gzipped copy of it, see compressedFilename.
+func compressFile(filename string, level int) error {
+	r, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	dst := compressedFilename(filename)
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	g, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(g, r); err != nil {
+		return err
+	}
+
+	if err := g.Close(); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(filename)
+}
+
+// Write the uncompressed trigram index in the gzipped file filename to w. A
+// damaged file fails its checksum, so it is never written out as an intact
+// one.
+func decompressTo(filename string, w io.Writer) error {
+	r, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	g, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	_, err = io.Copy(w, g)
+	return err
+}
+
+// Write the uncompressed trigram index in the gzipped file filename to dst.
+func decompressFile(filename, dst string) error {
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if err := decompressTo(filename, w); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// The file the trigram index of shard i is kept in.
+func (r *IndexRef) shardFile(i int) string {
+	if isCompressed(r.Compression) {
+		return compressedFilename(shardFilename(r.dir, i))
+	}
+	return shardFilename(r.dir, i)
+}
+
+// Check the trigram index of shard i and open it for searching. A
+// compressed index is decompressed into a file in the index's directory,
+// which is mapped like any other and removed right away, so it only takes
+// up the disk while the index is open.
+func (r *IndexRef) openShard(i int) (*index.Index, error) {
+	if !isCompressed(r.Compression) {
+		return index.OpenChecked(r.shardFile(i))
+	}
+
+	w, err := ioutil.TempFile(r.dir, "tri-open")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(w.Name())
+	defer w.Close()
+
+	if err := decompressTo(r.shardFile(i), w); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	// some systems won't remove a mapped file, it then goes along with
+	// the index's directory
+	return index.OpenChecked(w.Name())
+}
@@ -0,0 +1,158 @@
+package index
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etsy/hound/config"
+)
+
+func fileSize(t *testing.T, filename string) int64 {
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestCompression(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	files := map[string]string{}
+	for i := 0; i < 100; i++ {
+		files[fmt.Sprintf("f%d.go", i)] = fmt.Sprintf("package f%d // needle %d\n", i, i*i)
+	}
+	writeFiles(t, src, files)
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	off, err := Build(&IndexOptions{}, filepath.Join(dbpath, "idx-off"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off.Compression != "" {
+		t.Fatalf("expected no compression, got %s", off.Compression)
+	}
+
+	for _, c := range []string{config.CompressionFast, config.CompressionMax} {
+		dir := filepath.Join(dbpath, "idx-"+c)
+		if _, err := Build(&IndexOptions{Compression: c}, dir, src, url, "r1"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "tri")); !os.IsNotExist(err) {
+			t.Fatalf("%s: expected the trigram index to be compressed", c)
+		}
+		if a, b := fileSize(t, filepath.Join(dir, "tri.gz")), fileSize(t, filepath.Join(off.dir, "tri")); a >= b {
+			t.Fatalf("%s: expected the trigram index to shrink from %d bytes, got %d", c, b, a)
+		}
+
+		idx, err := Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if idx.Ref.Compression != c {
+			t.Fatalf("expected %s compression, got %s", c, idx.Ref.Compression)
+		}
+
+		res, err := idx.Search("needle 81$", &SearchOptions{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Matches) != 1 || res.Matches[0].Filename != "f9.go" {
+			t.Fatalf("%s: expected a match in f9.go, got %v", c, res.Matches)
+		}
+
+		if _, err := idx.Warmup(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the decompressed copy is only kept alive by its mapping
+		if open, _ := filepath.Glob(filepath.Join(dir, "tri-open*")); len(open) != 0 {
+			t.Fatalf("%s: expected the decompressed index to be removed, got %v", c, open)
+		}
+		idx.Close()
+	}
+
+	// a damaged compressed index fails its checksum
+	tri := filepath.Join(dbpath, "idx-fast", "tri.gz")
+	b, err := ioutil.ReadFile(tri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)/2] ^= 0xff
+	if err := ioutil.WriteFile(tri, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(filepath.Join(dbpath, "idx-fast")); err == nil {
+		t.Fatal("expected a damaged index to be corrupt")
+	} else if _, ok := err.(*CorruptIndexError); !ok {
+		t.Fatalf("expected a CorruptIndexError, got %v", err)
+	}
+}
+
+func TestUpdateCompressed(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"a.go": "package a // needle\n",
+		"b.go": "package b\n",
+	})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	opt := &IndexOptions{Compression: config.CompressionMax}
+	prev, err := Build(opt, filepath.Join(dbpath, "idx-prev"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, src, map[string]string{
+		"b.go": "package b // needle\n",
+	})
+
+	dir := filepath.Join(dbpath, "idx-update")
+	ref, err := Update(opt, dir, src, prev, url, "r2", []string{"b.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"tri", "tri.prev", "tri.delta"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be gone", name)
+		}
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	res, err := idx.Search("needle", &SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(res.Matches))
+	}
+}
@@ -0,0 +1,138 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The names of the encodings files are transcoded from, as IndexRef.Encodings
+// and IndexedFile.Encoding have them. Plain UTF-8 files have no name.
+const (
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "iso-8859-1"
+)
+
+var encodingNames = map[textEncoding]string{
+	encUTF8BOM: EncodingUTF8BOM,
+	encUTF16LE: EncodingUTF16LE,
+	encUTF16BE: EncodingUTF16BE,
+	encLatin1:  EncodingLatin1,
+}
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// Determines if p is text in ISO-8859-1. Every byte decodes to a character,
+// so the control characters that text doesn't use (which includes all of
+// C1) are what tells it apart from binary data.
+func validLatin1(p []byte) bool {
+	for _, b := range p {
+		switch {
+		case b == '\t' || b == '\n' || b == '\v' || b == '\f' || b == '\r' || b == 0x1b:
+		case b < 0x20 || (b >= 0x7f && b < 0xa0):
+			return false
+		}
+	}
+	return true
+}
+
+// Converts the contents of a file in encoding enc to UTF-8, without the byte
+// order mark if it has one.
+func decodeText(p []byte, enc textEncoding) []byte {
+	switch enc {
+	case encUTF8BOM:
+		return p[len(utf8BOM):]
+	case encUTF16LE:
+		return decodeUTF16(p[2:], binary.LittleEndian)
+	case encUTF16BE:
+		return decodeUTF16(p[2:], binary.BigEndian)
+	case encLatin1:
+		var buf bytes.Buffer
+		for _, c := range p {
+			buf.WriteRune(rune(c))
+		}
+		return buf.Bytes()
+	}
+	return p
+}
+
+// Writes the UTF-8 written to it in one of the encodings of IndexRef.Encodings,
+// see NewEncodingWriter.
+type encodingWriter struct {
+	w        io.Writer
+	encoding string
+	bom      bool
+
+	// the start of a rune that the last write ended with
+	partial []byte
+}
+
+// Returns a writer that writes the UTF-8 written to it (the contents of an
+// indexed file) in the encoding the file had originally, see
+// IndexedFile.Encoding. The byte order mark is written first when bom is
+// true. Plain UTF-8 is written as is.
+func NewEncodingWriter(w io.Writer, encoding string, bom bool) io.Writer {
+	if _, ok := encodingNames[encodingOf(encoding)]; !ok {
+		return w
+	}
+	return &encodingWriter{w: w, encoding: encoding, bom: bom}
+}
+
+func encodingOf(name string) textEncoding {
+	for enc, n := range encodingNames {
+		if n == name {
+			return enc
+		}
+	}
+	return encUTF8
+}
+
+func (e *encodingWriter) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+	if e.bom {
+		e.bom = false
+		switch e.encoding {
+		case EncodingUTF8BOM:
+			buf.Write(utf8BOM)
+		case EncodingUTF16LE:
+			buf.Write([]byte{0xff, 0xfe})
+		case EncodingUTF16BE:
+			buf.Write([]byte{0xfe, 0xff})
+		}
+	}
+
+	s := append(e.partial, p...)
+	for len(s) > 0 && utf8.FullRune(s) {
+		r, size := utf8.DecodeRune(s)
+		s = s[size:]
+
+		switch e.encoding {
+		case EncodingUTF16LE, EncodingUTF16BE:
+			var order binary.ByteOrder = binary.LittleEndian
+			if e.encoding == EncodingUTF16BE {
+				order = binary.BigEndian
+			}
+
+			var u [2]byte
+			for _, c := range utf16.Encode([]rune{r}) {
+				order.PutUint16(u[:], c)
+				buf.Write(u[:])
+			}
+		case EncodingLatin1:
+			// the file was decoded from ISO-8859-1, so every rune fits
+			buf.WriteByte(byte(r))
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	e.partial = append([]byte(nil), s...)
+
+	if _, err := e.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
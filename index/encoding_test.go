@@ -0,0 +1,154 @@
+package index
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEncodings(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	files := map[string][]byte{
+		"utf8.txt":    []byte("caf\xc3\xa9 needle\n"),
+		"latin1.txt":  []byte("caf\xe9 needle\n"),
+		"bom.txt":     []byte("\xef\xbb\xbfcaf\xc3\xa9 needle\n"),
+		"utf16be.txt": []byte("\xfe\xff\x00c\x00a\x00f\x00\xe9\x00 \x00n\x00e\x00e\x00d\x00l\x00e\x00\n"),
+		"broken.txt":  []byte("caf\xe9\x01 needle\n"),
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref, err := Build(&IndexOptions{}, dir, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encodings := map[string]string{
+		"latin1.txt":  EncodingLatin1,
+		"bom.txt":     EncodingUTF8BOM,
+		"utf16be.txt": EncodingUTF16BE,
+	}
+	if !reflect.DeepEqual(ref.Encodings, encodings) {
+		t.Fatalf("expected encodings %v, got %v", encodings, ref.Encodings)
+	}
+
+	excluded, err := readExcludedFilesJson(filepath.Join(ref.Dir(), excludedFileJsonFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(excluded) != 1 || excluded[0].Filename != "broken.txt" || excluded[0].Code != codeUndecodable {
+		t.Fatalf("expected broken.txt to be undecodable, got %v", excluded)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	// every file matches in UTF-8, the same as the file that was in it
+	res, err := idx.Search("café needle", &SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 4 {
+		t.Fatalf("expected 4 matches, got %d", len(res.Matches))
+	}
+	for _, fm := range res.Matches {
+		if line := fm.Matches[0].Line; line != "café needle" {
+			t.Fatalf("%s: expected the line in UTF-8, got %q", fm.Filename, line)
+		}
+	}
+
+	// the files can be written in their original encoding again
+	for name, enc := range encodings {
+		f, err := idx.OpenFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if f.Encoding != enc {
+			t.Fatalf("%s: expected encoding %s, got %s", name, enc, f.Encoding)
+		}
+
+		var buf bytes.Buffer
+		w := NewEncodingWriter(&buf, f.Encoding, true)
+
+		// a byte at a time splits the runes between writes
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range b {
+			if _, err := w.Write(b[i : i+1]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(buf.Bytes(), files[name]) {
+			t.Fatalf("%s: expected %q, got %q", name, files[name], buf.Bytes())
+		}
+	}
+}
+
+func TestUpdateEncodings(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"a.txt": "caf\xe9\n",
+		"b.txt": "caf\xe9\n",
+		"c.txt": "plain\n",
+	})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	opt := &IndexOptions{}
+	prev, err := Build(opt, filepath.Join(dbpath, "idx-prev"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, src, map[string]string{
+		"b.txt": "caf\xc3\xa9\n",
+		"c.txt": "\xef\xbb\xbfplain\n",
+	})
+
+	ref, err := Update(opt, filepath.Join(dbpath, "idx-update"), src, prev, url, "r2", []string{"b.txt", "c.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encodings := map[string]string{
+		"a.txt": EncodingLatin1,
+		"c.txt": EncodingUTF8BOM,
+	}
+	if !reflect.DeepEqual(ref.Encodings, encodings) {
+		t.Fatalf("expected encodings %v, got %v", encodings, ref.Encodings)
+	}
+}
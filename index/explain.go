@@ -0,0 +1,139 @@
+package index
+
+import (
+	goregexp "regexp"
+	"sort"
+
+	"github.com/etsy/hound/codesearch/index"
+	"github.com/etsy/hound/codesearch/regexp"
+)
+
+// Describes how the index would go about a search, without opening any of
+// the files.
+type Explanation struct {
+	// The regular expression that the files are grepped with.
+	Pattern string
+
+	// The trigram query used to find the candidate files, in codesearch
+	// notation. A "+" means the query can't narrow down the files at all
+	// (e.g. the pattern is shorter than a trigram) and "-" that nothing
+	// can match.
+	Query string
+
+	// The distinct trigrams in the query along with the number of files
+	// that contain each of them (the length of its posting list).
+	Trigrams []*TrigramPlan
+
+	// The number of files in the index and the number of those that would
+	// be opened and grepped.
+	Files      int
+	Candidates int
+}
+
+type TrigramPlan struct {
+	Trigram string
+	Files   int
+}
+
+// Compile the pattern and work out the trigram query that finds the files
+// that may match it. This is the planning step of Search. In terms mode the
+// returned regexp matches any of the terms and the returned terms must be
+// checked against each file, they are nil when every matching line is
+// enough on its own.
+func planQuery(pat string, opt *SearchOptions) (*regexp.Regexp, *index.Query, termQuery, error) {
+	if opt.Mode == ModeTerms {
+		return planTermQuery(pat, opt.IgnoreCase)
+	}
+
+	if opt.Mode == ModeLiteral {
+		pat = goregexp.QuoteMeta(pat)
+	}
+
+	re, err := regexp.Compile(GetRegexpPattern(pat, opt.IgnoreCase))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return re, index.RegexpQuery(re.Syntax), nil, nil
+}
+
+// Check that pat and the file regular expressions in opt compile, which is
+// all that can be wrong with a search before it is carried out.
+func ValidateQuery(pat string, opt *SearchOptions) error {
+	if _, _, _, err := planQuery(pat, opt); err != nil {
+		return err
+	}
+
+	for _, re := range []string{opt.FileRegexp, opt.ExcludeFileRegexp} {
+		if re == "" {
+			continue
+		}
+
+		if _, err := regexp.Compile(re); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Collect the distinct trigrams used anywhere in the query.
+func queryTrigrams(q *index.Query, seen map[string]bool) {
+	for _, t := range q.Trigram {
+		seen[t] = true
+	}
+
+	for _, sub := range q.Sub {
+		queryTrigrams(sub, seen)
+	}
+}
+
+// Explain how a search for pat would be carried out. Only the posting lists
+// are consulted, so this is cheap regardless of how many files would match.
+// Note that the candidates are counted before any of the file name or
+// language filters in the search options are applied.
+func (n *Index) Explain(pat string, opt *SearchOptions) (*Explanation, error) {
+	n.lck.RLock()
+	defer n.lck.RUnlock()
+
+	re, q, _, err := planQuery(pat, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	queryTrigrams(q, seen)
+
+	trigrams := make([]string, 0, len(seen))
+	for t := range seen {
+		trigrams = append(trigrams, t)
+	}
+	sort.Strings(trigrams)
+
+	// the counts of a sharded index are the sums over its shards
+	plans := make([]*TrigramPlan, 0, len(trigrams))
+	for _, t := range trigrams {
+		tri := uint32(t[0])<<16 | uint32(t[1])<<8 | uint32(t[2])
+		files := 0
+		for _, ix := range n.shards {
+			files += ix.PostingListLen(tri)
+		}
+		plans = append(plans, &TrigramPlan{
+			Trigram: t,
+			Files:   files,
+		})
+	}
+
+	files, candidates := 0, 0
+	for _, ix := range n.shards {
+		files += ix.NumNames()
+		candidates += len(ix.PostingQuery(q))
+	}
+
+	return &Explanation{
+		Pattern:    re.String(),
+		Query:      q.String(),
+		Trigrams:   plans,
+		Files:      files,
+		Candidates: candidates,
+	}, nil
+}
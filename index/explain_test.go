@@ -0,0 +1,53 @@
+package index
+
+import "testing"
+
+func TestExplain(t *testing.T) {
+	ref, err := buildIndex(url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Remove()
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	exp, err := idx.Explain("planQuery", &SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp.Files != ref.Files {
+		t.Fatalf("expected %d files, got %d", ref.Files, exp.Files)
+	}
+
+	if len(exp.Trigrams) == 0 {
+		t.Fatal("expected the query to use trigrams")
+	}
+
+	for _, tp := range exp.Trigrams {
+		if tp.Files < exp.Candidates {
+			t.Fatalf("expected trigram %q to be in at least %d files, got %d",
+				tp.Trigram, exp.Candidates, tp.Files)
+		}
+	}
+
+	if exp.Candidates == 0 || exp.Candidates >= exp.Files {
+		t.Fatalf("expected the query to narrow down the files, got %d of %d",
+			exp.Candidates, exp.Files)
+	}
+
+	// patterns that are too short for a trigram have to scan everything
+	exp, err = idx.Explain("ab", &SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp.Query != "+" || exp.Candidates != exp.Files {
+		t.Fatalf("expected a query matching all files, got %s with %d candidates",
+			exp.Query, exp.Candidates)
+	}
+}
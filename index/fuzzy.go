@@ -0,0 +1,205 @@
+package index
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/etsy/hound/codesearch/index"
+)
+
+// The weights used to score a fuzzy match of a file path. Every matched
+// character scores, more so when it follows the previous one or starts a
+// word, and gaps between matched characters cost.
+const (
+	fuzzyMatch       = 16.0
+	fuzzyConsecutive = 8.0
+	fuzzyBoundary    = 10.0
+	fuzzyBasename    = 4.0
+	fuzzyGapStart    = 3.0
+	fuzzyGap         = 1.0
+
+	// Ties go to the shorter path.
+	fuzzyLength = 0.01
+)
+
+// A file whose path fuzzily matched a pattern.
+type FoundFile struct {
+	// For hidden repos, the virtual repo and branch the file is in.
+	Repo   string `json:",omitempty"`
+	Branch string `json:",omitempty"`
+
+	Path  string
+	Score float64
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func isPathSeparator(c byte) bool {
+	return c == '/' || c == os.PathSeparator
+}
+
+// Does the character at i start a word in name? Words start after a path
+// separator or punctuation and at a lower to upper case change.
+func isWordStart(name string, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	p, c := name[i-1], name[i]
+	switch {
+	case isPathSeparator(p) || p == '_' || p == '-' || p == '.' || p == ' ':
+		return true
+	case 'a' <= p && p <= 'z' && 'A' <= c && c <= 'Z':
+		return true
+	}
+	return false
+}
+
+// Score how well name matches the lower case pattern pat, in which the
+// characters have to appear in order but not necessarily next to each
+// other. Returns false if name doesn't match at all.
+func fuzzyScore(pat, name string) (float64, bool) {
+	if pat == "" {
+		return 0, false
+	}
+
+	// find where the first match ends, then work back from there to where
+	// the shortest match ending there starts.
+	end, pi := -1, 0
+	for i := 0; i < len(name); i++ {
+		if lowerASCII(name[i]) == pat[pi] {
+			pi++
+			if pi == len(pat) {
+				end = i
+				break
+			}
+		}
+	}
+
+	if end < 0 {
+		return 0, false
+	}
+
+	start := end
+	for i, pi := end, len(pat)-1; i >= 0; i-- {
+		if lowerASCII(name[i]) == pat[pi] {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	base := strings.LastIndexAny(name, "/"+string(os.PathSeparator)) + 1
+
+	score, prev := 0.0, -1
+	for i, pi := start, 0; i <= end && pi < len(pat); i++ {
+		if lowerASCII(name[i]) != pat[pi] {
+			continue
+		}
+
+		score += fuzzyMatch
+		if prev >= 0 && i == prev+1 {
+			score += fuzzyConsecutive
+		} else if prev >= 0 {
+			score -= fuzzyGapStart + fuzzyGap*float64(i-prev-1)
+		}
+
+		if isWordStart(name, i) {
+			score += fuzzyBoundary
+		}
+
+		if i >= base {
+			score += fuzzyBasename
+		}
+
+		prev = i
+		pi++
+	}
+
+	return score - fuzzyLength*float64(len(name)), true
+}
+
+// Sort the found files from the best match to the worst.
+func SortFoundFiles(files []*FoundFile) {
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Score != files[j].Score {
+			return files[i].Score > files[j].Score
+		}
+		if files[i].Repo != files[j].Repo {
+			return files[i].Repo < files[j].Repo
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+// Find the (at most limit) files whose paths best match pat, which is
+// matched fuzzily rather than as a regular expression: the characters of pat
+// must appear in the path in order, but may have others between them. Only
+// the names in the index are looked at, no files are read. For hidden repos,
+// the files can be limited to those in the given (sorted) virtual repos.
+func (n *Index) Find(pat string, limit int, vrepos []string) []*FoundFile {
+	n.lck.RLock()
+	defer n.lck.RUnlock()
+
+	pat = strings.ToLower(strings.Replace(pat, " ", "", -1))
+
+	var files []*FoundFile
+	for _, ix := range n.shards {
+		files = n.findInShard(ix, pat, vrepos, files)
+	}
+
+	SortFoundFiles(files)
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files
+}
+
+// Append the files in the shard ix whose paths match pat to files.
+func (n *Index) findInShard(ix *index.Index, pat string, vrepos []string, files []*FoundFile) []*FoundFile {
+	for i, c := 0, ix.NumNames(); i < c; i++ {
+		name := ix.Name(uint32(i))
+
+		var repo, branch string
+		if n.Hidden {
+			names := strings.Split(name, string(os.PathSeparator))
+			if len(names) <= n.VRepoDepth {
+				continue
+			}
+
+			repo = n.FileRepo + "/" + names[0]
+			if len(vrepos) > 0 {
+				j := sort.SearchStrings(vrepos, repo)
+				if j >= len(vrepos) || vrepos[j] != repo {
+					continue
+				}
+			}
+
+			if n.VRepoDepth > 1 {
+				branch = names[1]
+			}
+			name = strings.Join(names[n.VRepoDepth:], string(os.PathSeparator))
+		}
+
+		score, ok := fuzzyScore(pat, name)
+		if !ok {
+			continue
+		}
+
+		files = append(files, &FoundFile{
+			Repo:   repo,
+			Branch: branch,
+			Path:   name,
+			Score:  score,
+		})
+	}
+	return files
+}
@@ -0,0 +1,75 @@
+package index
+
+import (
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("usrctrl", "docs/README.md"); ok {
+		t.Fatal("expected no match")
+	}
+
+	// best first
+	names := []string{
+		"app/controllers/users_ctrl.rb",
+		"app/controllers/user_controller.rb",
+		"lib/unusual/src/terrible.go",
+	}
+
+	var last float64
+	for i, name := range names {
+		score, ok := fuzzyScore("usrctrl", name)
+		if !ok {
+			t.Fatalf("expected %s to match", name)
+		}
+
+		if i > 0 && score >= last {
+			t.Fatalf("expected %s to score below %s (%f >= %f)", name, names[i-1], score, last)
+		}
+		last = score
+	}
+
+	// case doesn't matter in the path, and word starts count
+	a, _ := fuzzyScore("uc", "UserController.java")
+	b, _ := fuzzyScore("uc", "useless.java")
+	if a <= b {
+		t.Fatalf("expected camel case words to score higher (%f <= %f)", a, b)
+	}
+}
+
+func TestFind(t *testing.T) {
+	ref, err := buildIndex(url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Remove()
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	files := idx.Find("Idx Go", 10, nil)
+	if len(files) < 2 {
+		t.Fatalf("expected at least 2 files, got %d", len(files))
+	}
+
+	if files[0].Path != "index.go" {
+		t.Fatalf("expected index.go to be the best match, got %s", files[0].Path)
+	}
+
+	for i := 1; i < len(files); i++ {
+		if files[i].Score > files[i-1].Score {
+			t.Fatalf("expected files to be sorted by score, got %v then %v", files[i-1], files[i])
+		}
+	}
+
+	if files := idx.Find("idxgo", 1, nil); len(files) != 1 {
+		t.Fatalf("expected the files to be limited to 1, got %d", len(files))
+	}
+
+	if files := idx.Find("zzzzqqq", 10, nil); len(files) != 0 {
+		t.Fatalf("expected no files, got %d", len(files))
+	}
+}
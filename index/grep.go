@@ -13,6 +13,9 @@ var nl = []byte{'\n'}
 
 type grepper struct {
 	buf []byte
+
+	// The number of lines in the last file searched by grep2.
+	lines int
 }
 
 func countLines(b []byte) int {
@@ -45,7 +48,7 @@ func (g *grepper) grepFile(filename string, re *regexp.Regexp,
 	return g.grep(c, re, fn)
 }
 
-func (g *grepper) grep2File(filename string, re *regexp.Regexp, nctx int,
+func (g *grepper) grep2File(filename string, re *regexp.Regexp, nbefore, nafter int,
 	fn func(line []byte, lineno int, before [][]byte, after [][]byte) (bool, error)) error {
 	r, err := os.Open(filename)
 	if err != nil {
@@ -59,7 +62,7 @@ func (g *grepper) grep2File(filename string, re *regexp.Regexp, nctx int,
 	}
 	defer c.Close()
 
-	return g.grep2(c, re, nctx, fn)
+	return g.grep2(c, re, nbefore, nafter, fn)
 }
 
 func (g *grepper) fillFrom(r io.Reader) ([]byte, error) {
@@ -127,14 +130,16 @@ func firstNLines(buf []byte, n int) [][]byte {
 	return r
 }
 
-// TODO(knorton): This is still being tested. This is a grep that supports context lines. Unlike the version
+// TODO(knorton): This is still being tested. This is a grep that supports context lines (nbefore leading
+// and nafter trailing lines for each match). Unlike the version
 // in codesearch, this one does not operate on chunks. The downside is that we have to have the whole file
 // in memory to do the grep. Fortunately, we limit the size of files that get indexed anyway. 10M files tend
 // to not be source code.
 func (g *grepper) grep2(
 	r io.Reader,
 	re *regexp.Regexp,
-	nctx int,
+	nbefore,
+	nafter int,
 	fn func(line []byte, lineno int, before [][]byte, after [][]byte) (bool, error)) error {
 
 	buf, err := g.fillFrom(r)
@@ -142,6 +147,11 @@ func (g *grepper) grep2(
 		return err
 	}
 
+	g.lines = countLines(buf)
+	if len(buf) > 0 && buf[len(buf)-1] != '\n' {
+		g.lines++
+	}
+
 	lineno := 0
 	for {
 		if len(buf) == 0 {
@@ -173,8 +183,8 @@ func (g *grepper) grep2(
 		more, err := fn(
 			bytes.TrimRight(buf[str:end], "\n"),
 			lineno+1,
-			lastNLines(buf[:endl], nctx),
-			firstNLines(buf[end:], nctx))
+			lastNLines(buf[:endl], nbefore),
+			firstNLines(buf[end:], nafter))
 		if err != nil {
 			return err
 		}
@@ -247,6 +257,4 @@ func (g *grepper) grep(r io.Reader, re *regexp.Regexp, fn func(line []byte, line
 			return nil
 		}
 	}
-
-	return nil
 }
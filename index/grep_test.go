@@ -183,7 +183,7 @@ func assertGrepTest(t *testing.T, buf []byte, exp string, expects []*match) {
 
 	var g grepper
 	var m []*match
-	if err := g.grep2(bytes.NewBuffer(buf), re, 0,
+	if err := g.grep2(bytes.NewBuffer(buf), re, 0, 0,
 		func(line []byte, lineno int, before [][]byte, after [][]byte) (bool, error) {
 			m = append(m, aMatch(string(line), lineno))
 			return true, nil
@@ -228,6 +228,10 @@ func TestGrep(t *testing.T) {
 }
 
 func assertContextTest(t *testing.T, buf []byte, exp string, ctx int, expectsBefore [][]string, expectsAfter [][]string) {
+	assertAsymmetricContextTest(t, buf, exp, ctx, ctx, expectsBefore, expectsAfter)
+}
+
+func assertAsymmetricContextTest(t *testing.T, buf []byte, exp string, nbefore, nafter int, expectsBefore [][]string, expectsAfter [][]string) {
 	re, err := regexp.Compile(exp)
 	if err != nil {
 		t.Error(err)
@@ -237,7 +241,7 @@ func assertContextTest(t *testing.T, buf []byte, exp string, ctx int, expectsBef
 	var gotBefore [][][]byte
 	var gotAfter [][][]byte
 	var g grepper
-	if err := g.grep2(bytes.NewBuffer(buf), re, ctx,
+	if err := g.grep2(bytes.NewBuffer(buf), re, nbefore, nafter,
 		func(line []byte, lineno int, before [][]byte, after [][]byte) (bool, error) {
 			gotBefore = append(gotBefore, before)
 			gotAfter = append(gotAfter, after)
@@ -292,3 +296,19 @@ func TestContext(t *testing.T) {
 			[]string{"second", "third"},
 		})
 }
+
+func TestAsymmetricContext(t *testing.T) {
+	assertAsymmetricContextTest(t, subjA, "third", 1, 3,
+		[][]string{
+			[]string{"second"},
+		}, [][]string{
+			[]string{"fourth", "fifth", "sixth"},
+		})
+
+	assertAsymmetricContextTest(t, subjA, "fourth", 2, 0,
+		[][]string{
+			[]string{"second", "third"},
+		}, [][]string{
+			[]string{},
+		})
+}
@@ -1,15 +1,19 @@
 package index
 
 import (
+	"bytes"
 	"compress/gzip"
+	"encoding/binary"
 	"encoding/gob"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+	"unicode/utf16"
 	"unicode/utf8"
 	"strings"
 	"sort"
@@ -29,27 +33,193 @@ const (
 	reasonDotFile     = "Dot files are excluded."
 	reasonInvalidMode = "Invalid file mode."
 	reasonNotText     = "Not a text file."
+	reasonTooLarge    = "File is too large."
+	reasonSymlink     = "Symbolic links are excluded."
+	reasonExtension   = "Files with this extension are not included."
+	reasonUndecodable = "File could not be decoded as text."
+	reasonLFSPointer  = "Git LFS pointers are excluded."
+)
+
+// Machine readable codes for why a file was excluded, these are stable and
+// safe for clients to match on (unlike the reasons above).
+const (
+	codeBinary    = "binary"
+	codeTooLarge  = "too-large"
+	codeIgnored   = "ignored"
+	codeSymlink   = "symlink"
+	codeExtension = "extension"
+	codeUndecodable = "undecodable"
+	codeLFS       = "lfs"
+)
+
+// The encodings of text files that can be indexed. Files in any other than
+// plain UTF-8 are transcoded to UTF-8 before they are added to the index,
+// see IndexRef.Encodings.
+type textEncoding int
+
+const (
+	encBinary textEncoding = iota
+	encUTF8
+	encUTF16LE
+	encUTF16BE
+	encUTF8BOM
+	encLatin1
+
+	// Text that looks like it's in a known encoding but isn't valid in it,
+	// which is excluded like binary files are.
+	encUndecodable
+
+	// The pointer git LFS checks out in place of a file whose contents
+	// weren't fetched, which is excluded as there's nothing to search in it.
+	encLFSPointer
 )
 
 type Index struct {
 	Ref *IndexRef
-	idx *index.Index
+	shards []*index.Index
 	lck sync.RWMutex
 	Hidden bool
 	FileRepo string
+
+	// The number of leading path elements that belong to a virtual repo
+	// rather than to the file itself. Hidden repos are laid out as
+	// repo/branch/file, multi-root repos as repo/file.
+	VRepoDepth int
+
+	// The revisions of the virtual repos of a multi-root repo that are at
+	// one of their own rather than at the index's (like the branches of a
+	// repo), by virtual repo.
+	VRevs map[string]string
+
+	// The tokens to suggest, read on first use, see Suggest.
+	suggestLck  sync.Mutex
+	suggest     []*Suggestion
+	suggestRead bool
 }
 
 type IndexOptions struct {
 	ExcludeDotFiles bool
 	SpecialFiles    []string
+
+	// Directories that are skipped along with everything under them. Names
+	// match at any depth, paths containing a slash only from the top.
+	ExcludeDirs []string
+
+	// If non-empty, only these top level directories are indexed.
+	Roots []string
+
+	// If non-empty, only files with one of these extensions (with or
+	// without the leading dot, in any case) are indexed.
+	IncludeExtensions []string
+
+	// Split the trigram index into this many shards by a hash of the file
+	// paths. The shards are built in parallel and searched in parallel, 0
+	// or 1 builds a single index.
+	Shards int
+
+	// How much to compress the index on disk, one of the config.Compression
+	// constants. Empty is the same as config.CompressionOff.
+	Compression string
+
+	// When the files were last changed, in seconds since the epoch by
+	// their slash separated paths. Kept in the manifest for searches of
+	// the files modified since a time, see SearchOptions.Since. Only the
+	// files changed at or after ModTimesSince are in it, when that isn't 0.
+	ModTimes      map[string]int64
+	ModTimesSince int64
+
+	// Index what symbolic links point to rather than excluding them. Links
+	// that lead outside of the repo or loop back on themselves are still
+	// excluded.
+	FollowSymlinks bool
+
+	// Files larger than this many bytes are not indexed, 0 means there
+	// is no limit.
+	MaxFileSize int64
+
+	// How many files are read (and copied into the index, decoded and
+	// tokenized) at once while building, runtime.NumCPU() if 0. The trigram
+	// index is still written in the order the files were walked, so the
+	// index doesn't depend on it.
+	Workers int
+
+	// Count the tokens in the indexed files so that they can be suggested
+	// (see Suggest). This takes some memory once suggestions are asked for.
+	Suggest bool
+
+	// If set, called by Build after each file is indexed.
+	Progress func(BuildProgress)
+}
+
+// How far along Build is.
+type BuildProgress struct {
+	// The number of files indexed so far and their total size in bytes.
+	Files int
+	Bytes int64
 }
 
 type SearchOptions struct {
 	IgnoreCase     bool
-	LinesOfContext uint
+	LinesBefore    uint
+	LinesAfter     uint
 	FileRegexp     string
+
+	// Skip files whose names match this.
+	ExcludeFileRegexp string
+
 	Offset         int
 	Limit          int
+
+	// Only search files written in one of these languages.
+	Languages      []string
+
+	// Only count the matching files and lines, no matches are returned.
+	CountOnly      bool
+
+	// Stop collecting the matches in a file once it has this many, the
+	// file is then marked as truncated. 0 means there is no cap.
+	MaxMatchesPerFile int
+
+	// Attach blame to the matched lines. The index knows nothing about the
+	// vcs so this is left to the searcher.
+	Blame          bool
+
+	// How to order the matched files, either SortByScore or SortByPath.
+	// Empty sorts by path, though /api/v1/search defaults to SortByScore.
+	Sort           string
+
+	// How the query is interpreted, one of ModeRegex, ModeTerms or
+	// ModeLiteral. Defaults to ModeRegex.
+	Mode           string
+
+	// Trim matched lines longer than this many characters down to the
+	// part around the match, and lines of context down to their start. 0
+	// leaves lines as they are.
+	MaxLineLength  int
+
+	// Drop the matches that are only in comments or strings, in the
+	// languages whose syntax is known (see syntaxes). This is a best effort,
+	// the lines are lexed rather than parsed.
+	CodeOnly       bool
+
+	// Only search the files that were changed at or after this time (see
+	// IndexRef.ModTimes), files with unknown dates are left out. The zero
+	// time searches every file.
+	Since          time.Time
+
+	// Search case insensitively but mark each match with whether it also
+	// matches in the exact case (see Match.ExactCase), and put the files
+	// with exact matches first. IgnoreCase is ignored.
+	CaseRank       bool
+
+	// Only search the files with these names, as they are matched (see
+	// FileMatch.Filename). In hidden indexes the names start with the
+	// virtual repo of the file and a /. nil searches every file.
+	Within         map[string]bool
+
+	// Stop once this many files have been opened, and mark the response
+	// as limited. 0 opens as many as it takes.
+	MaxFilesOpened int
 }
 
 type Match struct {
@@ -57,6 +227,22 @@ type Match struct {
 	LineNumber int
 	Before     []string
 	After      []string
+	Blame      *Blame `json:",omitempty"`
+
+	// The length of Line in characters before it was trimmed, 0 if it
+	// wasn't (see MaxLineLength).
+	LineLength int `json:",omitempty"`
+
+	// Whether the line matches in the exact case too, only set by searches
+	// with CaseRank.
+	ExactCase *bool `json:",omitempty"`
+}
+
+// The commit that last changed a matched line.
+type Blame struct {
+	Sha    string
+	Author string
+	Date   time.Time
 }
 
 type SearchResponse struct {
@@ -64,20 +250,39 @@ type SearchResponse struct {
 	VMatches         map[string][]*FileMatch
 	FilesWithMatch   int
 	VFilesWithMatch  map[string]int
+	MatchCount       int `json:",omitempty"`
+	VMatchCount      map[string]int `json:"-"`
 	FilesOpened      int           `json:"-"`
 	Duration         time.Duration `json:"-"`
 	Revision         string
 	VRevision        map[string]string
+	LanguageCounts   map[string]int `json:"-"`
+
+	// The search stopped at MaxFilesOpened, so there may be more matches.
+	Limited          bool `json:",omitempty"`
 }
 
 type FileMatch struct {
 	Filename string
 	Matches  []*Match
+	Score    float64
+
+	// For hidden repos, the branch the file was found on and, when
+	// duplicates are collapsed, the other branches with the same matches.
+	Branch         string   `json:",omitempty"`
+	AlsoOnBranches []string `json:",omitempty"`
+
+	// There are more matches in the file than MaxMatchesPerFile allowed.
+	Truncated bool `json:",omitempty"`
+
+	// How many of the matches are in the exact case, see CaseRank.
+	ExactMatches int `json:",omitempty"`
 }
 
 type ExcludedFile struct {
 	Filename string
 	Reason   string
+	Code     string
 }
 
 type IndexRef struct {
@@ -85,12 +290,71 @@ type IndexRef struct {
 	Rev  string
 	Time time.Time
 	dir  string
+
+	// The number of files that were indexed and the size of the index
+	// on disk in bytes.
+	Files int
+	Size  int64
+
+	// The number of shards the trigram index is split into, 0 for indexes
+	// built before sharding (which are a single one).
+	Shards int
+
+	// How much the index is compressed, empty for indexes built without
+	// compression.
+	Compression string
+
+	// When the indexed files were last changed, see IndexOptions.ModTimes.
+	// Files that aren't in it have unknown dates, or were last changed
+	// before ModTimesSince.
+	ModTimes      map[string]int64
+	ModTimesSince int64
+
+	// How many times the index was updated with the files that changed
+	// (see Update) since it was last built from scratch.
+	Updates int
+
+	// How many of the indexed files have each extension (see ExtensionOf),
+	// nil for indexes built before they were counted.
+	Extensions map[string]int
+
+	// The original encodings of the indexed files that weren't plain UTF-8
+	// (see EncodingLatin1 and friends), by slash separated name. The index
+	// and the copies of the files have them in UTF-8.
+	Encodings map[string]string
 }
 
 func (r *IndexRef) Dir() string {
 	return r.dir
 }
 
+// An index that can't be opened because it is damaged, most likely because
+// it was only partly written. It should be removed and built again.
+type CorruptIndexError struct {
+	Dir string
+	Err error
+}
+
+func (e *CorruptIndexError) Error() string {
+	return fmt.Sprintf("corrupt index %s: %s", e.Dir, e.Err)
+}
+
+// The earliest time a search of the files changed since then can be answered
+// for, see ModTimesSince. The zero time when the dates go back all the way.
+func (r *IndexRef) DatedSince() time.Time {
+	if r.ModTimesSince == 0 {
+		return time.Time{}
+	}
+	return time.Unix(r.ModTimesSince, 0)
+}
+
+// Was the file with the given name in the index changed at or after t? Files
+// with unknown dates weren't.
+func (r *IndexRef) changedSince(name string, t time.Time) bool {
+	secs, ok := r.ModTimes[filepath.ToSlash(name)]
+	return ok && secs >= t.Unix()
+}
+
 func (r *IndexRef) writeManifest() error {
 	w, err := os.Create(filepath.Join(r.dir, manifestFilename))
 	if err != nil {
@@ -101,10 +365,32 @@ func (r *IndexRef) writeManifest() error {
 	return gob.NewEncoder(w).Encode(r)
 }
 
+// Open the index for searching, after checking that it is intact.
 func (r *IndexRef) Open() (*Index, error) {
+	if _, err := os.Stat(filepath.Join(r.dir, "raw")); err != nil {
+		return nil, &CorruptIndexError{r.dir, err}
+	}
+
+	n := 1
+	if r.Shards > 1 {
+		n = r.Shards
+	}
+
+	shards := make([]*index.Index, n)
+	for i := range shards {
+		ix, err := r.openShard(i)
+		if err != nil {
+			for _, ix := range shards[:i] {
+				ix.Close()
+			}
+			return nil, &CorruptIndexError{r.dir, err}
+		}
+		shards[i] = ix
+	}
+
 	return &Index{
-		Ref: r,
-		idx: index.Open(filepath.Join(r.dir, "tri")),
+		Ref:    r,
+		shards: shards,
 	}, nil
 }
 
@@ -115,18 +401,150 @@ func (r *IndexRef) Remove() error {
 func (n *Index) Close() error {
 	n.lck.Lock()
 	defer n.lck.Unlock()
-	return n.idx.Close()
+	return n.closeShards()
+}
+
+func (n *Index) closeShards() error {
+	var err error
+	for _, ix := range n.shards {
+		if e := ix.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
 func (n *Index) Destroy() error {
 	n.lck.Lock()
 	defer n.lck.Unlock()
-	if err := n.idx.Close(); err != nil {
+	if err := n.closeShards(); err != nil {
 		return err
 	}
 	return n.Ref.Remove()
 }
 
+// Is name a clean path relative to the root of the index that can't escape
+// it?
+func IsValidPath(name string) bool {
+	if name == "" || filepath.IsAbs(name) || filepath.Clean(name) != name {
+		return false
+	}
+
+	for _, p := range strings.Split(name, string(os.PathSeparator)) {
+		if p == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// An indexed file opened for reading with Index.OpenFile. Reads return the
+// file's contents, which are kept compressed in the index.
+type IndexedFile struct {
+	f *os.File
+	z *gzip.Reader
+
+	// The size of the file's contents.
+	Size int64
+
+	// The encoding the file was transcoded from, see IndexRef.Encodings.
+	// Empty for plain UTF-8.
+	Encoding string
+}
+
+func (f *IndexedFile) Read(b []byte) (int, error) {
+	return f.z.Read(b)
+}
+
+func (f *IndexedFile) Close() error {
+	f.z.Close()
+	return f.f.Close()
+}
+
+// Open an indexed file to read its contents at the indexed revision. Only
+// files that made it into the index have a copy, so excluded files are
+// reported as not existing. The file can still be read after the index has
+// been swapped out.
+func (n *Index) OpenFile(name string) (*IndexedFile, error) {
+	if !IsValidPath(name) {
+		return nil, fmt.Errorf("Invalid path: %s", name)
+	}
+
+	n.lck.RLock()
+	defer n.lck.RUnlock()
+
+	filename := filepath.Join(n.Ref.dir, "raw", name)
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.Mode().IsRegular() {
+		return nil, &os.PathError{Op: "read", Path: name, Err: os.ErrNotExist}
+	}
+
+	r, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// the size of the contents (mod 2^32) ends the gzip stream
+	var size int64
+	if fi.Size() >= 4 {
+		var b [4]byte
+		if _, err := r.ReadAt(b[:], fi.Size()-4); err != nil {
+			r.Close()
+			return nil, err
+		}
+		size = int64(binary.LittleEndian.Uint32(b[:]))
+	}
+
+	c, err := gzip.NewReader(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return &IndexedFile{
+		f:        r,
+		z:        c,
+		Size:     size,
+		Encoding: n.Ref.Encodings[filepath.ToSlash(name)],
+	}, nil
+}
+
+// Read the contents of an indexed file at the indexed revision, see
+// OpenFile.
+func (n *Index) ReadFile(name string) ([]byte, error) {
+	f, err := n.OpenFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ioutil.ReadAll(f)
+}
+
+// The sorted names of the indexed files that start with prefix. The names
+// come from the index itself, nothing is read from disk.
+func (n *Index) Files(prefix string) []string {
+	n.lck.RLock()
+	defer n.lck.RUnlock()
+
+	var files []string
+	p := []byte(prefix)
+	for _, ix := range n.shards {
+		for i, c := 0, ix.NumNames(); i < c; i++ {
+			if name := ix.NameBytes(uint32(i)); bytes.HasPrefix(name, p) {
+				files = append(files, string(name))
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files
+}
+
 func (n *Index) GetDir() string {
 	return n.Ref.dir
 }
@@ -135,6 +553,34 @@ func (n *Index) GetFile() string {
 	return filepath.Join(n.Ref.dir, "tri")
 }
 
+// Read through the whole trigram index (every shard of it) so that its
+// posting lists are in the page cache before the first search needs them.
+// Returns the number of bytes read.
+func (n *Index) Warmup() (int64, error) {
+	n.lck.RLock()
+	defer n.lck.RUnlock()
+
+	var total int64
+	for i := range n.shards {
+		c, err := readAll(n.Ref.shardFile(i))
+		total += c
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func readAll(filename string) (int64, error) {
+	r, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	return io.Copy(ioutil.Discard, r)
+}
+
 func toStrings(lines [][]byte) []string {
 	strs := make([]string, len(lines))
 	for i, n := 0, len(lines); i < n; i++ {
@@ -150,17 +596,162 @@ func GetRegexpPattern(pat string, ignoreCase bool) string {
 	return "(?m)" + pat
 }
 
+// What a search looks for. The compiled regexps keep state while matching,
+// so every shard searched at the same time needs a plan of its own.
+type searchPlan struct {
+	re     *regexp.Regexp
+	q      *index.Query
+	terms  termQuery
+	fre    *regexp.Regexp
+	xfre   *regexp.Regexp
+	exts   map[string]bool
+	vrepos []string
+	code   *codeFilter
+
+	// the case sensitive re of a search with CaseRank
+	exact *regexp.Regexp
+}
+
+func newSearchPlan(pat string, opt *SearchOptions, vrepos []string) (*searchPlan, error) {
+	// a case ranked search finds everything case insensitively, the exact
+	// matches are told apart as they are collected
+	var exact *regexp.Regexp
+	if opt.CaseRank {
+		o := *opt
+		o.IgnoreCase = false
+
+		var err error
+		if exact, _, _, err = planQuery(pat, &o); err != nil {
+			return nil, err
+		}
+
+		o.IgnoreCase = true
+		opt = &o
+	}
+
+	re, q, terms, err := planQuery(pat, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	var fre *regexp.Regexp
+	if opt.FileRegexp != "" {
+		fre, err = regexp.Compile(opt.FileRegexp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var xfre *regexp.Regexp
+	if opt.ExcludeFileRegexp != "" {
+		xfre, err = regexp.Compile(opt.ExcludeFileRegexp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var exts map[string]bool
+	if len(opt.Languages) > 0 {
+		exts, err = ExtensionsFor(opt.Languages)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var code *codeFilter
+	if opt.CodeOnly {
+		code = newCodeFilter(re.String())
+	}
+
+	return &searchPlan{re, q, terms, fre, xfre, exts, vrepos, code, exact}, nil
+}
+
 func (n *Index) Search(pat string, opt *SearchOptions, vrepos []string) (*SearchResponse, error) {
 	startedAt := time.Now()
 
 	n.lck.RLock()
 	defer n.lck.RUnlock()
 
-	re, err := regexp.Compile(GetRegexpPattern(pat, opt.IgnoreCase))
+	p, err := newSearchPlan(pat, opt, vrepos)
+	if err != nil {
+		return nil, err
+	}
+
+	// the best files of a ranked search can be anywhere, so the search
+	// finds the files the offset and limit cover among all of them, and
+	// only picks them out once they are ranked.
+	sopt := opt
+	if opt.ranked() {
+		o := *opt
+		o.Offset = 0
+		if opt.Limit > 0 {
+			o.Limit = opt.Offset + opt.Limit
+		}
+		sopt = &o
+	}
+
+	var res *SearchResponse
+	if len(n.shards) == 1 {
+		res, err = n.searchShard(n.shards[0], p, sopt)
+	} else {
+		res, err = n.searchShards(pat, p, sopt)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	rank(res.Matches, opt)
+	for _, vresult := range res.VMatches {
+		rank(vresult, opt)
+	}
+
+	if opt.ranked() {
+		res.Matches = pageOf(res.Matches, opt)
+		for repo, vresult := range res.VMatches {
+			if vresult = pageOf(vresult, opt); len(vresult) > 0 {
+				res.VMatches[repo] = vresult
+			} else {
+				delete(res.VMatches, repo)
+			}
+		}
+	}
+
+	if opt.MaxLineLength > 0 {
+		trimMatches(res, p.re.String(), opt.MaxLineLength)
+	}
+
+	res.Duration = time.Now().Sub(startedAt)
+	return res, nil
+}
+
+// The files of matches that opt.Offset and opt.Limit ask for.
+func pageOf(matches []*FileMatch, opt *SearchOptions) []*FileMatch {
+	if opt.Offset >= len(matches) {
+		return nil
+	}
+	matches = matches[opt.Offset:]
+
+	if opt.Limit > 0 && len(matches) > opt.Limit {
+		matches = matches[:opt.Limit]
+	}
+	return matches
+}
+
+// The name of a file in SearchOptions.Within, filerepo is the virtual
+// repo of the file in a hidden index.
+func withinName(filerepo, filename string) string {
+	if filerepo == "" {
+		return filename
+	}
+	return filerepo + "/" + filename
+}
+
+// Search a single shard of the index, the matched files are in the order
+// they are in the shard. A ranked search (see SearchOptions.ranked) looks
+// at every file, and only keeps the opt.Limit best of them.
+func (n *Index) searchShard(ix *index.Index, p *searchPlan, opt *SearchOptions) (*SearchResponse, error) {
+	re, terms, fre, xfre, exts, vrepos := p.re, p.terms, p.fre, p.xfre, p.exts, p.vrepos
+
 	var (
 		g                grepper
 		results          []*FileMatch
@@ -175,24 +766,28 @@ func (n *Index) Search(pat string, opt *SearchOptions, vrepos []string) (*Search
 	vresults        := map[string][]*FileMatch{}
 	vfilesFound     := map[string]int{}
 	vrevision       := map[string]string{}
+	vmatchCount     := map[string]int{}
+	matchCount      := 0
 
-	var fre *regexp.Regexp
-	if opt.FileRegexp != "" {
-		fre, err = regexp.Compile(opt.FileRegexp)
-		if err != nil {
-			return nil, err
-		}
-	}
+	// number of files with matches for each language
+	langCounts := map[string]int{}
 
-	files := n.idx.PostingQuery(index.RegexpQuery(re.Syntax))
+	// whether the search stopped at opt.MaxFilesOpened
+	limited := false
+
+	ranked := opt.ranked() && opt.Limit > 0
+
+	files := ix.PostingQuery(p.q)
 	for _, file := range files {
 		var (
 			matches []*Match
 			filerepo string
 			repobranch string
+			truncated bool
+			exactMatches int
 		)
 
-		name := n.idx.Name(file)
+		name := ix.Name(file)
 		hasMatch := false
 		showname := name
 
@@ -201,18 +796,43 @@ func (n *Index) Search(pat string, opt *SearchOptions, vrepos []string) (*Search
 			continue
 		}
 
+		if xfre != nil && xfre.MatchString(name, true, true) >= 0 {
+			continue
+		}
+
+		// reject files that are not in one of the languages
+		if exts != nil && !exts[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+
+		// reject files that weren't changed since then, or can't be dated
+		if !opt.Since.IsZero() && !n.Ref.changedSince(name, opt.Since) {
+			continue
+		}
+
 		/// for vrepos, it has org/repo format
 		if n.Hidden == true {
-			// name has: repo/branch/filename
+			// name has: repo/branch/filename or repo/filename
 			names := strings.Split(name, string(os.PathSeparator))
 
+			// files outside of any vrepo can't be attributed to one
+			if len(names) <= n.VRepoDepth {
+				continue
+			}
+
 			// need to use name org/repo to filerepo
 			rnames := []string{n.FileRepo, names[0]}
 			filerepo = strings.Join(rnames[:], "/")
 
 			// showname will be just filename after branch 
-			showname = filepath.Join(names[2:]...)
-			repobranch = names[1]
+			showname = filepath.Join(names[n.VRepoDepth:]...)
+			if n.VRepoDepth > 1 {
+				repobranch = names[1]
+			} else if rev, ok := n.VRevs[filerepo]; ok {
+				repobranch = rev
+			} else {
+				repobranch = n.Ref.Rev
+			}
 
 			if len(vrepos) > 0 {
 				// we can sort search as vrepos is already sorted 
@@ -227,25 +847,103 @@ func (n *Index) Search(pat string, opt *SearchOptions, vrepos []string) (*Search
 			filesFound = vfilesFound[filerepo]
 		}
 
-		if opt.Limit == 0 || (opt.Limit > 0 && filesCollected < opt.Limit) {
+		// reject files outside of the ones to search within
+		if opt.Within != nil && !opt.Within[withinName(filerepo, showname)] {
+			continue
+		}
+
+		if opt.MaxFilesOpened > 0 && filesOpened >= opt.MaxFilesOpened {
+			limited = true
+			break
+		}
+
+		// the trigrams only say a file may contain all of the terms, so
+		// that has to be checked before any of its lines are collected.
+		if terms != nil {
+			ok, err := g.matchesTerms(filepath.Join(n.Ref.dir, "raw", name), re, terms)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				filesOpened++
+				continue
+			}
+		}
 
+		p.code.open(filepath.Join(n.Ref.dir, "raw", name), name)
+
+		// in count only mode, simply stream through the file counting
+		// matched lines, nothing is collected and there is no limit.
+		if opt.CountOnly {
 			filesOpened++
 
-			if err := g.grep2File(filepath.Join(n.Ref.dir, "raw", name), re, int(opt.LinesOfContext),
+			count := 0
+			if err := g.grepFile(filepath.Join(n.Ref.dir, "raw", name), re,
+				func(line []byte, lineno int) (bool, error) {
+					if ok, err := p.code.inCode(line, lineno); !ok {
+						return err == nil, err
+					}
+					count++
+					return true, nil
+				}); err != nil {
+				return nil, err
+			}
+
+			if count == 0 {
+				continue
+			}
+
+			filesFound++
+			matchCount += count
+			if len(filerepo) > 0 {
+				vfilesFound[filerepo]++
+				vmatchCount[filerepo] += count
+				vrevision[filerepo] = repobranch
+			}
+			if lang := LanguageOf(name); lang != "" {
+				langCounts[lang]++
+			}
+			continue
+		}
+
+		if opt.Limit == 0 || ranked || filesCollected < opt.Limit {
+
+			filesOpened++
+
+			if err := g.grep2File(filepath.Join(n.Ref.dir, "raw", name), re, int(opt.LinesBefore), int(opt.LinesAfter),
 				func(line []byte, lineno int, before [][]byte, after [][]byte) (bool, error) {
 
+					if ok, err := p.code.inCode(line, lineno); !ok {
+						return err == nil, err
+					}
+
 					hasMatch = true
 					if filesFound < opt.Offset {
 						return false, nil
 					}
 
+					if opt.MaxMatchesPerFile > 0 && len(matches) >= opt.MaxMatchesPerFile {
+						truncated = true
+						return false, nil
+					}
+
 					matchesCollected++
-					matches = append(matches, &Match{
+					m := &Match{
 						Line:       string(line),
 						LineNumber: lineno,
 						Before:     toStrings(before),
 						After:      toStrings(after),
-					})
+					}
+
+					if p.exact != nil {
+						exact := p.exact.Match(line, true, true) >= 0
+						if exact {
+							exactMatches++
+						}
+						m.ExactCase = &exact
+					}
+					matches = append(matches, m)
 
 					if matchesCollected > matchLimit {
 						return false, fmt.Errorf("search exceeds limit on matches: %d", matchLimit)
@@ -261,6 +959,9 @@ func (n *Index) Search(pat string, opt *SearchOptions, vrepos []string) (*Search
 			if len(filerepo) > 0 {
 				vfilesFound[filerepo]++
 			}
+			if lang := LanguageOf(name); lang != "" {
+				langCounts[lang]++
+			}
 
 			continue
 		}
@@ -274,8 +975,12 @@ func (n *Index) Search(pat string, opt *SearchOptions, vrepos []string) (*Search
 		if len(filerepo) > 0 {
 			vfilesFound[filerepo]++
 		}
+		if lang := LanguageOf(name); lang != "" {
+			langCounts[lang]++
+		}
 
 		if len(matches) > 0 {
+			score := scoreFile(re.MatchString(showname, true, true) >= 0, len(matches), g.lines)
 
 			if len(filerepo) > 0 {
 				vfilesCollected[filerepo]++
@@ -283,52 +988,184 @@ func (n *Index) Search(pat string, opt *SearchOptions, vrepos []string) (*Search
 				vresults[filerepo] = append(vresults[filerepo], &FileMatch{
 					Filename: showname,
 					Matches: matches,
+					Score: score,
+					Branch: repobranch,
+					Truncated: truncated,
+					ExactMatches: exactMatches,
 				})
+
+				// only the best files are kept, so the matches of the
+				// others don't count against matchLimit
+				if ranked && len(vresults[filerepo]) >= 2*opt.Limit {
+					var dropped int
+					vresults[filerepo], dropped = keepBest(vresults[filerepo], opt)
+					matchesCollected -= dropped
+				}
 			} else {
 				filesCollected++
 				results = append(results, &FileMatch{
-					Filename: showname,
-					Matches:  matches,
+					Filename:     showname,
+					Matches:      matches,
+					Score:        score,
+					Truncated:    truncated,
+					ExactMatches: exactMatches,
 				})
+
+				if ranked && len(results) >= 2*opt.Limit {
+					var dropped int
+					results, dropped = keepBest(results, opt)
+					matchesCollected -= dropped
+				}
 			}
 		}
 	}
 
+	if ranked {
+		results, _ = keepBest(results, opt)
+		for repo, vresult := range vresults {
+			vresults[repo], _ = keepBest(vresult, opt)
+		}
+	}
+
 	return &SearchResponse{
 		Matches:         results,
 		VMatches:        vresults,
 		FilesWithMatch:  filesFound,
 		VFilesWithMatch: vfilesFound,
 		FilesOpened:     filesOpened,
-		Duration:        time.Now().Sub(startedAt),
 		Revision:        n.Ref.Rev,
 		VRevision:       vrevision,
+		LanguageCounts:  langCounts,
+		MatchCount:      matchCount,
+		VMatchCount:     vmatchCount,
+		Limited:         limited,
 	}, nil
 }
 
-func isTextFile(filename string) (bool, error) {
+// Peeks at the start of filename to determine how (or whether) it can be
+// indexed.
+func detectEncoding(filename string) (textEncoding, error) {
 	buf := make([]byte, filePeekSize)
 	r, err := os.Open(filename)
 	if err != nil {
-		return false, err
+		return encBinary, err
 	}
 	defer r.Close()
 
 	n, err := io.ReadFull(r, buf)
 	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
-		return false, err
+		return encBinary, err
+	}
+
+	// if we read less than filePeekSize we have the whole file, otherwise
+	// we only have a prefix.
+	if n < filePeekSize && isLFSPointer(buf[:n]) {
+		return encLFSPointer, nil
+	}
+	return sniffEncoding(buf[:n], n == filePeekSize), nil
+}
+
+// The first line of a git LFS pointer, which is never more than 1024 bytes.
+var lfsPointerVersion = []byte("version https://git-lfs.github.com/spec/v1\n")
+
+// Determines if p, the whole of a file, is a git LFS pointer.
+func isLFSPointer(p []byte) bool {
+	return len(p) <= 1024 &&
+		bytes.HasPrefix(p, lfsPointerVersion) &&
+		bytes.Contains(p, []byte("\noid sha256:"))
+}
+
+// Determines the encoding of p, which is a prefix of the file contents when
+// partial is true. Files with a byte order mark must be valid in the encoding
+// it marks, or they are undecodable. Otherwise anything containing a NUL byte
+// is considered binary, and text that isn't valid UTF-8 is taken to be in
+// ISO-8859-1 if it can be.
+func sniffEncoding(p []byte, partial bool) textEncoding {
+	if len(p) >= 2 {
+		switch {
+		case p[0] == 0xff && p[1] == 0xfe:
+			if validUTF16(p[2:], binary.LittleEndian, partial) {
+				return encUTF16LE
+			}
+			return encUndecodable
+		case p[0] == 0xfe && p[1] == 0xff:
+			if validUTF16(p[2:], binary.BigEndian, partial) {
+				return encUTF16BE
+			}
+			return encUndecodable
+		}
 	}
 
-	buf = buf[:n]
+	if bytes.IndexByte(p, 0) >= 0 {
+		return encBinary
+	}
+
+	enc := encUTF8
+	if bytes.HasPrefix(p, utf8BOM) {
+		enc = encUTF8BOM
+	}
 
-	if n < filePeekSize {
+	if partial {
+		// read a prefix, allow trailing partial runes.
+		if validUTF8IgnoringPartialTrailingRune(p) {
+			return enc
+		}
+	} else if utf8.Valid(p) {
 		// read the whole file, must be valid.
-		return utf8.Valid(buf), nil
+		return enc
 	}
 
-	// read a prefix, allow trailing partial runes.
-	return validUTF8IgnoringPartialTrailingRune(buf), nil
+	if enc == encUTF8 && validLatin1(p) {
+		return encLatin1
+	}
+
+	return encUndecodable
+}
 
+// Determines if p is valid UTF-16 text (without the byte order mark). Like
+// validUTF8IgnoringPartialTrailingRune, a partial buffer may end in the
+// middle of a code unit or surrogate pair.
+func validUTF16(p []byte, order binary.ByteOrder, partial bool) bool {
+	if len(p)%2 != 0 {
+		if !partial {
+			return false
+		}
+		p = p[:len(p)-1]
+	}
+
+	for i := 0; i < len(p); i += 2 {
+		u := order.Uint16(p[i:])
+		switch {
+		case u == 0:
+			return false
+		case u >= 0xdc00 && u <= 0xdfff:
+			// a low surrogate without a preceding high surrogate.
+			return false
+		case u >= 0xd800 && u <= 0xdbff:
+			if i+2 >= len(p) {
+				return partial
+			}
+			if l := order.Uint16(p[i+2:]); l < 0xdc00 || l > 0xdfff {
+				return false
+			}
+			i += 2
+		}
+	}
+	return true
+}
+
+// Converts UTF-16 text (without the byte order mark) to UTF-8.
+func decodeUTF16(p []byte, order binary.ByteOrder) []byte {
+	u := make([]uint16, len(p)/2)
+	for i := range u {
+		u[i] = order.Uint16(p[2*i:])
+	}
+
+	var buf bytes.Buffer
+	for _, r := range utf16.Decode(u) {
+		buf.WriteRune(r)
+	}
+	return buf.Bytes()
 }
 
 // Determines if the buffer contains valid UTF8 encoded string data. The buffer is assumed
@@ -357,36 +1194,52 @@ func validUTF8IgnoringPartialTrailingRune(p []byte) bool {
 	return true
 }
 
-func addFileToIndex(ix *index.IndexWriter, dst, src, path string) (string, error) {
+// Read the file at path, copy it into the index in dst and count its tokens
+// into tokens, if it's not nil. Returns the contents of the file in UTF-8.
+func copyFileToIndex(dst, src, path string, enc textEncoding, level int, tokens *tokenCounts) ([]byte, error) {
 	rel, err := filepath.Rel(src, path)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// open the file path to check size 
-	fi, err := os.Stat(path)
+	b, err := ioutil.ReadFile(path)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	r, err := os.Open(path)
-	if err != nil {
-		return "", err
+	// files in other encodings are stored and indexed as UTF-8 so that the
+	// index and grep can treat every file the same.
+	if enc != encUTF8 {
+		b = decodeText(b, enc)
 	}
-	defer r.Close()
 
 	dup := filepath.Join(dst, "raw", rel)
 	w, err := os.Create(dup)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer w.Close()
 
-	g := gzip.NewWriter(w)
-	defer g.Close()
+	g, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := g.Write(b); err != nil {
+		return nil, err
+	}
+	if err := g.Close(); err != nil {
+		return nil, err
+	}
+
+	if tokens != nil {
+		tw := newTokenWriter()
+		tw.Write(b)
+		tw.end()
+		tokens.addFile(tw.tokens, 1)
+	}
 
-	ix.Add(rel, io.TeeReader(r, g), fi.Size())
-    return "", nil
+	return b, nil
 }
 
 func addDirToIndex(dst, src, path string) error {
@@ -414,6 +1267,37 @@ func writeExcludedFilesJson(filename string, files []*ExcludedFile) error {
 	return json.NewEncoder(w).Encode(files)
 }
 
+// Is the directory with the given name and path relative to the top of the
+// repo one of the excluded dirs?
+func isExcludedDir(dirs []string, name, rel string) bool {
+	for _, dir := range dirs {
+		if strings.Contains(dir, "/") {
+			if filepath.ToSlash(rel) == dir {
+				return true
+			}
+		} else if name == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// Does the file with the given name have one of the extensions in exts? An
+// empty list includes every file.
+func hasIncludedExtension(exts []string, name string) bool {
+	if len(exts) == 0 {
+		return true
+	}
+
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	for _, e := range exts {
+		if strings.EqualFold(strings.TrimPrefix(e, "."), ext) {
+			return true
+		}
+	}
+	return false
+}
+
 func containsString(haystack []string, needle string) bool {
 	for i, n := 0, len(haystack); i < n; i++ {
 		if haystack[i] == needle {
@@ -423,28 +1307,151 @@ func containsString(haystack []string, needle string) bool {
 	return false
 }
 
-func indexAllFiles(opt *IndexOptions, dst, path string) error {
-	ix := index.Create(filepath.Join(dst, "tri"))
-	defer ix.Close()
+// Check whether the (non-directory) file at path is indexed, returns its
+// encoding if it is, or else why it's excluded.
+func checkFile(
+	opt *IndexOptions,
+	path,
+	rel string,
+	info os.FileInfo) (textEncoding, *ExcludedFile, error) {
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		return encBinary, &ExcludedFile{
+			rel,
+			reasonSymlink,
+			codeSymlink,
+		}, nil
+	}
+
+	if info.Mode()&os.ModeType != 0 {
+		return encBinary, &ExcludedFile{
+			rel,
+			reasonInvalidMode,
+			codeIgnored,
+		}, nil
+	}
+
+	if opt.MaxFileSize > 0 && info.Size() > opt.MaxFileSize {
+		return encBinary, &ExcludedFile{
+			rel,
+			reasonTooLarge,
+			codeTooLarge,
+		}, nil
+	}
+
+	enc, err := detectEncoding(path)
+	if err != nil {
+		return encBinary, nil, err
+	}
+
+	if enc == encBinary {
+		return encBinary, &ExcludedFile{
+			rel,
+			reasonNotText,
+			codeBinary,
+		}, nil
+	}
+
+	if enc == encUndecodable {
+		return encBinary, &ExcludedFile{
+			rel,
+			reasonUndecodable,
+			codeUndecodable,
+		}, nil
+	}
+
+	if enc == encLFSPointer {
+		return encBinary, &ExcludedFile{
+			rel,
+			reasonLFSPointer,
+			codeLFS,
+		}, nil
+	}
+
+	return enc, nil, nil
+}
+
+// A file read by readFileToIndex. Unless it's excluded (or couldn't be read),
+// data is its contents in UTF-8 and enc the encoding it was transcoded from,
+// see IndexRef.Encodings.
+type readFile struct {
+	data []byte
+	enc  string
+	ex   *ExcludedFile
+	err  error
+}
+
+// Do all of indexing the (non-directory) file at path except adding it to the
+// trigram index: check whether it's excluded, read it, copy it into the index
+// in dst and count its tokens. Unlike adding to the trigram index, this can be
+// done for many files at once.
+func readFileToIndex(
+	opt *IndexOptions,
+	dst,
+	src,
+	path,
+	rel string,
+	info os.FileInfo,
+	tokens *tokenCounts) *readFile {
+
+	enc, ex, err := checkFile(opt, path, rel, info)
+	if err != nil || ex != nil {
+		return &readFile{ex: ex, err: err}
+	}
+
+	data, err := copyFileToIndex(dst, src, path, enc, rawCompressionLevel(opt.Compression), tokens)
+	if err != nil {
+		return &readFile{err: err}
+	}
 
+	return &readFile{data: data, enc: encodingNames[enc]}
+}
+
+// Add the (non-directory) file at path to the index, returns the encoding it
+// was transcoded from (see IndexRef.Encodings) if it was added, or else why
+// it was excluded.
+func indexFile(
+	ix *index.IndexWriter,
+	opt *IndexOptions,
+	dst,
+	src,
+	path,
+	rel string,
+	info os.FileInfo,
+	tokens *tokenCounts) (string, *ExcludedFile, error) {
+
+	f := readFileToIndex(opt, dst, src, path, rel, info, tokens)
+	if f.err != nil || f.ex != nil {
+		return "", f.ex, f.err
+	}
+
+	ix.Add(rel, bytes.NewReader(f.data), int64(len(f.data)))
+	return f.enc, nil, nil
+}
+
+// Index all the files in path, returns what's known about the ones that were
+// added to the index. The tokens of the files are counted into tokens, if
+// it's not nil.
+func indexAllFiles(opt *IndexOptions, dst, path string, tokens *tokenCounts) (*indexedTally, error) {
 	excluded := []*ExcludedFile{}
 
 	// Make a file to store the excluded files for this repo
 	fileHandle, err := os.Create(filepath.Join(dst, "excluded_files.json"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer fileHandle.Close()
 
 	src, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// use top level path to indexed path (it's not required) 
-	ix.AddPaths([]string{filepath.Join(filepath.Base(filepath.Dir(dst)), filepath.Base(dst), "raw")})
+	// the files are indexed by the writers of their shards as the walk
+	// finds them
+	shards := startShardWriters(opt, dst, src, shardCount(opt), tokens)
 
-	if err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	walkErr := walkTree(src, opt.FollowSymlinks, func(path string, info os.FileInfo, err error) error {
 		// path or info could be nil when file is from local but with invalid name 
 		p := &path
 		if (p == nil || info == nil) {
@@ -452,9 +1459,18 @@ func indexAllFiles(opt *IndexOptions, dst, path string) error {
 		}
 
 		name := info.Name()
-		rel, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
+		rel, relErr := filepath.Rel(src, path)
+		if relErr != nil {
+			return relErr
+		}
+
+		if se, ok := err.(*symlinkError); ok {
+			excluded = append(excluded, &ExcludedFile{
+				rel,
+				se.reason,
+				codeSymlink,
+			})
+			return nil
 		}
 
 		// Is this file considered "special", this means it's not even a part
@@ -466,65 +1482,87 @@ func indexAllFiles(opt *IndexOptions, dst, path string) error {
 			return nil
 		}
 
-		if opt.ExcludeDotFiles && name[0] == '.' {
+		if info.IsDir() && rel != "." && isExcludedDir(opt.ExcludeDirs, name, rel) {
+			return filepath.SkipDir
+		}
+
+		// Only the listed roots are indexed, everything else at the top
+		// level is ignored entirely.
+		if len(opt.Roots) > 0 && filepath.Dir(rel) == "." && rel != "." &&
+			!containsString(opt.Roots, name) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
-
-			excluded = append(excluded, &ExcludedFile{
-				rel,
-				reasonDotFile,
-			})
 			return nil
 		}
 
-		if info.IsDir() {
-			return addDirToIndex(dst, src, path)
-		}
-
-		if info.Mode()&os.ModeType != 0 {
+		// the allowlist of extensions comes before any of the excludes
+		if !info.IsDir() && !hasIncludedExtension(opt.IncludeExtensions, name) {
 			excluded = append(excluded, &ExcludedFile{
 				rel,
-				reasonInvalidMode,
+				reasonExtension,
+				codeExtension,
 			})
 			return nil
 		}
 
-		txt, err := isTextFile(path)
-		if err != nil {
-			return err
-		}
+		if opt.ExcludeDotFiles && name[0] == '.' {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
 
-		if !txt {
 			excluded = append(excluded, &ExcludedFile{
 				rel,
-				reasonNotText,
+				reasonDotFile,
+				codeIgnored,
 			})
 			return nil
 		}
 
-		reasonForExclusion, err := addFileToIndex(ix, dst, src, path)
-		if err != nil {
-			return err
-		}
-		if reasonForExclusion != "" {
-			excluded = append(excluded, &ExcludedFile{rel, reasonForExclusion})
+		if info.IsDir() {
+			return addDirToIndex(dst, src, path)
 		}
 
-		return nil
-	}); err != nil {
-		return err
+		return shards.add(path, rel, info)
+	})
+
+	files, shardExcluded, err := shards.wait()
+	if walkErr != nil {
+		return nil, walkErr
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// list the excluded files in the order they were walked
+	excluded = append(excluded, shardExcluded...)
+	sort.SliceStable(excluded, func(i, j int) bool {
+		return index.WalkLess(excluded[i].Filename, excluded[j].Filename)
+	})
 
 	if err := writeExcludedFilesJson(
 		filepath.Join(dst, excludedFileJsonFilename),
 		excluded); err != nil {
-		return err
+		return nil, err
 	}
 
-	ix.Flush()
+	return files, nil
+}
 
-	return nil
+// The total size in bytes of all the files under dir.
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
 }
 
 // Read the metadata for the index directory. Note that even if this
@@ -560,17 +1598,56 @@ func Build(opt *IndexOptions, dst, src, url, rev string) (*IndexRef, error) {
 		return nil, err
 	}
 
-	if err := indexAllFiles(opt, dst, src); err != nil {
+	var tokens *tokenCounts
+	if opt.Suggest {
+		tokens = newTokenCounts()
+	}
+
+	files, err := indexAllFiles(opt, dst, src, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokens != nil {
+		if err := tokens.write(dst); err != nil {
+			return nil, err
+		}
+	}
+
+	if isCompressed(opt.Compression) {
+		for i := 0; i < shardCount(opt); i++ {
+			if err := compressFile(shardFilename(dst, i), triCompressionLevel(opt.Compression)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	size, err := DirSize(dst)
+	if err != nil {
 		return nil, err
 	}
 
 	r := &IndexRef{
-		Url:  url,
-		Rev:  rev,
-		Time: time.Now(),
-		dir:  dst,
+		Url:   url,
+		Rev:   rev,
+		Time:  time.Now(),
+		dir:   dst,
+		Files: files.count,
+		Size:  size,
+
+		Extensions: files.extensions,
+		Encodings:  files.encodings,
+	}
+
+	if n := shardCount(opt); n > 1 {
+		r.Shards = n
 	}
 
+	if isCompressed(opt.Compression) {
+		r.Compression = opt.Compression
+	}
+	r.ModTimes, r.ModTimesSince = opt.ModTimes, opt.ModTimesSince
+
 	if err := r.writeManifest(); err != nil {
 		return nil, err
 	}
@@ -578,11 +1655,12 @@ func Build(opt *IndexOptions, dst, src, url, rev string) (*IndexRef, error) {
 	return r, nil
 }
 
-// Open the index in dir for searching.
+// Open the index in dir for searching. The manifest is written last, so an
+// index without a readable one is treated as corrupt.
 func Open(dir string) (*Index, error) {
 	r, err := Read(dir)
 	if err != nil {
-		return nil, err
+		return nil, &CorruptIndexError{dir, err}
 	}
 
 	return r.Open()
@@ -1,11 +1,17 @@
 package index
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -54,7 +60,7 @@ func TestSearch(t *testing.T) {
 	defer idx.Close()
 
 	// Make sure we can carry out a search
-	if _, err := idx.Search("5a1c0dac2d9b3ea4085b30dd14375c18eab993d5", &SearchOptions{}); err != nil {
+	if _, err := idx.Search("5a1c0dac2d9b3ea4085b30dd14375c18eab993d5", &SearchOptions{}, nil); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -94,9 +100,953 @@ func TestRead(t *testing.T) {
 		t.Fatalf("expected rev of %s, got %s", rev, r.Rev)
 	}
 
+	if r.Files == 0 || r.Files != ref.Files {
+		t.Fatalf("expected file count of %d, got %d", ref.Files, r.Files)
+	}
+
+	if r.Size == 0 || r.Size != ref.Size {
+		t.Fatalf("expected size of %d, got %d", ref.Size, r.Size)
+	}
+
 	idx, err := r.Open()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer idx.Close()
 }
+
+func TestSearchLanguages(t *testing.T) {
+	ref, err := buildIndex(url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Remove()
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	res, err := idx.Search("package index", &SearchOptions{
+		Languages: []string{"go"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Matches) == 0 {
+		t.Fatal("expected matches in go files")
+	}
+
+	for _, m := range res.Matches {
+		if filepath.Ext(m.Filename) != ".go" {
+			t.Fatalf("expected only go files, got %s", m.Filename)
+		}
+	}
+
+	if res.LanguageCounts["go"] != res.FilesWithMatch {
+		t.Fatalf("expected %d go files to be counted, got %d",
+			res.FilesWithMatch, res.LanguageCounts["go"])
+	}
+
+	if _, err := idx.Search("package index", &SearchOptions{
+		Languages: []string{"klingon"},
+	}, nil); err == nil {
+		t.Fatal("expected unknown language to fail")
+	}
+}
+
+func TestSearchCountOnly(t *testing.T) {
+	ref, err := buildIndex(url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Remove()
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	full, err := idx.Search("package index", &SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := idx.Search("package index", &SearchOptions{
+		CountOnly: true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Matches) != 0 {
+		t.Fatalf("expected no matches in count only mode, got %d", len(res.Matches))
+	}
+
+	if res.FilesWithMatch != full.FilesWithMatch {
+		t.Fatalf("expected %d files with match, got %d", full.FilesWithMatch, res.FilesWithMatch)
+	}
+
+	lines := 0
+	for _, fm := range full.Matches {
+		lines += len(fm.Matches)
+	}
+
+	if res.MatchCount != lines {
+		t.Fatalf("expected match count of %d, got %d", lines, res.MatchCount)
+	}
+}
+
+func TestSearchMaxMatchesPerFile(t *testing.T) {
+	ref, err := buildIndex(url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Remove()
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	full, err := idx.Search("t.Fatal", &SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := idx.Search("t.Fatal", &SearchOptions{
+		MaxMatchesPerFile: 2,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Matches) != len(full.Matches) {
+		t.Fatalf("expected %d files, got %d", len(full.Matches), len(res.Matches))
+	}
+
+	counts := map[string]int{}
+	for _, fm := range full.Matches {
+		counts[fm.Filename] = len(fm.Matches)
+	}
+
+	truncated := 0
+	for _, fm := range res.Matches {
+		if len(fm.Matches) > 2 {
+			t.Fatalf("%s: expected at most 2 matches, got %d", fm.Filename, len(fm.Matches))
+		}
+
+		if fm.Truncated != (counts[fm.Filename] > 2) {
+			t.Fatalf("%s: unexpected truncated %t for %d matches",
+				fm.Filename, fm.Truncated, counts[fm.Filename])
+		}
+
+		if fm.Truncated {
+			truncated++
+		}
+	}
+
+	if truncated == 0 {
+		t.Fatal("expected some files to be truncated")
+	}
+}
+
+func TestMaxFileSize(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt := IndexOptions{
+		MaxFileSize: 1024,
+	}
+
+	ref, err := Build(&opt, dir, thisDir(), url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(ref.Dir(), excludedFileJsonFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var excluded []*ExcludedFile
+	if err := json.Unmarshal(b, &excluded); err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, e := range excluded {
+		if e.Filename == "index.go" {
+			found = e.Reason == reasonTooLarge && e.Code == codeTooLarge
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected index.go to be excluded as too large, got %s", b)
+	}
+}
+
+func TestSniffEncoding(t *testing.T) {
+	tests := []struct {
+		data    []byte
+		partial bool
+		enc     textEncoding
+	}{
+		{[]byte("plain ascii\n"), false, encUTF8},
+		{[]byte("caf\xc3\xa9\n"), false, encUTF8},
+		{[]byte("caf\xc3"), false, encLatin1},
+		{[]byte("caf\xc3"), true, encUTF8},
+		{[]byte("caf\xe9\r\n"), false, encLatin1},
+		{[]byte("caf\xe9\x01"), false, encUndecodable},
+		{[]byte("caf\x85"), false, encUndecodable},
+		{[]byte("\xef\xbb\xbfcaf\xc3\xa9"), false, encUTF8BOM},
+		{[]byte("\xef\xbb\xbfcaf\xe9"), false, encUndecodable},
+		{[]byte("text\x00more"), false, encBinary},
+		{[]byte("caf\xe9\x00"), false, encBinary},
+		{[]byte("\xff\xfeh\x00i\x00"), false, encUTF16LE},
+		{[]byte("\xfe\xff\x00h\x00i"), false, encUTF16BE},
+		{[]byte("\xff\xfeh\x00i"), false, encUndecodable},
+		{[]byte("\xff\xfeh\x00i"), true, encUTF16LE},
+		{[]byte("\xff\xfe\x00\xdch\x00"), false, encUndecodable},
+		{[]byte("\xff\xfe=\xd8"), true, encUTF16LE},
+		{[]byte("\xff\xfe=\xd8"), false, encUndecodable},
+		{[]byte("\xff\xfe\x00\x00"), false, encUndecodable},
+	}
+
+	for i, test := range tests {
+		if enc := sniffEncoding(test.data, test.partial); enc != test.enc {
+			t.Errorf("case %d: expected encoding %d, got %d", i, test.enc, enc)
+		}
+	}
+}
+
+func TestExcludedReasons(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	files := map[string][]byte{
+		"utf8.txt":  []byte("needle in utf-8\n"),
+		"utf16.txt": []byte("\xff\xfen\x00e\x00e\x00d\x00l\x00e\x00\n\x00"),
+		"image.bin": []byte("needle\x00\x01\x02"),
+		".hidden":   []byte("needle\n"),
+		"big.txt":   []byte(strings.Repeat("needle\n", 100)),
+		"model.bin": []byte("version https://git-lfs.github.com/spec/v1\noid sha256:4d7a2146\nsize 12345\n"),
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink("utf8.txt", filepath.Join(src, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt := IndexOptions{
+		ExcludeDotFiles: true,
+		MaxFileSize:     512,
+	}
+
+	ref, err := Build(&opt, dir, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(ref.Dir(), excludedFileJsonFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var excluded []*ExcludedFile
+	if err := json.Unmarshal(b, &excluded); err != nil {
+		t.Fatal(err)
+	}
+
+	codes := map[string]string{}
+	for _, e := range excluded {
+		codes[e.Filename] = e.Code
+	}
+
+	expected := map[string]string{
+		"image.bin": codeBinary,
+		".hidden":   codeIgnored,
+		"big.txt":   codeTooLarge,
+		"link.txt":  codeSymlink,
+		"model.bin": codeLFS,
+	}
+	if !reflect.DeepEqual(codes, expected) {
+		t.Fatalf("expected exclusions %v, got %v", expected, codes)
+	}
+
+	if ref.Files != 2 {
+		t.Fatalf("expected 2 files to be indexed, got %d", ref.Files)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	res, err := idx.Search("needle", &SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for _, fm := range res.Matches {
+		found[fm.Filename] = true
+	}
+
+	if !found["utf8.txt"] || !found["utf16.txt"] || len(found) != 2 {
+		t.Fatalf("expected matches in utf8.txt and utf16.txt, got %v", found)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	ref, err := buildIndex(url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Remove()
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	b, err := idx.ReadFile("index.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := ioutil.ReadFile(filepath.Join(thisDir(), "index.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != string(src) {
+		t.Fatal("expected the indexed copy of index.go to match the source")
+	}
+
+	f, err := idx.OpenFile("index.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.Size != int64(len(src)) {
+		t.Fatalf("expected a size of %d, got %d", len(src), f.Size)
+	}
+
+	if _, err := idx.ReadFile("nope.go"); !os.IsNotExist(err) {
+		t.Fatalf("expected a missing file to not exist, got %v", err)
+	}
+
+	for _, name := range []string{"../index.go", "/etc/passwd", "./index.go", ""} {
+		if _, err := idx.ReadFile(name); err == nil {
+			t.Fatalf("expected invalid path %q to fail", name)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	ref, err := buildIndex(url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Remove()
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	files := idx.Files("")
+	if len(files) != ref.Files {
+		t.Fatalf("expected %d files, got %d", ref.Files, len(files))
+	}
+
+	if !sort.StringsAreSorted(files) {
+		t.Fatalf("expected files to be sorted, got %v", files)
+	}
+
+	files = idx.Files("index")
+	if len(files) != 2 || files[0] != "index.go" || files[1] != "index_test.go" {
+		t.Fatalf("expected index.go and index_test.go, got %v", files)
+	}
+}
+
+func TestSubmoduleDirsAreIndexed(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	// a checked out submodule has a .git file rather than a directory
+	if err := os.Mkdir(filepath.Join(src, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"a.go":       "package a\n",
+		"lib/.git":   "gitdir: ../.git/modules/lib\n",
+		"lib/lib.go": "package lib\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt := IndexOptions{
+		SpecialFiles: []string{".git"},
+	}
+
+	ref, err := Build(&opt, dir, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	if got := strings.Join(idx.Files(""), ","); got != "a.go,lib/lib.go" {
+		t.Fatalf("expected a.go and lib/lib.go, got %s", got)
+	}
+}
+
+func TestExcludeDirs(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	for _, name := range []string{
+		"main.go",
+		"node_modules/dep/index.js",
+		"web/node_modules/dep/index.js",
+		"third_party/big/huge.c",
+		"third_party/small/tiny.c",
+		"lib/third_party/big/kept.c",
+		"docs/node_modules",
+	} {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("needle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt := IndexOptions{
+		ExcludeDirs: []string{"node_modules", "third_party/big"},
+	}
+
+	ref, err := Build(&opt, dir, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	files := idx.Files("")
+	expected := []string{
+		"docs/node_modules",
+		"lib/third_party/big/kept.c",
+		"main.go",
+		"third_party/small/tiny.c",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+}
+
+func TestIncludeExtensions(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"main.go":               "needle\n",
+		"lib/util.PY":           "needle\n",
+		"lib/image.png":         "needle\n",
+		"Makefile":              "needle\n",
+		".hidden.go":            "needle\n",
+		"node_modules/dep/x.go": "needle\n",
+	})
+
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt := IndexOptions{
+		IncludeExtensions: []string{"go", ".py"},
+		ExcludeDirs:       []string{"node_modules"},
+		ExcludeDotFiles:   true,
+	}
+
+	ref, err := Build(&opt, dir, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	files := idx.Files("")
+	expected := []string{"lib/util.PY", "main.go"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+
+	excluded, err := readExcludedFilesJson(filepath.Join(ref.Dir(), excludedFileJsonFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := map[string]string{}
+	for _, e := range excluded {
+		codes[filepath.ToSlash(e.Filename)] = e.Code
+	}
+
+	// the excludes still apply to the files that are included
+	expectedCodes := map[string]string{
+		".hidden.go":    codeIgnored,
+		"Makefile":      codeExtension,
+		"lib/image.png": codeExtension,
+	}
+	if !reflect.DeepEqual(codes, expectedCodes) {
+		t.Fatalf("expected exclusions %v, got %v", expectedCodes, codes)
+	}
+}
+
+func TestSearchSince(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"old.go":     "needle\n",
+		"new.go":     "needle\n",
+		"unknown.go": "needle\n",
+	})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	opt := &IndexOptions{
+		ModTimes: map[string]int64{
+			"old.go": at.Add(-time.Second).Unix(),
+			"new.go": at.Unix(),
+		},
+		ModTimesSince: at.Add(-time.Hour).Unix(),
+	}
+	if _, err := Build(opt, filepath.Join(dbpath, "idx"), src, url, "r1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the dates are kept in the manifest
+	idx, err := Open(filepath.Join(dbpath, "idx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	if from := idx.Ref.DatedSince(); !from.Equal(at.Add(-time.Hour)) {
+		t.Fatalf("expected the dates to go back to %s, got %s", at.Add(-time.Hour), from)
+	}
+
+	for _, test := range []struct {
+		since time.Time
+		files int
+	}{
+		{time.Time{}, 3},
+		{at.Add(-time.Hour), 2},
+		{at, 1},
+		{at.Add(time.Second), 0},
+	} {
+		res, err := idx.Search("needle", &SearchOptions{Since: test.since}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Matches) != test.files {
+			t.Fatalf("since %s: expected %d files, got %d", test.since, test.files, len(res.Matches))
+		}
+	}
+}
+
+func TestSearchWithin(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"a/x.go": "needle haystack\n",
+		"a/y.go": "needle\n",
+		"b/x.go": "needle haystack\n",
+	})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	ref, err := Build(&IndexOptions{}, filepath.Join(dbpath, "idx"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	res, err := idx.Search("haystack", &SearchOptions{
+		Within: map[string]bool{"a/x.go": true, "a/y.go": true},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 1 || res.Matches[0].Filename != "a/x.go" {
+		t.Fatalf("expected a match in a/x.go only, got %v", res.Matches)
+	}
+
+	// an empty set searches nothing
+	res, err = idx.Search("needle", &SearchOptions{Within: map[string]bool{}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 0 {
+		t.Fatalf("expected no matches, got %d", len(res.Matches))
+	}
+
+	// in a hidden index the names start with the virtual repo
+	idx.Hidden, idx.FileRepo, idx.VRepoDepth = true, "org", 1
+	res, err = idx.Search("haystack", &SearchOptions{
+		Within: map[string]bool{"org/b/x.go": true, "org/a/y.go": true},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.VMatches) != 1 || len(res.VMatches["org/b"]) != 1 || res.VMatches["org/b"][0].Filename != "x.go" {
+		t.Fatalf("expected a match in x.go of org/b only, got %v", res.VMatches)
+	}
+}
+
+func TestSearchMaxFilesOpened(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	files := map[string]string{}
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("f%02d.go", i)] = "x\n"
+	}
+	writeFiles(t, src, files)
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	for _, shards := range []int{1, 4} {
+		ref, err := Build(&IndexOptions{Shards: shards}, filepath.Join(dbpath, fmt.Sprintf("idx-%d", shards)), src, url, "r1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		idx, err := ref.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := idx.Search("x", &SearchOptions{MaxFilesOpened: 8}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the shards split the files between them, so they may open fewer
+		if res.FilesOpened == 0 || res.FilesOpened > 8 || (shards == 1 && res.FilesOpened != 8) ||
+			len(res.Matches) != res.FilesOpened || !res.Limited {
+			t.Fatalf("%d shards: expected up to 8 files to be opened and the search to be limited, got %d, %d and %t",
+				shards, res.FilesOpened, len(res.Matches), res.Limited)
+		}
+
+		// a search that opens every file before the limit isn't limited
+		res, err = idx.Search("x", &SearchOptions{MaxFilesOpened: 20}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Matches) != 20 || res.Limited {
+			t.Fatalf("%d shards: expected all 20 files, got %d and %t", shards, len(res.Matches), res.Limited)
+		}
+		idx.Close()
+	}
+}
+
+func TestSearchCaseRank(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"a.go": "var foo = 1\n",
+		"b.go": "type Foo struct{}\nvar foo Foo\n",
+		"c.go": "const FOO = 2\n",
+	})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	ref, err := Build(&IndexOptions{}, filepath.Join(dbpath, "idx"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	res, err := idx.Search("Foo", &SearchOptions{CaseRank: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, fm := range res.Matches {
+		got = append(got, fmt.Sprintf("%s:%d", fm.Filename, fm.ExactMatches))
+		for _, m := range fm.Matches {
+			exact := strings.Contains(m.Line, "Foo")
+			if m.ExactCase == nil || *m.ExactCase != exact {
+				t.Fatalf("%s:%d: expected the match to be exact %t", fm.Filename, m.LineNumber, exact)
+			}
+		}
+	}
+
+	// the file with exact matches comes first, the others in path order
+	if strings.Join(got, " ") != "b.go:2 a.go:0 c.go:0" {
+		t.Fatalf("unexpected files %v", got)
+	}
+
+	// the files with exact matches come first whatever the limit
+	res, err = idx.Search("Foo", &SearchOptions{CaseRank: true, Sort: SortByPath, Limit: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 1 || res.Matches[0].Filename != "b.go" {
+		t.Fatalf("expected only b.go, got %v", res.Matches)
+	}
+
+	res, err = idx.Search("Foo", &SearchOptions{CaseRank: true, Offset: 1, Limit: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 1 || res.Matches[0].Filename != "a.go" {
+		t.Fatalf("expected only a.go, got %v", res.Matches)
+	}
+
+	// matches aren't marked without it
+	res, err = idx.Search("Foo", &SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 1 || res.Matches[0].Matches[0].ExactCase != nil {
+		t.Fatalf("expected only b.go to match unmarked, got %v", res.Matches)
+	}
+}
+
+func TestSearchScoreLimit(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	// the files score the same, except for z.go which comes last
+	files := map[string]string{
+		"z.go": strings.Repeat("needle\n", 5),
+	}
+	for i := 0; i < 10; i++ {
+		files[fmt.Sprintf("f%d.go", i)] = "needle\n" + strings.Repeat("hay\n", 9)
+	}
+	writeFiles(t, src, files)
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	ref, err := Build(&IndexOptions{}, filepath.Join(dbpath, "idx"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	for _, test := range []struct {
+		opt  SearchOptions
+		want string
+	}{
+		{SearchOptions{Sort: SortByScore, Limit: 2}, "z.go f0.go"},
+		{SearchOptions{Sort: SortByScore, Offset: 1, Limit: 2}, "f0.go f1.go"},
+		{SearchOptions{Sort: SortByScore, Offset: 9, Limit: 5}, "f8.go f9.go"},
+		{SearchOptions{Sort: SortByPath, Limit: 2}, "f0.go f1.go"},
+	} {
+		opt := test.opt
+		res, err := idx.Search("needle", &opt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, fm := range res.Matches {
+			got = append(got, fm.Filename)
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Fatalf("%+v: expected %s, got %v", test.opt, test.want, got)
+		}
+
+		if res.FilesWithMatch != 11 {
+			t.Fatalf("%+v: expected 11 files with matches, got %d", test.opt, res.FilesWithMatch)
+		}
+	}
+}
+
+func TestSearchLiteral(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"call.go":  "x := a.b(c)\n",
+		"regex.go": "x := aXbc\n",
+		"upper.go": "X := A.B(C) + [1]*\n",
+		"long.go":  strings.Repeat("x", 300) + " a.b(c) " + strings.Repeat("y", 300) + "\n",
+	})
+
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref, err := Build(&IndexOptions{}, dir, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	for _, test := range []struct {
+		q     string
+		opt   SearchOptions
+		files []string
+	}{
+		{"a.b(c)", SearchOptions{}, []string{"regex.go"}},
+		{"a.b(c)", SearchOptions{Mode: ModeLiteral}, []string{"call.go", "long.go"}},
+		{"a.b(c)", SearchOptions{Mode: ModeLiteral, IgnoreCase: true}, []string{"call.go", "long.go", "upper.go"}},
+		{"[1]*", SearchOptions{Mode: ModeLiteral}, []string{"upper.go"}},
+	} {
+		res, err := idx.Search(test.q, &test.opt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var found []string
+		for _, fm := range res.Matches {
+			found = append(found, fm.Filename)
+		}
+		sort.Strings(found)
+
+		if !reflect.DeepEqual(found, test.files) {
+			t.Fatalf("%q in mode %q: expected %v, got %v", test.q, test.opt.Mode, test.files, found)
+		}
+	}
+
+	// a trimmed line is trimmed around the literal match
+	res, err := idx.Search("a.b(c)", &SearchOptions{Mode: ModeLiteral, MaxLineLength: 40, FileRegexp: "long"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 1 || !strings.Contains(res.Matches[0].Matches[0].Line, " a.b(c) ") {
+		t.Fatalf("expected the line to be trimmed around the match, got %v", res.Matches)
+	}
+}
@@ -0,0 +1,77 @@
+package index
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Maps the name of a language to the file extensions that hold it. To teach
+// hound about a new language, simply add it here.
+var languages = map[string][]string{
+	"c":          {".c", ".h"},
+	"cpp":        {".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx"},
+	"csharp":     {".cs"},
+	"css":        {".css", ".less", ".scss", ".sass"},
+	"go":         {".go"},
+	"html":       {".htm", ".html"},
+	"java":       {".java"},
+	"javascript": {".js", ".jsx", ".mjs"},
+	"json":       {".json"},
+	"kotlin":     {".kt", ".kts"},
+	"markdown":   {".md", ".markdown"},
+	"objc":       {".m", ".mm"},
+	"perl":       {".pl", ".pm"},
+	"php":        {".php"},
+	"proto":      {".proto"},
+	"python":     {".py"},
+	"ruby":       {".rb"},
+	"rust":       {".rs"},
+	"scala":      {".scala"},
+	"shell":      {".sh", ".bash", ".zsh"},
+	"sql":        {".sql"},
+	"swift":      {".swift"},
+	"typescript": {".ts", ".tsx"},
+	"xml":        {".xml"},
+	"yaml":       {".yaml", ".yml"},
+}
+
+// The reverse of languages, built on init.
+var extToLanguage = map[string]string{}
+
+func init() {
+	for lang, exts := range languages {
+		for _, ext := range exts {
+			extToLanguage[ext] = lang
+		}
+	}
+}
+
+// Get the set of file extensions covered by the given languages. An error is
+// returned for any language we don't know about.
+func ExtensionsFor(langs []string) (map[string]bool, error) {
+	exts := map[string]bool{}
+	for _, lang := range langs {
+		e, ok := languages[strings.ToLower(lang)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown language: %s", lang)
+		}
+
+		for _, ext := range e {
+			exts[ext] = true
+		}
+	}
+	return exts, nil
+}
+
+// Get the language of the file from its extension, returns "" if the
+// language is not known.
+func LanguageOf(filename string) string {
+	return extToLanguage[ExtensionOf(filename)]
+}
+
+// Get the extension of the file in lower case, as IndexRef.Extensions
+// counts it. Files without one have "".
+func ExtensionOf(filename string) string {
+	return strings.ToLower(filepath.Ext(filename))
+}
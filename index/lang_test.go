@@ -0,0 +1,28 @@
+package index
+
+import "testing"
+
+func TestExtensionsFor(t *testing.T) {
+	exts, err := ExtensionsFor([]string{"go", "Proto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exts) != 2 || !exts[".go"] || !exts[".proto"] {
+		t.Fatalf("expected .go and .proto, got %v", exts)
+	}
+
+	if _, err := ExtensionsFor([]string{"klingon"}); err == nil {
+		t.Fatal("expected unknown language to fail")
+	}
+}
+
+func TestLanguageOf(t *testing.T) {
+	if lang := LanguageOf("foo/bar.GO"); lang != "go" {
+		t.Fatalf("expected go, got %s", lang)
+	}
+
+	if lang := LanguageOf("Makefile"); lang != "" {
+		t.Fatalf("expected unknown language, got %s", lang)
+	}
+}
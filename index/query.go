@@ -0,0 +1,233 @@
+package index
+
+import (
+	"fmt"
+	stdregexp "regexp"
+	"strings"
+)
+
+// A search query with its operators pulled out. Operators are words at the
+// start of the query or after whitespace:
+//
+//	path:internal/   only files whose path contains internal/
+//	-path:_test.go   skip files whose path contains _test.go
+//	file:\.go$       only files whose path matches the regular expression
+//	lang:go          only files written in the language
+//
+// Values containing spaces can be double quoted, as in path:"my docs/".
+// Whatever is left over is the pattern searched for in the file contents.
+// To search for text that looks like an operator, put a backslash in front
+// of it: \path:foo searches for path:foo.
+type Query struct {
+	Pattern string
+
+	// The literal path fragments from path: and -path:.
+	Paths        []string
+	ExcludePaths []string
+
+	// The regular expressions from file:.
+	Files []string
+
+	Languages []string
+}
+
+var queryOperators = []string{"path:", "-path:", "file:", "lang:"}
+
+// Pull the operators out of the query q.
+func ParseQuery(q string) (*Query, error) {
+	var (
+		res Query
+		pat strings.Builder
+	)
+
+	for i := 0; i < len(q); {
+		c := q[i]
+		if c == ' ' || c == '\t' {
+			pat.WriteByte(c)
+			i++
+			continue
+		}
+
+		end := strings.IndexAny(q[i:], " \t")
+		if end < 0 {
+			end = len(q) - i
+		}
+		word := q[i : i+end]
+
+		if c == '\\' && queryOperator(word[1:]) != "" {
+			// an escaped operator is just text
+			pat.WriteString(word[1:])
+			i += end
+			continue
+		}
+
+		op := queryOperator(word)
+		if op == "" {
+			pat.WriteString(word)
+			i += end
+			continue
+		}
+
+		val := word[len(op):]
+		if val[0] == '"' {
+			// the quoted value may run past the end of the word
+			start := i + len(op) + 1
+			n := strings.IndexByte(q[start:], '"')
+			if n < 0 {
+				return nil, fmt.Errorf("unterminated quote in %s", op)
+			}
+			val = q[start : start+n]
+			i = start + n + 1
+		} else {
+			i += end
+		}
+
+		if val == "" {
+			return nil, fmt.Errorf("empty value for %s", op)
+		}
+
+		switch op {
+		case "path:":
+			res.Paths = append(res.Paths, val)
+		case "-path:":
+			res.ExcludePaths = append(res.ExcludePaths, val)
+		case "file:":
+			res.Files = append(res.Files, val)
+		case "lang:":
+			res.Languages = append(res.Languages, strings.ToLower(val))
+		}
+
+		// don't leave the operator's whitespace behind in the pattern
+		for i < len(q) && (q[i] == ' ' || q[i] == '\t') {
+			i++
+		}
+	}
+
+	res.Pattern = strings.TrimSpace(pat.String())
+	return &res, nil
+}
+
+// The operator that word starts with, if it has a value after it.
+func queryOperator(word string) string {
+	for _, op := range queryOperators {
+		if strings.HasPrefix(word, op) && len(word) > len(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// Add the query's filters to the search options. Filters from the query
+// can't be combined with a FileRegexp that is already set, since a single
+// regular expression can't require all of them.
+func (q *Query) Apply(opt *SearchOptions) error {
+	var files []string
+	for _, p := range q.Paths {
+		files = append(files, stdregexp.QuoteMeta(p))
+	}
+	files = append(files, q.Files...)
+
+	switch {
+	case len(files) > 1:
+		return fmt.Errorf("only one path: or file: is allowed in a query")
+	case len(files) == 1 && opt.FileRegexp != "":
+		return fmt.Errorf("path: and file: can't be used along with files")
+	case len(files) == 1:
+		opt.FileRegexp = files[0]
+	}
+
+	if len(q.ExcludePaths) > 0 {
+		excludes := make([]string, 0, len(q.ExcludePaths))
+		for _, p := range q.ExcludePaths {
+			excludes = append(excludes, stdregexp.QuoteMeta(p))
+		}
+		opt.ExcludeFileRegexp = strings.Join(excludes, "|")
+	}
+
+	opt.Languages = append(opt.Languages, q.Languages...)
+	return nil
+}
+
+// Rewrite the tokens (see tokenWriter) in the pattern of the query q with
+// fn, leaving its operators and their values as they are. A character after a
+// backslash is never part of a token, it's an escape like \b.
+func mapPatternTokens(q string, fn func(token string) string) string {
+	var res strings.Builder
+	for i := 0; i < len(q); {
+		c := q[i]
+		if c == ' ' || c == '\t' {
+			res.WriteByte(c)
+			i++
+			continue
+		}
+
+		end := strings.IndexAny(q[i:], " \t")
+		if end < 0 {
+			end = len(q) - i
+		}
+		word := q[i : i+end]
+
+		if op := queryOperator(word); op != "" {
+			// a quoted value may run past the end of the word
+			if word[len(op)] == '"' {
+				start := i + len(op) + 1
+				if n := strings.IndexByte(q[start:], '"'); n >= 0 {
+					end = start + n + 1 - i
+				} else {
+					end = len(q) - i
+				}
+			}
+			res.WriteString(q[i : i+end])
+			i += end
+			continue
+		}
+
+		for j := 0; j < len(word); {
+			switch {
+			case word[j] == '\\' && j+1 < len(word):
+				res.WriteString(word[j : j+2])
+				j += 2
+			case isTokenByte(word[j]):
+				k := j
+				for k < len(word) && isTokenByte(word[k]) {
+					k++
+				}
+				res.WriteString(fn(word[j:k]))
+				j = k
+			default:
+				res.WriteByte(word[j])
+				j++
+			}
+		}
+		i += end
+	}
+	return res.String()
+}
+
+// The distinct tokens in the pattern of the query q that could be
+// identifiers, in the order they're in. They're at least as long as the
+// shortest tokens that are suggested and don't start with a digit.
+func PatternTokens(q string) []string {
+	var tokens []string
+	seen := map[string]bool{}
+	mapPatternTokens(q, func(token string) string {
+		if len(token) >= minTokenLen && len(token) <= maxTokenLen &&
+			(token[0] < '0' || token[0] > '9') && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+		return token
+	})
+	return tokens
+}
+
+// Replace the tokens in the pattern of the query q that are keys of with by
+// their values.
+func ReplacePatternTokens(q string, with map[string]string) string {
+	return mapPatternTokens(q, func(token string) string {
+		if r, ok := with[token]; ok {
+			return r
+		}
+		return token
+	})
+}
@@ -0,0 +1,156 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		q   string
+		exp Query
+	}{
+		{"Deprecated", Query{Pattern: "Deprecated"}},
+		{"path:internal/ Deprecated", Query{Pattern: "Deprecated", Paths: []string{"internal/"}}},
+		{"foo  bar -path:_test.go", Query{Pattern: "foo  bar", ExcludePaths: []string{"_test.go"}}},
+		{"lang:Go file:\\.go$ x", Query{Pattern: "x", Files: []string{`\.go$`}, Languages: []string{"go"}}},
+		{`path:"my docs/" a b`, Query{Pattern: "a b", Paths: []string{"my docs/"}}},
+		{`a path:"x y"`, Query{Pattern: "a", Paths: []string{"x y"}}},
+		{`\path:foo`, Query{Pattern: "path:foo"}},
+		{"path: foo", Query{Pattern: "path: foo"}},
+		{"(path:foo)", Query{Pattern: "(path:foo)"}},
+	}
+
+	for _, test := range tests {
+		q, err := ParseQuery(test.q)
+		if err != nil {
+			t.Fatalf("%q: %s", test.q, err)
+		}
+
+		if !reflect.DeepEqual(*q, test.exp) {
+			t.Fatalf("%q: expected %+v, got %+v", test.q, test.exp, *q)
+		}
+	}
+
+	for _, q := range []string{`path:"foo`, `path:"" foo`} {
+		if _, err := ParseQuery(q); err == nil {
+			t.Fatalf("expected an error for %q", q)
+		}
+	}
+}
+
+func TestQueryApply(t *testing.T) {
+	q, err := ParseQuery("path:a.b -path:c -path:d lang:go x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := SearchOptions{Languages: []string{"js"}}
+	if err := q.Apply(&opt); err != nil {
+		t.Fatal(err)
+	}
+
+	if opt.FileRegexp != `a\.b` {
+		t.Fatalf("unexpected file regexp %q", opt.FileRegexp)
+	}
+
+	if opt.ExcludeFileRegexp != "c|d" {
+		t.Fatalf("unexpected exclude file regexp %q", opt.ExcludeFileRegexp)
+	}
+
+	if !reflect.DeepEqual(opt.Languages, []string{"js", "go"}) {
+		t.Fatalf("unexpected languages %v", opt.Languages)
+	}
+
+	// the files field and path: can't both be honoured
+	opt = SearchOptions{FileRegexp: "foo"}
+	if err := q.Apply(&opt); err == nil {
+		t.Fatal("expected an error applying path: along with files")
+	}
+
+	q, err = ParseQuery("path:a file:b x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Apply(&SearchOptions{}); err == nil {
+		t.Fatal("expected an error for more than one path: or file:")
+	}
+}
+
+func TestSearchExcludeFileRegexp(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	for _, name := range []string{"a.go", "a_test.go", "b.go"} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte("// Deprecated\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref, err := Build(&IndexOptions{}, dir, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	res, err := idx.Search("Deprecated", &SearchOptions{ExcludeFileRegexp: `_test\.go`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found []string
+	for _, fm := range res.Matches {
+		found = append(found, fm.Filename)
+	}
+	sort.Strings(found)
+
+	if !reflect.DeepEqual(found, []string{"a.go", "b.go"}) {
+		t.Fatalf("expected the test file to be excluded, got %v", found)
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	if err := ValidateQuery("foo(", &SearchOptions{}); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+
+	if err := ValidateQuery("foo", &SearchOptions{FileRegexp: "["}); err == nil {
+		t.Fatal("expected an error for an invalid file regexp")
+	}
+
+	if err := ValidateQuery("foo", &SearchOptions{ExcludeFileRegexp: `_test\.go`}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPatternTokens(t *testing.T) {
+	q := `fooo\bar.*baz_qux path:"my dir" lang:go 1st fooo ab`
+	if got := strings.Join(PatternTokens(q), ","); got != "fooo,baz_qux" {
+		t.Fatalf("expected fooo and baz_qux, got %s", got)
+	}
+
+	got := ReplacePatternTokens(`fooo(bar) path:fooo file:"fooo bar"`, map[string]string{"fooo": "foo"})
+	if got != `foo(bar) path:fooo file:"fooo bar"` {
+		t.Fatalf("expected only the pattern to change, got %s", got)
+	}
+}
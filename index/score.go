@@ -0,0 +1,107 @@
+package index
+
+import (
+	"math"
+	"sort"
+)
+
+// The ways results can be ordered.
+const (
+	// Files with the best matches come first.
+	SortByScore = "score"
+
+	// Files are ordered by path, which is the order they were indexed in.
+	SortByPath = "path"
+)
+
+// Weights of the signals that go into the score of a file.
+const (
+	filenameWeight = 4.0
+	densityWeight  = 2.0
+)
+
+// Scores a file that has matches, higher is better. A file scores higher when
+// the pattern also matches its name, the more matches it has (with
+// diminishing returns) and the larger the fraction of its lines that match.
+func scoreFile(nameMatch bool, matches, lines int) float64 {
+	score := math.Log2(1 + float64(matches))
+
+	if lines > 0 {
+		density := float64(matches) / float64(lines)
+		if density > 1 {
+			density = 1
+		}
+		score += densityWeight * density
+	}
+
+	if nameMatch {
+		score += filenameWeight
+	}
+
+	// keep the score readable in responses.
+	return math.Round(score*1000) / 1000
+}
+
+// Orders the file matches by descending score, files with equal scores keep
+// their path order.
+func sortByScore(fms []*FileMatch) {
+	sort.SliceStable(fms, func(i, j int) bool {
+		return fms[i].Score > fms[j].Score
+	})
+}
+
+// Is the search of opt ranked, so that which files make its offset and limit
+// is only known once all of them have been scored or checked for the exact
+// case?
+func (o *SearchOptions) ranked() bool {
+	return o.Sort == SortByScore || o.CaseRank
+}
+
+// Orders the file matches the way opt asks for, see SortByScore and
+// SearchOptions.CaseRank. The matches are in path order to begin with.
+func rank(fms []*FileMatch, opt *SearchOptions) {
+	if opt.Sort == SortByScore {
+		sortByScore(fms)
+	}
+
+	if opt.CaseRank {
+		sortByCase(fms)
+	}
+}
+
+// Keeps the opt.Limit best files of fms (see rank), which are in path order,
+// in that order so that files that rank the same still end up in path
+// order. Returns them and how many matches the others had.
+func keepBest(fms []*FileMatch, opt *SearchOptions) ([]*FileMatch, int) {
+	if len(fms) <= opt.Limit {
+		return fms, 0
+	}
+
+	best := make([]*FileMatch, len(fms))
+	copy(best, fms)
+	rank(best, opt)
+
+	keep := map[*FileMatch]bool{}
+	for _, fm := range best[:opt.Limit] {
+		keep[fm] = true
+	}
+
+	dropped := 0
+	res := fms[:0]
+	for _, fm := range fms {
+		if keep[fm] {
+			res = append(res, fm)
+		} else {
+			dropped += len(fm.Matches)
+		}
+	}
+	return res, dropped
+}
+
+// Moves the files with matches in the exact case ahead of the ones without,
+// see SearchOptions.CaseRank. Otherwise the files keep their order.
+func sortByCase(fms []*FileMatch) {
+	sort.SliceStable(fms, func(i, j int) bool {
+		return fms[i].ExactMatches > 0 && fms[j].ExactMatches == 0
+	})
+}
@@ -0,0 +1,37 @@
+package index
+
+import "testing"
+
+func TestScoreFile(t *testing.T) {
+	if scoreFile(true, 1, 100) <= scoreFile(false, 1, 100) {
+		t.Fatal("expected a filename match to score higher")
+	}
+
+	if scoreFile(false, 10, 100) <= scoreFile(false, 1, 100) {
+		t.Fatal("expected more matches to score higher")
+	}
+
+	if scoreFile(false, 5, 10) <= scoreFile(false, 5, 1000) {
+		t.Fatal("expected denser matches to score higher")
+	}
+}
+
+func TestSortByScore(t *testing.T) {
+	fms := []*FileMatch{
+		{Filename: "a", Score: 1},
+		{Filename: "b", Score: 3},
+		{Filename: "c", Score: 1},
+		{Filename: "d", Score: 2},
+	}
+
+	sortByScore(fms)
+
+	var names string
+	for _, fm := range fms {
+		names += fm.Filename
+	}
+
+	if names != "bdac" {
+		t.Fatalf("expected order bdac, got %s", names)
+	}
+}
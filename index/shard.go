@@ -0,0 +1,370 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/etsy/hound/codesearch/index"
+)
+
+// The most shards a repo can be split into.
+const maxShards = 64
+
+// The memory all the shards of an index together buffer their trigrams in
+// before spilling them to disk, which is what a single index always had.
+const shardPostBytes = 64 << 20
+
+// The trigram index file of shard i. The first shard keeps the name an
+// unsharded index has always had.
+func shardFilename(dir string, i int) string {
+	if i == 0 {
+		return filepath.Join(dir, "tri")
+	}
+	return filepath.Join(dir, fmt.Sprintf("tri-%d", i))
+}
+
+// The number of shards the options ask for, unsharded is a single one.
+func shardCount(opt *IndexOptions) int {
+	switch {
+	case opt.Shards < 1:
+		return 1
+	case opt.Shards > maxShards:
+		return maxShards
+	}
+	return opt.Shards
+}
+
+// The shard the file at rel goes into. This only depends on the path, so a
+// file stays in the same shard from one build to the next.
+func shardOf(rel string, n int) int {
+	if n <= 1 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(filepath.ToSlash(rel)))
+	return int(h.Sum32() % uint32(n))
+}
+
+// The number of files the options have read at once, see
+// IndexOptions.Workers.
+func workerCount(opt *IndexOptions) int {
+	if opt.Workers < 1 {
+		return runtime.NumCPU()
+	}
+	return opt.Workers
+}
+
+// A file found by the walk, waiting to be indexed into its shard.
+type walkedFile struct {
+	path string
+	rel  string
+	info os.FileInfo
+
+	// gets the file once one of the workers has read it
+	read chan *readFile
+}
+
+// Writes the trigram indexes of the shards of a repo, each one in its own
+// goroutine. The files are read by a pool of workers (see readFileToIndex),
+// but every shard adds them in the order they were walked, so the names in a
+// shard are in walk order just like in an unsharded index and the index is
+// the same however the reads were scheduled.
+type shardWriters struct {
+	opt   *IndexOptions
+	dst   string
+	src   string
+	files []chan *walkedFile
+	wg    sync.WaitGroup
+
+	// the files for the workers to read
+	reads   chan *walkedFile
+	readers sync.WaitGroup
+
+	// a file holds a slot from when it's handed out until its shard has
+	// added it, which bounds the files held in memory by the workers
+	slots chan bool
+
+	// counts the tokens of the files, nil unless suggestions are on
+	tokens *tokenCounts
+
+	lck      sync.Mutex
+	indexed  indexedTally
+	nbytes   int64
+	excluded []*ExcludedFile
+	err      error
+}
+
+// What's known about the files that were indexed, which goes into the
+// manifest.
+type indexedTally struct {
+	count int
+
+	// see IndexRef.Extensions and IndexRef.Encodings
+	extensions map[string]int
+	encodings  map[string]string
+}
+
+func startShardWriters(opt *IndexOptions, dst, src string, n int, tokens *tokenCounts) *shardWriters {
+	w := &shardWriters{
+		opt:    opt,
+		dst:    dst,
+		src:    src,
+		tokens: tokens,
+		indexed: indexedTally{
+			extensions: map[string]int{},
+		},
+	}
+
+	// use top level path to indexed path (it's not required)
+	paths := []string{filepath.Join(filepath.Base(filepath.Dir(dst)), filepath.Base(dst), "raw")}
+
+	// a shard waits on the file at the front of its queue, so only as
+	// many files as the workers can read are let in at once, whichever
+	// shards they go to
+	workers := workerCount(opt)
+	w.slots = make(chan bool, workers)
+	w.reads = make(chan *walkedFile, workers)
+	for i := 0; i < workers; i++ {
+		w.readers.Add(1)
+		go w.read()
+	}
+
+	for i := 0; i < n; i++ {
+		ix := index.CreateWithPostBuffer(shardFilename(dst, i), shardPostBytes/n)
+		ix.AddPaths(paths)
+
+		ch := make(chan *walkedFile, workers)
+		w.files = append(w.files, ch)
+
+		w.wg.Add(1)
+		go w.write(ix, ch)
+	}
+
+	return w
+}
+
+// Read the files handed to the workers until there are no more.
+func (w *shardWriters) read() {
+	defer w.readers.Done()
+
+	for f := range w.reads {
+		// once a shard failed, the rest of the files aren't read
+		if err := w.failed(); err != nil {
+			f.read <- &readFile{err: err}
+			continue
+		}
+
+		f.read <- readFileToIndex(w.opt, w.dst, w.src, f.path, f.rel, f.info, w.tokens)
+	}
+}
+
+func (w *shardWriters) write(ix *index.IndexWriter, files <-chan *walkedFile) {
+	defer w.wg.Done()
+	defer ix.Close()
+
+	for f := range files {
+		r := <-f.read
+
+		// once a shard failed, the rest of the files are just drained
+		if w.failed() == nil {
+			if r.err == nil && r.ex == nil {
+				ix.Add(f.rel, bytes.NewReader(r.data), int64(len(r.data)))
+			}
+			w.indexedFile(f, r.enc, r.ex, r.err)
+		}
+		<-w.slots
+	}
+
+	if w.failed() == nil {
+		ix.Flush()
+	}
+}
+
+func (w *shardWriters) indexedFile(f *walkedFile, enc string, ex *ExcludedFile, err error) {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return
+	}
+
+	if ex != nil {
+		w.excluded = append(w.excluded, ex)
+		return
+	}
+
+	w.indexed.add(f.rel, enc)
+	w.nbytes += f.info.Size()
+	if w.opt.Progress != nil {
+		w.opt.Progress(BuildProgress{w.indexed.count, w.nbytes})
+	}
+}
+
+func (w *shardWriters) failed() error {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	return w.err
+}
+
+// Hand the (non-directory) file at path to the writer of its shard. Returns
+// the error of any shard that failed so that the walk stops.
+func (w *shardWriters) add(path, rel string, info os.FileInfo) error {
+	if err := w.failed(); err != nil {
+		return err
+	}
+
+	w.slots <- true
+	f := &walkedFile{path, rel, info, make(chan *readFile, 1)}
+	w.files[shardOf(rel, len(w.files))] <- f
+	w.reads <- f
+	return nil
+}
+
+// Count the file rel, which was transcoded from enc, see indexFile.
+func (f *indexedTally) add(rel, enc string) {
+	f.count++
+	f.extensions[ExtensionOf(rel)]++
+	if enc != "" {
+		if f.encodings == nil {
+			f.encodings = map[string]string{}
+		}
+		f.encodings[filepath.ToSlash(rel)] = enc
+	}
+}
+
+// Wait for the shards to be written. Returns what's known about the files
+// that were indexed and the ones that were excluded, in no particular order.
+func (w *shardWriters) wait() (*indexedTally, []*ExcludedFile, error) {
+	close(w.reads)
+	for _, ch := range w.files {
+		close(ch)
+	}
+	w.readers.Wait()
+	w.wg.Wait()
+
+	return &w.indexed, w.excluded, w.err
+}
+
+// Search every shard at once and merge what they found into what searching
+// a single index would have found. p is the plan for pat, which the first
+// shard uses, the others plan the search for themselves.
+func (n *Index) searchShards(pat string, p *searchPlan, opt *SearchOptions) (*SearchResponse, error) {
+	// which files the offset skips is only known once the shards are
+	// merged, so each shard collects from the first file on, as many files
+	// as the offset and the limit together cover.
+	sopt := *opt
+	sopt.Offset = 0
+	if opt.Limit > 0 {
+		sopt.Limit = opt.Offset + opt.Limit
+	}
+
+	// the files that may be opened are split between the shards
+	if opt.MaxFilesOpened > 0 {
+		sopt.MaxFilesOpened = (opt.MaxFilesOpened + len(n.shards) - 1) / len(n.shards)
+	}
+
+	resps := make([]*SearchResponse, len(n.shards))
+	errs := make([]error, len(n.shards))
+
+	var wg sync.WaitGroup
+	for i, ix := range n.shards {
+		wg.Add(1)
+		go func(i int, ix *index.Index) {
+			defer wg.Done()
+
+			sp := p
+			if i > 0 {
+				if sp, errs[i] = newSearchPlan(pat, opt, p.vrepos); errs[i] != nil {
+					return
+				}
+			}
+			resps[i], errs[i] = n.searchShard(ix, sp, &sopt)
+		}(i, ix)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return mergeShardResponses(resps, opt), nil
+}
+
+func mergeShardResponses(resps []*SearchResponse, opt *SearchOptions) *SearchResponse {
+	res := &SearchResponse{
+		VMatches:        map[string][]*FileMatch{},
+		VFilesWithMatch: map[string]int{},
+		VMatchCount:     map[string]int{},
+		VRevision:       map[string]string{},
+		LanguageCounts:  map[string]int{},
+		Revision:        resps[0].Revision,
+	}
+
+	for _, r := range resps {
+		res.Matches = append(res.Matches, r.Matches...)
+		res.FilesWithMatch += r.FilesWithMatch
+		res.MatchCount += r.MatchCount
+		res.FilesOpened += r.FilesOpened
+		res.Limited = res.Limited || r.Limited
+
+		for repo, matches := range r.VMatches {
+			res.VMatches[repo] = append(res.VMatches[repo], matches...)
+		}
+		for repo, count := range r.VFilesWithMatch {
+			res.VFilesWithMatch[repo] += count
+		}
+		for repo, count := range r.VMatchCount {
+			res.VMatchCount[repo] += count
+		}
+		for repo, rev := range r.VRevision {
+			res.VRevision[repo] = rev
+		}
+		for lang, count := range r.LanguageCounts {
+			res.LanguageCounts[lang] += count
+		}
+	}
+
+	res.Matches = shardWindow(res.Matches, opt)
+	for repo, matches := range res.VMatches {
+		if matches = shardWindow(matches, opt); len(matches) > 0 {
+			res.VMatches[repo] = matches
+		} else {
+			delete(res.VMatches, repo)
+		}
+	}
+
+	return res
+}
+
+// Put the files found in all of the shards back in the order an unsharded
+// index has them in, then keep the ones the offset and limit ask for. Those
+// of a ranked search are the best files of every shard, which Index.Search
+// then ranks and picks its page out of.
+func shardWindow(matches []*FileMatch, opt *SearchOptions) []*FileMatch {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Branch != matches[j].Branch {
+			return matches[i].Branch < matches[j].Branch
+		}
+		return index.WalkLess(matches[i].Filename, matches[j].Filename)
+	})
+
+	if opt.ranked() {
+		if opt.Limit > 0 {
+			matches, _ = keepBest(matches, opt)
+		}
+		return matches
+	}
+	return pageOf(matches, opt)
+}
@@ -0,0 +1,231 @@
+package index
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func buildShardedIndex(t *testing.T, shards int) *IndexRef {
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := Build(&IndexOptions{Shards: shards}, dir, thisDir(), url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+// The files and line numbers of the matches, in the order they were
+// returned.
+func matchedLines(res *SearchResponse) []string {
+	var lines []string
+	for _, fm := range res.Matches {
+		for _, m := range fm.Matches {
+			lines = append(lines, fmt.Sprintf("%s:%d:%s", fm.Filename, m.LineNumber, m.Line))
+		}
+	}
+	return lines
+}
+
+func TestShardedSearch(t *testing.T) {
+	single := buildShardedIndex(t, 0)
+	defer single.Remove()
+
+	sharded := buildShardedIndex(t, 4)
+	defer sharded.Remove()
+
+	if single.Shards != 0 || sharded.Shards != 4 {
+		t.Fatalf("expected 0 and 4 shards, got %d and %d", single.Shards, sharded.Shards)
+	}
+
+	if single.Files != sharded.Files {
+		t.Fatalf("expected %d files in the sharded index, got %d", single.Files, sharded.Files)
+	}
+	if !reflect.DeepEqual(single.Extensions, sharded.Extensions) {
+		t.Fatalf("expected extensions %v in the sharded index, got %v", single.Extensions, sharded.Extensions)
+	}
+
+	for i := 1; i < 4; i++ {
+		if _, err := os.Stat(shardFilename(sharded.Dir(), i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ex1, err := readExcludedFilesJson(filepath.Join(single.Dir(), excludedFileJsonFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex2, err := readExcludedFilesJson(filepath.Join(sharded.Dir(), excludedFileJsonFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ex1, ex2) {
+		t.Fatalf("expected the same excluded files, got %v and %v", ex1, ex2)
+	}
+
+	idx1, err := single.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx1.Close()
+
+	idx2, err := Open(sharded.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx2.Close()
+
+	if f1, f2 := idx1.Files(""), idx2.Files(""); !reflect.DeepEqual(f1, f2) {
+		t.Fatalf("expected the same files, got %v and %v", f1, f2)
+	}
+
+	tests := []struct {
+		pat string
+		opt SearchOptions
+	}{
+		{"func", SearchOptions{}},
+		{"func", SearchOptions{Limit: 3}},
+		{"func", SearchOptions{Offset: 2, Limit: 3}},
+		{"func", SearchOptions{Offset: 1000}},
+		{"shard", SearchOptions{IgnoreCase: true, Sort: SortByScore}},
+		{"func", SearchOptions{Sort: SortByScore, Limit: 3}},
+		{"func", SearchOptions{Sort: SortByScore, Offset: 2, Limit: 3}},
+		{"Func", SearchOptions{CaseRank: true, Sort: SortByPath, Limit: 3}},
+		{"shard", SearchOptions{CountOnly: true}},
+		{"IndexOptions", SearchOptions{FileRegexp: "_test\\.go$"}},
+	}
+
+	for _, test := range tests {
+		opt := test.opt
+		res1, err := idx1.Search(test.pat, &opt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res2, err := idx2.Search(test.pat, &opt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if l1, l2 := matchedLines(res1), matchedLines(res2); !reflect.DeepEqual(l1, l2) {
+			t.Fatalf("%s %+v: expected %v, got %v", test.pat, opt, l1, l2)
+		}
+
+		if opt.Limit == 0 && res1.FilesWithMatch != res2.FilesWithMatch {
+			t.Fatalf("%s %+v: expected %d files with matches, got %d",
+				test.pat, opt, res1.FilesWithMatch, res2.FilesWithMatch)
+		}
+
+		if res1.MatchCount != res2.MatchCount {
+			t.Fatalf("%s %+v: expected a match count of %d, got %d",
+				test.pat, opt, res1.MatchCount, res2.MatchCount)
+		}
+	}
+
+	e1, err := idx1.Explain("shard", &SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, err := idx2.Explain("shard", &SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e1.Files != e2.Files || e1.Candidates != e2.Candidates {
+		t.Fatalf("expected %d files and %d candidates, got %d and %d",
+			e1.Files, e1.Candidates, e2.Files, e2.Candidates)
+	}
+}
+
+// Build the index of src into a directory named hound/idx, so that the paths
+// in the trigram index are the same whichever temp dir it's in. Remove the
+// index with removeWithWorkers.
+func buildWithWorkers(t testing.TB, src string, shards, workers int) *IndexRef {
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := Build(&IndexOptions{Shards: shards, Workers: workers}, filepath.Join(dir, "hound", "idx"), src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func removeWithWorkers(ref *IndexRef) {
+	os.RemoveAll(filepath.Dir(filepath.Dir(ref.Dir())))
+}
+
+func TestBuildWorkers(t *testing.T) {
+	for _, shards := range []int{0, 3} {
+		serial := buildWithWorkers(t, thisDir(), shards, 1)
+		defer removeWithWorkers(serial)
+
+		parallel := buildWithWorkers(t, thisDir(), shards, 8)
+		defer removeWithWorkers(parallel)
+
+		// the trigram index is the same however the reads were scheduled
+		for i := 0; i < shardCount(&IndexOptions{Shards: shards}); i++ {
+			a, err := ioutil.ReadFile(shardFilename(serial.Dir(), i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadFile(shardFilename(parallel.Dir(), i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(a, b) {
+				t.Fatalf("%d shards: expected shard %d to be the same with 1 and 8 workers", shards, i)
+			}
+		}
+
+		if serial.Files != parallel.Files || !reflect.DeepEqual(serial.Extensions, parallel.Extensions) {
+			t.Fatalf("%d shards: expected the same files with 1 and 8 workers, got %d and %d",
+				shards, serial.Files, parallel.Files)
+		}
+	}
+}
+
+func benchmarkBuild(b *testing.B, workers int) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	// lots of small files, which is where reading them in parallel helps
+	line := "func handle(w http.ResponseWriter, r *http.Request) { return }\n"
+	for i := 0; i < 2000; i++ {
+		path := filepath.Join(src, fmt.Sprintf("dir%d", i%20), fmt.Sprintf("file%d.go", i))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			b.Fatal(err)
+		}
+		data := fmt.Sprintf("package dir%d\n%s", i%20, strings.Repeat(line, 50+i%50))
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ref := buildWithWorkers(b, src, 0, workers)
+		removeWithWorkers(ref)
+	}
+}
+
+func BenchmarkBuildSerial(b *testing.B) {
+	benchmarkBuild(b, 1)
+}
+
+func BenchmarkBuildParallel(b *testing.B) {
+	benchmarkBuild(b, runtime.NumCPU())
+}
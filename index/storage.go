@@ -0,0 +1,73 @@
+package index
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+)
+
+// Where the indexes of a dbpath are kept. Indexes are always built and
+// searched in local directories (the trigram index is mapped into memory),
+// so a backend that keeps them elsewhere, like an object store, copies them
+// between there and the directories of their refs. List has to at least
+// fetch the manifests into those, Open the rest of the index.
+type Storage interface {
+	// Return a new directory, which doesn't exist yet, to build an index in.
+	Create() (string, error)
+
+	// Keep the index that was just built in the directory of ref, from
+	// then on List finds it.
+	Save(ref *IndexRef) error
+
+	// Return the refs of the indexes that are kept. The ones whose manifest
+	// can't be read only know their directory, see Read.
+	List() ([]*IndexRef, error)
+
+	// Open the index of ref for searching, see IndexRef.Open.
+	Open(ref *IndexRef) (*Index, error)
+
+	// Remove the index of ref for good.
+	Remove(ref *IndexRef) error
+}
+
+// Keeps the indexes in directories of the dbpath, which is the default.
+type LocalStorage struct {
+	Dir string
+}
+
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{Dir: dir}
+}
+
+// The names are based on pseudo-randomness with a time-based seed.
+func (s *LocalStorage) Create() (string, error) {
+	r := uint64(rand.Uint32())<<32 | uint64(rand.Uint32())
+	return filepath.Join(s.Dir, fmt.Sprintf("idx-%08x", r)), nil
+}
+
+// The index is already where it's kept.
+func (s *LocalStorage) Save(ref *IndexRef) error {
+	return nil
+}
+
+func (s *LocalStorage) List() ([]*IndexRef, error) {
+	dirs, err := filepath.Glob(filepath.Join(s.Dir, "idx-*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []*IndexRef
+	for _, dir := range dirs {
+		r, _ := Read(dir)
+		refs = append(refs, r)
+	}
+	return refs, nil
+}
+
+func (s *LocalStorage) Open(ref *IndexRef) (*Index, error) {
+	return ref.Open()
+}
+
+func (s *LocalStorage) Remove(ref *IndexRef) error {
+	return ref.Remove()
+}
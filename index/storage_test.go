@@ -0,0 +1,83 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{"a.go": "needle\n"})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	s := NewLocalStorage(dbpath)
+
+	dir, err := s.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(dir) != dbpath || !strings.HasPrefix(filepath.Base(dir), "idx-") {
+		t.Fatalf("expected an index dir in the dbpath, got %s", dir)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatal("expected the dir not to exist yet")
+	}
+
+	ref, err := Build(&IndexOptions{}, dir, src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(ref); err != nil {
+		t.Fatal(err)
+	}
+
+	// a dir with no manifest is listed too
+	if err := os.Mkdir(filepath.Join(dbpath, "idx-partial"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 indexes, got %d", len(refs))
+	}
+
+	for _, r := range refs {
+		if r.Dir() != dir {
+			continue
+		}
+
+		if r.Url != url || r.Rev != "r1" {
+			t.Fatalf("unexpected ref %+v", r)
+		}
+
+		idx, err := s.Open(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx.Close()
+
+		if err := s.Remove(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatal("expected the index to be removed")
+	}
+}
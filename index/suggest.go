@@ -0,0 +1,352 @@
+package index
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	suggestFilename = "suggest.gob"
+
+	// Tokens shorter than this aren't worth suggesting and longer ones are
+	// more likely to be data than identifiers.
+	minTokenLen = 3
+	maxTokenLen = 64
+
+	// The most tokens kept for a repo, the ones found in the fewest files
+	// are dropped beyond that.
+	maxSuggestTokens = 50000
+)
+
+// A token (an identifier or word) found in the indexed files and the number
+// of files it was found in.
+type Suggestion struct {
+	Token string
+	Count int
+}
+
+// Counts the files each token is found in while an index is built. It's
+// shared by the writers of all of the shards.
+type tokenCounts struct {
+	lck    sync.Mutex
+	counts map[string]int
+}
+
+func newTokenCounts() *tokenCounts {
+	return &tokenCounts{counts: map[string]int{}}
+}
+
+// Count (or with a delta of -1, uncount) the tokens of a file.
+func (t *tokenCounts) addFile(tokens map[string]bool, delta int) {
+	t.lck.Lock()
+	defer t.lck.Unlock()
+
+	for token := range tokens {
+		if n := t.counts[token] + delta; n > 0 {
+			t.counts[token] = n
+		} else {
+			delete(t.counts, token)
+		}
+	}
+}
+
+// Write the most common tokens to the index in dir, ordered for Suggest.
+func (t *tokenCounts) write(dir string) error {
+	t.lck.Lock()
+	sugs := make([]*Suggestion, 0, len(t.counts))
+	for token, n := range t.counts {
+		sugs = append(sugs, &Suggestion{token, n})
+	}
+	t.lck.Unlock()
+
+	if len(sugs) > maxSuggestTokens {
+		sortSuggestions(sugs)
+		sugs = sugs[:maxSuggestTokens]
+	}
+
+	sort.Slice(sugs, func(i, j int) bool {
+		return suggestLess(sugs[i].Token, sugs[j].Token)
+	})
+
+	w, err := os.Create(filepath.Join(dir, suggestFilename))
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	return gob.NewEncoder(w).Encode(sugs)
+}
+
+// Read back the token counts of the index in dir, see write.
+func readTokenCounts(dir string) (*tokenCounts, error) {
+	sugs, err := readSuggestions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTokenCounts()
+	for _, s := range sugs {
+		t.counts[s.Token] = s.Count
+	}
+	return t, nil
+}
+
+func readSuggestions(dir string) ([]*Suggestion, error) {
+	r, err := os.Open(filepath.Join(dir, suggestFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var sugs []*Suggestion
+	if err := gob.NewDecoder(r).Decode(&sugs); err != nil {
+		return nil, err
+	}
+	return sugs, nil
+}
+
+// Tokens are ordered case insensitively, so that all of the ones that start
+// with a prefix, in any case, are next to each other.
+func suggestLess(a, b string) bool {
+	la, lb := strings.ToLower(a), strings.ToLower(b)
+	if la != lb {
+		return la < lb
+	}
+	return a < b
+}
+
+// Order suggestions by the number of files they're in, most first.
+func sortSuggestions(sugs []*Suggestion) {
+	sort.Slice(sugs, func(i, j int) bool {
+		if sugs[i].Count != sugs[j].Count {
+			return sugs[i].Count > sugs[j].Count
+		}
+		return sugs[i].Token < sugs[j].Token
+	})
+}
+
+func isTokenByte(c byte) bool {
+	return c == '_' ||
+		(c >= 'a' && c <= 'z') ||
+		(c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9')
+}
+
+// Collects the distinct tokens of whatever is written to it. A token is a
+// run of letters, digits and underscores that doesn't start with a digit.
+type tokenWriter struct {
+	cur    []byte
+	long   bool
+	tokens map[string]bool
+}
+
+func newTokenWriter() *tokenWriter {
+	return &tokenWriter{tokens: map[string]bool{}}
+}
+
+func (w *tokenWriter) Write(p []byte) (int, error) {
+	for _, c := range p {
+		if !isTokenByte(c) {
+			w.end()
+			continue
+		}
+
+		if len(w.cur) >= maxTokenLen {
+			w.long = true
+			continue
+		}
+		w.cur = append(w.cur, c)
+	}
+	return len(p), nil
+}
+
+// End the current token, if there is one.
+func (w *tokenWriter) end() {
+	if !w.long && len(w.cur) >= minTokenLen && (w.cur[0] < '0' || w.cur[0] > '9') {
+		w.tokens[string(w.cur)] = true
+	}
+	w.cur = w.cur[:0]
+	w.long = false
+}
+
+// The distinct tokens of a raw file of an index.
+func rawFileTokens(filename string) (map[string]bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	w := newTokenWriter()
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, err
+	}
+	w.end()
+	return w.tokens, nil
+}
+
+// Was the index built with suggestions?
+func (r *IndexRef) HasSuggestions() bool {
+	_, err := os.Stat(filepath.Join(r.dir, suggestFilename))
+	return err == nil
+}
+
+// Return up to limit of the tokens in the index that start with prefix (in
+// any case), the ones found in the most files first. Indexes that were built
+// without suggestions have none.
+func (n *Index) Suggest(prefix string, limit int) []*Suggestion {
+	sugs := n.suggestions()
+
+	lower := strings.ToLower(prefix)
+	i := sort.Search(len(sugs), func(i int) bool {
+		return strings.ToLower(sugs[i].Token) >= lower
+	})
+
+	var res []*Suggestion
+	for ; i < len(sugs) && strings.HasPrefix(strings.ToLower(sugs[i].Token), lower); i++ {
+		res = append(res, sugs[i])
+	}
+
+	sortSuggestions(res)
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// The suggestions of the index, they're read the first time they're needed
+// so that indexes that are never asked for any don't hold on to them.
+func (n *Index) suggestions() []*Suggestion {
+	n.suggestLck.Lock()
+	defer n.suggestLck.Unlock()
+
+	if !n.suggestRead {
+		n.suggest, _ = readSuggestions(n.Ref.dir)
+		n.suggestRead = true
+	}
+	return n.suggest
+}
+
+// A token in the index that is close to another one, see Similar.
+type SimilarToken struct {
+	Token string
+	Count int
+
+	// The edits between the two tokens (see editDistance), 0 if they only
+	// differ in case.
+	Edits int
+}
+
+// The most edits a token can be from one that it's taken to be a misspelling
+// of, a single one for short tokens.
+func maxEdits(token string) int {
+	if len(token) <= 5 {
+		return 1
+	}
+	return 2
+}
+
+// The number of characters that have to be inserted, deleted, replaced or
+// swapped with the one next to them to turn a into b, ignoring case. Gives up
+// with max+1 once it's more than max.
+func editDistance(a, b string, max int) int {
+	if d := len(a) - len(b); d > max || -d > max {
+		return max + 1
+	}
+
+	// the last three rows of the table, for the swaps
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		least := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if lowerASCII(a[i-1]) == lowerASCII(b[j-1]) {
+				cost = 0
+			}
+
+			d := prev[j-1] + cost
+			if n := prev[j] + 1; n < d {
+				d = n
+			}
+			if n := cur[j-1] + 1; n < d {
+				d = n
+			}
+			if i > 1 && j > 1 &&
+				lowerASCII(a[i-1]) == lowerASCII(b[j-2]) &&
+				lowerASCII(a[i-2]) == lowerASCII(b[j-1]) {
+				if n := prev2[j-2] + 1; n < d {
+					d = n
+				}
+			}
+
+			cur[j] = d
+			if d < least {
+				least = d
+			}
+		}
+
+		if least > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// Return up to limit of the tokens in the index that are at most a few edits
+// (see maxEdits) from token, the closest and then the ones found in the most
+// files first. The token itself, in any case, has no edits. Indexes that were
+// built without suggestions have none.
+func (n *Index) Similar(token string, limit int) []*SimilarToken {
+	max := maxEdits(token)
+
+	var res []*SimilarToken
+	for _, sug := range n.suggestions() {
+		if d := editDistance(token, sug.Token, max); d <= max {
+			res = append(res, &SimilarToken{
+				Token: sug.Token,
+				Count: sug.Count,
+				Edits: d,
+			})
+		}
+	}
+
+	SortSimilarTokens(res)
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// Order similar tokens by their edits, fewest first, and then by the number
+// of files they're in, most first.
+func SortSimilarTokens(toks []*SimilarToken) {
+	sort.Slice(toks, func(i, j int) bool {
+		if toks[i].Edits != toks[j].Edits {
+			return toks[i].Edits < toks[j].Edits
+		}
+		if toks[i].Count != toks[j].Count {
+			return toks[i].Count > toks[j].Count
+		}
+		return toks[i].Token < toks[j].Token
+	})
+}
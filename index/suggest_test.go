@@ -0,0 +1,180 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenWriter(t *testing.T) {
+	w := newTokenWriter()
+
+	// tokens can be split across writes
+	w.Write([]byte("func fooBar(x int) { return 1st_place + fo"))
+	w.Write([]byte("oBar + ab + under_score }"))
+	w.end()
+
+	for _, token := range []string{"func", "fooBar", "int", "return", "under_score"} {
+		if !w.tokens[token] {
+			t.Fatalf("expected %s to be a token, got %v", token, w.tokens)
+		}
+	}
+
+	if len(w.tokens) != 5 {
+		t.Fatalf("expected 5 tokens, got %v", w.tokens)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"a.go":  "package a\nfunc Handler() {}\n",
+		"b.go":  "package b\nfunc handle() { Handler() }\n",
+		"c.txt": "handled\n",
+	})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	opt := &IndexOptions{Suggest: true, Shards: 2}
+	ref, err := Build(opt, filepath.Join(dbpath, "idx-a"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	sugs := idx.Suggest("HAND", 2)
+	if len(sugs) != 2 || sugs[0].Token != "Handler" || sugs[0].Count != 2 || sugs[1].Token != "handle" {
+		t.Fatalf("expected Handler in 2 files and then handle, got %v", sugs)
+	}
+
+	if sugs := idx.Suggest("pack", 10); len(sugs) != 1 || sugs[0].Count != 2 {
+		t.Fatalf("expected package in 2 files, got %v", sugs)
+	}
+
+	// updating an index recounts the changed files, sharded ones can't be
+	writeFiles(t, src, map[string]string{
+		"a.go": "package a\nfunc Other() {}\n",
+	})
+
+	opt.Shards = 0
+	prev, err := Build(opt, filepath.Join(dbpath, "idx-b"), src, url, "r2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, src, map[string]string{
+		"b.go": "package b\nfunc handle() {}\n",
+	})
+
+	ref, err = Update(opt, filepath.Join(dbpath, "idx-c"), src, prev, url, "r3", []string{"b.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer updated.Close()
+
+	if sugs := updated.Suggest("handler", 10); len(sugs) != 0 {
+		t.Fatalf("expected Handler to be gone, got %v", sugs)
+	}
+	if sugs := updated.Suggest("handle", 10); len(sugs) != 2 {
+		t.Fatalf("expected handle and handled, got %v", sugs)
+	}
+
+	// an index without suggestions can't be updated into one with them
+	plain, err := Build(&IndexOptions{}, filepath.Join(dbpath, "idx-d"), src, url, "r3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.HasSuggestions() {
+		t.Fatal("expected no suggestions")
+	}
+	if _, err := Update(opt, filepath.Join(dbpath, "idx-e"), src, plain, url, "r4", []string{"b.go"}); err == nil {
+		t.Fatal("expected the update to fail")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		edits int
+	}{
+		{"handler", "handler", 0},
+		{"Handler", "handler", 0},
+		{"handler", "handlr", 1},
+		{"handler", "hnadler", 1},
+		{"handler", "handlers", 1},
+		{"handler", "bandler", 1},
+		{"handler", "hndlr", 2},
+		{"handler", "foo", 3},
+	}
+
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b, 2); got != test.edits {
+			t.Fatalf("%s and %s: expected %d edits, got %d", test.a, test.b, test.edits, got)
+		}
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"a.go": "package a\nfunc Handler() {}\n",
+		"b.go": "package b\nfunc handle() { Handler() }\n",
+		"c.go": "package c\nfunc Candle() {}\n",
+	})
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	ref, err := Build(&IndexOptions{Suggest: true}, filepath.Join(dbpath, "idx-a"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	sims := idx.Similar("hnadler", 10)
+	if len(sims) != 2 || sims[0].Token != "Handler" || sims[0].Edits != 1 || sims[1].Token != "handle" || sims[1].Edits != 2 {
+		t.Fatalf("expected Handler and then handle, got %v", sims)
+	}
+
+	// a short token can only be a single edit away
+	if sims := idx.Similar("hadle", 10); len(sims) != 1 || sims[0].Token != "handle" {
+		t.Fatalf("expected handle, got %v", sims)
+	}
+
+	if sims := idx.Similar("handle", 1); len(sims) != 1 || sims[0].Edits != 0 {
+		t.Fatalf("expected handle itself, got %v", sims)
+	}
+}
@@ -0,0 +1,173 @@
+package index
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/etsy/hound/codesearch/index"
+	"github.com/etsy/hound/codesearch/regexp"
+)
+
+const (
+	// The query is a single regular expression.
+	ModeRegex = "regex"
+
+	// The query is a list of terms that a file must all contain. Terms
+	// joined by OR are alternatives, so "a b OR c" finds files containing
+	// a and either b or c. Each term is a regular expression, double
+	// quotes group a term that contains spaces.
+	ModeTerms = "terms"
+
+	// The query is a string that is looked for as is, so a.b(c) finds
+	// a.b(c) and nothing else. This is what symbols are best searched with.
+	ModeLiteral = "literal"
+)
+
+// A query in terms mode. A file matches when, for every clause, at least one
+// of the clause's terms matches one of its lines.
+type termQuery [][]*regexp.Regexp
+
+// Split a terms mode query into its clauses, each of which is a list of
+// alternative terms.
+func parseTerms(q string) ([][]string, error) {
+	var (
+		clauses [][]string
+		or      bool
+	)
+
+	for i := 0; i < len(q); {
+		c := q[i]
+		if c == ' ' || c == '\t' {
+			i++
+			continue
+		}
+
+		var term string
+		quoted := c == '"'
+		if quoted {
+			end := strings.IndexByte(q[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in query")
+			}
+			term = q[i+1 : i+1+end]
+			i += end + 2
+		} else {
+			end := strings.IndexAny(q[i:], " \t")
+			if end < 0 {
+				end = len(q) - i
+			}
+			term = q[i : i+end]
+			i += end
+		}
+
+		if term == "OR" && !quoted {
+			if len(clauses) == 0 || or {
+				return nil, fmt.Errorf("OR must be between two terms")
+			}
+			or = true
+			continue
+		}
+
+		if term == "" {
+			return nil, fmt.Errorf("empty term in query")
+		}
+
+		if or {
+			clauses[len(clauses)-1] = append(clauses[len(clauses)-1], term)
+			or = false
+		} else {
+			clauses = append(clauses, []string{term})
+		}
+	}
+
+	if or {
+		return nil, fmt.Errorf("OR must be between two terms")
+	}
+
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("no terms in query")
+	}
+
+	return clauses, nil
+}
+
+// Plan a terms mode query. The trigram query is the conjunction of the
+// clauses' queries, which are the disjunction of their terms' queries.
+func planTermQuery(pat string, ignoreCase bool) (*regexp.Regexp, *index.Query, termQuery, error) {
+	clauses, err := parseTerms(pat)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var (
+		tq   termQuery
+		q    *index.Query
+		alts []string
+	)
+
+	for _, clause := range clauses {
+		var res []*regexp.Regexp
+		var cq *index.Query
+		for _, term := range clause {
+			re, err := regexp.Compile(GetRegexpPattern(term, ignoreCase))
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			res = append(res, re)
+			alts = append(alts, "(?:"+term+")")
+
+			if sub := index.RegexpQuery(re.Syntax); cq == nil {
+				cq = sub
+			} else {
+				cq = cq.Or(sub)
+			}
+		}
+
+		tq = append(tq, res)
+		if q == nil {
+			q = cq
+		} else {
+			q = q.And(cq)
+		}
+	}
+
+	re, err := regexp.Compile(GetRegexpPattern(strings.Join(alts, "|"), ignoreCase))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// with a single clause, any line matching re is a match.
+	if len(tq) == 1 {
+		tq = nil
+	}
+
+	return re, q, tq, nil
+}
+
+// Whether the file satisfies every clause of terms, re must match any of
+// the terms.
+func (g *grepper) matchesTerms(filename string, re *regexp.Regexp, terms termQuery) (bool, error) {
+	left := len(terms)
+	done := make([]bool, len(terms))
+	if err := g.grepFile(filename, re,
+		func(line []byte, lineno int) (bool, error) {
+			for i, clause := range terms {
+				if done[i] {
+					continue
+				}
+
+				for _, term := range clause {
+					if term.Match(line, true, true) >= 0 {
+						done[i] = true
+						left--
+						break
+					}
+				}
+			}
+			return left > 0, nil
+		}); err != nil {
+		return false, err
+	}
+
+	return left == 0, nil
+}
@@ -0,0 +1,119 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseTerms(t *testing.T) {
+	tests := []struct {
+		q       string
+		clauses [][]string
+	}{
+		{"foo", [][]string{{"foo"}}},
+		{"Deprecated  TODO", [][]string{{"Deprecated"}, {"TODO"}}},
+		{"a b OR c", [][]string{{"a"}, {"b", "c"}}},
+		{"a OR b OR c d", [][]string{{"a", "b", "c"}, {"d"}}},
+		{`"foo bar" baz`, [][]string{{"foo bar"}, {"baz"}}},
+		{`a "OR" b`, [][]string{{"a"}, {"OR"}, {"b"}}},
+	}
+
+	for _, test := range tests {
+		clauses, err := parseTerms(test.q)
+		if err != nil {
+			t.Fatalf("%q: %s", test.q, err)
+		}
+
+		if !reflect.DeepEqual(clauses, test.clauses) {
+			t.Fatalf("%q: expected %v, got %v", test.q, test.clauses, clauses)
+		}
+	}
+
+	for _, q := range []string{"", "OR a", "a OR", "a OR OR b", `"foo`, `a ""`} {
+		if _, err := parseTerms(q); err == nil {
+			t.Fatalf("expected an error for %q", q)
+		}
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	files := map[string]string{
+		"both.go":       "// Deprecated: use other\n// TODO remove\n",
+		"deprecated.go": "// Deprecated: use other\n",
+		"todo.go":       "// TODO remove\n",
+		"fixme.go":      "// Deprecated: use other\n// FIXME remove\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref, err := Build(&IndexOptions{}, dir, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	tests := []struct {
+		q     string
+		files []string
+	}{
+		{"Deprecated TODO", []string{"both.go"}},
+		{"Deprecated TODO OR FIXME", []string{"both.go", "fixme.go"}},
+		{"TODO OR FIXME", []string{"both.go", "fixme.go", "todo.go"}},
+	}
+
+	for _, test := range tests {
+		res, err := idx.Search(test.q, &SearchOptions{Mode: ModeTerms}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var found []string
+		for _, fm := range res.Matches {
+			found = append(found, fm.Filename)
+		}
+		sort.Strings(found)
+
+		if !reflect.DeepEqual(found, test.files) {
+			t.Fatalf("%q: expected %v, got %v", test.q, test.files, found)
+		}
+
+		if res.FilesWithMatch != len(test.files) {
+			t.Fatalf("%q: expected %d files with a match, got %d",
+				test.q, len(test.files), res.FilesWithMatch)
+		}
+	}
+
+	// both of the matched lines are returned
+	res, err := idx.Search("Deprecated TODO", &SearchOptions{Mode: ModeTerms}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(res.Matches[0].Matches); n != 2 {
+		t.Fatalf("expected 2 matched lines, got %d", n)
+	}
+}
@@ -0,0 +1,115 @@
+package index
+
+import (
+	goregexp "regexp"
+	"unicode/utf8"
+)
+
+// Put in place of the part of a line that was trimmed.
+const ellipsis = "…"
+
+// Trim the matched lines of res, and the lines of context around them, down
+// to max characters. A matched line keeps the part around what matched, which
+// is found by matching expr against it again, context keeps its start.
+func trimMatches(res *SearchResponse, expr string, max int) {
+	// the index's regexps can only tell where a match ends
+	re, err := goregexp.Compile(expr)
+	if err != nil {
+		re = nil
+	}
+
+	trim := func(fms []*FileMatch) {
+		for _, fm := range fms {
+			for _, m := range fm.Matches {
+				trimMatch(m, re, max)
+			}
+		}
+	}
+
+	trim(res.Matches)
+	for _, fms := range res.VMatches {
+		trim(fms)
+	}
+}
+
+func trimMatch(m *Match, re *goregexp.Regexp, max int) {
+	if n := utf8.RuneCountInString(m.Line); n > max {
+		start, end := 0, 0
+		if re != nil {
+			if loc := re.FindStringIndex(m.Line); loc != nil {
+				start, end = loc[0], loc[1]
+			}
+		}
+
+		m.Line = trimAround(m.Line, start, end, max)
+		m.LineLength = n
+	}
+
+	for i, line := range m.Before {
+		m.Before[i] = trimAround(line, 0, 0, max)
+	}
+	for i, line := range m.After {
+		m.After[i] = trimAround(line, 0, 0, max)
+	}
+}
+
+// Trim line down to max characters centered on the bytes from start to end,
+// marking the ends that were cut off with an ellipsis. If that span is
+// longer than max, its start is kept. Lines are cut between characters, never
+// in the middle of one.
+func trimAround(line string, start, end, max int) string {
+	if utf8.RuneCountInString(line) <= max {
+		return line
+	}
+
+	// make sure the span starts and ends on character boundaries
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+
+	n := utf8.RuneCountInString(line[start:end])
+	if n >= max {
+		to := forward(line, start, max)
+		return ellipsisIf(start > 0) + line[start:to] + ellipsisIf(to < len(line))
+	}
+
+	// share what's left between both sides of the span, a side that runs
+	// out gives the rest to the other
+	left := (max - n) / 2
+	from := back(line, start, left)
+	left = utf8.RuneCountInString(line[from:start])
+	to := forward(line, end, max-n-left)
+	if right := utf8.RuneCountInString(line[end:to]); n+left+right < max {
+		from = back(line, from, max-n-left-right)
+	}
+
+	return ellipsisIf(from > 0) + line[from:to] + ellipsisIf(to < len(line))
+}
+
+func ellipsisIf(b bool) string {
+	if b {
+		return ellipsis
+	}
+	return ""
+}
+
+// The offset n characters after i in s, or the end of s.
+func forward(s string, i, n int) int {
+	for ; n > 0 && i < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return i
+}
+
+// The offset n characters before i in s, or the start of s.
+func back(s string, i, n int) int {
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return i
+}
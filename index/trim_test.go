@@ -0,0 +1,67 @@
+package index
+
+import (
+	goregexp "regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTrimAround(t *testing.T) {
+	line := "0123456789abcdefghij"
+	for _, test := range []struct {
+		start, end, max int
+		want            string
+	}{
+		// short enough already
+		{0, 0, 20, line},
+		// centered on the span
+		{10, 12, 6, "…89abcd…"},
+		// the span is at the start, so the end gets the rest
+		{0, 2, 6, "012345…"},
+		// and the other way around
+		{18, 20, 6, "…efghij"},
+		// the span is too long to keep, its start is kept
+		{4, 16, 6, "…456789…"},
+	} {
+		if got := trimAround(line, test.start, test.end, test.max); got != test.want {
+			t.Fatalf("trimming %d-%d to %d: expected %q, got %q",
+				test.start, test.end, test.max, test.want, got)
+		}
+	}
+}
+
+func TestTrimAroundMultibyte(t *testing.T) {
+	line := strings.Repeat("日本語", 10) + "needle" + strings.Repeat("ü", 30)
+	start := strings.Index(line, "needle")
+
+	// a span that starts and ends in the middle of characters
+	got := trimAround(line, start-1, start+len("needle")+1, 12)
+	if !utf8.ValidString(got) {
+		t.Fatalf("expected valid UTF-8, got %q", got)
+	}
+	if !strings.Contains(got, "needle") {
+		t.Fatalf("expected the match to be kept, got %q", got)
+	}
+	if n := utf8.RuneCountInString(strings.Trim(got, ellipsis)); n > 14 {
+		t.Fatalf("expected at most 14 characters, got %d in %q", n, got)
+	}
+}
+
+func TestTrimMatch(t *testing.T) {
+	m := &Match{
+		Line:   strings.Repeat("x", 100) + "needle" + strings.Repeat("y", 100),
+		Before: []string{strings.Repeat("b", 50)},
+		After:  []string{"short"},
+	}
+
+	trimMatch(m, goregexp.MustCompile("(?m)needle"), 16)
+
+	if m.Line != "…xxxxxneedleyyyyy…" || m.LineLength != 206 {
+		t.Fatalf("expected the line around needle of 206 characters, got %q of %d", m.Line, m.LineLength)
+	}
+
+	if m.Before[0] != strings.Repeat("b", 16)+"…" || m.After[0] != "short" {
+		t.Fatalf("expected the context to keep its start, got %q and %q", m.Before, m.After)
+	}
+}
@@ -0,0 +1,367 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/etsy/hound/codesearch/index"
+)
+
+// Is the directory with the given name and path relative to the top of the
+// repo skipped by Build, along with everything under it?
+func isSkippedDir(opt *IndexOptions, name, rel string) bool {
+	return containsString(opt.SpecialFiles, name) ||
+		isExcludedDir(opt.ExcludeDirs, name, rel) ||
+		(len(opt.Roots) > 0 && filepath.Dir(rel) == "." && !containsString(opt.Roots, name)) ||
+		(opt.ExcludeDotFiles && name[0] == '.')
+}
+
+// Would Build skip the file at rel without even listing it as excluded?
+func isSkippedFile(opt *IndexOptions, rel string) bool {
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		if isSkippedDir(opt, filepath.Base(dir), dir) {
+			return true
+		}
+	}
+
+	name := filepath.Base(rel)
+	return containsString(opt.SpecialFiles, name) ||
+		(len(opt.Roots) > 0 && filepath.Dir(rel) == "." && !containsString(opt.Roots, name))
+}
+
+// Link (or, failing that, copy) the file src to dst.
+func linkFile(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// Carry the raw files of the index in prev over to dst, leaving out the
+// files that changed. The files are never changed once written, so they are
+// shared with prev where possible.
+func linkRawFiles(prev, dst string, changed map[string]bool) error {
+	return filepath.Walk(prev, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(prev, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			return os.Mkdir(filepath.Join(dst, rel), os.ModePerm)
+		}
+
+		if changed[rel] {
+			return nil
+		}
+
+		return linkFile(path, filepath.Join(dst, rel))
+	})
+}
+
+func readExcludedFilesJson(filename string) ([]*ExcludedFile, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ExcludedFile
+	if err := json.Unmarshal(b, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Index the files at rels (relative to src) that still exist into the
+// index file delta, returns the encodings of the ones that weren't plain
+// UTF-8 (see IndexRef.Encodings) and the ones that were excluded. The tokens
+// of the files are counted into tokens, if it's not nil.
+func indexChangedFiles(opt *IndexOptions, dst, src, delta string, rels []string, tokens *tokenCounts) (map[string]string, []*ExcludedFile, error) {
+	ix := index.Create(delta)
+	defer ix.Close()
+
+	// the paths of the index are those of prev, these are only for show
+	ix.AddPaths([]string{filepath.Join(filepath.Base(filepath.Dir(dst)), filepath.Base(dst), "raw")})
+
+	encodings := map[string]string{}
+	var excluded []*ExcludedFile
+	for _, rel := range rels {
+		path := filepath.Join(src, rel)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		if info.IsDir() || isSkippedFile(opt, rel) {
+			continue
+		}
+
+		if !hasIncludedExtension(opt.IncludeExtensions, info.Name()) {
+			excluded = append(excluded, &ExcludedFile{
+				rel,
+				reasonExtension,
+				codeExtension,
+			})
+			continue
+		}
+
+		if opt.ExcludeDotFiles && strings.HasPrefix(info.Name(), ".") {
+			excluded = append(excluded, &ExcludedFile{
+				rel,
+				reasonDotFile,
+				codeIgnored,
+			})
+			continue
+		}
+
+		// a directory may have been replaced by a file or vice versa
+		dup := filepath.Join(dst, "raw", rel)
+		if err := os.RemoveAll(dup); err != nil {
+			return nil, nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dup), os.ModePerm); err != nil {
+			return nil, nil, err
+		}
+
+		enc, ex, err := indexFile(ix, opt, dst, src, path, rel, info, tokens)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ex != nil {
+			excluded = append(excluded, ex)
+		} else if enc != "" {
+			encodings[filepath.ToSlash(rel)] = enc
+		}
+	}
+
+	ix.Flush()
+
+	return encodings, excluded, nil
+}
+
+// The token counts of prev without the files at rels, which are counted
+// again as they're indexed. Returns nil if suggestions are off, and fails if
+// prev was built without them, since the counts can't be made up.
+func changedTokenCounts(opt *IndexOptions, prev *IndexRef, rels []string) (*tokenCounts, error) {
+	if !opt.Suggest {
+		return nil, nil
+	}
+
+	tokens, err := readTokenCounts(prev.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rel := range rels {
+		raw := filepath.Join(prev.dir, "raw", rel)
+		if info, err := os.Lstat(raw); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		old, err := rawFileTokens(raw)
+		if err != nil {
+			return nil, err
+		}
+		tokens.addFile(old, -1)
+	}
+
+	return tokens, nil
+}
+
+// Build the index for rev of the files in src into dst by updating prev, the
+// index of an earlier revision of the same files. changed holds the paths
+// (relative to src and slash separated) of every file that was added,
+// modified or deleted since then. Only those files are read, everything else
+// is carried over from prev, so this is much cheaper than Build when few
+// files changed. The options must be the same as the ones prev was built
+// with.
+func Update(
+	opt *IndexOptions,
+	dst,
+	src string,
+	prev *IndexRef,
+	url,
+	rev string,
+	changed []string) (*IndexRef, error) {
+
+	if prev.Shards > 1 || shardCount(opt) > 1 {
+		return nil, errors.New("sharded indexes can't be updated")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dst, "raw"), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	src, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return nil, err
+	}
+
+	// the new files have to be added in the order Build would add them
+	rels := make([]string, 0, len(changed))
+	isChanged := map[string]bool{}
+	for _, name := range changed {
+		rel := filepath.FromSlash(name)
+		if !isChanged[rel] {
+			rels = append(rels, rel)
+			isChanged[rel] = true
+		}
+	}
+	sort.Slice(rels, func(i, j int) bool {
+		return index.WalkLess(rels[i], rels[j])
+	})
+
+	if err := linkRawFiles(filepath.Join(prev.dir, "raw"), filepath.Join(dst, "raw"), isChanged); err != nil {
+		return nil, err
+	}
+
+	prevExcluded, err := readExcludedFilesJson(filepath.Join(prev.dir, excludedFileJsonFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := []*ExcludedFile{}
+	for _, ex := range prevExcluded {
+		if !isChanged[ex.Filename] {
+			excluded = append(excluded, ex)
+		}
+	}
+
+	// index just the changed files, then fold them into prev's index
+	delta := filepath.Join(dst, "tri.delta")
+	defer os.Remove(delta)
+
+	tokens, err := changedTokenCounts(opt, prev, rels)
+	if err != nil {
+		return nil, err
+	}
+
+	changedEncodings, changedExcluded, err := indexChangedFiles(opt, dst, src, delta, rels, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	var encodings map[string]string
+	for name, enc := range prev.Encodings {
+		if !isChanged[filepath.FromSlash(name)] {
+			changedEncodings[name] = enc
+		}
+	}
+	if len(changedEncodings) > 0 {
+		encodings = changedEncodings
+	}
+
+	if tokens != nil {
+		if err := tokens.write(dst); err != nil {
+			return nil, err
+		}
+	}
+	excluded = append(excluded, changedExcluded...)
+
+	if err := writeExcludedFilesJson(
+		filepath.Join(dst, excludedFileJsonFilename),
+		excluded); err != nil {
+		return nil, err
+	}
+
+	// a compressed index has to be written out in full to be merged
+	prevTri := prev.shardFile(0)
+	if isCompressed(prev.Compression) {
+		prevTri = filepath.Join(dst, "tri.prev")
+		defer os.Remove(prevTri)
+
+		if err := decompressFile(prev.shardFile(0), prevTri); err != nil {
+			return nil, err
+		}
+	}
+
+	tri := filepath.Join(dst, "tri")
+	index.Update(tri, prevTri, delta, rels)
+
+	if isCompressed(prev.Compression) {
+		if err := os.Remove(prevTri); err != nil {
+			return nil, err
+		}
+	}
+
+	// the merged index lists every file, the ones kept from prev as well
+	cix, err := index.OpenChecked(tri)
+	if err != nil {
+		return nil, err
+	}
+	files := cix.NumNames()
+	extensions := map[string]int{}
+	for i := 0; i < files; i++ {
+		extensions[ExtensionOf(cix.Name(uint32(i)))]++
+	}
+	cix.Close()
+
+	if err := os.Remove(delta); err != nil {
+		return nil, err
+	}
+
+	if isCompressed(opt.Compression) {
+		if err := compressFile(tri, triCompressionLevel(opt.Compression)); err != nil {
+			return nil, err
+		}
+	}
+
+	size, err := DirSize(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &IndexRef{
+		Url:     url,
+		Rev:     rev,
+		Time:    time.Now(),
+		dir:     dst,
+		Files:   files,
+		Size:    size,
+		Updates: prev.Updates + 1,
+
+		Extensions: extensions,
+		Encodings:  encodings,
+	}
+
+	if isCompressed(opt.Compression) {
+		r.Compression = opt.Compression
+	}
+	r.ModTimes, r.ModTimesSince = opt.ModTimes, opt.ModTimesSince
+
+	if err := r.writeManifest(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
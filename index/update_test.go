@@ -0,0 +1,181 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	src, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	writeFiles(t, src, map[string]string{
+		"a.go":              "package a // needle\n",
+		"a/b.go":            "package b\n",
+		"c.go":              "package c // needle\n",
+		"gone.go":           "package gone // needle\n",
+		"node_modules/x.js": "needle\n",
+		"z.bin":             "\x00\x01\x02",
+	})
+
+	opt := IndexOptions{
+		ExcludeDirs: []string{"node_modules"},
+	}
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	prev, err := Build(&opt, filepath.Join(dbpath, "idx-prev"), src, url, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// change, add and remove some files
+	writeFiles(t, src, map[string]string{
+		"a/b.go":            "package b // needle\n",
+		"a/new/d.go":        "package d // needle\n",
+		"c.go":              "package c\n",
+		"node_modules/y.js": "needle\n",
+		"z.bin":             "binary no more\n",
+	})
+	if err := os.Remove(filepath.Join(src, "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := []string{"a/b.go", "a/new/d.go", "c.go", "gone.go", "node_modules/y.js", "z.bin"}
+	ref, err := Update(&opt, filepath.Join(dbpath, "idx-update"), src, prev, url, "r2", changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := Build(&opt, filepath.Join(dbpath, "idx-full"), src, url, "r2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ref.Files != full.Files {
+		t.Fatalf("expected %d files, got %d", full.Files, ref.Files)
+	}
+
+	if exts := map[string]int{".go": 4}; !reflect.DeepEqual(prev.Extensions, exts) {
+		t.Fatalf("expected extensions %v before the update, got %v", exts, prev.Extensions)
+	}
+	if exts := map[string]int{".go": 4, ".bin": 1}; !reflect.DeepEqual(ref.Extensions, exts) || !reflect.DeepEqual(full.Extensions, exts) {
+		t.Fatalf("expected extensions %v, got %v updated and %v built", exts, ref.Extensions, full.Extensions)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	fidx, err := full.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fidx.Close()
+
+	// the files are in the same order as a full build would have them
+	if got, exp := strings.Join(idx.Files(""), ","), strings.Join(fidx.Files(""), ","); got != exp {
+		t.Fatalf("expected files %s, got %s", exp, got)
+	}
+
+	res, err := idx.Search("needle", &SearchOptions{Sort: SortByPath}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found []string
+	for _, fm := range res.Matches {
+		found = append(found, filepath.ToSlash(fm.Filename))
+	}
+
+	// in the order they're walked, same as the full build
+	if exp := []string{"a/b.go", "a/new/d.go", "a.go"}; !reflect.DeepEqual(found, exp) {
+		t.Fatalf("expected matches in %v, got %v", exp, found)
+	}
+
+	b, err := idx.ReadFile(filepath.FromSlash("a/b.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "package b // needle\n" {
+		t.Fatalf("expected the changed contents of a/b.go, got %q", b)
+	}
+
+	// the previous index is untouched
+	if err := prev.Remove(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = idx.ReadFile("a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "package a // needle\n" {
+		t.Fatalf("expected the contents of a.go, got %q", b)
+	}
+
+	excluded, err := readExcludedFilesJson(filepath.Join(ref.Dir(), excludedFileJsonFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(excluded) != 0 {
+		t.Fatalf("expected z.bin to no longer be excluded, got %v", excluded)
+	}
+}
+
+func TestIsSkippedFile(t *testing.T) {
+	opt := IndexOptions{
+		SpecialFiles:    []string{".git"},
+		ExcludeDirs:     []string{"vendor", "third_party/big"},
+		ExcludeDotFiles: true,
+	}
+
+	var skipped []string
+	for _, name := range []string{
+		"a.go",
+		".git",
+		".git/config",
+		"vendor/x.go",
+		"lib/vendor/x.go",
+		"third_party/big/x.go",
+		"third_party/small/x.go",
+		".github/x.yml",
+		".gitignore",
+	} {
+		if isSkippedFile(&opt, filepath.FromSlash(name)) {
+			skipped = append(skipped, name)
+		}
+	}
+	sort.Strings(skipped)
+
+	exp := []string{".git", ".git/config", ".github/x.yml", "lib/vendor/x.go", "third_party/big/x.go", "vendor/x.go"}
+	if !reflect.DeepEqual(skipped, exp) {
+		t.Fatalf("expected %v to be skipped, got %v", exp, skipped)
+	}
+}
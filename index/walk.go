@@ -0,0 +1,140 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Why a symbolic link wasn't followed.
+const (
+	reasonSymlinkBroken  = "Symbolic link is broken."
+	reasonSymlinkOutside = "Symbolic link points outside the repo."
+	reasonSymlinkLoop    = "Symbolic link loops back to a directory that contains it."
+)
+
+// Passed to the walk function for a symbolic link that couldn't be followed.
+type symlinkError struct {
+	reason string
+}
+
+func (e *symlinkError) Error() string {
+	return e.reason
+}
+
+// Walk the tree at root like filepath.Walk: fn is called for every file and
+// directory in lexical order and can return filepath.SkipDir to skip the rest
+// of a directory. Unless follow is set, symbolic links are passed to fn as
+// they are, which is all filepath.Walk does. If it is, fn gets what a link
+// points to (under the link's own path) and the walk carries on into linked
+// directories. Links that are broken, point outside of root or loop back to
+// a directory that contains them are passed along with a *symlinkError.
+func walkTree(root string, follow bool, fn filepath.WalkFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkPath(root, root, info, follow, nil, fn)
+	}
+
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func walkPath(
+	root,
+	path string,
+	info os.FileInfo,
+	follow bool,
+	parents []os.FileInfo,
+	fn filepath.WalkFunc) error {
+
+	if follow && info.Mode()&os.ModeSymlink != 0 {
+		target, reason := followSymlink(root, path, parents)
+		if reason != "" {
+			return fn(path, info, &symlinkError{reason})
+		}
+		info = target
+	}
+
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	names, err := readDirNames(path)
+	err1 := fn(path, info, err)
+	if err1 == filepath.SkipDir {
+		return nil
+	} else if err != nil || err1 != nil {
+		return err1
+	}
+
+	parents = append(parents, info)
+	for _, name := range names {
+		filename := filepath.Join(path, name)
+		fi, err := os.Lstat(filename)
+		if err != nil {
+			err = fn(filename, nil, err)
+		} else {
+			err = walkPath(root, filename, fi, follow, parents, fn)
+		}
+
+		// a file asking to skip the directory skips the rest of it
+		if err == filepath.SkipDir {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Return what the symbolic link at path points to, or why it can't be
+// followed. parents are the directories that contain path.
+func followSymlink(root, path string, parents []os.FileInfo) (os.FileInfo, string) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, reasonSymlinkBroken
+	}
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return nil, reasonSymlinkOutside
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, reasonSymlinkBroken
+	}
+
+	if info.IsDir() {
+		for _, p := range parents {
+			if os.SameFile(p, info) {
+				return nil, reasonSymlinkLoop
+			}
+		}
+	}
+
+	return info, ""
+}
+
+// The sorted names of the entries in the directory dir.
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
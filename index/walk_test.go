@@ -0,0 +1,120 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Make a repo with links to a file, to a directory, back to the top, out of
+// the repo and to nothing at all.
+func makeSymlinkRepo(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(dir, "src")
+	writeFiles(t, src, map[string]string{
+		"a.txt":     "needle a\n",
+		"dir/b.txt": "needle b\n",
+	})
+	writeFiles(t, filepath.Join(dir, "outside"), map[string]string{
+		"c.txt": "needle c\n",
+	})
+
+	for link, target := range map[string]string{
+		"link.txt": "a.txt",
+		"linkdir":  "dir",
+		"self":     ".",
+		"dir/up":   "..",
+		"out":      "../outside",
+		"broken":   "nope",
+	} {
+		if err := os.Symlink(target, filepath.Join(src, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return src, func() { os.RemoveAll(dir) }
+}
+
+// The names of the indexed files and the excluded ones along with why.
+func indexedFiles(t *testing.T, opt *IndexOptions, src string) ([]string, map[string]string) {
+	dst, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	ref, err := Build(opt, dst, src, url, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ref.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	var names []string
+	for _, name := range idx.Files("") {
+		names = append(names, filepath.ToSlash(name))
+	}
+
+	files, err := readExcludedFilesJson(filepath.Join(dst, excludedFileJsonFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	excluded := map[string]string{}
+	for _, f := range files {
+		excluded[filepath.ToSlash(f.Filename)] = f.Reason
+	}
+
+	return names, excluded
+}
+
+func TestSymlinksAreExcluded(t *testing.T) {
+	src, cleanup := makeSymlinkRepo(t)
+	defer cleanup()
+
+	names, excluded := indexedFiles(t, &IndexOptions{}, src)
+
+	if !reflect.DeepEqual(names, []string{"a.txt", "dir/b.txt"}) {
+		t.Fatalf("unexpected files %v", names)
+	}
+
+	for _, link := range []string{"link.txt", "linkdir", "self", "dir/up", "out", "broken"} {
+		if excluded[link] != reasonSymlink {
+			t.Fatalf("expected %s to be excluded as a link, got %q", link, excluded[link])
+		}
+	}
+}
+
+func TestFollowSymlinks(t *testing.T) {
+	src, cleanup := makeSymlinkRepo(t)
+	defer cleanup()
+
+	names, excluded := indexedFiles(t, &IndexOptions{FollowSymlinks: true}, src)
+
+	exp := []string{"a.txt", "dir/b.txt", "link.txt", "linkdir/b.txt"}
+	if !reflect.DeepEqual(names, exp) {
+		t.Fatalf("expected files %v, got %v", exp, names)
+	}
+
+	for link, reason := range map[string]string{
+		"self":       reasonSymlinkLoop,
+		"dir/up":     reasonSymlinkLoop,
+		"linkdir/up": reasonSymlinkLoop,
+		"out":        reasonSymlinkOutside,
+		"broken":     reasonSymlinkBroken,
+	} {
+		if excluded[link] != reason {
+			t.Fatalf("expected %s to be excluded with %q, got %q", link, reason, excluded[link])
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package logger provides a small leveled logger for hound. It can write
+// either human readable lines (the default) or one JSON object per line
+// so the output can be consumed by a log aggregator.
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// Parse the name of a level (debug, info, warn, error).
+func ParseLevel(s string) (Level, error) {
+	for l, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(l), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("logger: unknown level %q", s)
+}
+
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatJson
+)
+
+// Parse the name of a format (text, json).
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "text":
+		return FormatText, nil
+	case "json":
+		return FormatJson, nil
+	}
+	return FormatText, fmt.Errorf("logger: unknown format %q", s)
+}
+
+// Additional key/value pairs attached to a log entry, things like repo,
+// rev, event and error.
+type Fields map[string]interface{}
+
+type Logger struct {
+	lck    sync.Mutex
+	w      io.Writer
+	level  Level
+	format Format
+}
+
+// Create a new Logger that writes entries at or above level to w.
+func New(w io.Writer, level Level, format Format) *Logger {
+	return &Logger{
+		w:      w,
+		level:  level,
+		format: format,
+	}
+}
+
+func (l *Logger) SetLevel(level Level) {
+	l.lck.Lock()
+	defer l.lck.Unlock()
+	l.level = level
+}
+
+func (l *Logger) SetFormat(format Format) {
+	l.lck.Lock()
+	defer l.lck.Unlock()
+	l.format = format
+}
+
+func (l *Logger) SetOutput(w io.Writer) {
+	l.lck.Lock()
+	defer l.lck.Unlock()
+	l.w = w
+}
+
+// Values that don't marshal sensibly on their own (errors mostly) are
+// turned into strings.
+func fieldValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	}
+	return v
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatText(buf *bytes.Buffer, t time.Time, level Level, msg string, fields Fields) {
+	fmt.Fprintf(buf, "%s [%s] %s", t.Format("2006/01/02 15:04:05"), level, msg)
+	for _, k := range sortedKeys(fields) {
+		v := fmt.Sprint(fieldValue(fields[k]))
+		if strings.ContainsAny(v, " \t\n\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(buf, " %s=%s", k, v)
+	}
+	buf.WriteByte('\n')
+}
+
+func formatJson(buf *bytes.Buffer, t time.Time, level Level, msg string, fields Fields) error {
+	entry := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		entry[k] = fieldValue(v)
+	}
+	entry["time"] = t.Format(time.RFC3339)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+
+	return json.NewEncoder(buf).Encode(entry)
+}
+
+// Write an entry with the given level, message and fields. Entries below the
+// logger's level are dropped.
+func (l *Logger) Log(level Level, msg string, fields Fields) {
+	l.lck.Lock()
+	defer l.lck.Unlock()
+
+	if level < l.level {
+		return
+	}
+
+	var buf bytes.Buffer
+	if l.format == FormatJson {
+		if err := formatJson(&buf, time.Now(), level, msg, fields); err != nil {
+			buf.Reset()
+			formatText(&buf, time.Now(), level, msg, fields)
+		}
+	} else {
+		formatText(&buf, time.Now(), level, msg, fields)
+	}
+
+	l.w.Write(buf.Bytes())
+}
+
+func (l *Logger) Debug(msg string, fields Fields) {
+	l.Log(LevelDebug, msg, fields)
+}
+
+func (l *Logger) Info(msg string, fields Fields) {
+	l.Log(LevelInfo, msg, fields)
+}
+
+func (l *Logger) Warn(msg string, fields Fields) {
+	l.Log(LevelWarn, msg, fields)
+}
+
+func (l *Logger) Error(msg string, fields Fields) {
+	l.Log(LevelError, msg, fields)
+}
+
+// The logger used by the package level functions.
+var std = New(os.Stderr, LevelInfo, FormatText)
+
+func SetLevel(level Level) {
+	std.SetLevel(level)
+}
+
+func SetFormat(format Format) {
+	std.SetFormat(format)
+}
+
+func SetOutput(w io.Writer) {
+	std.SetOutput(w)
+}
+
+func Debug(msg string, fields Fields) {
+	std.Debug(msg, fields)
+}
+
+func Info(msg string, fields Fields) {
+	std.Info(msg, fields)
+}
+
+func Warn(msg string, fields Fields) {
+	std.Warn(msg, fields)
+}
+
+func Error(msg string, fields Fields) {
+	std.Error(msg, fields)
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelWarn, FormatText)
+
+	l.Info("dropped", nil)
+	if buf.Len() != 0 {
+		t.Fatalf("expected info to be dropped, got %s", buf.String())
+	}
+
+	l.Warn("kept", Fields{"repo": "foo"})
+	if !strings.Contains(buf.String(), "[warn] kept repo=foo") {
+		t.Fatalf("unexpected text entry: %s", buf.String())
+	}
+}
+
+func TestJsonFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelDebug, FormatJson)
+
+	l.Error("index build failed", Fields{
+		"repo":  "foo",
+		"error": errors.New("boom"),
+	})
+
+	var entry map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry["level"] != "error" || entry["msg"] != "index build failed" {
+		t.Fatalf("unexpected json entry: %s", buf.String())
+	}
+
+	if entry["repo"] != "foo" || entry["error"] != "boom" {
+		t.Fatalf("unexpected json fields: %s", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := ParseLevel("WARN"); err != nil || l != LevelWarn {
+		t.Fatalf("expected warn, got %s (%v)", l, err)
+	}
+
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Fatal("expected unknown level to fail")
+	}
+}
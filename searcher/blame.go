@@ -0,0 +1,108 @@
+package searcher
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
+	"github.com/etsy/hound/vcs"
+)
+
+// The number of files whose blame is kept around. When the cache fills up
+// it is simply emptied, blame is only ever asked for a handful of files at a
+// time.
+const maxBlameCacheFiles = 256
+
+// Caches the blame of files by revision, so the vcs is asked at most once
+// for each file no matter how many matches it has.
+type blameCache struct {
+	lck   sync.Mutex
+	files map[string][]*vcs.BlameLine
+}
+
+func newBlameCache() *blameCache {
+	return &blameCache{
+		files: map[string][]*vcs.BlameLine{},
+	}
+}
+
+// Get the blame for the file at path in dir as of rev, asking the vcs if
+// it's not in the cache.
+func (c *blameCache) get(wd *vcs.WorkDir, dir, rev, path string) ([]*vcs.BlameLine, error) {
+	key := rev + "\x00" + filepath.Join(dir, path)
+
+	c.lck.Lock()
+	lines, ok := c.files[key]
+	c.lck.Unlock()
+	if ok {
+		return lines, nil
+	}
+
+	lines, err := wd.Blame(dir, rev, path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lck.Lock()
+	defer c.lck.Unlock()
+	if len(c.files) >= maxBlameCacheFiles {
+		c.files = map[string][]*vcs.BlameLine{}
+	}
+	c.files[key] = lines
+
+	return lines, nil
+}
+
+// Attach blame to the matched lines in res. Files that can't be blamed are
+// left as they are.
+func (s *Searcher) blameMatches(res *index.SearchResponse) {
+	for _, fm := range res.Matches {
+		s.blameFile(s.vcsDir, s.idx.Ref.Rev, fm)
+	}
+
+	for vrepo, fms := range res.VMatches {
+		for _, fm := range fms {
+			// vrepo has org/repo format, each vrepo (and for hidden repos,
+			// each branch) is its own checkout which is blamed as is. The
+			// cache is emptied when the index is swapped, so there is no
+			// need for a revision to tell them apart.
+			dir := filepath.Join(s.vcsDir, filepath.Base(vrepo))
+			if s.idx.VRepoDepth > 1 {
+				dir = filepath.Join(dir, fm.Branch)
+			}
+			s.blameFile(dir, "", fm)
+		}
+	}
+}
+
+func (s *Searcher) blameFile(dir, rev string, fm *index.FileMatch) {
+	if s.wd == nil {
+		return
+	}
+
+	lines, err := s.blames.get(s.wd, dir, rev, fm.Filename)
+	if err == vcs.ErrBlameNotSupported {
+		return
+	} else if err != nil {
+		logger.Warn("blame failed", logger.Fields{
+			"repo":  vcs.ScrubUrl(s.Repo.Url),
+			"file":  fm.Filename,
+			"error": err,
+		})
+		return
+	}
+
+	for _, m := range fm.Matches {
+		if m.LineNumber < 1 || m.LineNumber > len(lines) {
+			continue
+		}
+
+		b := lines[m.LineNumber-1]
+		m.Blame = &index.Blame{
+			Sha:    b.Sha,
+			Author: b.Author,
+			Date:   b.Date,
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package searcher
+
+import (
+	"sync"
+	"time"
+)
+
+// The number of reindexes Changes remembers, older ones are forgotten.
+const maxChanges = 1000
+
+// A repo that was reindexed at a new revision.
+type Change struct {
+	Repo   string
+	OldRev string
+	NewRev string
+	Time   time.Time
+}
+
+var changes = struct {
+	lck    sync.Mutex
+	events []*Change
+}{}
+
+// Remember that the named repo is now searched at newRev.
+func recordChange(name, oldRev, newRev string) {
+	changes.lck.Lock()
+	defer changes.lck.Unlock()
+
+	if len(changes.events) >= maxChanges {
+		changes.events = changes.events[len(changes.events)-maxChanges+1:]
+	}
+
+	changes.events = append(changes.events, &Change{
+		Repo:   name,
+		OldRev: oldRev,
+		NewRev: newRev,
+		Time:   time.Now(),
+	})
+}
+
+// The repos that were reindexed after since, oldest first. Only the last
+// maxChanges reindexes are remembered.
+func Changes(since time.Time) []*Change {
+	changes.lck.Lock()
+	defer changes.lck.Unlock()
+
+	res := []*Change{}
+	for _, c := range changes.events {
+		if c.Time.After(since) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
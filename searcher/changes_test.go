@@ -0,0 +1,35 @@
+package searcher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestChanges(t *testing.T) {
+	changes.events = nil
+
+	start := time.Now()
+	for i := 0; i < maxChanges+5; i++ {
+		recordChange("repo", fmt.Sprintf("r%d", i), fmt.Sprintf("r%d", i+1))
+	}
+
+	all := Changes(time.Time{})
+	if len(all) != maxChanges {
+		t.Fatalf("expected %d changes, got %d", maxChanges, len(all))
+	}
+
+	// the oldest ones are forgotten first
+	if all[0].OldRev != "r5" || all[len(all)-1].NewRev != fmt.Sprintf("r%d", maxChanges+5) {
+		t.Fatalf("expected r5 to r%d, got %s to %s",
+			maxChanges+5, all[0].OldRev, all[len(all)-1].NewRev)
+	}
+
+	if c := Changes(all[len(all)-1].Time); len(c) != 0 {
+		t.Fatalf("expected no changes after the last one, got %d", len(c))
+	}
+
+	if c := Changes(start.Add(-time.Second)); len(c) != maxChanges {
+		t.Fatalf("expected %d changes since the start, got %d", maxChanges, len(c))
+	}
+}
@@ -0,0 +1,129 @@
+package searcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/etsy/hound/logger"
+)
+
+// The circuit breaker of a searcher, which opens once the repo's pulls have
+// failed PullFailuresToBackOff times in a row. While it's open the repo is
+// polled less and less often (see pollDelay) and each poll is a single
+// attempt, a probe of whether the remote is back. The first pull that
+// succeeds closes it again.
+type circuit struct {
+	lck sync.Mutex
+
+	// the pulls that failed in a row and the last one's error
+	failures int
+	err      error
+
+	// when the circuit opened and when the next poll is due, zero while
+	// it's closed
+	openSince time.Time
+	nextPoll  time.Time
+}
+
+// A repo whose circuit is open, see Searcher.Circuit.
+type OpenCircuit struct {
+	Repo  string
+	Error string
+
+	// The pulls that failed in a row, when the circuit opened and when
+	// the repo will be polled next.
+	Failures  int
+	OpenSince time.Time
+	NextPoll  time.Time `json:",omitempty"`
+}
+
+// Whether the repo's pulls keep failing, which it is polled less often for.
+// Returns nil while they don't.
+func (s *Searcher) Circuit() *OpenCircuit {
+	s.circuit.lck.Lock()
+	defer s.circuit.lck.Unlock()
+
+	if s.circuit.openSince.IsZero() {
+		return nil
+	}
+
+	return &OpenCircuit{
+		Repo:      s.name,
+		Error:     s.circuit.err.Error(),
+		Failures:  s.circuit.failures,
+		OpenSince: s.circuit.openSince,
+		NextPoll:  s.circuit.nextPoll,
+	}
+}
+
+// Is the circuit open?
+func (s *Searcher) circuitOpen() bool {
+	s.circuit.lck.Lock()
+	defer s.circuit.lck.Unlock()
+	return !s.circuit.openSince.IsZero()
+}
+
+// A pull of the repo failed with err, which opens the circuit once there
+// have been enough of them in a row.
+func (s *Searcher) pullFailed(err error) {
+	s.circuit.lck.Lock()
+	defer s.circuit.lck.Unlock()
+
+	s.circuit.failures++
+	s.circuit.err = err
+
+	n := s.Repo.PullFailuresToBackOff
+	if n <= 0 || s.circuit.failures < n || !s.circuit.openSince.IsZero() {
+		return
+	}
+
+	s.circuit.openSince = time.Now()
+	logger.Warn("circuit opened, backing off polls", logger.Fields{
+		"event":    "circuit",
+		"repo":     s.name,
+		"failures": s.circuit.failures,
+		"error":    err,
+	})
+}
+
+// A pull of the repo succeeded, which closes the circuit.
+func (s *Searcher) pullSucceeded() {
+	s.circuit.lck.Lock()
+	defer s.circuit.lck.Unlock()
+
+	if !s.circuit.openSince.IsZero() {
+		logger.Info("circuit closed, polling again", logger.Fields{
+			"event":    "circuit",
+			"repo":     s.name,
+			"failures": s.circuit.failures,
+		})
+	}
+
+	s.circuit.failures = 0
+	s.circuit.err = nil
+	s.circuit.openSince = time.Time{}
+	s.circuit.nextPoll = time.Time{}
+}
+
+// How long to wait before the next poll, given the repo's usual delay. While
+// the circuit is open the delay doubles with every failure after the one
+// that opened it, up to MaxPollBackoff.
+func (s *Searcher) pollDelay(delay time.Duration) time.Duration {
+	s.circuit.lck.Lock()
+	defer s.circuit.lck.Unlock()
+
+	if delay <= 0 || s.circuit.openSince.IsZero() {
+		return delay
+	}
+
+	max := s.Repo.MaxPollBackoff()
+	for i := s.Repo.PullFailuresToBackOff; i < s.circuit.failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max && max > 0 {
+		delay = max
+	}
+
+	s.circuit.nextPoll = time.Now().Add(delay)
+	return delay
+}
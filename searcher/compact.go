@@ -0,0 +1,141 @@
+package searcher
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
+)
+
+// When the searcher last showed any sign of life, either a search or a new
+// index going live.
+func (s *Searcher) lastActive() time.Time {
+	t := s.IndexRef().Time
+	if searched := s.LastSearched(); searched.After(t) {
+		return searched
+	}
+	return t
+}
+
+// When the index was last compacted, the zero time if it never was.
+func (s *Searcher) LastCompacted() time.Time {
+	if t := atomic.LoadInt64(&s.lastCompaction); t != 0 {
+		return time.Unix(0, t)
+	}
+	return time.Time{}
+}
+
+// Compact the index whenever the searcher has been idle for the given time,
+// until it shuts down. opt are the options the poll loop indexes with.
+func (s *Searcher) compactWhenIdle(idle time.Duration, opt *index.IndexOptions) {
+	for {
+		wait := idle - time.Since(s.lastActive())
+		if wait <= 0 {
+			if s.IndexRef().Updates > 0 {
+				s.compact(idle, opt)
+			}
+
+			// a failed compaction isn't retried until the next idle spell
+			wait = idle
+		}
+
+		select {
+		case <-s.doneCh:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Build the index of the current revision from scratch and swap it in. This
+// never runs alongside an update of the same repo, and takes its turn with
+// the limiter like any build.
+func (s *Searcher) compact(idle time.Duration, opt *index.IndexOptions) {
+	s.indexLck.Lock()
+	defer s.indexLck.Unlock()
+
+	// the searcher may have been stopped, searched or updated while this
+	// was waiting for the lock
+	select {
+	case <-s.doneCh:
+		return
+	default:
+	}
+
+	prev := s.IndexRef()
+	if prev.Updates == 0 || time.Since(s.lastActive()) < idle {
+		return
+	}
+
+	s.lim.Acquire(s.Repo.IndexWeight)
+	defer s.lim.Release(s.Repo.IndexWeight)
+
+	// the working tree is only rebuilt from if it still holds the revision
+	// of the index, a failed update may have left it at a later one
+	if head, err := s.wd.HeadRev(s.vcsDir); err != nil || head != prev.Rev {
+		logger.Debug("working tree moved on, not compacting", logger.Fields{
+			"event": "compact",
+			"repo":  s.name,
+			"rev":   prev.Rev,
+		})
+		return
+	}
+
+	logger.Info("compacting index", logger.Fields{
+		"event":   "compact",
+		"repo":    s.name,
+		"rev":     prev.Rev,
+		"updates": prev.Updates,
+	})
+
+	o := *opt
+	o.ModTimes, o.ModTimesSince = prev.ModTimes, prev.ModTimesSince
+
+	store := storageFor(s.dbpath)
+	dir, err := store.Create()
+	if err != nil {
+		logger.Error("failed index compaction", logger.Fields{
+			"event": "compact",
+			"repo":  s.name,
+			"error": err,
+		})
+		return
+	}
+
+	idx, err := buildAndOpenIndex(&o, store, s.wd.WorkTree(s.vcsDir), dir, prev.Url, prev.Rev, s.name, prev.Files)
+	if err != nil {
+		logger.Error("failed index compaction", logger.Fields{
+			"event": "compact",
+			"repo":  s.name,
+			"error": err,
+		})
+		return
+	}
+
+	roots, _ := s.wd.Roots(s.vcsDir)
+	vrepos := setVRepos(s, idx, s.vcsDir, prev.Rev, roots)
+	if err := s.swapIndexes(idx, vrepos); err != nil {
+		logger.Error("failed index swap", logger.Fields{
+			"repo":  s.name,
+			"error": err,
+		})
+		if err := idx.Destroy(); err != nil {
+			logger.Error("failed to destroy index", logger.Fields{
+				"repo":  s.name,
+				"error": err,
+			})
+		}
+		return
+	}
+
+	atomic.StoreInt64(&s.lastCompaction, time.Now().UnixNano())
+
+	logger.Info("compacted index", logger.Fields{
+		"event":      "compact",
+		"repo":       s.name,
+		"rev":        prev.Rev,
+		"sizeBefore": prev.Size,
+		"sizeAfter":  idx.Ref.Size,
+	})
+}
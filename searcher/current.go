@@ -0,0 +1,110 @@
+package searcher
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/etsy/hound/index"
+)
+
+// Each repo has a marker in the dbpath that names its live index. It's moved
+// to a new index once that is built and saved, before the index is swapped
+// in, and it's what startup looks at first. Whatever a crash interrupts, the
+// marker names either the old index or the new one, both of which are whole,
+// and any other index of the repo is left for the cleanup at startup and the
+// sweeper. Repos that were last served before there were markers have none,
+// they fall back to any index of their url and revision.
+func currentMarker(dbpath, name string) string {
+	return filepath.Join(dbpath, fmt.Sprintf("current-%x", sha1.Sum([]byte(name))))
+}
+
+// The directory of the index the marker of repo name names, empty if it has
+// no marker.
+func readCurrent(dbpath, name string) string {
+	b, err := ioutil.ReadFile(currentMarker(dbpath, name))
+	if err != nil {
+		return ""
+	}
+
+	dir := strings.TrimSpace(string(b))
+	if dir == "" || filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(dbpath, dir)
+}
+
+// Point the marker of repo name at the index in dir. The marker is written
+// next to where it goes and renamed into place, so it's never seen half
+// written. An index in the dbpath is named relative to it, so the dbpath can
+// be moved.
+func writeCurrent(dbpath, name, dir string) error {
+	dst := currentMarker(dbpath, name)
+	tmp := dst + ".tmp"
+
+	if inDbPath(dbpath, dir) {
+		dir, _ = filepath.Rel(dbpath, dir)
+	}
+
+	w, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(w, dir); err != nil {
+		w.Close()
+		return err
+	}
+
+	// the rename is only as good as what's on disk before it
+	if err := w.Sync(); err != nil {
+		w.Close()
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+// Remove the marker of repo name, for when the repo is gone.
+func removeCurrent(dbpath, name string) error {
+	err := os.Remove(currentMarker(dbpath, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Find the index of url and rev a repo can reuse, given the directory its
+// marker names (see readCurrent). A repo with a marker only reuses the index
+// it names, so an index that was built but never swapped in is never mistaken
+// for the live one. Returns nil if there is no such index.
+func (r *foundRefs) findCurrent(url, rev, current string) *index.IndexRef {
+	if current == "" {
+		return r.find(url, rev)
+	}
+
+	for _, ref := range r.refs {
+		if ref.Dir() == current && ref.Url == url && ref.Rev == rev {
+			return ref
+		}
+	}
+	return nil
+}
+
+// Find the index of url a repo that isn't updated serves, which is the one its
+// marker names or, without one, the most recently built.
+func (r *foundRefs) findLatestCurrent(url, current string) *index.IndexRef {
+	for _, ref := range r.refs {
+		if current != "" && ref.Dir() == current && ref.Url == url {
+			return ref
+		}
+	}
+	return r.findLatest(url)
+}
@@ -0,0 +1,220 @@
+package searcher
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/logger"
+)
+
+// How long to wait before retrying a repo that failed to start, the wait
+// doubles with every failed retry up to the max.
+var (
+	minRetryDelay = 30 * time.Second
+	maxRetryDelay = 10 * time.Minute
+)
+
+// A repo whose searcher failed to start, which is retried in the background
+// (see RetryFailed) until it starts or is removed from the config.
+type FailedRepo struct {
+	Repo  string
+	Error string
+
+	// The number of times the repo failed to start, when it first failed,
+	// when it last did and when it will be retried next.
+	Attempts    int
+	FailedSince time.Time
+	LastFailure time.Time
+	NextRetry   time.Time `json:",omitempty"`
+}
+
+type failedRepo struct {
+	status FailedRepo
+
+	dbpath string
+	repo   *config.Repo
+	lim    *limiter
+
+	// closed to stop retrying
+	stop chan empty
+}
+
+var failures = struct {
+	lck       sync.Mutex
+	byRepo    map[string]*failedRepo
+	recovered func(name string, s *Searcher)
+}{byRepo: map[string]*failedRepo{}}
+
+// The repos that failed to start and haven't started since, ordered by repo.
+func Failed() []*FailedRepo {
+	failures.lck.Lock()
+	defer failures.lck.Unlock()
+
+	res := make([]*FailedRepo, 0, len(failures.byRepo))
+	for _, f := range failures.byRepo {
+		c := f.status
+		res = append(res, &c)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Repo < res[j].Repo
+	})
+	return res
+}
+
+// Retry the repos that failed to start in the background, handing the
+// searcher of each one that does start (which is already polling) to fn.
+// Until this is called, failed repos are only recorded.
+func RetryFailed(fn func(name string, s *Searcher)) {
+	failures.lck.Lock()
+	defer failures.lck.Unlock()
+
+	failures.recovered = fn
+	for name, f := range failures.byRepo {
+		f.scheduleRetry(name)
+	}
+}
+
+// Stop retrying the named repo, if it failed to start. This is for repos
+// that are removed from the config, or are about to be started anew.
+func ForgetFailed(name string) {
+	failures.lck.Lock()
+	defer failures.lck.Unlock()
+
+	if f, ok := failures.byRepo[name]; ok {
+		close(f.stop)
+		delete(failures.byRepo, name)
+	}
+}
+
+// Remember that the named repo failed to start with err.
+func recordFailure(dbpath, name string, repo *config.Repo, lim *limiter, err error) {
+	failures.lck.Lock()
+	defer failures.lck.Unlock()
+
+	now := time.Now()
+
+	f := &failedRepo{
+		status: FailedRepo{
+			Repo:        name,
+			FailedSince: now,
+		},
+		dbpath: dbpath,
+		repo:   repo,
+		lim:    lim,
+		stop:   make(chan empty),
+	}
+
+	// the repo was started again, which replaces any earlier failure
+	if old, ok := failures.byRepo[name]; ok {
+		close(old.stop)
+		f.status.FailedSince = old.status.FailedSince
+		f.status.Attempts = old.status.Attempts
+	}
+	failures.byRepo[name] = f
+
+	f.failed(name, err, now)
+}
+
+// A retry of the named repo failed with err. Nothing is recorded if the repo
+// was forgotten in the meantime.
+func (f *failedRepo) failedAgain(name string, err error) {
+	failures.lck.Lock()
+	defer failures.lck.Unlock()
+
+	if failures.byRepo[name] == f {
+		f.failed(name, err, time.Now())
+	}
+}
+
+// Must be called with the lock held.
+func (f *failedRepo) failed(name string, err error, now time.Time) {
+	f.status.Error = err.Error()
+	f.status.Attempts++
+	f.status.LastFailure = now
+
+	if failures.recovered != nil {
+		f.scheduleRetry(name)
+	}
+}
+
+// The searcher of the named repo started after all, returns whether it's
+// still wanted. It isn't if the repo was forgotten in the meantime.
+func recoverFailure(name string, f *failedRepo) bool {
+	failures.lck.Lock()
+	defer failures.lck.Unlock()
+
+	if failures.byRepo[name] != f {
+		return false
+	}
+
+	delete(failures.byRepo, name)
+	return true
+}
+
+// Retry the repo once the delay for the attempts so far has passed. Must be
+// called with the lock held.
+func (f *failedRepo) scheduleRetry(name string) {
+	delay := minRetryDelay
+	for i := 1; i < f.status.Attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	f.status.NextRetry = time.Now().Add(delay)
+	go f.retry(name, delay)
+}
+
+func (f *failedRepo) retry(name string, delay time.Duration) {
+	select {
+	case <-f.stop:
+		return
+	case <-time.After(delay):
+	}
+
+	logger.Info("retrying searcher", logger.Fields{
+		"event": "retry",
+		"repo":  name,
+	})
+
+	refs, err := findExistingRefs(storageFor(f.dbpath))
+	if err != nil {
+		f.failedAgain(name, err)
+		return
+	}
+
+	f.lim.Acquire(f.repo.IndexWeight)
+	s, err := newSearcher(f.dbpath, name, f.repo, refs, f.lim)
+	f.lim.Release(f.repo.IndexWeight)
+
+	if err != nil {
+		logger.Error("searcher failed to start", logger.Fields{
+			"repo":  name,
+			"error": err,
+		})
+		f.failedAgain(name, err)
+		return
+	}
+
+	s.begin()
+
+	if !recoverFailure(name, f) {
+		s.Stop()
+		return
+	}
+
+	logger.Info("searcher recovered", logger.Fields{
+		"event": "retry",
+		"repo":  name,
+	})
+
+	failures.lck.Lock()
+	fn := failures.recovered
+	failures.lck.Unlock()
+
+	fn(name, s)
+}
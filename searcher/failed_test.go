@@ -0,0 +1,94 @@
+package searcher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/etsy/hound/config"
+)
+
+func TestRetryFailed(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	defer func(min time.Duration) {
+		minRetryDelay = min
+	}(minRetryDelay)
+	minRetryDelay = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the repo doesn't exist yet, so the first clone fails
+	src := filepath.Join(dir, "src")
+	dbpath := filepath.Join(dir, "db")
+	if err := os.Mkdir(dbpath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"dbpath": dbpath,
+		"repos": map[string]interface{}{
+			"a": map[string]interface{}{
+				"url":                 "file://" + src,
+				"enable-poll-updates": false,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromBytes(b, false); err != nil {
+		t.Fatal(err)
+	}
+	defer ForgetFailed("a")
+
+	searchers, errs, err := MakeAll(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(searchers) != 0 || errs["a"] == nil {
+		t.Fatalf("expected a to fail, got %v and %v", searchers, errs)
+	}
+
+	failed := Failed()
+	if len(failed) != 1 || failed[0].Repo != "a" || failed[0].Attempts != 1 || failed[0].Error == "" {
+		t.Fatalf("expected a to be failed once, got %+v", failed)
+	}
+
+	runGit(t, "", "init", "-q", src)
+	commitFile(t, src, "a.txt", "a\n")
+	runGit(t, src, "branch", "-M", "master")
+
+	recovered := make(chan *Searcher, 1)
+	RetryFailed(func(name string, s *Searcher) {
+		if name == "a" {
+			recovered <- s
+		}
+	})
+
+	select {
+	case s := <-recovered:
+		defer s.Stop()
+		if s.IndexRef().Files != 1 {
+			t.Fatalf("expected 1 file indexed, got %d", s.IndexRef().Files)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("expected a to be retried")
+	}
+
+	if failed := Failed(); len(failed) != 0 {
+		t.Fatalf("expected no failed repos, got %+v", failed)
+	}
+}
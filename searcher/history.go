@@ -0,0 +1,417 @@
+package searcher
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
+	"github.com/etsy/hound/vcs"
+)
+
+// The most indexes of revisions other than the live ones that are kept
+// around for searches. Beyond that, the ones searched least recently are
+// removed once the searches that are using them are done.
+const maxHistoricalIndexes = 4
+
+// How many indexes of other revisions can be built in a burst, and how often
+// one more can be built after that. Any search can ask for a revision, and
+// each one that isn't kept around is a whole export and index build.
+const (
+	maxHistoricalBurst      = 4
+	historicalBuildInterval = time.Minute
+)
+
+// How many ranges of revisions can be diffed in a burst, and how often one
+// more can be diffed after that. A diff can fetch the history of both of
+// its ends, a few thousand commits each.
+const (
+	maxDiffBurst = 16
+	diffInterval = 15 * time.Second
+)
+
+// Returned by SearchRev for repos whose index can't be built for a single
+// revision.
+var ErrRevNotSupported = errors.New("searching another revision is not supported for this repo")
+
+// Returned by SearchRev for a revision the repo's vcs can't find, not even
+// by fetching it.
+type UnknownRevError struct {
+	Rev string
+	Err error
+}
+
+func (e *UnknownRevError) Error() string {
+	return fmt.Sprintf("unknown revision %q: %s", e.Rev, e.Err)
+}
+
+// Returned by SearchRev when the index of the revision would have to be
+// built, but too many indexes of other revisions were built lately, see
+// maxHistoricalBurst.
+var ErrTooManyRevBuilds = errors.New("too many other revisions were indexed lately, try again later")
+
+// Returned by DiffFiles past maxDiffBurst.
+var ErrTooManyDiffs = errors.New("too many ranges of revisions were diffed lately, try again later")
+
+// Returned by DiffFiles for repos that can't tell which files a range of
+// revisions changed.
+var ErrDiffNotSupported = errors.New("searching the files changed in a range of revisions is not supported for this repo")
+
+// The index of an older revision of a repo.
+type historicalIndex struct {
+	key string
+	idx *index.Index
+
+	// The searches that are using the index, it isn't closed until they
+	// are done, even if it's evicted.
+	users   int
+	evicted bool
+
+	// The scratch directory the index was built in, empty for an index that
+	// was found in the dbpath, which is only closed and left for the sweeper.
+	scratch string
+
+	// The searcher of the repo, see evictHistorical.
+	owner *Searcher
+}
+
+// The historical indexes, least recently searched last.
+var history = struct {
+	lck     sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+}{
+	size:    maxHistoricalIndexes,
+	lru:     list.New(),
+	entries: map[string]*list.Element{},
+}
+
+func historyKey(url, rev string) string {
+	return url + "@" + rev
+}
+
+// Get the historical index for key, the caller has to release it when done.
+// Returns nil if there is none.
+func acquireHistorical(key string) *historicalIndex {
+	history.lck.Lock()
+	defer history.lck.Unlock()
+
+	e, ok := history.entries[key]
+	if !ok {
+		return nil
+	}
+
+	history.lru.MoveToFront(e)
+	h := e.Value.(*historicalIndex)
+	h.users++
+	return h
+}
+
+// Keep h for later searches, evicting the least recently searched indexes if
+// there are too many. h is acquired for the caller.
+func addHistorical(h *historicalIndex) {
+	var evicted []*historicalIndex
+
+	history.lck.Lock()
+	h.users++
+	history.entries[h.key] = history.lru.PushFront(h)
+
+	for history.lru.Len() > history.size {
+		e := history.lru.Back()
+		history.lru.Remove(e)
+
+		o := e.Value.(*historicalIndex)
+		delete(history.entries, o.key)
+		o.evicted = true
+		if o.users == 0 {
+			evicted = append(evicted, o)
+		}
+	}
+	history.lck.Unlock()
+
+	for _, o := range evicted {
+		o.remove()
+	}
+}
+
+// Evict the historical indexes of s, which is being stopped. They are removed
+// once the searches that are using them are done.
+func evictHistorical(s *Searcher) {
+	var evicted []*historicalIndex
+
+	history.lck.Lock()
+	for e := history.lru.Front(); e != nil; {
+		next := e.Next()
+
+		h := e.Value.(*historicalIndex)
+		if h.owner == s {
+			history.lru.Remove(e)
+			delete(history.entries, h.key)
+			h.evicted = true
+			if h.users == 0 {
+				evicted = append(evicted, h)
+			}
+		}
+		e = next
+	}
+	history.lck.Unlock()
+
+	for _, h := range evicted {
+		h.remove()
+	}
+}
+
+// What's left of a burst of expensive work, which refills by one every
+// interval up to max.
+type burst struct {
+	lck      sync.Mutex
+	tokens   float64
+	last     time.Time
+	max      float64
+	interval time.Duration
+}
+
+func newBurst(max int, interval time.Duration) *burst {
+	return &burst{
+		tokens:   float64(max),
+		max:      float64(max),
+		interval: interval,
+	}
+}
+
+// Take one out of the burst, reports whether there was one left.
+func (b *burst) take() bool {
+	b.lck.Lock()
+	defer b.lck.Unlock()
+
+	now := time.Now()
+	if !b.last.IsZero() {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+		if b.tokens > b.max {
+			b.tokens = b.max
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// The builds of historical indexes and the diffs left in their bursts.
+var (
+	historyBuilds = newBurst(maxHistoricalBurst, historicalBuildInterval)
+	diffs         = newBurst(maxDiffBurst, diffInterval)
+)
+
+// Take a build of a historical index out of the burst, reports whether there
+// was one left.
+func takeHistoricalBuild() bool {
+	return historyBuilds.take()
+}
+
+// Done searching h, it's removed if it was evicted in the meantime.
+func releaseHistorical(h *historicalIndex) {
+	history.lck.Lock()
+	h.users--
+	done := h.evicted && h.users == 0
+	history.lck.Unlock()
+
+	if done {
+		h.remove()
+	}
+}
+
+func (h *historicalIndex) remove() {
+	if h.scratch == "" {
+		h.idx.Close()
+		building.remove(h.idx.Ref.Dir())
+		return
+	}
+
+	h.idx.Close()
+	if err := os.RemoveAll(h.scratch); err != nil {
+		logger.Error("failed to remove historical index", logger.Fields{
+			"dir":   h.scratch,
+			"error": err,
+		})
+	}
+}
+
+// Search the repo as it was at rev, which can be anything the repo's vcs
+// takes for a revision. The live index is searched if rev is empty or names
+// the revision it's at. Otherwise an index of rev is built (or an existing
+// one is reused), which takes a turn of the indexer limit like any other
+// build, and fails with ErrTooManyRevBuilds past maxHistoricalBurst.
+func (s *Searcher) SearchRev(pat string, opt *index.SearchOptions, rev string) (*index.SearchResponse, error) {
+	if rev == "" {
+		return s.Search(pat, opt, nil)
+	}
+
+	// the index of a multi-root repo spans many repos, each at its own
+	// revision
+	if s.Repo.IsHidden() || s.HasVRepos() {
+		return nil, ErrRevNotSupported
+	}
+
+	full, err := s.wd.ResolveRev(s.vcsDir, rev)
+	if err == vcs.ErrExportNotSupported {
+		return nil, ErrRevNotSupported
+	} else if err != nil {
+		return nil, &UnknownRevError{rev, err}
+	}
+
+	if full == s.IndexRef().Rev {
+		return s.Search(pat, opt, nil)
+	}
+
+	h, err := s.historicalIndex(full)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseHistorical(h)
+
+	atomic.StoreInt64(&s.lastSearch, time.Now().UnixNano())
+
+	return h.idx.Search(pat, opt, nil)
+}
+
+// The files that the range of revisions rng (base..head or base...head, see
+// vcs.DiffDriver) changed, by their names in the index. A range that can't be
+// made sense of is a *vcs.RangeError, and past maxDiffBurst it fails with
+// ErrTooManyDiffs.
+func (s *Searcher) DiffFiles(rng string) ([]string, error) {
+	if s.Repo.IsHidden() || s.HasVRepos() {
+		return nil, ErrDiffNotSupported
+	}
+
+	if !diffs.take() {
+		return nil, ErrTooManyDiffs
+	}
+
+	files, err := s.wd.DiffFiles(s.vcsDir, rng)
+	if err == vcs.ErrDiffNotSupported {
+		return nil, ErrDiffNotSupported
+	}
+	return files, err
+}
+
+// Get the index of the repo at the full revision rev, building it if there
+// isn't one yet. The caller has to release it.
+func (s *Searcher) historicalIndex(rev string) (*historicalIndex, error) {
+	key := historyKey(s.Repo.Url, rev)
+	if h := acquireHistorical(key); h != nil {
+		return h, nil
+	}
+
+	// only one of the searches that ask for the same revision builds it
+	s.historyLck.Lock()
+	defer s.historyLck.Unlock()
+
+	if h := acquireHistorical(key); h != nil {
+		return h, nil
+	}
+
+	s.lim.Acquire(s.Repo.IndexWeight)
+	defer s.lim.Release(s.Repo.IndexWeight)
+
+	h, err := s.openHistorical(key, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	addHistorical(h)
+	return h, nil
+}
+
+// Open the index of the repo at rev that's in the dbpath, if there is one,
+// or build it in a scratch directory outside of it.
+func (s *Searcher) openHistorical(key, rev string) (*historicalIndex, error) {
+	store := storageFor(s.dbpath)
+	refs, err := findExistingRefs(store)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref := refs.find(s.Repo.Url, rev); ref != nil {
+		// keep the sweeper away from the index while it's in use
+		building.add(ref.Dir())
+
+		idx, err := store.Open(ref)
+		if err == nil {
+			return &historicalIndex{key: key, idx: idx, owner: s}, nil
+		}
+		building.remove(ref.Dir())
+	}
+
+	if !takeHistoricalBuild() {
+		return nil, ErrTooManyRevBuilds
+	}
+
+	scratch, err := ioutil.TempDir("", "hound-rev")
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.buildHistorical(scratch, rev)
+	if err != nil {
+		os.RemoveAll(scratch)
+		return nil, err
+	}
+
+	return &historicalIndex{
+		key:     key,
+		idx:     idx,
+		scratch: scratch,
+		owner:   s,
+	}, nil
+}
+
+func (s *Searcher) buildHistorical(scratch, rev string) (*index.Index, error) {
+	src := filepath.Join(scratch, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := s.wd.Export(s.vcsDir, rev, src); err != nil {
+		return nil, err
+	}
+
+	logger.Info("building index of an older revision", logger.Fields{
+		"event": "history",
+		"url":   vcs.ScrubUrl(s.Repo.Url),
+		"rev":   rev,
+	})
+
+	name := fmt.Sprintf("%s@%s", s.name, shortRev(rev))
+	// the index is thrown away with the scratch directory, it isn't kept
+	// in the dbpath's storage
+	return buildAndOpenIndex(
+		indexOptions(s.Repo, s.wd),
+		index.NewLocalStorage(scratch),
+		src,
+		filepath.Join(scratch, "idx"),
+		s.Repo.Url,
+		rev,
+		name,
+		s.IndexRef().Files)
+}
+
+// The first few characters of a revision, which is all that's needed to
+// tell it apart in logs and build progress.
+func shortRev(rev string) string {
+	if len(rev) > 12 {
+		return rev[:12]
+	}
+	return rev
+}
@@ -0,0 +1,110 @@
+package searcher
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
+)
+
+// How often the progress of a build is logged.
+const progressLogInterval = 10 * time.Second
+
+// The progress of an index build that is under way.
+type BuildStatus struct {
+	Repo    string
+	Started time.Time
+
+	// The number of files indexed so far and their total size in bytes.
+	Files int
+	Bytes int64
+
+	// The number of files in the repo's previous index, which is the best
+	// guess there is of how many files this build will index, and how far
+	// along the build is compared to that. Both are 0 for a first build.
+	ExpectedFiles int     `json:",omitempty"`
+	Percent       float64 `json:",omitempty"`
+
+	logged time.Time
+}
+
+var builds = struct {
+	lck    sync.Mutex
+	byRepo map[string]*BuildStatus
+}{byRepo: map[string]*BuildStatus{}}
+
+// The builds that are under way, ordered by repo.
+func Builds() []*BuildStatus {
+	builds.lck.Lock()
+	defer builds.lck.Unlock()
+
+	res := make([]*BuildStatus, 0, len(builds.byRepo))
+	for _, b := range builds.byRepo {
+		c := *b
+		res = append(res, &c)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Repo < res[j].Repo
+	})
+	return res
+}
+
+// Start tracking a build of the named repo. The returned options report the
+// build's progress, the returned func must be called once the build is done.
+func trackBuild(name string, expected int, opt *index.IndexOptions) (*index.IndexOptions, func()) {
+	now := time.Now()
+	status := &BuildStatus{
+		Repo:          name,
+		Started:       now,
+		ExpectedFiles: expected,
+		logged:        now,
+	}
+
+	builds.lck.Lock()
+	builds.byRepo[name] = status
+	builds.lck.Unlock()
+
+	o := *opt
+	o.Progress = func(p index.BuildProgress) {
+		builds.lck.Lock()
+		defer builds.lck.Unlock()
+
+		status.Files = p.Files
+		status.Bytes = p.Bytes
+		if status.ExpectedFiles > 0 {
+			// the repo may have grown since, so never claim to be done
+			status.Percent = 100 * float64(p.Files) / float64(status.ExpectedFiles)
+			if status.Percent > 99 {
+				status.Percent = 99
+			}
+		}
+
+		if time.Since(status.logged) < progressLogInterval {
+			return
+		}
+		status.logged = time.Now()
+
+		fields := logger.Fields{
+			"event": "progress",
+			"repo":  name,
+			"files": p.Files,
+			"bytes": p.Bytes,
+		}
+		if status.ExpectedFiles > 0 {
+			fields["percent"] = fmt.Sprintf("%0.0f", status.Percent)
+		}
+		logger.Info("indexing", fields)
+	}
+
+	return &o, func() {
+		builds.lck.Lock()
+		defer builds.lck.Unlock()
+		if builds.byRepo[name] == status {
+			delete(builds.byRepo, name)
+		}
+	}
+}
@@ -1,36 +1,83 @@
 package searcher
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 	"strings"
 	"encoding/json"
 
 	"github.com/etsy/hound/config"
 	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
 	"github.com/etsy/hound/vcs"
 )
 
+// When more than this fraction of a repo's files change between revisions,
+// the index is built from scratch rather than updated.
+const maxChangedFraction = 0.25
+
 type Searcher struct {
 	idx  *index.Index
 	lck  sync.RWMutex
 	Repo *config.Repo
 	vrepos map[string]string
 
+	// The repo's config as the last reload left it, see Config.
+	config atomic.Value
+
+	// Changes every time a new index goes live, see Generation.
+	gen uint64
+
+	// When the searcher was last searched, in unix nanoseconds.
+	lastSearch int64
+
+	// When the index was last compacted, in unix nanoseconds, see
+	// compactWhenIdle.
+	lastCompaction int64
+
+	// Held while a new index is built for the repo, so that an update and
+	// a compaction never build one at the same time. It is taken before
+	// the limiter.
+	indexLck sync.Mutex
+
+	// The working directory of the repo, used for blame. The blames are
+	// those of the current index and are replaced along with it.
+	wd     *vcs.WorkDir
+	vcsDir string
+	blames *blameCache
+
+	// Backs off the polls of a repo whose pulls keep failing, see Circuit.
+	circuit circuit
+
+	// What's needed to build the indexes of older revisions, see SearchRev.
+	name       string
+	dbpath     string
+	lim        *limiter
+	historyLck sync.Mutex
+
 	// The channel is used to request updates from the API and
 	// to signal that it is ok for searchers to begin polling.
 	// It has a buffer size of 1 to allow at most one pending
 	// update at a time.
 	updateCh chan time.Time
 
-	shutdownRequested bool
+	// The channels of the requests waiting for the next update to finish,
+	// see UpdateAndNotify.
+	waitLck sync.Mutex
+	waiters []chan *UpdateResult
+
+	// Set (atomically) once Stop is called, the poller reads it between
+	// updates.
+	shutdownRequested int32
 	shutdownCh        chan empty
 	doneCh            chan empty
 }
@@ -44,28 +91,214 @@ type searcherResult struct {
 	err      error
 }
 
+// The source of searcher generations, shared by all searchers so that a
+// generation is never reused, not even by a searcher replaced on reload.
+var generations uint64
+
+func nextGeneration() uint64 {
+	return atomic.AddUint64(&generations, 1)
+}
+
+// What happens after every reindex, see SetReportMemory, SetGCAfterReindex
+// and SetGCInterval.
+var afterReindex = struct {
+	reportMemory int32
+	gc           int32
+
+	// the least time between collections in nanoseconds, 0 collects inline
+	interval int64
+
+	// holds a collection that's waiting its turn, see collectAfterReindexes
+	pending chan empty
+	once    sync.Once
+}{
+	gc:       1,
+	interval: int64(defaultGCInterval),
+	pending:  make(chan empty, 1),
+}
+
+const defaultGCInterval = 30 * time.Second
+
+func setFlag(f *int32, v bool) {
+	var n int32
+	if v {
+		n = 1
+	}
+	atomic.StoreInt32(f, n)
+}
+
+// Log the size of the heap after every reindex, off by default.
+func SetReportMemory(v bool) {
+	setFlag(&afterReindex.reportMemory, v)
+}
+
+// Force a garbage collection after every reindex, on by default. On busy
+// servers it can be better to leave it to the runtime.
+func SetGCAfterReindex(v bool) {
+	setFlag(&afterReindex.gc, v)
+}
+
+// Collect garbage (and report on memory) after reindexes in the background,
+// at most once every d however many repos were reindexed in the meantime,
+// 30 seconds by default. With 0 every reindex collects right away in its
+// repo's poller, as it used to.
+func SetGCInterval(d time.Duration) {
+	atomic.StoreInt64(&afterReindex.interval, int64(d))
+}
+
+// Called after every reindex. This is just a good time to GC since we know
+// there will be a whole set of dead posting lists on the heap. Ensuring these
+// go away quickly helps to prevent the heap from expanding uncessarily.
+func reindexed() {
+	if atomic.LoadInt32(&afterReindex.gc) == 0 &&
+		atomic.LoadInt32(&afterReindex.reportMemory) == 0 {
+		return
+	}
+
+	if atomic.LoadInt64(&afterReindex.interval) <= 0 {
+		collectGarbage()
+		return
+	}
+
+	afterReindex.once.Do(func() {
+		go collectAfterReindexes()
+	})
+
+	// a collection that's already waiting covers this reindex too
+	select {
+	case afterReindex.pending <- empty{}:
+	default:
+	}
+}
+
+func collectGarbage() {
+	if atomic.LoadInt32(&afterReindex.gc) != 0 {
+		runtime.GC()
+	}
+
+	if atomic.LoadInt32(&afterReindex.reportMemory) != 0 {
+		reportOnMemory()
+	}
+}
+
+// Collect once for every request of reindexed, waiting out the interval
+// after each collection so that the reindexes in the meantime share the next
+// one.
+func collectAfterReindexes() {
+	for range afterReindex.pending {
+		collectGarbage()
+		time.Sleep(time.Duration(atomic.LoadInt64(&afterReindex.interval)))
+	}
+}
+
+// Makes the storage of the indexes of a dbpath, see SetStorage.
+var newStorage = func(dbpath string) index.Storage {
+	return index.NewLocalStorage(dbpath)
+}
+
+// Keep the indexes somewhere other than in the directories of the dbpath.
+// fn is asked for the storage of a dbpath whenever it's needed, so it has
+// to hand out the same one each time. Set it before making any searchers.
+func SetStorage(fn func(dbpath string) index.Storage) {
+	newStorage = fn
+}
+
+func storageFor(dbpath string) index.Storage {
+	return newStorage(dbpath)
+}
+
 type empty struct{}
-type limiter chan bool
+
+// Given to the requests waiting on an update when the searcher is stopped
+// before the update is done.
+var ErrStopped = errors.New("the searcher was stopped")
+
+// What an update that was waited on came to, see UpdateAndNotify.
+type UpdateResult struct {
+	// The revision that is searchable now that the update is done.
+	Revision string
+
+	// Whether the index was rebuilt, false when nothing changed.
+	Changed bool
+
+	// Why the update failed, if it did.
+	Err error
+}
 
 /**
  * Holds a set of IndexRefs that were found in the dbpath at startup,
  * these indexes can be 'claimed' and re-used by newly created searchers.
  */
 type foundRefs struct {
+	store   index.Storage
 	refs    []*index.IndexRef
 	claimed map[*index.IndexRef]bool
 }
 
-func makeLimiter(n int) limiter {
-	return limiter(make(chan bool, n))
+// A weighted semaphore that lets holders in as long as their weights add
+// up to no more than its size. Holders are let in in the order they came,
+// so a heavy one waiting for room isn't overtaken by light ones forever.
+type limiter struct {
+	lck  sync.Mutex
+	cond *sync.Cond
+	size int
+	used int
+
+	// The turn of the next holder to come and of the one let in next.
+	next    uint64
+	serving uint64
 }
 
-func (l limiter) Acquire() {
-	l <- true
+// A limiter with a total weight of n. Configs that weren't loaded from a
+// file may not have a limit, which would block every Acquire, so at least
+// one is always let in.
+func makeLimiter(n int) *limiter {
+	if n < 1 {
+		n = 1
+	}
+	l := &limiter{size: n}
+	l.cond = sync.NewCond(&l.lck)
+	return l
 }
 
-func (l limiter) Release() {
-	<-l
+// Every holder weighs at least 1, and a holder heavier than the whole
+// limiter takes all of it instead of waiting forever.
+func (l *limiter) weight(w int) int {
+	if w < 1 {
+		return 1
+	}
+	if w > l.size {
+		return l.size
+	}
+	return w
+}
+
+// Wait until there's room for a holder of weight w.
+func (l *limiter) Acquire(w int) {
+	w = l.weight(w)
+
+	l.lck.Lock()
+	defer l.lck.Unlock()
+
+	turn := l.next
+	l.next++
+	for l.serving != turn || l.used+w > l.size {
+		l.cond.Wait()
+	}
+	l.used += w
+	l.serving++
+	l.cond.Broadcast()
+}
+
+// Give back the room a holder of weight w took.
+func (l *limiter) Release(w int) {
+	w = l.weight(w)
+
+	l.lck.Lock()
+	defer l.lck.Unlock()
+
+	l.used -= w
+	l.cond.Broadcast()
 }
 
 /**
@@ -81,6 +314,24 @@ func (r *foundRefs) find(url, rev string) *index.IndexRef {
 	return nil
 }
 
+/**
+ * Find the most recently built Index ref for the repo url, returns nil
+ * if there are no refs for the url.
+ */
+func (r *foundRefs) findLatest(url string) *index.IndexRef {
+	var latest *index.IndexRef
+	for _, ref := range r.refs {
+		if ref.Url != url {
+			continue
+		}
+
+		if latest == nil || ref.Time.After(latest.Time) {
+			latest = ref
+		}
+	}
+	return latest
+}
+
 /**
  * Claim a ref for reuse. This ensures they ref will not be garbage
  * collected at the end of startup.
@@ -99,7 +350,7 @@ func (r *foundRefs) removeUnclaimed() error {
 			continue
 		}
 
-		if err := ref.Remove(); err != nil {
+		if err := r.store.Remove(ref); err != nil {
 			return err
 		}
 	}
@@ -107,15 +358,34 @@ func (r *foundRefs) removeUnclaimed() error {
 }
 
 // Perform atomic swap of index in the searcher so that the new
-// index is made "live".
-func (s *Searcher) swapIndexes(idx *index.Index) error {
+// index is made "live". The repo's marker is moved to the new index first
+// (see currentMarker), if that fails the old index stays live. Once the new
+// one is, the swap is done, an old index that can't be removed is left to the
+// sweeper.
+func (s *Searcher) swapIndexes(idx *index.Index, vrepos map[string]string) error {
+	if err := writeCurrent(s.dbpath, s.name, idx.Ref.Dir()); err != nil {
+		return err
+	}
+
 	s.lck.Lock()
 	defer s.lck.Unlock()
 
 	oldIdx := s.idx
 	s.idx = idx
-
-	return oldIdx.Destroy()
+	s.vrepos = vrepos
+	s.gen = nextGeneration()
+
+	// the checkouts of virtual repos are blamed as they are, so their
+	// blame is only good for the index they were searched in
+	s.blames = newBlameCache()
+
+	if err := oldIdx.Destroy(); err != nil {
+		logger.Error("failed to destroy index", logger.Fields{
+			"repo":  s.name,
+			"error": err,
+		})
+	}
+	return nil
 }
 
 // Perform a basic search on the current index using the supplied pattern
@@ -125,38 +395,212 @@ func (s *Searcher) swapIndexes(idx *index.Index) error {
 func (s *Searcher) Search(pat string, opt *index.SearchOptions, vrepos []string) (*index.SearchResponse, error) {
 	s.lck.RLock()
 	defer s.lck.RUnlock()
-	return s.idx.Search(pat, opt, vrepos)
+
+	atomic.StoreInt64(&s.lastSearch, time.Now().UnixNano())
+
+	res, err := s.idx.Search(pat, opt, vrepos)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.Blame {
+		s.blameMatches(res)
+	}
+
+	return res, nil
+}
+
+// Explain how the current index would carry out a search for pat.
+func (s *Searcher) Explain(pat string, opt *index.SearchOptions) (*index.Explanation, error) {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+	return s.idx.Explain(pat, opt)
+}
+
+// Suggest the tokens in the current index that start with prefix, see
+// index.Suggest.
+func (s *Searcher) Suggest(prefix string, limit int) []*index.Suggestion {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+	return s.idx.Suggest(prefix, limit)
+}
+
+// The tokens in the current index that are close to token, see
+// index.Similar.
+func (s *Searcher) Similar(token string, limit int) []*index.SimilarToken {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+	return s.idx.Similar(token, limit)
+}
+
+// Find the files in the current index whose paths best match pat, see
+// index.Find.
+func (s *Searcher) Find(pat string, limit int, vrepos []string) []*index.FoundFile {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+	return s.idx.Find(pat, limit, vrepos)
+}
+
+// Page the live index into memory, see index.Warmup.
+func (s *Searcher) Warmup() (int64, error) {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+	return s.idx.Warmup()
+}
+
+// A number that identifies the index that is currently live, it changes
+// whenever a new index is swapped in. Anything derived from the index can be
+// cached for as long as the generation stays the same.
+func (s *Searcher) Generation() uint64 {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+	return s.gen
+}
+
+// When the searcher was last searched, the zero time if it never was.
+func (s *Searcher) LastSearched() time.Time {
+	if t := atomic.LoadInt64(&s.lastSearch); t != 0 {
+		return time.Unix(0, t)
+	}
+	return time.Time{}
+}
+
+// Get the ref of the index that is currently live.
+func (s *Searcher) IndexRef() *index.IndexRef {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+	return s.idx.Ref
+}
+
+// Read a file from the live index, returns the contents and the revision
+// they are from. For searchers with virtual repos, vrepo is the virtual repo
+// (org/repo) the file is in and branch the branch it is on, which defaults
+// to the branch the virtual repo was last indexed at.
+func (s *Searcher) ReadFile(vrepo, branch, path string) ([]byte, string, error) {
+	f, rev, err := s.OpenFile(vrepo, branch, path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	return b, rev, err
 }
 
-// Get the excluded files as a JSON string. This is only used for returning
-// the data directly to clients (thus JSON).
-func (s *Searcher) GetExcludedFiles(repo string) string {
+// Open a file in the live index for reading, see ReadFile.
+func (s *Searcher) OpenFile(vrepo, branch, path string) (*index.IndexedFile, string, error) {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+
+	if vrepo == "" {
+		f, err := s.idx.OpenFile(path)
+		return f, s.idx.Ref.Rev, err
+	}
+
+	if _, ok := s.vrepos[vrepo]; !ok {
+		return nil, "", fmt.Errorf("No such repository: %s", vrepo)
+	}
+
+	// vrepo has org/repo format, the files are under repo
+	name := filepath.Base(vrepo)
+	if s.idx.VRepoDepth < 2 {
+		f, err := s.idx.OpenFile(filepath.Join(name, path))
+		return f, s.vrepos[vrepo], err
+	}
+
+	if branch == "" {
+		branch = s.vrepos[vrepo]
+	}
+
+	if !index.IsValidPath(branch) || strings.Contains(branch, string(os.PathSeparator)) {
+		return nil, "", fmt.Errorf("Invalid branch: %s", branch)
+	}
+
+	f, err := s.idx.OpenFile(filepath.Join(name, branch, path))
+	return f, branch, err
+}
+
+// The sorted paths of the indexed files that start with prefix. For
+// searchers with virtual repos, the files are limited to those in vrepo on
+// branch, which defaults to the branch the virtual repo was last indexed at.
+func (s *Searcher) Files(vrepo, branch, prefix string) ([]string, error) {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+
+	if vrepo == "" {
+		return s.idx.Files(prefix), nil
+	}
+
+	if _, ok := s.vrepos[vrepo]; !ok {
+		return nil, fmt.Errorf("No such repository: %s", vrepo)
+	}
+
+	// vrepo has org/repo format, the files are under repo
+	dir := filepath.Base(vrepo)
+	if s.idx.VRepoDepth > 1 {
+		if branch == "" {
+			branch = s.vrepos[vrepo]
+		}
+
+		if !index.IsValidPath(branch) || strings.Contains(branch, string(os.PathSeparator)) {
+			return nil, fmt.Errorf("Invalid branch: %s", branch)
+		}
+		dir = filepath.Join(dir, branch)
+	}
+	dir += string(os.PathSeparator)
+
+	files := s.idx.Files(dir + prefix)
+	for i, file := range files {
+		files[i] = strings.TrimPrefix(file, dir)
+	}
+
+	return files, nil
+}
+
+// Get the excluded files as a JSON array. This is only used for returning
+// the data directly to clients (thus JSON). Fails if the list of excluded
+// files is missing from the index or can't be parsed.
+func (s *Searcher) GetExcludedFiles(repo string) (string, error) {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+
 	path := filepath.Join(s.idx.GetDir(), "excluded_files.json")
 	dat, err := ioutil.ReadFile(path)
 	if err != nil {
-		log.Printf("Couldn't read excluded_files.json %v\n", err)
+		return "", err
+	}
+
+	raw := []*index.ExcludedFile{}
+	if err := json.Unmarshal(dat, &raw); err != nil {
+		return "", fmt.Errorf("invalid excluded_files.json: %s", err)
+	}
+
+	// a null list is as good as an empty one
+	excluded := raw
+	if excluded == nil {
+		excluded = []*index.ExcludedFile{}
 	}
 
 	if repo != "" {
 		// repo has org/repo format, we only need to take base name 
 		repo = filepath.Base(repo)
-		excluded := []*index.ExcludedFile{}
-		raw := []*index.ExcludedFile{}
-		json.Unmarshal(dat, &raw)
+		excluded = []*index.ExcludedFile{}
+		depth := s.idx.VRepoDepth
 		for _, d := range raw {
-			if strings.Index(d.Filename, repo) == 0 {
-				// name has repo/branch/filename
-				names := strings.Split(d.Filename, string(os.PathSeparator))
-				d.Filename = filepath.Join(names[2:]...)
+			// name has repo/branch/filename or repo/filename
+			names := strings.Split(d.Filename, string(os.PathSeparator))
+			if len(names) > depth && names[0] == repo {
+				d.Filename = filepath.Join(names[depth:]...)
 				excluded = append(excluded, d)
 			}
 		}
-
-		out, _ := json.Marshal(excluded)
-		return string(out)
 	}
 
-	return string(dat)
+	out, err := json.Marshal(excluded)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
 }
 
 // Triggers an immediate poll of the repository.
@@ -180,13 +624,64 @@ func (s *Searcher) Update() bool {
 	return true
 }
 
+// Schedule an update like Update, and return a channel that is sent the
+// result once it's done. The update always starts after this is called, so
+// whatever was pushed before is searchable by the time it's sent. Returns
+// false if push updates are not enabled for the repo.
+func (s *Searcher) UpdateAndNotify() (<-chan *UpdateResult, bool) {
+	ch := make(chan *UpdateResult, 1)
+	if s.Repo == nil {
+		ch <- &UpdateResult{}
+		return ch, true
+	}
+
+	if !s.Repo.PushUpdatesEnabled() {
+		return nil, false
+	}
+
+	s.waitLck.Lock()
+	s.waiters = append(s.waiters, ch)
+	s.waitLck.Unlock()
+
+	s.Update()
+	return ch, true
+}
+
+// Take the channels of the requests waiting for an update, the update that is
+// about to start is theirs.
+func (s *Searcher) takeWaiters() []chan *UpdateResult {
+	s.waitLck.Lock()
+	defer s.waitLck.Unlock()
+
+	waiters := s.waiters
+	s.waiters = nil
+	return waiters
+}
+
+func (s *Searcher) hasWaiters() bool {
+	s.waitLck.Lock()
+	defer s.waitLck.Unlock()
+	return len(s.waiters) > 0
+}
+
+func notifyWaiters(waiters []chan *UpdateResult, res *UpdateResult) {
+	// the channels are buffered, so a request that gave up doesn't block
+	for _, ch := range waiters {
+		ch <- res
+	}
+}
+
 // Shut down the searcher cleanly, waiting for any indexing operations to complete.
 func (s *Searcher) Stop() {
 	select {
 	case s.shutdownCh <- empty{}:
-		s.shutdownRequested = true
+		atomic.StoreInt32(&s.shutdownRequested, 1)
 	default:
 	}
+
+	// the indexes of other revisions were built under the temp dir, which
+	// nothing else cleans up
+	evictHistorical(s)
 }
 
 // Blocks until the searcher's associated goroutine is stopped.
@@ -195,29 +690,71 @@ func (s *Searcher) Wait() {
 }
 
 func (s *Searcher) completeShutdown() {
+	// let a compaction under way finish first
+	s.indexLck.Lock()
+	defer s.indexLck.Unlock()
+
+	notifyWaiters(s.takeWaiters(), &UpdateResult{
+		Revision: s.Repo.Revision,
+		Err:      ErrStopped,
+	})
 	close(s.doneCh)
 }
 
-// Get searcher's virtual repos 
+// Get searcher's virtual repos, sorted by name
 func (s *Searcher) GetVRepos() []string {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+
 	var vrepos []string
 	for k, _ := range s.vrepos {
 		vrepos = append(vrepos, k)
 	}
+	sort.Strings(vrepos)
 
 	return vrepos
 }
 
 // Get searcher's revision
 func (s *Searcher) GetVRepoRev(repo string) string {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+
 	return s.vrepos[repo]
 }
 
+// The repo's config as the last reload left it, the parts that can change
+// without a restart (see config.Diff) are only current here and not in Repo.
+// Its Revision is not kept, that's in Repo.
+func (s *Searcher) Config() *config.Repo {
+	if repo, ok := s.config.Load().(*config.Repo); ok {
+		return repo
+	}
+	return s.Repo
+}
+
+// Apply a reloaded config of the repo that differs from the current one only
+// in what doesn't need a restart. repo replaces the config whole, so that a
+// request never sees a change that is half applied, and must not be changed
+// afterwards.
+func (s *Searcher) Reconfigure(repo *config.Repo) {
+	s.config.Store(repo)
+}
+
 // Get searcher's hidden attribute 
 func (s *Searcher) IsHidden() bool {
 	return s.Repo.IsHidden()
 }
 
+// Does the searcher expose its content as virtual repos (either because
+// it is hidden or because it is a multi-root repo)?
+func (s *Searcher) HasVRepos() bool {
+	s.lck.RLock()
+	defer s.lck.RUnlock()
+
+	return s.IsHidden() || len(s.vrepos) > 0
+}
+
 // Wait for either the delay period to expire or an update request to
 // arrive. Note that an empty delay will result in an infinite timeout.
 func (s *Searcher) waitForUpdate(delay time.Duration) {
@@ -239,53 +776,181 @@ func (s *Searcher) begin() {
 	s.updateCh <- time.Now()
 }
 
-// Generate a new index directory in the dbpath. The names are based
-// on pseudo-randomness with a time-based seed.
-func nextIndexDir(dbpath string) string {
-	r := uint64(rand.Uint32())<<32 | uint64(rand.Uint32())
-	return filepath.Join(dbpath, fmt.Sprintf("idx-%08x", r))
-}
-
-// Read the refs associated with each of the index dirs
-// in the given dbpath.
-func findExistingRefs(dbpath string) (*foundRefs, error) {
-	dirs, err := filepath.Glob(filepath.Join(dbpath, "idx-*"))
+// Read the refs of the indexes kept in store.
+func findExistingRefs(store index.Storage) (*foundRefs, error) {
+	refs, err := store.List()
 	if err != nil {
 		return nil, err
 	}
 
-	var refs []*index.IndexRef
-	for _, dir := range dirs {
-		r, _ := index.Read(dir)
-		refs = append(refs, r)
-	}
-
 	return &foundRefs{
+		store:   store,
 		refs:    refs,
 		claimed: map[*index.IndexRef]bool{},
 	}, nil
 }
 
+// Open the index in dir, which is kept in store. An index without a
+// readable manifest is treated as corrupt, like index.Open does.
+func openIndex(store index.Storage, dir string) (*index.Index, error) {
+	r, err := index.Read(dir)
+	if err != nil {
+		return nil, &index.CorruptIndexError{Dir: dir, Err: err}
+	}
+	return store.Open(r)
+}
+
 // Open an index at the given path. If the idxDir is already present, it will
 // simply open and use that index. If, however, the idxDir does not exist a new
-// one will be built.
+// one will be built. An existing index that turns out to be corrupt is removed
+// and a new one is built in its place. The build's progress is reported under
+// name, expected is the number of files it's likely to index (0 if unknown).
 func buildAndOpenIndex(
 	opt *index.IndexOptions,
-	dbpath,
+	store index.Storage,
 	vcsDir,
 	idxDir,
 	url,
-	rev string) (*index.Index, error) {
+	rev,
+	name string,
+	expected int) (*index.Index, error) {
 	if _, err := os.Stat(idxDir); err != nil {
+		// keep the sweeper away from the index while it's being built
+		building.add(idxDir)
+		defer building.remove(idxDir)
+
+		opt, done := trackBuild(name, expected, opt)
+		defer done()
+
 		r, err := index.Build(opt, idxDir, vcsDir, url, rev)
 		if err != nil {
 			return nil, err
 		}
 
-		return r.Open()
+		if err := store.Save(r); err != nil {
+			return nil, err
+		}
+
+		return store.Open(r)
+	}
+
+	idx, err := openIndex(store, idxDir)
+	if _, ok := err.(*index.CorruptIndexError); ok {
+		logger.Error("corrupt index, rebuilding", logger.Fields{
+			"event": "corrupt",
+			"url":   vcs.ScrubUrl(url),
+			"rev":   rev,
+			"error": err,
+		})
+
+		ref, _ := index.Read(idxDir)
+		if err := store.Remove(ref); err != nil {
+			return nil, err
+		}
+
+		next, err := store.Create()
+		if err != nil {
+			return nil, err
+		}
+
+		return buildAndOpenIndex(opt, store, vcsDir, next, url, rev, name, expected)
 	}
 
-	return index.Open(idxDir)
+	return idx, err
+}
+
+// Build the index for rev by updating prev with just the files that changed
+// since it was built. Returns nil, after cleaning up, if the driver can't
+// say what changed or so much did that a full build is no slower, in which
+// case the index has to be built from scratch.
+func updateIndex(
+	opt *index.IndexOptions,
+	store index.Storage,
+	wd *vcs.WorkDir,
+	vcsDir string,
+	prev *index.IndexRef,
+	idxDir,
+	url,
+	rev,
+	name string) *index.Index {
+
+	changed, err := wd.Changes(vcsDir, prev.Rev, rev)
+	if err == vcs.ErrChangesNotSupported {
+		return nil
+	} else if err != nil {
+		logger.Warn("couldn't list changes, rebuilding index", logger.Fields{
+			"repo":  name,
+			"from":  prev.Rev,
+			"to":    rev,
+			"error": err,
+		})
+		return nil
+	}
+
+	if float64(len(changed)) > maxChangedFraction*float64(prev.Files) {
+		return nil
+	}
+
+	building.add(idxDir)
+	defer building.remove(idxDir)
+
+	logger.Info("updating index", logger.Fields{
+		"event":   "reindex",
+		"repo":    name,
+		"rev":     rev,
+		"changed": len(changed),
+	})
+
+	r, err := index.Update(opt, idxDir, wd.WorkTree(vcsDir), prev, url, rev, changed)
+	if err == nil {
+		err = store.Save(r)
+	}
+	if err == nil {
+		var idx *index.Index
+		if idx, err = store.Open(r); err == nil {
+			return idx
+		}
+	}
+
+	logger.Warn("failed index update, rebuilding index", logger.Fields{
+		"repo":  name,
+		"rev":   rev,
+		"error": err,
+	})
+
+	if err := os.RemoveAll(idxDir); err != nil {
+		logger.Error("failed to remove index", logger.Fields{
+			"repo":  name,
+			"error": err,
+		})
+	}
+	return nil
+}
+
+// When each file of the repo was last changed, for searches of the files
+// changed since a time, and when the dates start (0 if they go back all the
+// way, see vcs.ModTimesDriver). Returns nil if the driver can't date files.
+func fileModTimes(wd *vcs.WorkDir, vcsDir, name string) (map[string]int64, int64) {
+	times, since, err := wd.ModTimes(vcsDir)
+	if err == vcs.ErrModTimesNotSupported {
+		return nil, 0
+	} else if err != nil {
+		logger.Warn("couldn't date files", logger.Fields{
+			"repo":  name,
+			"error": err,
+		})
+		return nil, 0
+	}
+
+	res := make(map[string]int64, len(times))
+	for path, t := range times {
+		res[path] = t.Unix()
+	}
+
+	if since.IsZero() {
+		return res, 0
+	}
+	return res, since.Unix()
 }
 
 // Simply prints out statistics about the heap. When hound rebuilds a new
@@ -296,15 +961,31 @@ func reportOnMemory() {
 
 	// Print out interesting heap info.
 	runtime.ReadMemStats(&ms)
-	fmt.Printf("HeapInUse = %0.2f\n", float64(ms.HeapInuse)/1e6)
-	fmt.Printf("HeapIdle  = %0.2f\n", float64(ms.HeapIdle)/1e6)
+	logger.Info("memory", logger.Fields{
+		"event":     "memory",
+		"heapInUse": fmt.Sprintf("%0.2f", float64(ms.HeapInuse)/1e6),
+		"heapIdle":  fmt.Sprintf("%0.2f", float64(ms.HeapIdle)/1e6),
+	})
 }
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-// Make a searcher for each repo in the Config. This function kind of has a notion
+// Leave a disabled repo without a searcher. Its latest index is claimed so
+// that it's still there when the repo is enabled again.
+func skipDisabled(dbpath, name string, repo *config.Repo, refs *foundRefs) {
+	logger.Info("repo is disabled", logger.Fields{
+		"event": "start",
+		"repo":  name,
+	})
+
+	if ref := refs.findLatestCurrent(repo.Url, readCurrent(dbpath, name)); ref != nil {
+		refs.claim(ref)
+	}
+}
+
+// Make a searcher for each enabled repo in the Config. This function kind of has a notion
 // of partial errors. First, if the error returned is non-nil then a fatal error has
 // occurred and no other return values are valid. If an error occurs that is specific
 // to a particular searcher, that searcher will not be present in the searcher map and
@@ -313,20 +994,31 @@ func MakeAll(cfg *config.Config) (map[string]*Searcher, map[string]error, error)
 	errs := map[string]error{}
 	searchers := map[string]*Searcher{}
 
-	refs, err := findExistingRefs(cfg.DbPath)
+	refs, err := findExistingRefs(storageFor(cfg.DbPath))
 	if err != nil {
 		return nil, nil, err
 	}
 
 	lim := makeLimiter(cfg.MaxConcurrentIndexers)
 
-	n := len(cfg.Repos)
+	// the refs are claimed by the searchers as they start, so the disabled
+	// repos claim theirs before any of them do
+	repos := map[string]*config.Repo{}
+	for name, repo := range cfg.Repos {
+		if repo.IsEnabled() {
+			repos[name] = repo
+		} else {
+			skipDisabled(cfg.DbPath, name, repo, refs)
+		}
+	}
+
+	n := len(repos)
 	// Channel to receive the results from newSearcherConcurrent function.
 	resultCh := make(chan searcherResult, n)
 
 	// Start new searchers for all repos in different go routines while
 	// respecting cfg.MaxConcurrentIndexers.
-	for name, repo := range cfg.Repos {
+	for name, repo := range repos {
 		go newSearcherConcurrent(cfg.DbPath, name, repo, refs, lim, resultCh)
 	}
 
@@ -334,8 +1026,12 @@ func MakeAll(cfg *config.Config) (map[string]*Searcher, map[string]error, error)
 	for i := 0; i < n; i++ {
 		r := <-resultCh
 		if r.err != nil {
-			log.Print(r.err)
+			logger.Error("searcher failed to start", logger.Fields{
+				"repo":  r.name,
+				"error": r.err,
+			})
 			errs[r.name] = r.err
+			recordFailure(cfg.DbPath, r.name, cfg.Repos[r.name], lim, r.err)
 			continue
 		}
 		searchers[r.name] = r.searcher
@@ -358,7 +1054,7 @@ func Make(cfg *config.Config) (map[string]*Searcher, map[string]error, error) {
 	errs := map[string]error{}
 	searchers := map[string]*Searcher{}
 
-	refs, err := findExistingRefs(cfg.DbPath)
+	refs, err := findExistingRefs(storageFor(cfg.DbPath))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -366,10 +1062,19 @@ func Make(cfg *config.Config) (map[string]*Searcher, map[string]error, error) {
 	lim := makeLimiter(cfg.MaxConcurrentIndexers)
 
 	for name, repo := range cfg.Repos {
+		if !repo.IsEnabled() {
+			skipDisabled(cfg.DbPath, name, repo, refs)
+			continue
+		}
+
 		s, err := newSearcher(cfg.DbPath, name, repo, refs, lim)
 		if err != nil {
-			log.Print(err)
+			logger.Error("searcher failed to start", logger.Fields{
+				"repo":  name,
+				"error": err,
+			})
 			errs[name] = err
+			recordFailure(cfg.DbPath, name, repo, lim, err)
 			continue
 		}
 
@@ -384,6 +1089,42 @@ func Make(cfg *config.Config) (map[string]*Searcher, map[string]error, error) {
 	return searchers, errs, nil
 }
 
+// Make a searcher for each repo in the Config using only the indexes that are
+// already in the dbpath. Nothing is cloned, pulled or indexed and the searchers
+// never poll for updates, which makes this suitable for serving a dbpath that
+// was built elsewhere. Repos without an index will have an entry in the error
+// map.
+func OpenAll(cfg *config.Config) (map[string]*Searcher, map[string]error, error) {
+	errs := map[string]error{}
+	searchers := map[string]*Searcher{}
+
+	refs, err := findExistingRefs(storageFor(cfg.DbPath))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for name, repo := range cfg.Repos {
+		if !repo.IsEnabled() {
+			skipDisabled(cfg.DbPath, name, repo, refs)
+			continue
+		}
+
+		s, err := openSearcher(cfg.DbPath, name, repo, refs)
+		if err != nil {
+			logger.Error("searcher failed to open", logger.Fields{
+				"repo":  name,
+				"error": err,
+			})
+			errs[name] = err
+			continue
+		}
+
+		searchers[name] = s
+	}
+
+	return searchers, errs, nil
+}
+
 // Creates a new Searcher that is available for searches as soon as this returns.
 // This will pull or clone the target repo and start watching the repo for changes.
 func New(dbpath, name string, repo *config.Repo) (*Searcher, error) {
@@ -397,23 +1138,24 @@ func New(dbpath, name string, repo *config.Repo) (*Searcher, error) {
 	return s, nil
 }
 
-func setVRepos(s *Searcher, vcsDir string) bool {
+// Set the vrepo attributes of idx, which must not be live yet, and return
+// the searcher's vrepos at rev (nil if it has none). The vrepos only become
+// visible along with idx, in swapIndexes.
+func setVRepos(s *Searcher, idx *index.Index, vcsDir, rev string, roots []string) map[string]string {
 	repo := s.Repo
-	idx := s.idx
+	vrepos := map[string]string{}
 
 	// do special for hidden repo
 	if repo.IsHidden() == true {
 		// set index hidden attribute 
 		idx.Hidden = repo.IsHidden()
 		idx.FileRepo = filepath.Base(vcsDir)
-
-		// empty vrepos first 
-		s.vrepos = make(map[string]string)
+		idx.VRepoDepth = 2
 
 		// get all sub directory as org/repo_branch reo for hidden repo 
 		dirs, err := filepath.Glob(filepath.Join(vcsDir, "*", "*"))
 		if err != nil {
-			return false
+			return vrepos
 		}
 
 		for _, dir := range dirs {
@@ -424,15 +1166,113 @@ func setVRepos(s *Searcher, vcsDir string) bool {
 			names := strings.Split(dir, string(os.PathSeparator))
 			rname := []string{filepath.Base(vcsDir), names[len(names)-2]}
 
-			s.vrepos[strings.Join(rname[:], "/")] = names[len(names)-1]
+			vrepos[strings.Join(rname[:], "/")] = names[len(names)-1]
+		}
+	} else if len(roots) > 0 {
+		// multi-root repo, each root is a vrepo laid out as vcsDir/repo
+		idx.Hidden = true
+		idx.FileRepo = filepath.Base(vcsDir)
+		idx.VRepoDepth = 1
+		idx.VRevs = nil
+
+		// the working dir of a repo's branches is named by the driver, so
+		// they are named after the repo instead, each at its own revision
+		branches := s.wd.HasBranches()
+		if branches {
+			idx.FileRepo = s.name
+			idx.VRevs = make(map[string]string)
 		}
+
+		for _, root := range roots {
+			rname := []string{idx.FileRepo, root}
+			vrepo := strings.Join(rname[:], "/")
+			vrepos[vrepo] = rev
+
+			if !branches {
+				continue
+			}
+
+			brev, err := s.wd.BranchRev(vcsDir, root)
+			if err != nil {
+				logger.Warn("couldn't get revision of branch", logger.Fields{
+					"repo":   s.name,
+					"branch": root,
+					"error":  err,
+				})
+				continue
+			}
+			vrepos[vrepo] = brev
+			idx.VRevs[vrepo] = brev
+		}
+	} else {
+		return nil
 	}
 
-	return true
+	return vrepos
 }
 
 
-// Update the vcs and reindex the given repo.
+// Pull or clone the repo, making up to attempts attempts and retrying
+// failures that look transient with an exponential backoff. The caller must
+// hold the repo's tokens from the limiter; they are given back while waiting
+// between attempts so that a flaky repo does not hold up the others.
+func pullOrCloneWithRetry(
+	wd *vcs.WorkDir,
+	vcsDir,
+	name string,
+	repo *config.Repo,
+	attempts int,
+	lim *limiter) (string, error) {
+
+	delay := time.Duration(repo.MsBetweenRetries) * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		rev, err := wd.PullOrCloneTimeout(vcsDir, repo.Url, repo.PullTimeout())
+		if err == nil {
+			if attempt > 1 {
+				logger.Info("vcs pull succeeded", logger.Fields{
+					"repo":    name,
+					"attempt": attempt,
+				})
+			}
+			return rev, nil
+		}
+
+		if !vcs.IsTransient(err) {
+			logger.Warn("vcs pull failed with a permanent error, not retrying", logger.Fields{
+				"repo":  name,
+				"error": err,
+			})
+			return "", err
+		}
+
+		if attempt >= attempts {
+			logger.Warn("vcs pull failed, giving up", logger.Fields{
+				"repo":    name,
+				"attempt": attempt,
+				"error":   err,
+			})
+			return "", err
+		}
+
+		logger.Info("vcs pull failed, retrying", logger.Fields{
+			"repo":    name,
+			"attempt": attempt,
+			"of":      attempts,
+			"delay":   delay,
+			"error":   err,
+		})
+
+		lim.Release(repo.IndexWeight)
+		time.Sleep(delay)
+		lim.Acquire(repo.IndexWeight)
+
+		delay *= 2
+	}
+}
+
+// Update the vcs and reindex the given repo. Returns the revision that is
+// searchable afterwards, whether the index was rebuilt and why the update
+// failed, if it did.
 func updateAndReindex(
 	s *Searcher,
 	dbpath,
@@ -441,50 +1281,107 @@ func updateAndReindex(
 	rev string,
 	wd *vcs.WorkDir,
 	opt *index.IndexOptions,
-	lim limiter) (string, bool) {
-
-	// acquire a token from the rate limiter
-	lim.Acquire()
-	defer lim.Release()
+	lim *limiter) (string, bool, error) {
 
 	repo := s.Repo
-	newRev, err := wd.PullOrClone(vcsDir, repo.Url)
+
+	// acquire as many tokens as the repo weighs from the rate limiter
+	lim.Acquire(repo.IndexWeight)
+	defer lim.Release(repo.IndexWeight)
+
+	// while the circuit is open a poll is only a probe, so it isn't retried
+	attempts := repo.PullAttempts
+	if s.circuitOpen() {
+		attempts = 1
+	}
+
+	newRev, err := pullOrCloneWithRetry(wd, vcsDir, name, repo, attempts, lim)
 
 	if err != nil {
-		log.Printf("vcs pull error (%s - %s): %s", name, repo.Url, err)
-		return rev, false
+		s.pullFailed(err)
+		logger.Error("vcs pull error", logger.Fields{
+			"event": "pull",
+			"repo":  name,
+			"url":   vcs.ScrubUrl(repo.Url),
+			"error": err,
+		})
+		return rev, false, err
 	}
+	s.pullSucceeded()
 
 	if newRev == rev {
-		return rev, false
+		return rev, false, nil
 	}
 
-	log.Printf("Rebuilding %s for %s", name, newRev)
-	idx, err := buildAndOpenIndex(
-		opt,
-		dbpath,
-		vcsDir,
-		nextIndexDir(dbpath),
-		repo.Url,
-		newRev)
+	roots, err := wd.Roots(vcsDir)
 	if err != nil {
-		log.Printf("failed index build (%s): %s", name, err)
-		return rev, false
+		logger.Error("vcs roots error", logger.Fields{
+			"repo":  name,
+			"url":   vcs.ScrubUrl(repo.Url),
+			"error": err,
+		})
+		return rev, false, err
+	}
+	opt.Roots = roots
+	opt.ModTimes, opt.ModTimesSince = fileModTimes(wd, vcsDir, name)
+
+	// virtual repos are laid out by the driver, so only a plain repo can
+	// be updated from its changes. Nor do the changes cover the files behind
+	// symbolic links, and a sharded index is always built from scratch.
+	store := storageFor(dbpath)
+	idxDir, err := store.Create()
+
+	var idx *index.Index
+	if err == nil && !repo.IsHidden() && len(roots) == 0 && !opt.FollowSymlinks && opt.Shards <= 1 {
+		idx = updateIndex(opt, store, wd, vcsDir, s.IndexRef(), idxDir, repo.Url, newRev, name)
 	}
 
-	// set revision and vrepos
-	repo.Revision = newRev
-	setVRepos(s, vcsDir)
+	if err == nil && idx == nil {
+		logger.Info("rebuilding index", logger.Fields{
+			"event": "reindex",
+			"repo":  name,
+			"rev":   newRev,
+		})
+		idx, err = buildAndOpenIndex(
+			opt,
+			store,
+			wd.WorkTree(vcsDir),
+			idxDir,
+			repo.Url,
+			newRev,
+			name,
+			s.IndexRef().Files)
+	}
+	if err != nil {
+		logger.Error("failed index build", logger.Fields{
+			"event": "reindex",
+			"repo":  name,
+			"rev":   newRev,
+			"error": err,
+		})
+		return rev, false, err
+	}
 
-	if err := s.swapIndexes(idx); err != nil {
-		log.Printf("failed index swap (%s): %s", name, err)
+	vrepos := setVRepos(s, idx, vcsDir, newRev, roots)
+	if err := s.swapIndexes(idx, vrepos); err != nil {
+		logger.Error("failed index swap", logger.Fields{
+			"repo":  name,
+			"error": err,
+		})
 		if err := idx.Destroy(); err != nil {
-			log.Printf("failed to destroy index (%s): %s\n", name, err)
+			logger.Error("failed to destroy index", logger.Fields{
+				"repo":  name,
+				"error": err,
+			})
 		}
-		return rev, false
+		return rev, false, err
 	}
 
-	return newRev, true
+	repo.Revision = newRev
+
+	recordChange(name, rev, newRev)
+
+	return newRev, true, nil
 }
 
 // Creates a new Searcher that is capable of re-claiming an existing index directory
@@ -493,61 +1390,97 @@ func newSearcher(
 	dbpath, name string,
 	repo *config.Repo,
 	refs *foundRefs,
-	lim limiter) (*Searcher, error) {
+	lim *limiter) (*Searcher, error) {
 
-	log.Printf("Searcher started for %s", name)
+	logger.Info("searcher started", logger.Fields{
+		"event": "start",
+		"repo":  name,
+	})
 
 	wd, err := vcs.New(repo.Vcs, repo.VcsConfig())
 	if err != nil {
 		return nil, err
 	}
 
-	opt := &index.IndexOptions{
-		ExcludeDotFiles: repo.ExcludeDotFiles,
-		SpecialFiles:    wd.SpecialFiles(),
-	}
+	opt := indexOptions(repo, wd)
 
 	vcsDir, err := wd.WorkingDirForRepo(dbpath, repo)
 	if err != nil {
 		return nil, err
 	}
 
-	rev, err := wd.PullOrClone(vcsDir, repo.Url)
+	current := readCurrent(dbpath, name)
+	rev, err := startingRev(wd, vcsDir, name, repo, refs, current)
 	if err != nil {
 		return nil, err
 	}
 
+	roots, err := wd.Roots(vcsDir)
+	if err != nil {
+		return nil, err
+	}
+	opt.Roots = roots
+
+	// an index without suggestions can't be reused by a repo that wants them
+	store := storageFor(dbpath)
 	var idxDir string
-	ref := refs.find(repo.Url, rev)
-	if ref == nil {
-		idxDir = nextIndexDir(dbpath)
+	ref := refs.findCurrent(repo.Url, rev, current)
+	if ref == nil || (opt.Suggest && !ref.HasSuggestions()) {
+		if idxDir, err = store.Create(); err != nil {
+			return nil, err
+		}
+		opt.ModTimes, opt.ModTimesSince = fileModTimes(wd, vcsDir, name)
 	} else {
 		idxDir = ref.Dir()
 		refs.claim(ref)
 	}
 
+	// an index of an older revision gives an idea of how big the build is
+	expected := 0
+	if latest := refs.findLatest(repo.Url); latest != nil {
+		expected = latest.Files
+	}
+
 	idx, err := buildAndOpenIndex(
 		opt,
-		dbpath,
-		vcsDir,
+		store,
+		wd.WorkTree(vcsDir),
 		idxDir,
 		repo.Url,
-		rev)
+		rev,
+		name,
+		expected)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := writeCurrent(dbpath, name, idx.Ref.Dir()); err != nil {
+		idx.Close()
+		return nil, err
+	}
+
 	s := &Searcher{
 		idx:        idx,
+		gen:        nextGeneration(),
 		updateCh:   make(chan time.Time, 1),
 		Repo:       repo,
+		wd:         wd,
+		vcsDir:     vcsDir,
+		blames:     newBlameCache(),
+		name:       name,
+		dbpath:     dbpath,
+		lim:        lim,
 		doneCh:     make(chan empty),
 		shutdownCh: make(chan empty, 1),
 	}
 
 	// set revision and vrepos
 	repo.Revision = rev
-	setVRepos(s, vcsDir)
+	s.vrepos = setVRepos(s, idx, vcsDir, rev, roots)
+
+	if repo.MsIdleBeforeCompaction > 0 {
+		go s.compactWhenIdle(time.Duration(repo.MsIdleBeforeCompaction)*time.Millisecond, opt)
+	}
 
 	go func() {
 
@@ -566,35 +1499,172 @@ func newSearcher(
 		}
 
 		for {
-			// Wait for a signal to proceed
-			s.waitForUpdate(delay)
+			// Wait for a signal to proceed, unless a request is already
+			// waiting on one. Updates asked for before polling began are
+			// lost along with the signal that began it.
+			if !s.hasWaiters() {
+				s.waitForUpdate(s.pollDelay(delay))
+			}
 
-			if s.shutdownRequested {
+			if atomic.LoadInt32(&s.shutdownRequested) != 0 {
 				s.completeShutdown()
 				return
 			}
 
 			// attempt to update and reindex this searcher
-			newRev, ok := updateAndReindex(s, dbpath, vcsDir, name, rev, wd, opt, lim)
+			waiters := s.takeWaiters()
+			s.indexLck.Lock()
+			newRev, ok, err := updateAndReindex(s, dbpath, vcsDir, name, rev, wd, opt, lim)
+			s.indexLck.Unlock()
+			notifyWaiters(waiters, &UpdateResult{
+				Revision: newRev,
+				Changed:  ok,
+				Err:      err,
+			})
 			if !ok {
 				continue
 			}
 
 			rev = newRev
+			reindexed()
+		}
+	}()
+
+	return s, nil
+}
+
+// The options the repo is indexed with. The repo's settings already have
+// the config's defaults filled in (see config.Repo), whatever is still unset
+// gets the built-in default.
+func indexOptions(repo *config.Repo, wd *vcs.WorkDir) *index.IndexOptions {
+	exclude, include := repo.ExcludedDirs()
+	return &index.IndexOptions{
+		ExcludeDotFiles: repo.DotFilesExcluded(),
+		SpecialFiles:    withoutStrings(wd.SpecialFiles(), include),
+		ExcludeDirs:     exclude,
+		MaxFileSize:     repo.FileSizeLimit(),
+		FollowSymlinks:  repo.SymlinksFollowed(),
+		Shards:          repo.IndexShards,
+		Workers:         repo.IndexWorkers,
+		Compression:     repo.IndexCompression,
+		Suggest:         repo.SuggestionsEnabled(),
+
+		IncludeExtensions: repo.IncludeExtensions,
+	}
+}
 
-			// This is just a good time to GC since we know there will be a
-			// whole set of dead posting lists on the heap. Ensuring these
-			// go away quickly helps to prevent the heap from expanding
-			// uncessarily.
-			runtime.GC()
+// The strings in a that aren't in b.
+func withoutStrings(a, b []string) []string {
+	var res []string
+	for _, s := range a {
+		found := false
+		for _, t := range b {
+			if s == t {
+				found = true
+				break
+			}
+		}
 
-			reportOnMemory()
+		if !found {
+			res = append(res, s)
 		}
-	}()
+	}
+	return res
+}
+
+// Creates a new Searcher from the latest existing index for the repo. The
+// searcher is never updated.
+func openSearcher(
+	dbpath, name string,
+	repo *config.Repo,
+	refs *foundRefs) (*Searcher, error) {
+
+	wd, err := vcs.New(repo.Vcs, repo.VcsConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	vcsDir, err := wd.WorkingDirForRepo(dbpath, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := refs.findLatestCurrent(repo.Url, readCurrent(dbpath, name))
+	if ref == nil {
+		return nil, fmt.Errorf("no index found for %s", name)
+	}
+	refs.claim(ref)
+
+	idx, err := storageFor(dbpath).Open(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// the working dir may not have been shipped along with the indexes
+	roots, _ := wd.Roots(vcsDir)
+
+	s := &Searcher{
+		idx:        idx,
+		gen:        nextGeneration(),
+		updateCh:   make(chan time.Time, 1),
+		Repo:       repo,
+		wd:         wd,
+		vcsDir:     vcsDir,
+		blames:     newBlameCache(),
+		name:       name,
+		dbpath:     dbpath,
+		lim:        makeLimiter(1),
+		doneCh:     make(chan empty),
+		shutdownCh: make(chan empty, 1),
+	}
+
+	// set revision and vrepos
+	repo.Revision = ref.Rev
+	s.vrepos = setVRepos(s, idx, vcsDir, ref.Rev, roots)
+
+	// there is no poller to wait on
+	s.completeShutdown()
+
+	logger.Info("searcher opened", logger.Fields{
+		"event": "open",
+		"repo":  name,
+		"rev":   ref.Rev,
+	})
 
 	return s, nil
 }
 
+// The revision a searcher starts out serving. If the working dir is still
+// checked out at a revision that has an index (the revision is kept in the
+// index's manifest), that index is served as it was before a restart and the
+// repo only moves on at its next poll. Otherwise, or if the repo is never
+// polled, the repo is pulled (or cloned) first. current is the index the
+// repo's marker names, see findCurrent.
+func startingRev(
+	wd *vcs.WorkDir,
+	vcsDir,
+	name string,
+	repo *config.Repo,
+	refs *foundRefs,
+	current string) (string, error) {
+
+	if repo.PollUpdatesEnabled() {
+		if _, err := os.Stat(vcsDir); err == nil {
+			rev, err := wd.HeadRev(vcsDir)
+			if err == nil && refs.findCurrent(repo.Url, rev, current) != nil {
+				logger.Info("reusing index without pulling", logger.Fields{
+					"event": "start",
+					"repo":  name,
+					"rev":   rev,
+				})
+				return rev, nil
+			}
+		}
+	}
+
+	return wd.PullOrCloneTimeout(vcsDir, repo.Url, repo.PullTimeout())
+}
+
 // This function is a wrapper around `newSearcher` function.
 // It respects the parameter `cfg.MaxConcurrentIndexers` while making the
 // creation of searchers for various repositories concurrent.
@@ -602,12 +1672,12 @@ func newSearcherConcurrent(
 	dbpath, name string,
 	repo *config.Repo,
 	refs *foundRefs,
-	lim limiter,
+	lim *limiter,
 	resultCh chan searcherResult) {
 
-	// acquire a token from the rate limiter
-	lim.Acquire()
-	defer lim.Release()
+	// acquire as many tokens as the repo weighs from the rate limiter
+	lim.Acquire(repo.IndexWeight)
+	defer lim.Release(repo.IndexWeight)
 
 	s, err := newSearcher(dbpath, name, repo, refs, lim)
 	if err != nil {
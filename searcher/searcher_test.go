@@ -0,0 +1,1261 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
+	"github.com/etsy/hound/vcs"
+)
+
+func TestCorruptIndexIsRebuilt(t *testing.T) {
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	idxDir := filepath.Join(dbpath, "idx-corrupt")
+	idx, err := buildAndOpenIndex(&index.IndexOptions{}, index.NewLocalStorage(dbpath), src, idxDir, "url", "rev", "repo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Close()
+
+	// chop the trigram index in half, as if the process died writing it
+	tri := filepath.Join(idxDir, "tri")
+	fi, err := os.Stat(tri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(tri, fi.Size()/2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := index.Open(idxDir); err == nil {
+		t.Fatal("expected the truncated index to fail to open")
+	} else if _, ok := err.(*index.CorruptIndexError); !ok {
+		t.Fatalf("expected a corrupt index error, got %s", err)
+	}
+
+	idx, err = buildAndOpenIndex(&index.IndexOptions{}, index.NewLocalStorage(dbpath), src, idxDir, "url", "rev", "repo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	if idx.GetDir() == idxDir {
+		t.Fatal("expected the index to be rebuilt in a new dir")
+	}
+
+	if _, err := os.Stat(idxDir); !os.IsNotExist(err) {
+		t.Fatal("expected the corrupt index to be removed")
+	}
+
+	res, err := idx.Search("needle", &index.SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Matches) != 1 {
+		t.Fatalf("expected 1 match in the rebuilt index, got %d", len(res.Matches))
+	}
+}
+
+func TestTrackBuild(t *testing.T) {
+	opt, done := trackBuild("repo", 200, &index.IndexOptions{})
+	opt.Progress(index.BuildProgress{Files: 50, Bytes: 1024})
+
+	b := Builds()
+	if len(b) != 1 || b[0].Repo != "repo" {
+		t.Fatalf("expected a build of repo to be under way, got %v", b)
+	}
+
+	if b[0].Files != 50 || b[0].Bytes != 1024 || b[0].Percent != 25 {
+		t.Fatalf("expected 50 files, 1024 bytes and 25%%, got %+v", b[0])
+	}
+
+	done()
+	if b := Builds(); len(b) != 0 {
+		t.Fatalf("expected no builds once done, got %v", b)
+	}
+}
+
+func TestGetExcludedFilesErrors(t *testing.T) {
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	idx, err := buildAndOpenIndex(&index.IndexOptions{}, index.NewLocalStorage(dbpath), src, filepath.Join(dbpath, "idx-excluded"), "url", "rev", "repo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	s := &Searcher{idx: idx}
+	path := filepath.Join(idx.GetDir(), "excluded_files.json")
+
+	// nothing is excluded, which must still be a valid (empty) array
+	for _, dat := range []string{"[]", "null"} {
+		if err := ioutil.WriteFile(path, []byte(dat), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, repo := range []string{"", "repo"} {
+			res, err := s.GetExcludedFiles(repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != "[]" {
+				t.Fatalf("expected [] for %s, got %q", dat, res)
+			}
+		}
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`[{"Filename": "a.txt"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.GetExcludedFiles(""); err == nil {
+		t.Fatal("expected an error for malformed json")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.GetExcludedFiles("repo"); !os.IsNotExist(err) {
+		t.Fatalf("expected a not exist error for a missing file, got %v", err)
+	}
+}
+
+func TestMakeAllWithoutMaxConcurrentIndexers(t *testing.T) {
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	b, err := json.Marshal(map[string]interface{}{
+		"dbpath": dbpath,
+		"repos": map[string]interface{}{
+			"a": map[string]string{"url": "file://" + src, "vcs": "local"},
+			"b": map[string]string{"url": "file://" + src, "vcs": "local"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromBytes(b, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// a config that didn't come from a file has no defaults at all
+	cfg.MaxConcurrentIndexers = 0
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+
+		searchers, errs, err := MakeAll(&cfg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(searchers) != 2 || len(errs) != 0 {
+			t.Errorf("expected 2 searchers, got %d with errors %v", len(searchers), errs)
+		}
+
+		for _, s := range searchers {
+			s.Stop()
+			s.Wait()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("MakeAll hung with max-concurrent-indexers of 0")
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+	}
+}
+
+// Commit the file with the given contents to the git repo in dir.
+func commitFile(t *testing.T, dir, file, data string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", file)
+	runGit(t, dir, "-c", "user.name=hound", "-c", "user.email=hound@example.com",
+		"commit", "-q", "-m", file)
+}
+
+func TestRestartReusesIndexWithoutPulling(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	runGit(t, "", "init", "-q", src)
+	commitFile(t, src, "a.txt", "a\n")
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	makeOne := func() *Searcher {
+		b, err := json.Marshal(map[string]interface{}{
+			"dbpath": dbpath,
+			"repos": map[string]interface{}{
+				"a": map[string]string{"url": "file://" + src},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var cfg config.Config
+		if err := cfg.LoadFromBytes(b, false); err != nil {
+			t.Fatal(err)
+		}
+		cfg.MaxConcurrentIndexers = 1
+
+		searchers, errs, err := MakeAll(&cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(errs) != 0 {
+			t.Fatal(errs)
+		}
+
+		s := searchers["a"]
+		s.Stop()
+		s.Wait()
+		return s
+	}
+
+	first := makeOne()
+
+	// the repo moves on while hound is down
+	commitFile(t, src, "b.txt", "b\n")
+
+	second := makeOne()
+	if second.Repo.Revision != first.Repo.Revision {
+		t.Fatalf("expected revision %s after a restart, got %s",
+			first.Repo.Revision, second.Repo.Revision)
+	}
+
+	if second.IndexRef().Dir() != first.IndexRef().Dir() {
+		t.Fatalf("expected index %s to be reused, got %s",
+			first.IndexRef().Dir(), second.IndexRef().Dir())
+	}
+}
+
+func TestCrashBeforeSwap(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	runGit(t, "", "init", "-q", src)
+	commitFile(t, src, "a.txt", "a\n")
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	cfg := &config.Config{
+		DbPath:                dbpath,
+		MaxConcurrentIndexers: 1,
+		Repos: map[string]*config.Repo{
+			"a": {
+				Url:            "file://" + src,
+				Vcs:            "git",
+				MsBetweenPolls: 60000,
+			},
+		},
+	}
+
+	makeOne := func() *Searcher {
+		searchers, errs, err := MakeAll(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(errs) != 0 {
+			t.Fatal(errs)
+		}
+
+		s := searchers["a"]
+		s.Stop()
+		s.Wait()
+		return s
+	}
+
+	first := makeOne()
+	live := first.IndexRef().Dir()
+	if got := readCurrent(dbpath, "a"); got != live {
+		t.Fatalf("expected the marker to name %s, got %s", live, got)
+	}
+
+	// the process died after building another index of the same revision,
+	// before it was swapped in, and while building yet another one
+	idx, err := buildAndOpenIndex(&index.IndexOptions{}, index.NewLocalStorage(dbpath),
+		src, filepath.Join(dbpath, "idx-0crashed"), "file://"+src, first.Repo.Revision, "a", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Close()
+
+	half := filepath.Join(dbpath, "idx-1halfbuilt")
+	if err := os.MkdirAll(filepath.Join(half, "raw"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	second := makeOne()
+	if second.IndexRef().Dir() != live {
+		t.Fatalf("expected the marked index %s to be reused, got %s", live, second.IndexRef().Dir())
+	}
+
+	for _, dir := range []string{idx.Ref.Dir(), half} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed", dir)
+		}
+	}
+
+	// the marker moves along with a swap
+	idx, err = buildAndOpenIndex(&index.IndexOptions{}, index.NewLocalStorage(dbpath),
+		src, filepath.Join(dbpath, "idx-swapped"), "file://"+src, first.Repo.Revision, "a", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blames := second.blames
+	if err := second.swapIndexes(idx, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	// blame of the old index is gone with it
+	if second.blames == blames {
+		t.Fatal("expected the blame cache to be replaced")
+	}
+
+	if got := readCurrent(dbpath, "a"); got != idx.Ref.Dir() {
+		t.Fatalf("expected the marker to name %s, got %s", idx.Ref.Dir(), got)
+	}
+	if _, err := os.Stat(live); !os.IsNotExist(err) {
+		t.Fatal("expected the old index to be removed")
+	}
+}
+
+func TestSearchRev(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	runGit(t, "", "init", "-q", src)
+	commitFile(t, src, "a.txt", "before\n")
+
+	out, err := exec.Command("git", "-C", src, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := strings.TrimSpace(string(out))
+
+	commitFile(t, src, "a.txt", "after\n")
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	s, err := newSearcher(dbpath, "a", &config.Repo{
+		Url:            "file://" + src,
+		Vcs:            "git",
+		MsBetweenPolls: 1000,
+	}, &foundRefs{}, makeLimiter(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	matches := func(pat, rev string) int {
+		res, err := s.SearchRev(pat, &index.SearchOptions{}, rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(res.Matches)
+	}
+
+	if matches("before", "") != 0 || matches("after", "") != 1 {
+		t.Fatal("expected the live index to be at the latest revision")
+	}
+
+	// the clone is shallow, so the old revision has to be fetched, after
+	// which it can be abbreviated
+	for _, rev := range []string{old, old[:10]} {
+		if matches("before", rev) != 1 || matches("after", rev) != 0 {
+			t.Fatalf("expected the index of %s to be searched", rev)
+		}
+	}
+
+	h := acquireHistorical(historyKey(s.Repo.Url, old))
+	if h == nil {
+		t.Fatalf("expected the index of %s to be kept", old)
+	}
+	releaseHistorical(h)
+
+	for _, rev := range []string{"--no-such-rev", "no-such-branch"} {
+		if _, err := s.SearchRev("before", &index.SearchOptions{}, rev); err == nil {
+			t.Fatalf("expected an error for %s", rev)
+		} else if _, ok := err.(*UnknownRevError); !ok {
+			t.Fatalf("expected %s to be an unknown revision, got %v", rev, err)
+		}
+	}
+
+	// once the burst of builds is used up, only the kept indexes are
+	// searched
+	defer func(tokens float64) {
+		historyBuilds.tokens = tokens
+	}(historyBuilds.tokens)
+	historyBuilds.tokens = 0
+
+	if matches("before", old) != 1 {
+		t.Fatalf("expected the kept index of %s to be searched", old)
+	}
+
+	// and so are diffs
+	defer func(tokens float64) {
+		diffs.tokens = tokens
+	}(diffs.tokens)
+	diffs.tokens = 0
+
+	if _, err := s.DiffFiles(old + "..master"); err != ErrTooManyDiffs {
+		t.Fatalf("expected the diff to be refused, got %v", err)
+	}
+
+	// stopping the searcher removes the indexes it built
+	s.Stop()
+	if _, err := os.Stat(h.scratch); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed", h.scratch)
+	}
+
+	if _, err := s.SearchRev("before", &index.SearchOptions{}, old); err != ErrTooManyRevBuilds {
+		t.Fatalf("expected the build to be refused, got %v", err)
+	}
+}
+
+func TestSearchBranches(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	runGit(t, "", "init", "-q", src)
+	commitFile(t, src, "a.txt", "shared\n")
+	runGit(t, src, "branch", "-M", "master")
+	runGit(t, src, "checkout", "-q", "-b", "develop")
+	commitFile(t, src, "b.txt", "develop only\n")
+
+	dbpath := filepath.Join(dir, "db")
+	if err := os.Mkdir(dbpath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"dbpath": dbpath,
+		"repos": map[string]interface{}{
+			"a": map[string]interface{}{
+				"url":                 "file://" + src,
+				"enable-poll-updates": false,
+				"vcs-config": map[string]interface{}{
+					"branches": []string{"master", "develop"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromBytes(b, false); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := newSearcher(dbpath, "a", cfg.Repos["a"], &foundRefs{}, makeLimiter(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	if got := strings.Join(s.GetVRepos(), ","); got != "a/develop,a/master" {
+		t.Fatalf("expected vrepos a/develop and a/master, got %s", got)
+	}
+
+	out, err := exec.Command("git", "-C", src, "rev-parse", "develop").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	develop := strings.TrimSpace(string(out))
+
+	if rev := s.GetVRepoRev("a/develop"); rev != develop {
+		t.Fatalf("expected a/develop to be at %s, got %s", develop, rev)
+	}
+
+	res, err := s.Search("develop only", &index.SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.VMatches) != 1 || len(res.VMatches["a/develop"]) != 1 {
+		t.Fatalf("expected a match on a/develop only, got %v", res.VMatches)
+	}
+	if rev := res.VRevision["a/develop"]; rev != develop {
+		t.Fatalf("expected the match to be at %s, got %s", develop, rev)
+	}
+
+	res, err = s.Search("shared", &index.SearchOptions{}, []string{"a/master"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.VMatches) != 1 || len(res.VMatches["a/master"]) != 1 {
+		t.Fatalf("expected a match on a/master only, got %v", res.VMatches)
+	}
+}
+
+func TestIndexOptions(t *testing.T) {
+	wd, err := vcs.New("git", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	yes, size := true, int64(100)
+	repo := &config.Repo{
+		ExcludeDotFiles: &yes,
+		FollowSymlinks:  &yes,
+		MaxFileSize:     &size,
+		ExcludeDirs:     []string{"vendor/", "!.git"},
+	}
+
+	opt := indexOptions(repo, wd)
+	if !opt.ExcludeDotFiles || !opt.FollowSymlinks || opt.MaxFileSize != 100 {
+		t.Fatalf("unexpected options %+v", opt)
+	}
+
+	if len(opt.ExcludeDirs) != 1 || opt.ExcludeDirs[0] != "vendor" {
+		t.Fatalf("unexpected excluded dirs %v", opt.ExcludeDirs)
+	}
+
+	for _, name := range opt.SpecialFiles {
+		if name == ".git" {
+			t.Fatal("expected .git to be indexed")
+		}
+	}
+}
+
+// A driver whose pulls hang until they're given up on.
+type hungDriver struct {
+	ctx context.Context
+}
+
+func (d *hungDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
+	return filepath.Join(dbpath, "vcs-hung"), nil
+}
+
+func (d *hungDriver) Clone(dir, url string) (string, error) { return d.Pull(dir) }
+
+func (d *hungDriver) Pull(dir string) (string, error) {
+	<-d.ctx.Done()
+	return "", d.ctx.Err()
+}
+
+func (d *hungDriver) HeadRev(dir string) (string, error) { return "", nil }
+func (d *hungDriver) SpecialFiles() []string             { return nil }
+
+func (d *hungDriver) WithContext(ctx context.Context) vcs.Driver {
+	return &hungDriver{ctx}
+}
+
+func TestHungPullTimesOut(t *testing.T) {
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	wd := &vcs.WorkDir{Driver: &hungDriver{context.Background()}}
+	s := &Searcher{
+		Repo: &config.Repo{
+			Url:           "file:///hung",
+			MsPullTimeout: 50,
+			PullAttempts:  1,
+		},
+	}
+
+	lim := makeLimiter(1)
+	done := make(chan bool)
+	go func() {
+		_, ok, _ := updateAndReindex(s, dbpath, filepath.Join(dbpath, "vcs-hung"), "hung", "", wd, &index.IndexOptions{}, lim)
+		done <- ok
+	}()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("expected the update to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the pull to time out")
+	}
+
+	// the limiter's slot is free again
+	if lim.used != 0 {
+		t.Fatal("expected the limiter to be released")
+	}
+}
+
+// Start a holder of weight w that holds the limiter until release is
+// closed, and report on in when it's let in.
+func holdLimiter(lim *limiter, w int, in chan<- int, release <-chan bool) {
+	lim.Acquire(w)
+	in <- w
+	<-release
+	lim.Release(w)
+}
+
+// How many holders were let in within a moment.
+func countIn(in <-chan int) int {
+	n := 0
+	for {
+		select {
+		case <-in:
+			n++
+		case <-time.After(50 * time.Millisecond):
+			return n
+		}
+	}
+}
+
+func TestLimiterWeights(t *testing.T) {
+	lim := makeLimiter(4)
+	in := make(chan int, 10)
+
+	// a repo that weighs 3 leaves room for only one light one
+	heavy := make(chan bool)
+	go holdLimiter(lim, 3, in, heavy)
+	if w := <-in; w != 3 {
+		t.Fatalf("expected the heavy repo to be let in, got %d", w)
+	}
+
+	light := make(chan bool)
+	for i := 0; i < 3; i++ {
+		go holdLimiter(lim, 1, in, light)
+	}
+	if n := countIn(in); n != 1 {
+		t.Fatalf("expected 1 light repo to be let in next to the heavy one, got %d", n)
+	}
+
+	// the rest are let in once the heavy one is done
+	close(heavy)
+	if n := countIn(in); n != 2 {
+		t.Fatalf("expected the 2 other light repos to be let in, got %d", n)
+	}
+
+	// a heavy repo waiting for room isn't overtaken by lighter ones, and
+	// one heavier than the whole limiter takes all of it
+	heavier := make(chan bool)
+	go holdLimiter(lim, 10, in, heavier)
+	time.Sleep(50 * time.Millisecond)
+	last := make(chan bool)
+	go holdLimiter(lim, 1, in, last)
+	if n := countIn(in); n != 0 {
+		t.Fatalf("expected no repo to be let in while the light ones run, got %d", n)
+	}
+
+	close(light)
+	if w := <-in; w != 10 {
+		t.Fatalf("expected the heavier repo to be let in first, got %d", w)
+	}
+	if n := countIn(in); n != 0 {
+		t.Fatalf("expected the heavier repo to take the whole limiter, got %d more", n)
+	}
+
+	close(heavier)
+	if w := <-in; w != 1 {
+		t.Fatalf("expected the last repo to be let in, got %d", w)
+	}
+	close(last)
+}
+
+func TestCompactWhenIdle(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	// few enough files change for the index to be updated rather than
+	// built again
+	runGit(t, "", "init", "-q", src)
+	for i := 0; i < 10; i++ {
+		commitFile(t, src, fmt.Sprintf("%d.txt", i), "a\n")
+	}
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	b, err := json.Marshal(map[string]interface{}{
+		"dbpath": dbpath,
+		"repos": map[string]interface{}{
+			"a": map[string]interface{}{
+				"url":                       "file://" + src,
+				"enable-poll-updates":       false,
+				"enable-push-updates":       true,
+				"ms-idle-before-compaction": 200,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromBytes(b, false); err != nil {
+		t.Fatal(err)
+	}
+
+	searchers, errs, err := MakeAll(&cfg)
+	if err != nil || len(errs) != 0 {
+		t.Fatal(err, errs)
+	}
+
+	s := searchers["a"]
+	defer s.Wait()
+	defer s.Stop()
+
+	// an index that was built from scratch is never compacted
+	time.Sleep(400 * time.Millisecond)
+	if !s.LastCompacted().IsZero() {
+		t.Fatal("expected a fresh index not to be compacted")
+	}
+
+	// hold off the compaction as an update would
+	s.indexLck.Lock()
+	locked := true
+	defer func() {
+		if locked {
+			s.indexLck.Unlock()
+		}
+	}()
+
+	commitFile(t, src, "b.txt", "needle\n")
+	rev, ok, err := updateAndReindex(s, dbpath, s.vcsDir, "a", s.Repo.Revision, s.wd, indexOptions(s.Repo, s.wd), s.lim)
+	if !ok || err != nil {
+		t.Fatalf("expected the index to be updated, got %v", err)
+	}
+	if ref := s.IndexRef(); ref.Updates != 1 || ref.Rev != rev {
+		t.Fatalf("expected an update to %s, got %d updates at %s", rev, ref.Updates, ref.Rev)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	if !s.LastCompacted().IsZero() {
+		t.Fatal("expected no compaction while the index is being built")
+	}
+	s.indexLck.Unlock()
+	locked = false
+
+	deadline := time.Now().Add(10 * time.Second)
+	for s.LastCompacted().IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the idle index to be compacted")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if ref := s.IndexRef(); ref.Updates != 0 || ref.Rev != rev {
+		t.Fatalf("expected a fresh index at %s, got %d updates at %s", rev, ref.Updates, ref.Rev)
+	}
+
+	sr, err := s.Search("needle", &index.SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.FilesWithMatch != 1 {
+		t.Fatalf("expected needle to be found, got %d files", sr.FilesWithMatch)
+	}
+}
+
+func TestUpdateAndNotify(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	src, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	runGit(t, "", "init", "-q", src)
+	commitFile(t, src, "a.txt", "a\n")
+
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	b, err := json.Marshal(map[string]interface{}{
+		"dbpath": dbpath,
+		"repos": map[string]interface{}{
+			"a": map[string]interface{}{
+				"url":                 "file://" + src,
+				"enable-poll-updates": false,
+				"enable-push-updates": true,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromBytes(b, false); err != nil {
+		t.Fatal(err)
+	}
+
+	searchers, errs, err := MakeAll(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	s := searchers["a"]
+	defer s.Wait()
+	defer s.Stop()
+
+	wait := func() *UpdateResult {
+		ch, ok := s.UpdateAndNotify()
+		if !ok {
+			t.Fatal("expected push updates to be enabled")
+		}
+
+		select {
+		case res := <-ch:
+			return res
+		case <-time.After(30 * time.Second):
+			t.Fatal("expected the update to finish")
+		}
+		return nil
+	}
+
+	// the new commit is searchable as soon as the update is done
+	commitFile(t, src, "b.txt", "needle\n")
+	res := wait()
+	if res.Err != nil || !res.Changed || res.Revision != s.Repo.Revision {
+		t.Fatalf("expected the index to change, got %+v", res)
+	}
+
+	sr, err := s.Search("needle", &index.SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.FilesWithMatch != 1 {
+		t.Fatalf("expected needle to be found, got %d files", sr.FilesWithMatch)
+	}
+
+	if res := wait(); res.Err != nil || res.Changed {
+		t.Fatalf("expected nothing to change, got %+v", res)
+	}
+}
+
+// Dates the files changed since a time, like a shallow clone does.
+type datingDriver struct {
+	hungDriver
+	times map[string]time.Time
+	since time.Time
+}
+
+func (d *datingDriver) ModTimes(dir string) (map[string]time.Time, time.Time, error) {
+	return d.times, d.since, nil
+}
+
+func TestFileModTimes(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	since := at.AddDate(0, 0, -30)
+	wd := &vcs.WorkDir{Driver: &datingDriver{
+		times: map[string]time.Time{"dated.go": at},
+		since: since,
+	}}
+
+	times, from := fileModTimes(wd, "", "a")
+	if len(times) != 1 || times["dated.go"] != at.Unix() {
+		t.Fatalf("expected only dated.go to be dated, got %v", times)
+	}
+	if from != since.Unix() {
+		t.Fatalf("expected the dates to start at %d, got %d", since.Unix(), from)
+	}
+
+	// dates that go back all the way have no start
+	wd = &vcs.WorkDir{Driver: &datingDriver{times: map[string]time.Time{"dated.go": at}}}
+	if _, from := fileModTimes(wd, "", "a"); from != 0 {
+		t.Fatalf("expected the dates to have no start, got %d", from)
+	}
+
+	// drivers that can't date files don't
+	if times, _ := fileModTimes(&vcs.WorkDir{Driver: &hungDriver{}}, "", "a"); times != nil {
+		t.Fatalf("expected no dates, got %v", times)
+	}
+}
+
+// Keeps the indexes of every dbpath in a directory of its own and records
+// what it was asked to do.
+type recordingStorage struct {
+	*index.LocalStorage
+	lck   sync.Mutex
+	calls []string
+}
+
+func (s *recordingStorage) record(call string, ref *index.IndexRef) {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+	s.calls = append(s.calls, call+" "+filepath.Base(ref.Dir()))
+}
+
+func (s *recordingStorage) Save(ref *index.IndexRef) error {
+	s.record("save", ref)
+	return s.LocalStorage.Save(ref)
+}
+
+func (s *recordingStorage) Open(ref *index.IndexRef) (*index.Index, error) {
+	s.record("open", ref)
+	return s.LocalStorage.Open(ref)
+}
+
+func (s *recordingStorage) Remove(ref *index.IndexRef) error {
+	s.record("remove", ref)
+	return s.LocalStorage.Remove(ref)
+}
+
+func TestStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, dbpath, kept := filepath.Join(dir, "src"), filepath.Join(dir, "db"), filepath.Join(dir, "kept")
+	for _, d := range []string{src, dbpath, kept} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &recordingStorage{LocalStorage: index.NewLocalStorage(kept)}
+	defer SetStorage(newStorage)
+	SetStorage(func(string) index.Storage { return store })
+
+	// an orphan for the startup cleanup to remove
+	if err := os.Mkdir(filepath.Join(kept, "idx-orphan"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		DbPath: dbpath,
+		Repos: map[string]*config.Repo{
+			"a": {Url: "file://" + src, Vcs: "local", MsBetweenPolls: 60000},
+		},
+	}
+	searchers, errs, err := MakeAll(cfg)
+	if err != nil || len(errs) > 0 {
+		t.Fatal(err, errs)
+	}
+	s := searchers["a"]
+	defer s.Stop()
+
+	// the index is kept in the storage rather than in the dbpath
+	built := filepath.Base(s.IndexRef().Dir())
+	if filepath.Dir(s.IndexRef().Dir()) != kept {
+		t.Fatalf("expected the index to be kept in %s, got %s", kept, s.IndexRef().Dir())
+	}
+	if dirs, _ := filepath.Glob(filepath.Join(dbpath, "idx-*")); len(dirs) > 0 {
+		t.Fatalf("expected no indexes in the dbpath, got %v", dirs)
+	}
+
+	if got := strings.Join(store.calls, ","); got != "save "+built+",open "+built+",remove idx-orphan" {
+		t.Fatalf("unexpected calls %s", got)
+	}
+}
+
+func TestCircuit(t *testing.T) {
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	wd := &vcs.WorkDir{Driver: &hungDriver{context.Background()}}
+	s := &Searcher{
+		name: "hung",
+		Repo: &config.Repo{
+			Url:                   "file:///hung",
+			MsPullTimeout:         10,
+			PullAttempts:          1,
+			PullFailuresToBackOff: 2,
+			MsMaxPollBackoff:      5000,
+		},
+	}
+
+	poll := time.Second
+	lim := makeLimiter(1)
+	for i := 1; i <= 2; i++ {
+		if s.Circuit() != nil {
+			t.Fatalf("expected the circuit to be closed after %d failures", i-1)
+		}
+		if d := s.pollDelay(poll); d != poll {
+			t.Fatalf("expected the usual delay while closed, got %s", d)
+		}
+
+		if _, ok, _ := updateAndReindex(s, dbpath, filepath.Join(dbpath, "vcs-hung"), "hung", "", wd, &index.IndexOptions{}, lim); ok {
+			t.Fatal("expected the update to fail")
+		}
+	}
+
+	c := s.Circuit()
+	if c == nil || c.Repo != "hung" || c.Failures != 2 || c.Error == "" {
+		t.Fatalf("expected the circuit of hung to be open after 2 failures, got %+v", c)
+	}
+
+	// the delay doubles with every failure after the one that opened it
+	for _, d := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := s.pollDelay(poll); got != d {
+			t.Fatalf("expected a delay of %s, got %s", d, got)
+		}
+		s.pullFailed(fmt.Errorf("still gone"))
+	}
+
+	if c := s.Circuit(); c.NextPoll.IsZero() || c.Error != "still gone" {
+		t.Fatalf("expected the next poll and the last error, got %+v", c)
+	}
+
+	s.pullSucceeded()
+	if s.Circuit() != nil || s.pollDelay(poll) != poll {
+		t.Fatal("expected a successful pull to close the circuit")
+	}
+}
+
+// Stands in for the rest of a busy server's heap, which every collection
+// has to mark.
+var liveHeap [][]int
+
+// Reindex 100 repos at once, the way a push to all of them would, through
+// their pollers and the indexer limit. Each one collects garbage afterwards,
+// which is what interval changes.
+func benchmarkReindex(b *testing.B, interval time.Duration) {
+	defer SetGCInterval(time.Duration(atomic.LoadInt64(&afterReindex.interval)))
+	SetGCInterval(interval)
+
+	logger.SetLevel(logger.LevelError)
+	defer logger.SetLevel(logger.LevelInfo)
+
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbpath := filepath.Join(dir, "db")
+	if err := os.Mkdir(dbpath, 0755); err != nil {
+		b.Fatal(err)
+	}
+
+	// each repo has 50 files of about 4KB
+	var srcs []string
+	repos := map[string]interface{}{}
+	for r := 0; r < 100; r++ {
+		src := filepath.Join(dir, fmt.Sprintf("src-%d", r))
+		if err := os.Mkdir(src, 0755); err != nil {
+			b.Fatal(err)
+		}
+
+		for f := 0; f < 50; f++ {
+			var buf bytes.Buffer
+			for l := 0; l < 100; l++ {
+				fmt.Fprintf(&buf, "func repo%dFile%dLine%d() { return %d }\n", r, f, l, r*f*l)
+			}
+			if err := ioutil.WriteFile(filepath.Join(src, fmt.Sprintf("f%d.go", f)), buf.Bytes(), 0644); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		srcs = append(srcs, src)
+		repos[fmt.Sprintf("repo-%d", r)] = map[string]interface{}{
+			"url":                 "file://" + src,
+			"vcs":                 "local",
+			"enable-push-updates": true,
+		}
+	}
+
+	j, err := json.Marshal(map[string]interface{}{
+		"dbpath": dbpath,
+		"repos":  repos,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := cfg.LoadFromBytes(j, false); err != nil {
+		b.Fatal(err)
+	}
+
+	searchers, errs, err := MakeAll(&cfg)
+	if err != nil || len(errs) > 0 {
+		b.Fatal(err, errs)
+	}
+	defer func() {
+		for _, s := range searchers {
+			s.Stop()
+		}
+	}()
+
+	// waits for the updates of every repo, the first of which get the
+	// polls that began when they were made out of the way
+	update := func() {
+		var chs []<-chan *UpdateResult
+		for _, s := range searchers {
+			ch, _ := s.UpdateAndNotify()
+			chs = append(chs, ch)
+		}
+
+		for _, ch := range chs {
+			if res := <-ch; res.Err != nil {
+				b.Fatal(res.Err)
+			}
+		}
+	}
+	update()
+
+	liveHeap = make([][]int, 1<<18)
+	for i := range liveHeap {
+		liveHeap[i] = make([]int, 16)
+	}
+	defer func() { liveHeap = nil }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// a new file changes the modification time of the repo, which is
+		// what the local driver takes for its revision
+		b.StopTimer()
+		for _, src := range srcs {
+			if err := ioutil.WriteFile(filepath.Join(src, fmt.Sprintf("changed-%d.go", i)), []byte("var changed = true\n"), 0644); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StartTimer()
+
+		update()
+	}
+}
+
+// How reindexes collected garbage before, see SetGCInterval.
+func BenchmarkReindexInline(b *testing.B) {
+	benchmarkReindex(b, 0)
+}
+
+func BenchmarkReindexInBackground(b *testing.B) {
+	benchmarkReindex(b, defaultGCInterval)
+}
@@ -0,0 +1,379 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/index"
+	"github.com/etsy/hound/logger"
+	"github.com/etsy/hound/vcs"
+)
+
+const (
+	// How often the dbpath is swept for orphaned indexes.
+	sweepInterval = 10 * time.Minute
+
+	// Index directories younger than this are never swept, they may belong
+	// to a searcher that has only just been created.
+	sweepGracePeriod = 10 * time.Minute
+
+	// The number of least recently searched repos to report when the
+	// dbpath is over its budget.
+	overBudgetReportSize = 5
+)
+
+// The index directories that are currently being built.
+type buildingDirs struct {
+	lck  sync.Mutex
+	dirs map[string]bool
+}
+
+var building = &buildingDirs{dirs: map[string]bool{}}
+
+func (b *buildingDirs) add(dir string) {
+	b.lck.Lock()
+	defer b.lck.Unlock()
+	b.dirs[dir] = true
+}
+
+func (b *buildingDirs) remove(dir string) {
+	b.lck.Lock()
+	defer b.lck.Unlock()
+	delete(b.dirs, dir)
+}
+
+func (b *buildingDirs) has(dir string) bool {
+	b.lck.Lock()
+	defer b.lck.Unlock()
+	return b.dirs[dir]
+}
+
+// How much disk the dbpath uses, in bytes.
+type DiskUsage struct {
+	// used by the live indexes
+	Indexes int64
+
+	// used by everything in the dbpath, including working directories
+	Total int64
+
+	// the configured limit, 0 if there is none
+	Budget int64 `json:",omitempty"`
+
+	Measured time.Time
+}
+
+var lastUsage struct {
+	lck   sync.RWMutex
+	usage *DiskUsage
+}
+
+// The disk usage as of the last sweep, nil if there hasn't been one.
+func LastDiskUsage() *DiskUsage {
+	lastUsage.lck.RLock()
+	defer lastUsage.lck.RUnlock()
+	return lastUsage.usage
+}
+
+// Why an index directory in the dbpath is kept, see IndexDir.
+const (
+	// It's the live index of a searcher.
+	ClaimLive = "live"
+
+	// It's being built, or searched as an older revision of its repo.
+	ClaimBuilding = "building"
+
+	// It's too new to tell, it may belong to a searcher that is being
+	// created (see sweepGracePeriod).
+	ClaimRecent = "recent"
+
+	// It's the index a disabled repo will serve once it's enabled again,
+	// the one its marker names (see readCurrent) or, without one, its
+	// latest.
+	ClaimDisabled = "disabled"
+)
+
+// An index directory in the dbpath and what, if anything, it belongs to.
+type IndexDir struct {
+	Dir   string
+	Url   string `json:",omitempty"`
+	Rev   string `json:",omitempty"`
+	Built time.Time
+
+	// The repo whose live index it is.
+	Repo string `json:",omitempty"`
+
+	// Why it's kept, one of the Claim constants. It's an orphan that can be
+	// removed if this is empty.
+	Claim string `json:",omitempty"`
+
+	ref *index.IndexRef
+}
+
+// List the index directories in the dbpath of cfg and what they belong to.
+// Directories whose manifest can't be read are listed without a url.
+func ListIndexes(cfg *config.Config, searchers map[string]*Searcher) ([]*IndexDir, error) {
+	live := map[string]string{}
+	for name, s := range searchers {
+		live[s.IndexRef().Dir()] = name
+	}
+
+	refs, err := findExistingRefs(storageFor(cfg.DbPath))
+	if err != nil {
+		return nil, err
+	}
+
+	// the same index the repo claims at startup, see skipDisabled
+	disabled := map[*index.IndexRef]bool{}
+	for name, repo := range cfg.Repos {
+		if repo.IsEnabled() {
+			continue
+		}
+
+		if ref := refs.findLatestCurrent(repo.Url, readCurrent(cfg.DbPath, name)); ref != nil {
+			disabled[ref] = true
+		}
+	}
+
+	var res []*IndexDir
+	for _, ref := range refs.refs {
+		dir := ref.Dir()
+		d := &IndexDir{
+			Dir:   dir,
+			Url:   ref.Url,
+			Rev:   ref.Rev,
+			Built: ref.Time,
+			ref:   ref,
+		}
+
+		// an index whose directory can't be looked at may be on its way in
+		fi, err := os.Stat(dir)
+		switch name, ok := live[dir]; {
+		case ok:
+			d.Repo, d.Claim = name, ClaimLive
+		case building.has(dir):
+			d.Claim = ClaimBuilding
+		case err != nil || time.Since(fi.ModTime()) < sweepGracePeriod:
+			d.Claim = ClaimRecent
+		case disabled[ref]:
+			d.Claim = ClaimDisabled
+		}
+		res = append(res, d)
+	}
+
+	return res, nil
+}
+
+// Remove the index directories in the dbpath of cfg that don't belong to
+// anything (see ListIndexes), returns the directories that were removed.
+// Unlike the cleanup at startup, this is safe to run while searchers are
+// being built.
+func SweepOrphans(cfg *config.Config, searchers map[string]*Searcher) ([]string, error) {
+	dirs, err := ListIndexes(cfg, searchers)
+	if err != nil {
+		return nil, err
+	}
+
+	store := storageFor(cfg.DbPath)
+
+	var removed []string
+	for _, d := range dirs {
+		if d.Claim != "" {
+			continue
+		}
+
+		if err := store.Remove(d.ref); err != nil {
+			return removed, err
+		}
+		removed = append(removed, d.Dir)
+	}
+
+	return removed, nil
+}
+
+// The working directory of repo in dbpath, see vcs.Driver.
+func workingDirFor(dbpath string, repo *config.Repo) (string, error) {
+	wd, err := vcs.New(repo.Vcs, repo.VcsConfig())
+	if err != nil {
+		return "", err
+	}
+	return wd.WorkingDirForRepo(dbpath, repo)
+}
+
+// Is dir inside the dbpath (and not the dbpath itself)?
+func inDbPath(dbpath, dir string) bool {
+	rel, err := filepath.Rel(dbpath, dir)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Remove what the searcher of a repo that was removed from cfg leaves in the
+// dbpath: its working directory, if it's a clone hound made there (see
+// vcs.ManagedDriver), the indexes of its url that nothing else claims and its
+// marker (see currentMarker). searchers are the ones that are still running,
+// and s must be stopped and waited for first. While another repo of cfg has
+// the same working directory, or the same url, that repo keeps the working
+// directory or the indexes. The repos of cfg can't change while it does.
+// Returns the directories that were removed.
+func (s *Searcher) Cleanup(cfg *config.Config, searchers map[string]*Searcher) ([]string, error) {
+	if cfg.Repos[s.name] == nil {
+		if err := removeCurrent(cfg.DbPath, s.name); err != nil {
+			return nil, err
+		}
+	}
+
+	// repos of the same url can still have working directories of their
+	// own, like when they check out different branches
+	var sharedDir, sharedUrl bool
+	for _, repo := range cfg.Repos {
+		if repo.Url != s.Repo.Url {
+			continue
+		}
+		sharedUrl = true
+
+		if dir, err := workingDirFor(cfg.DbPath, repo); err != nil || dir == s.vcsDir {
+			sharedDir = true
+		}
+	}
+
+	var removed []string
+	if s.wd.ManagesWorkingDir() && !sharedDir {
+		// a bare repo's working tree is next to it
+		for _, dir := range []string{s.wd.WorkTree(s.vcsDir), s.vcsDir} {
+			if !inDbPath(cfg.DbPath, dir) {
+				continue
+			}
+			if _, err := os.Stat(dir); err != nil {
+				continue
+			}
+
+			if err := os.RemoveAll(dir); err != nil {
+				return removed, err
+			}
+			removed = append(removed, dir)
+		}
+	}
+
+	// the indexes are known by their url only
+	if sharedUrl {
+		return removed, nil
+	}
+
+	dirs, err := ListIndexes(cfg, searchers)
+	if err != nil {
+		return removed, err
+	}
+
+	// the searcher's own index is recent when it was only just built
+	store := storageFor(cfg.DbPath)
+	own := s.IndexRef().Dir()
+	for _, d := range dirs {
+		if d.Url != s.Repo.Url || !(d.Claim == "" || d.Claim == ClaimRecent && d.Dir == own) {
+			continue
+		}
+
+		if err := store.Remove(d.ref); err != nil {
+			return removed, err
+		}
+		removed = append(removed, d.Dir)
+	}
+
+	return removed, nil
+}
+
+// Measure how much disk the dbpath and the live indexes use.
+func measureDiskUsage(cfg *config.Config, searchers map[string]*Searcher) (*DiskUsage, error) {
+	total, err := index.DirSize(cfg.DbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &DiskUsage{
+		Total:    total,
+		Budget:   cfg.MaxDbPathSize,
+		Measured: time.Now(),
+	}
+
+	for _, s := range searchers {
+		usage.Indexes += s.IndexRef().Size
+	}
+
+	return usage, nil
+}
+
+// The names of the n least recently searched searchers.
+func leastRecentlySearched(searchers map[string]*Searcher, n int) []string {
+	names := make([]string, 0, len(searchers))
+	for name := range searchers {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return searchers[names[i]].LastSearched().Before(searchers[names[j]].LastSearched())
+	})
+
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
+
+// Sweep the dbpath once, removing orphaned indexes and checking the disk
+// usage against the budget. The repos of cfg can't change while it does.
+func sweep(cfg *config.Config, searchers map[string]*Searcher) {
+	cfg.RLockRepos()
+	defer cfg.RUnlockRepos()
+
+	removed, err := SweepOrphans(cfg, searchers)
+	for _, dir := range removed {
+		logger.Info("removed orphaned index", logger.Fields{
+			"event": "sweep",
+			"dir":   dir,
+		})
+	}
+	if err != nil {
+		logger.Error("failed to sweep orphaned indexes", logger.Fields{
+			"event": "sweep",
+			"error": err,
+		})
+	}
+
+	usage, err := measureDiskUsage(cfg, searchers)
+	if err != nil {
+		logger.Error("failed to measure dbpath", logger.Fields{
+			"event": "sweep",
+			"error": err,
+		})
+		return
+	}
+
+	lastUsage.lck.Lock()
+	lastUsage.usage = usage
+	lastUsage.lck.Unlock()
+
+	if usage.Budget > 0 && usage.Total > usage.Budget {
+		logger.Error("dbpath is over its size budget", logger.Fields{
+			"event":                 "sweep",
+			"size":                  usage.Total,
+			"budget":                usage.Budget,
+			"leastRecentlySearched": leastRecentlySearched(searchers, overBudgetReportSize),
+		})
+	}
+}
+
+// Periodically sweep the dbpath in the background. The searchers are asked
+// for on every sweep since they change as the config is reloaded.
+func StartSweeper(cfg *config.Config, searchers func() map[string]*Searcher) {
+	go func() {
+		for {
+			sweep(cfg, searchers())
+			time.Sleep(sweepInterval)
+		}
+	}()
+}
@@ -0,0 +1,252 @@
+package searcher
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/etsy/hound/config"
+	"github.com/etsy/hound/index"
+)
+
+func TestSweepOrphans(t *testing.T) {
+	dbpath, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	old := time.Now().Add(-2 * sweepGracePeriod)
+	mkdir := func(name string, mtime time.Time) string {
+		dir := filepath.Join(dbpath, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(dir, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	orphan := mkdir("idx-orphan", old)
+	recent := mkdir("idx-recent", time.Now())
+	inProgress := mkdir("idx-building", old)
+	vcsDir := mkdir("vcs-repo", old)
+
+	building.add(inProgress)
+	defer building.remove(inProgress)
+
+	removed, err := SweepOrphans(&config.Config{DbPath: dbpath}, map[string]*Searcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(removed) != 1 || removed[0] != orphan {
+		t.Fatalf("expected only %s to be removed, got %v", orphan, removed)
+	}
+
+	for _, dir := range []string{recent, inProgress, vcsDir} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Fatalf("expected %s to be kept", dir)
+		}
+	}
+}
+
+func TestListIndexes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	dbpath := filepath.Join(dir, "db")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-2 * sweepGracePeriod)
+	build := func(name, url, rev string, built time.Time) string {
+		dst := filepath.Join(dbpath, name)
+		if _, err := index.Build(&index.IndexOptions{}, dst, src, url, rev); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(dst, built, built); err != nil {
+			t.Fatal(err)
+		}
+		return dst
+	}
+
+	// of the disabled repo's indexes, only the latest is kept
+	build("idx-disabled-1", "file:///disabled", "r1", old)
+	build("idx-disabled-2", "file:///disabled", "r2", old)
+	build("idx-gone", "file:///gone", "r1", old)
+	build("idx-new", "file:///new", "r1", time.Now())
+
+	disabled := false
+	cfg := &config.Config{
+		DbPath: dbpath,
+		Repos: map[string]*config.Repo{
+			"disabled": {Url: "file:///disabled", Enabled: &disabled},
+		},
+	}
+
+	dirs, err := ListIndexes(cfg, map[string]*Searcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]string{}
+	for _, d := range dirs {
+		claims[filepath.Base(d.Dir)] = d.Claim
+	}
+
+	expected := map[string]string{
+		"idx-disabled-1": "",
+		"idx-disabled-2": ClaimDisabled,
+		"idx-gone":       "",
+		"idx-new":        ClaimRecent,
+	}
+	if !reflect.DeepEqual(claims, expected) {
+		t.Fatalf("expected claims of %v, got %v", expected, claims)
+	}
+
+	removed, err := SweepOrphans(cfg, map[string]*Searcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("expected the 2 orphans to be removed, got %v", removed)
+	}
+
+	// the index the repo's marker names is kept rather than its latest,
+	// that's the one it serves again
+	build("idx-disabled-3", "file:///disabled", "r3", old)
+	if err := writeCurrent(dbpath, "disabled", filepath.Join(dbpath, "idx-disabled-2")); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, err = ListIndexes(cfg, map[string]*Searcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims = map[string]string{}
+	for _, d := range dirs {
+		claims[filepath.Base(d.Dir)] = d.Claim
+	}
+
+	expected = map[string]string{
+		"idx-disabled-2": ClaimDisabled,
+		"idx-disabled-3": "",
+		"idx-new":        ClaimRecent,
+	}
+	if !reflect.DeepEqual(claims, expected) {
+		t.Fatalf("expected claims of %v, got %v", expected, claims)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	dbpath := filepath.Join(dir, "db")
+	if err := os.MkdirAll(dbpath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "", "init", "-q", src)
+	commitFile(t, src, "a.txt", "needle\n")
+
+	start := func(name, vcs string) *Searcher {
+		s, err := newSearcher(dbpath, name, &config.Repo{
+			Url:            "file://" + src,
+			Vcs:            vcs,
+			MsBetweenPolls: 60000,
+		}, &foundRefs{}, makeLimiter(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.begin()
+		s.Stop()
+		s.Wait()
+		return s
+	}
+
+	// the clone and the index of a git repo are removed
+	cloned := start("a", "git")
+	removed, err := cloned.Cleanup(&config.Config{DbPath: dbpath}, map[string]*Searcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(removed)
+	expected := []string{cloned.IndexRef().Dir(), cloned.vcsDir}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(removed, expected) {
+		t.Fatalf("expected %v to be removed, got %v", expected, removed)
+	}
+	for _, dir := range expected {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be gone", dir)
+		}
+	}
+
+	// while another repo has the url and the clone, nothing is
+	kept := start("b", "git")
+	removed, err = kept.Cleanup(&config.Config{
+		DbPath: dbpath,
+		Repos:  map[string]*config.Repo{"c": {Url: kept.Repo.Url, Vcs: "git"}},
+	}, map[string]*Searcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("expected nothing to be removed, got %v", removed)
+	}
+
+	// a repo of the url that clones another branch only keeps the indexes
+	branch := &config.Repo{Url: kept.Repo.Url, Vcs: "git", VcsConfigMessage: &config.SecretMessage{}}
+	*branch.VcsConfigMessage = []byte(`{"ref": "other"}`)
+	removed, err = kept.Cleanup(&config.Config{
+		DbPath: dbpath,
+		Repos:  map[string]*config.Repo{"c": branch},
+	}, map[string]*Searcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != kept.vcsDir {
+		t.Fatalf("expected just the clone to be removed, got %v", removed)
+	}
+	if _, err := os.Stat(kept.IndexRef().Dir()); err != nil {
+		t.Fatal("expected the index to be kept")
+	}
+
+	// the directory of a local repo is the user's, only its index goes (the
+	// one of b is too recent to tell whether it's an orphan)
+	local := start("d", "local")
+	removed, err = local.Cleanup(&config.Config{DbPath: dbpath}, map[string]*Searcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != local.IndexRef().Dir() {
+		t.Fatalf("expected just the index to be removed, got %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(src, "a.txt")); err != nil {
+		t.Fatal("expected the local repo to be kept")
+	}
+}
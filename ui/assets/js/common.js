@@ -5,7 +5,9 @@ export function ExpandVars(template, values) {
     return template;
 };
 
-export function UrlToRepo(reponame, repo, path, line, rev) {
+// Links to line (or, if lineEnd is past it, the lines from line to lineEnd)
+// of the file at path in the repo's web UI.
+export function UrlToRepo(reponame, repo, path, line, rev, lineEnd) {
     if (typeof(repo) == 'undefined') {
         // repo is not found, might be caused by hot-reloading, put url to be /
         return '/';
@@ -16,6 +18,10 @@ export function UrlToRepo(reponame, repo, path, line, rev) {
         filename = path.substring(path.lastIndexOf('/') + 1),
         anchor = line ? ExpandVars(pattern.anchor, { line : line, filename : filename }) : '';
 
+    if (line && lineEnd > line && pattern['range-anchor']) {
+        anchor = ExpandVars(pattern['range-anchor'], { line : line, lineEnd : lineEnd, filename : filename });
+    }
+
     // Determine if the URL passed is a GitHub wiki
     var wikiUrl = /\.wiki$/.exec(url);
     if (wikiUrl) {
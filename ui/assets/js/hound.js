@@ -71,6 +71,7 @@ var ParamsFromUrl = function(params) {
   params = params || {
     q: '',
     i: 'true',
+    literal: 'false',
     files: '',
     repos: '*'
   };
@@ -227,7 +228,8 @@ var Model = {
         _this.didSearch.raise(_this, _this.results, _this.stats);
       },
       error: function(xhr, status, err) {
-        _this.didError.raise(this, "The server broke down");
+        var data = xhr.responseJSON;
+        _this.didError.raise(this, data && data.Error ? data.Error : "The server broke down");
       }
     });
   },
@@ -263,7 +265,8 @@ var Model = {
         _this.didLoadMore.raise(_this, repo, _this.results);
       },
       error: function(xhr, status, err) {
-        _this.didError.raise(this, "The server broke down");
+        var data = xhr.responseJSON;
+        _this.didError.raise(this, data && data.Error ? data.Error : "The server broke down");
       }
     });
   },
@@ -290,8 +293,8 @@ var Model = {
     return url.substring(bx + 1, ax) + ' / ' + name;
   },
 
-  UrlToRepo: function(repo, path, line, rev) {
-    return UrlToRepo(this.repos[repo], path, line, rev);
+  UrlToRepo: function(repo, path, line, rev, lineEnd) {
+    return UrlToRepo(repo, this.repos[repo], path, line, rev, lineEnd);
   }
 
 };
@@ -373,9 +376,11 @@ var SearchBar = React.createClass({
     this.props.onSearchRequested(this.getParams());
   },
   getRegExp : function() {
-    return new RegExp(
-      this.refs.q.getDOMNode().value.trim(),
-      this.refs.icase.getDOMNode().checked ? 'ig' : 'g');
+    var q = this.refs.q.getDOMNode().value.trim();
+    if (this.refs.literal.getDOMNode().checked) {
+      q = q.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+    }
+    return new RegExp(q, this.refs.icase.getDOMNode().checked ? 'ig' : 'g');
   },
   getParams: function() {
     // selecting all repos is the same as not selecting any, so normalize the url
@@ -389,16 +394,19 @@ var SearchBar = React.createClass({
       q : this.refs.q.getDOMNode().value.trim(),
       files : this.refs.files.getDOMNode().value.trim(),
       repos : repos.join(','),
-      i: this.refs.icase.getDOMNode().checked ? 'fosho' : 'nope'
+      i: this.refs.icase.getDOMNode().checked ? 'fosho' : 'nope',
+      literal: this.refs.literal.getDOMNode().checked ? 'fosho' : 'nope'
     };
   },
   setParams: function(params) {
     var q = this.refs.q.getDOMNode(),
         i = this.refs.icase.getDOMNode(),
+        literal = this.refs.literal.getDOMNode(),
         files = this.refs.files.getDOMNode();
 
     q.value = params.q;
     i.checked = ParamValueToBool(params.i);
+    literal.checked = ParamValueToBool(params.literal || '');
     files.value = params.files;
   },
   hasAdvancedValues: function() {
@@ -501,6 +509,12 @@ var SearchBar = React.createClass({
                 <input id="ignore-case" type="checkbox" ref="icase" />
               </div>
             </div>
+            <div className="field">
+              <label htmlFor="literal" title="Match the query as is, which is best for symbols like a.b(c)">Literal</label>
+              <div className="field-input">
+                <input id="literal" type="checkbox" ref="literal" />
+              </div>
+            </div>
             <div className="field">
               <label className="multiselect_label" htmlFor="repos">Select Repo</label>
               <div className="field-input">
@@ -652,11 +666,17 @@ var FileContentView = React.createClass({
           fileName = this.props.fileName,
           blocks = this.props.blocks;
       var matches = blocks.map(function(block) {
+        var start = block[0].Number,
+            end = block[block.length - 1].Number;
         var lines = block.map(function(line) {
           var content = ContentFor(line, regexp);
+          // a matched line links to its whole block, context to itself
+          var url = line.Match ?
+              Model.UrlToRepo(repo, fileName, start, rev, end) :
+              Model.UrlToRepo(repo, fileName, line.Number, rev);
           return (
             <div className="line">
-              <a href={Model.UrlToRepo(repo, fileName, line.Number, rev)}
+              <a href={url}
                   className="lnum"
                   target="_blank">{line.Number}</a>
               <span className="lval" dangerouslySetInnerHTML={{__html:content}} />
@@ -668,10 +688,16 @@ var FileContentView = React.createClass({
         );
       });
 
+      // the file itself links to its first block
+      var first = blocks[0] || [],
+          url = first.length ?
+              Model.UrlToRepo(repo, fileName, first[0].Number, rev, first[first.length - 1].Number) :
+              Model.UrlToRepo(repo, fileName, null, rev);
+
       return (
         <div className={"file " + (this.state.open ? 'open' : 'closed')}>
           <div className="title" onClick={this.toggleContent}>
-            <a href={Model.UrlToRepo(repo, fileName, null, rev)}>
+            <a href={url}>
               {fileName}
             </a>
           </div>
@@ -854,6 +880,7 @@ var App = React.createClass({
     this.setState({
       q: params.q,
       i: params.i,
+      literal: params.literal,
       files: params.files,
       repos: repos
     });
@@ -908,6 +935,7 @@ var App = React.createClass({
     var path = location.pathname +
       '?q=' + encodeURIComponent(params.q) +
       '&i=' + encodeURIComponent(params.i) +
+      '&literal=' + encodeURIComponent(params.literal) +
       '&files=' + encodeURIComponent(params.files) +
       '&repos=' + params.repos;
     history.pushState({path:path}, '', path);
@@ -918,6 +946,7 @@ var App = React.createClass({
         <SearchBar ref="searchBar"
             q={this.state.q}
             i={this.state.i}
+            literal={this.state.literal}
             files={this.state.files}
             repos={this.state.repos}
             onSearchRequested={this.onSearchRequested} />
@@ -409,7 +409,7 @@ func jsBabelStandalone6260MinJs() (*asset, error) {
 	return a, nil
 }
 
-var _jsCommonJs = "\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x54\x4d\x6f\xdb\x38\x10\xbd\xfb\x57\xcc\xa1\x00\xa5\x46\x11\x77\x17\x39\xc9\x30\x9a\xc3\x6e\x37\x05\xf6\x0b\x69\xb3\x97\xc4\xa8\x29\x69\x24\x72\x4d\x91\x02\x3f\x62\x1b\xa9\xff\xfb\x82\xd4\x47\x15\xa7\x3e\xd0\x24\xf5\xe6\x91\xf3\xf8\x66\xf0\xd8\x6b\xe3\xa0\xf1\xaa\x72\x42\x2b\xf8\xed\xd8\x33\x55\xff\xcb\x8c\x4d\x1c\x76\xbd\x64\x0e\x33\x78\x66\xd2\xa3\x4d\xe1\x65\x05\x00\xd0\x68\x03\xc9\x33\x33\xa0\x58\x87\x20\xd4\xc5\xe7\xf0\x9b\x42\x61\x33\x4f\x73\x83\xbd\x64\x15\x26\xe4\x85\xc0\xd5\x10\x7b\x05\xe4\x4c\x26\xfa\xc7\xb0\xb5\x4d\xd7\x91\xe4\x1c\x47\x83\xce\x1b\x35\x53\xac\x57\xe7\xf5\x6a\x75\x79\xe3\x07\x23\xbf\xe8\x7b\xec\x75\x62\xb0\xd7\x81\x24\x83\x30\xcb\xa0\x67\x8e\x67\x20\x85\x8a\x3b\xcf\xd3\x05\x45\x03\x89\x3b\xf5\xa8\x9b\x18\x91\xc2\x66\x03\xc4\xab\x1a\x1b\xa1\xb0\x26\xcb\x3c\x28\x8d\x54\x20\x2c\x28\xed\xa0\xd1\x5e\xd5\x19\x74\xa2\xe5\x0e\x4a\x84\x8a\x79\x8b\x35\x94\x27\xe0\xda\x5d\x1b\x94\x9a\xd5\x42\xb5\x19\xf4\xde\x81\x37\x12\x9c\x0e\x30\x3a\xf3\x8d\x09\x11\x4a\xa6\x34\xe3\x5f\x10\x33\xc0\x37\xf1\xb4\xdc\x1b\x39\xab\x45\x9f\xf2\x56\xb8\x77\x34\x03\x42\xd2\x6c\x26\xea\x99\x73\x68\xd4\x18\xf1\x48\xbc\x91\xd7\xe3\x1e\xd9\x7e\x87\x35\x42\x62\x54\x7a\x13\xc5\xc8\xad\x2f\xad\x33\x42\xb5\x49\x5c\x4a\x66\xdd\x27\x55\xe3\xf1\xef\x26\x21\x94\xa4\x70\x05\x3f\x2f\x0e\x61\xaa\xe2\xda\xc0\x26\x4a\x08\x1f\x96\xde\x18\xcf\xca\x07\x48\x06\x2f\x03\xa6\x18\xd5\x9e\xcf\x2d\xbe\x4f\xcf\x29\x14\x40\xc8\x7a\x48\x99\x52\xf8\x15\x1d\x9a\x2e\x84\x89\x06\x1c\x47\x78\xb8\xff\x03\x7a\x66\x83\xa4\xc2\x02\x83\xdf\x85\xbb\xf3\x25\x1c\xc4\x5e\xcc\x32\x85\xc5\x43\x94\x8a\x3e\xe5\x61\xf1\x8e\xe6\x78\xc4\x2a\xf1\x46\x8e\xde\x09\xef\x3b\xc2\x96\x6f\x39\x08\x7c\xa1\x6d\xc0\x05\x6d\x69\x98\x90\x74\xa9\x2f\x9f\x44\x5b\xc0\xbb\x7a\x7c\x89\xb7\x1a\x11\x12\x72\x0a\x34\x16\x6a\x1d\xed\x62\x7d\x1f\xad\x5a\x0b\x83\x95\x1b\x14\x92\x42\xed\x85\x6a\x97\xaf\x4f\x29\xdc\xb1\x6a\x7f\x02\xab\xa5\x8f\x96\x76\x1a\x1a\x71\x84\xaf\x56\x77\x08\x9d\x36\xf8\x15\xf4\x20\xd1\xcd\x4f\x37\xc4\xc2\x81\xa3\x02\x6f\x85\x6a\xe1\xf3\xe7\x3b\xb0\xee\x24\xa3\x7a\x36\x9f\x08\xbf\x70\x61\xe1\xa0\xcd\xde\xc6\x72\x2d\xb5\xe3\xd0\x0a\xc7\x7d\xb9\x40\x43\xd2\x0a\x77\x3b\x6c\xe7\x95\xee\x0a\x6f\xd1\x84\xb7\xa2\x1f\xb5\x0e\xb6\x4b\x81\xa9\x7a\xa2\x2c\x85\x2b\x7d\xb5\x47\xf7\x8a\xc1\x5a\x5e\x50\xca\xdb\xdb\xf9\x73\xae\x4d\x4b\x97\x4c\x69\x3e\xe7\x79\x8f\x2d\x1e\x01\x8f\xbd\x64\xa1\xd6\x0a\xf8\x93\xb9\x8a\x03\x0a\xc7\xd1\xc0\xae\x15\x6e\x07\xda\xc0\x8e\xb7\x3b\x68\xb4\x94\xfa\x30\x94\x17\x53\xb0\xbb\xdd\xcd\xd9\xfd\x85\x47\x97\x81\x95\xde\xf4\xe0\xfb\x28\x0c\xd7\xd6\x45\x9b\x95\x27\x30\x18\xcb\x10\xbc\x72\x42\x4e\xe4\x0c\x76\xc5\x40\x4e\x77\xc1\x5e\xb1\x96\x67\xc6\x8f\x42\x31\x29\x4f\x19\xb4\x86\x95\xc0\xa4\x04\x83\x1d\x13\x2a\xd0\x54\x9c\x19\x56\x39\x34\xa3\xbc\xc1\x86\xd6\xf2\x7f\x98\x71\x36\xf8\x30\xa8\xf8\x8d\xb7\xe9\x6d\x92\xbf\xff\x90\x26\xc5\xb7\x27\x9a\x26\xf9\xfb\xf4\x87\xc6\x9c\x02\xdf\x3a\x93\x50\x1a\x1a\xe3\x04\x78\xfc\x65\x1b\xfa\xe3\xeb\xbd\x9b\xed\xfa\xc2\x39\x9f\x48\x07\xd6\x1b\x0c\x22\x18\x1c\xea\x46\x89\x0a\x0d\xdc\x23\xab\x1c\xfd\xcf\x1e\xe1\xc0\x4e\xc1\x52\xb5\x06\xc7\x85\x2d\x96\xad\xf5\x6d\x49\x3f\x92\x92\x59\xbc\xf6\x46\x92\x6d\x76\x71\xc9\x22\x8c\xaf\x9a\x10\x2f\x86\x2e\xbb\xe8\x70\xcf\x45\x18\x2e\xbb\x48\x31\xfe\x2f\x91\x43\xb7\x2e\xe6\xd9\x90\x5a\xba\x5e\x9d\x57\xff\x07\x00\x00\xff\xff\x1f\xa4\xab\xac\x97\x06\x00\x00"
+var _jsCommonJs = "\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\x55\x4d\x6f\xdc\x36\x10\xbd\xef\xaf\x78\x87\x20\x94\x62\x59\x6c\x8b\x9c\x64\xb8\xf1\xa1\x49\x13\x20\xfd\x80\x13\xf7\xe2\x18\x59\x4a\x1a\x49\xac\xb9\xa4\xc0\x0f\x7b\x8d\x64\xff\x7b\x41\xae\x24\xaf\xd7\x29\x60\xc8\xe4\xec\xe3\x9b\xe1\x9b\x0f\xd2\x76\x34\xd6\xa3\x0b\xba\xf1\xd2\x68\xbc\xdd\x8e\x42\xb7\xff\x08\xeb\x32\x4f\x9b\x51\x09\x4f\x05\xee\x84\x0a\xe4\x72\x7c\x5b\x01\x40\x67\x2c\xb2\x3b\x61\xa1\xc5\x86\x20\xf5\xd1\xcf\xf1\x6f\x3e\x8a\xf3\x65\x59\x5a\x1a\x95\x68\x28\x63\xdf\x18\x4e\xf6\x67\x4f\xc0\x76\x6c\xa6\xbf\x8e\xa6\x9b\xfc\x2c\x91\xec\xd2\xd7\x92\x0f\x56\x2f\x14\x67\xab\xdd\xd9\x6a\xc5\x39\x3e\x4a\x7d\xeb\xe0\x0d\x94\xd4\x84\xcc\xd8\x02\xb2\x4b\x9b\xb7\xba\x85\x74\x18\x85\xf3\x90\xbe\x80\x1f\x28\xd9\x1d\x3a\x6b\x36\x69\x39\x9f\x7b\xab\xdb\x3c\x92\x99\x2e\xa1\x3a\xa9\x08\xc2\x63\x14\x7e\x88\x97\x8a\x36\x4b\xa3\x61\x0e\xf7\x54\xe3\xea\x43\xb9\x3a\xd6\xea\xca\xaa\xcf\xe6\x92\x46\x93\x45\x60\x0c\xbf\x40\x5c\x15\x89\xa4\x48\xde\xa2\xe5\xae\x58\x1c\x4e\x1a\xc9\x0e\x99\x7f\x18\xc9\x74\xe9\x68\x8e\xf3\x73\xb0\xa0\x5b\xea\xa4\xa6\x96\x1d\x4a\xc9\x79\xe2\x8c\xb7\xd2\xc6\xa3\x33\x41\xb7\x05\x36\xb2\x1f\x3c\x6a\x42\x23\x82\xa3\x16\xf5\x03\x06\xe3\x4f\x2d\x29\x23\x5a\xa9\xfb\x02\x63\xf0\x08\x56\xc5\xdb\xd6\x04\xbe\xf0\x4d\x9a\x32\xce\x66\xa5\xd3\xbf\x98\xcf\x08\x3f\x4f\xde\xca\x60\xd5\x92\x30\xfe\xa5\xec\xa5\x7f\xc1\x0b\x30\x96\x17\x0b\xd1\x28\xbc\x27\xab\xa7\x13\xd7\x2c\x58\x75\x3a\xd9\xd8\xcd\x23\x2c\xea\x1a\xa5\xc1\x79\x52\xa5\x74\xa1\x76\xde\x4a\xdd\x67\x69\xab\x84\xf3\x1f\x74\x4b\xdb\xbf\xba\x8c\x71\x96\xe3\x04\x3f\x1f\x38\x11\xba\x19\x8c\xc5\x79\x12\x10\x6f\x0e\xcb\x73\xf2\x55\xee\x21\x05\xbe\xed\x31\xd5\x24\xfb\xe2\xb7\x7a\x0c\x61\x97\xa3\x02\x63\x67\xab\x25\x09\x11\x8b\x97\x2f\xe7\xfc\xe0\x57\xcc\x96\x89\xfe\x9a\x59\xa1\x7b\x3a\xdd\x7b\x61\x37\x87\xa9\x59\x82\x7b\x1e\xd5\xf1\xb1\x67\xe1\xcd\x0e\xab\xd9\xf5\xff\x45\xfc\x24\x4b\x9c\xe3\x37\xf2\x64\x37\x31\x48\xb9\x2f\xdc\xab\xcb\x8f\xb1\xde\x63\x15\x48\x07\x81\xdf\xa5\x7f\x1f\x6a\xdc\xcb\x5b\xb9\x64\x36\x6e\xae\x52\x76\xf9\x97\x32\x6e\x5e\xf0\x92\xb6\xd4\x64\xc1\xaa\xc9\x43\x2c\xc9\x09\x76\x78\xc7\x7d\x4d\x1c\x95\x43\xc4\xc5\x72\xe0\x71\xc1\xf2\x05\x1c\x33\x3a\xe7\xf9\x00\xbe\x69\xa7\xe2\x79\xae\x1c\x63\xe0\x3c\xc5\xea\xd0\x9a\x54\xe1\x2e\x8c\xa9\xcd\x5a\x69\xa9\xf1\x49\x9e\xf8\xb9\x95\xba\x3f\x92\xe2\xbd\x68\x6e\x1f\xe0\x8c\x0a\x69\x74\x79\x83\x4e\x6e\xf1\xd5\x99\x0d\x61\x63\x2c\x7d\x9d\x7b\xfb\xf5\x4f\xaf\x63\x1b\x0f\xa4\x11\x9c\xd4\x3d\x3e\x7d\x7a\x0f\xe7\x1f\x54\x52\xcf\x95\x33\xe1\xe7\x41\x3a\xdc\x1b\x7b\xeb\xd2\x90\xab\x8d\x1f\xd0\x4b\x3f\x84\xfa\x00\x8d\xac\x97\xfe\x62\x6f\x2e\x1b\xb3\xa9\x82\x23\x1b\xf3\xc6\xdf\x19\x13\x3b\x25\x87\xd0\xed\x4c\x59\x4b\x5f\x87\xe6\x96\xfc\x13\x06\xe7\x86\x8a\xf3\xa1\xbf\x58\x7e\x2e\x8d\xed\xf9\x21\x53\x5e\x2e\xf7\xbc\xa4\x9e\xb6\xa0\xed\xa8\x44\x1c\x0f\x15\xfe\x10\xbe\x19\x40\xd2\x0f\x64\xb1\xee\xa5\x5f\xc3\x58\xac\x87\x7e\x8d\xce\x28\x65\xee\xf7\x13\x41\x68\xac\x2f\xd6\xcb\xed\xfe\xa4\xad\x2f\xe0\x54\xb0\x23\xc2\x98\x6a\x67\x30\xce\x47\x87\x11\x6d\x29\x4d\x0e\x04\xed\xa5\x9a\xc9\x05\xd6\xd5\x9e\x9c\xaf\xe3\x08\x4a\xe3\x67\x61\x7c\x27\xb5\x50\xea\xa1\x40\x6f\x45\x0d\xa1\x14\x2c\x6d\x84\xd4\x91\xa6\x19\x84\x15\x8d\x27\x3b\xc9\x1b\x07\x8c\x73\xc3\xdf\xc2\x7a\x17\xeb\x30\xaa\xf8\x7d\xe8\xf3\x8b\xac\x7c\xf5\x26\xcf\xaa\xef\x5f\x78\x9e\x95\xaf\xf2\x1f\x16\xe6\x7c\xf0\x79\x65\x32\xce\xe3\x73\x32\x03\xae\x7f\xb9\xc1\x09\xd8\x53\xdb\xeb\x9b\xe3\x26\xfa\xc0\x36\x70\xc1\x52\x14\xc1\x52\xbc\x98\x80\x96\x0d\x59\x5c\x92\x68\x3c\xff\xd7\x6d\x71\x2f\x1e\xe2\xf0\x6c\x0d\xfc\x20\x5d\x75\xf8\x20\xfd\xa8\xdf\x6b\xe1\xe8\x34\x58\x95\x7a\xfd\x49\x90\x55\xfc\x3e\x8e\xb4\xd8\x24\x55\xea\x91\x47\x9b\xa5\xbb\x2a\x3d\x14\x8b\x65\x3f\x38\xaa\xa9\x53\x0e\x91\xfb\x97\xa6\x4a\x2f\x4d\x5c\xad\x00\x60\x97\x9f\xad\x76\xab\xff\x06\x00\x8a\x87\xbf\x5a\xcd\x07\x00\x00"
 
 func jsCommonJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -424,12 +424,12 @@ func jsCommonJs() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "js/common.js", size: 1687, mode: os.FileMode(436), modTime: time.Unix(1559013197, 0)}
+	info := bindataFileInfo{name: "js/common.js", size: 1997, mode: os.FileMode(436), modTime: time.Unix(1792081081, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _jsExcluded_filesJs = "\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x56\xf1\x6f\xdb\xba\x11\xfe\x57\x1c\xa2\x30\xc8\x97\x0b\x9d\x14\xef\x87\x41\x06\xd1\xe1\x15\x79\x5b\x81\x6d\x1d\xd2\x6c\xbf\xb8\x46\x41\x4b\x67\x9b\x2d\x4d\x0a\x24\x95\xc6\x50\xf5\xbf\x0f\xa4\x24\x5b\x71\xac\x24\x03\x02\x58\xe1\xf1\x78\xdf\x7d\xf7\xdd\x91\x17\xeb\xca\xe4\x41\x59\x43\x91\xd5\x0f\xd2\x4d\x82\xa8\x9b\x79\xbf\x38\x71\xd4\xb0\x5a\xad\x69\x58\x98\x25\x73\x18\x2a\x67\x26\xf1\x9b\xe3\x63\x69\x5d\xf0\xf3\xe8\x22\x45\x5c\x12\xb5\xca\x0c\xe8\xec\xe2\x06\x3a\x63\x56\x37\xcd\xbc\x73\xc2\xe8\x94\x4b\xad\xa9\xec\x7d\x41\xc2\xf1\xdb\x31\x90\x5c\x8b\x8b\xeb\xe3\x5a\xe3\xf8\x4e\x20\x38\x9e\x8b\x00\x8e\x17\xe2\x08\x15\x02\x18\x56\x3b\x6e\xe3\x27\xfb\xf5\xeb\xf3\xea\x3b\xe6\x81\x17\xb8\x56\x06\xff\xed\x6c\x89\x2e\xec\xd3\xb6\x1a\x4d\xb5\x43\x27\x57\x1a\xb3\x8b\x6b\xd8\x60\xc8\x4c\xc3\x1a\x70\xdc\x89\x61\xea\xa4\x32\xad\x77\x41\x2e\x44\xd8\x97\x68\xd7\x93\x2f\xfb\xdd\xca\xea\xe9\xb4\xfd\xe5\xc1\x7e\x09\x4e\x99\xcd\xbd\xdc\x4c\xa7\x63\x11\x9f\xef\x85\xfa\x41\xea\x0a\x33\xf2\x4f\x5b\x54\x1a\x49\xc3\x60\xcc\x99\x7c\xfb\x86\xbe\xdb\xd6\xbb\x5d\x5c\xb7\x70\xc3\x93\xf4\x53\x51\x6e\xa6\x61\x3a\xa5\x28\x1c\x45\xc6\xe0\x2f\xd3\xd0\x57\x08\xe7\x6a\x4d\x7f\x8f\x56\x62\x53\x28\x22\xfa\x9c\x70\x3a\x8d\x7f\xfc\x18\xe9\xe8\x14\x6b\x69\x44\x07\x2e\x77\x28\x03\x52\x53\x69\xcd\xe2\x71\x8e\x47\x2d\x8c\x40\x37\x40\x0a\x5c\xcb\x4a\x07\x72\xca\x78\x9b\x05\x36\x0c\xde\x27\x40\x3e\xf1\x72\x24\x19\xd9\xda\x3a\x9a\x64\x34\x51\x66\x82\xcc\xf1\x82\x1a\x90\x70\x48\x37\xb0\xfa\x20\xa2\xb0\x6c\xf8\x4a\x99\x22\xe1\x02\xc9\x58\xaf\x2f\x13\x39\x32\xe2\xb9\x9a\x4f\xb2\xfd\x70\xd8\x71\x3c\x95\x77\xd8\x9b\xec\x8c\xf1\xa0\xe0\x88\x2b\x00\x91\x04\x02\x83\x10\xc3\xd9\x93\x92\x74\x1b\x3b\x8a\x4a\x67\x83\x8d\x49\xf2\xad\xf4\x9f\x7f\x9a\x9e\xac\xb6\x0b\xa2\x43\x3c\xa3\x14\x84\x80\xa3\x8e\x7b\xf1\x9e\x35\x74\xf1\x44\xe3\x2e\xea\xd2\xe3\x24\x72\x96\x07\x72\x6c\xcb\x2e\x60\xcf\x9c\x8b\xcc\x05\x86\x02\xb9\xc3\x52\xcb\x1c\x29\xa9\xc9\xa5\xbb\x24\x0d\x81\xb0\x70\xcb\x03\x4d\xd8\x9c\x2f\x60\x18\xd5\x1e\x04\x7e\xfb\x58\x4a\x53\xfc\x57\x3a\x2f\x0c\x04\xfe\x1f\xa7\xef\xed\x1d\x96\x4f\xd3\x07\x07\x12\x6c\xd2\xe5\x83\x55\xc5\xe4\x5a\x08\xd1\x0b\x92\xcc\x48\x12\x57\x2e\x02\xaf\x9c\x3e\x80\x9c\x7d\xe5\x1b\x15\xde\xcd\x80\x10\x06\x4a\x84\x05\xa9\x9c\xbe\x2a\x65\x08\xe8\x0c\x59\x82\x16\x8e\xfb\x6a\xd5\x4a\x86\x3a\xae\xa5\x0f\x9f\x4c\x81\x8f\x9f\xd7\x94\xcc\x08\xbb\xbc\x61\xe0\x85\xfc\x60\xa8\xe2\xd2\xe4\x5b\xeb\xa0\xd6\xca\x60\x26\x61\xad\x34\x1a\xb9\xc3\x4c\x37\x2c\x23\x64\x3e\xfb\xca\x7f\xaa\x1f\xea\xdd\x8c\xe3\x23\xe6\x34\x67\xd3\x29\xcd\x45\x3e\x84\x12\xed\x33\x20\xb3\xf8\x4b\x18\x38\xe1\x86\xd6\x5d\xd1\xe1\xf4\x82\x10\x96\xd2\x29\xc5\x8c\x6e\x54\xf8\xb5\xdd\xb0\xbf\x52\xfe\xdb\x07\x46\xb3\x5f\x5f\x67\x8c\xf2\xdf\xd8\x21\xcc\xbc\x4c\x81\xc8\x6c\x46\x2e\xcb\xc5\xfb\xe5\x25\x49\x1f\xbf\x1f\x4b\x62\xa8\x5a\x90\x95\xf4\x78\x55\x39\x4d\x96\x50\x57\x4e\x67\x39\x94\x32\x6c\x33\x07\x0e\x1f\x32\x0b\x6d\x72\x99\x07\x87\xa5\x4d\x69\x61\xc3\x9a\x06\xe0\x45\xb9\xb4\xfd\xec\xe8\x35\x03\x29\xee\x50\x1e\xba\xfa\xa3\x96\xde\xd3\xba\x50\xbe\xd4\x72\xff\xaf\x78\x1e\xb9\x7d\xcc\x75\x55\x60\x71\x67\x7f\x12\x70\x68\x0a\x74\xc3\x76\x88\x67\xa1\xa0\xd7\x60\x8e\xf5\x67\x34\x6c\x95\x8f\x42\x2f\x3d\xef\x91\xc1\xc9\xda\xf0\xff\x58\x14\xfe\x67\x57\x19\x48\x2d\x7c\xb2\x9b\xdf\xe1\x83\xf2\xca\x9a\x03\x3d\x43\xdc\xb7\x1a\x77\x68\x02\x25\xc1\x91\xd6\xfd\xbc\xb5\x20\x50\xe7\x31\xc7\x36\xb5\x18\x8d\x34\xe7\xf7\x4a\x02\xf5\xd6\xe1\x3a\xc3\x66\x14\x29\x63\x6f\x8b\xe3\x50\x7a\x6b\xc8\xf3\x83\xee\x92\x81\xb1\xa6\x61\x60\xdf\x5c\x89\xfb\x38\x42\xc7\x6b\x11\x83\xc4\xc1\x3c\x08\xe6\x51\xba\x7c\xab\xcc\x86\xbd\x44\x5e\xa1\x1e\x08\xd4\xaa\xc8\x88\xb1\x57\x0e\x7d\x9c\xda\x23\xec\xa8\xdd\x86\x40\xed\x5d\x9e\x11\xb5\x93\x1b\xf4\xb3\x55\xe5\xf7\x7c\xa3\xd6\xf1\x1a\x1b\x3f\x3d\xd5\x86\x7c\xe9\xe1\x70\xce\x09\x6b\x7b\x26\x88\xc5\xb2\x2f\xed\x09\x4d\x9e\xaf\xad\xbb\x95\xf9\x96\x1e\x72\x75\xac\x0e\xbc\xac\xfc\x96\x9e\x8b\x25\xa1\x8e\x7e\xa9\x4b\x4a\x9b\xe1\x50\x75\xc7\x46\x39\xd1\x67\xc3\xd8\x18\xf4\xd0\x12\xfe\x82\xb0\xb6\x28\x8b\x17\x37\xbc\xac\xcb\x6d\xcf\x4c\xaf\x2c\x32\x86\xe4\xb0\xb3\x95\x0e\x61\xa3\x1a\x5c\xd9\x62\xff\x54\x86\x5a\xf9\x58\xd0\xd0\xea\x2d\x7f\x55\x6f\xb1\x95\xff\xa8\x42\xb0\x86\xc0\x56\x9a\x42\xe3\x47\xad\xf2\x1f\xd9\xf0\x2e\x1d\x94\xca\x9a\xe8\x90\xb6\x50\x8c\x77\xd8\x88\x3e\x49\x64\xfc\x6a\xd5\x1e\x7c\xa6\xe4\x79\xe5\x1c\x9a\x90\x2e\x12\x21\x4e\xe6\x40\x7c\xd7\x5c\x0a\x32\xf1\xa8\x31\x0f\x58\x8c\x11\xd5\x1d\x0f\xb5\x35\x2d\xe8\x74\xce\x20\x8b\xf6\xb5\x10\x57\x4f\x47\x0d\x83\x23\x67\x4f\x9b\x3f\x59\x23\x77\xea\x4d\xdc\xfd\x23\xf2\x3d\x4a\xc3\x62\x09\xa1\xed\xd5\xe7\x14\xc4\x40\xe7\x55\x8f\xe3\xaa\xcf\xa1\x4e\x72\x77\x30\xa8\x44\x16\x9e\x57\x07\x06\x0c\x1f\xec\xd1\xf5\x85\x16\x38\xce\x86\xb8\xb1\x53\x12\x26\x32\xf4\xab\x64\xfc\xa9\x74\x68\x1f\x7e\xfd\x08\x8b\x3a\xf7\x24\xbe\xba\x3f\x19\x15\x94\xd4\x5f\x82\x0c\x38\x32\xcb\x3a\x7e\xde\x71\xf9\x5d\x3e\xd2\x74\x0d\x12\x59\xaa\xd9\xc3\xcd\x2c\x11\x45\xa0\x90\x41\xde\xef\x4b\xcc\xc8\xf7\xd8\x14\xe0\xab\x3c\x47\xef\xb3\xe1\x53\x11\xb9\xc7\x90\xc2\xd0\xc4\x93\xcf\x42\x7c\x40\xa3\x73\x76\x50\x9d\xee\xba\xcc\xad\xf1\x56\x23\x4f\x56\xea\x52\x9e\x69\xa6\xf8\x6c\xb1\x84\xd6\xbd\xfb\xc8\x62\x3f\x36\xcd\x13\xd2\x9f\xbf\x36\x53\x22\x61\x00\xe1\x30\x92\xe3\x5b\xf8\x38\x94\xda\x23\x93\x14\x7c\xdc\xd8\x4a\x61\x81\xcb\x86\xc1\x39\x02\xb0\x25\xb4\xe3\x20\x6b\x15\x10\x55\x9b\xd8\xf8\xdb\xed\xfd\x1b\xc8\x89\x6d\x3c\x40\xd6\xa6\x89\x30\x40\x78\xf3\x7f\x31\x75\xae\xf5\xdf\x76\xef\x74\xd9\x14\xdf\x72\x6b\x82\x54\x06\xdd\xab\xd7\x33\x99\x91\x06\xc8\xdf\xed\xf8\xcc\xdc\xde\xf4\x33\xb3\x57\xdf\xa4\x95\xdf\xab\x52\x3f\xc0\x59\xb7\x72\x1d\xcc\xd2\x74\x25\x5c\xbd\x06\x53\x41\x27\xb5\xee\x71\xfd\x03\xf7\x9e\x9e\x16\x97\x3d\xed\xd7\x68\x1d\x36\xeb\x39\x41\x8c\x74\xa9\xed\x35\x3a\xd8\x9e\x16\x06\xb5\x1c\x98\x0e\x8b\x67\x63\x1c\x45\x79\x62\xe8\x2e\xcb\x58\xe9\x79\x8b\xa2\x2d\xf7\x47\xbb\x2b\xad\x89\x38\xce\x61\xd3\xa9\x06\x0c\x0a\x9b\x57\x71\x81\x6f\x30\x74\xb6\x3f\xf6\x9f\x0a\x4a\x9c\xb5\x81\x30\xd6\x2c\xd9\xfc\x7f\x01\x00\x00\xff\xff\x24\x88\x51\x39\x7c\x10\x00\x00"
+var _jsExcluded_filesJs = "\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\x56\x6d\x6f\xdb\x36\x10\xfe\x2b\x0e\x51\x18\xe2\xc2\xc8\x49\xd1\x0f\x83\x0c\xad\x43\x87\x6c\x2b\xb0\xad\x43\x92\xed\x8b\x6b\x14\xb4\x74\xb6\xd5\xca\xa4\x40\x52\x69\x0c\x47\xff\x7d\x77\xd4\x8b\x65\xc7\x4a\x32\xc0\xb0\x24\xde\xf1\x5e\x9e\x7b\xee\xc8\xb3\x65\xa9\x12\x97\x69\x15\x00\xdf\xdd\x4b\x33\x72\xf1\xae\x9a\xb6\x8b\x23\x13\x28\xbe\xcb\x96\x81\x9b\xa9\x39\x37\xe0\x4a\xa3\x46\xf4\x1e\xc2\x43\xa1\x8d\xb3\x53\xda\x22\x63\x5a\x8a\x77\x59\xa4\x44\x1e\x9d\x5d\x89\x46\x18\xed\xaa\x6a\xda\x6c\x02\xda\x94\xc8\x3c\x0f\x64\xbb\x57\x48\xb1\x7f\x37\x1c\x3f\xf2\xf8\xec\x72\xbf\x56\x99\x70\x13\x83\x30\x61\x12\x3b\xfc\x4f\xe3\x7d\xa8\xc2\x09\x8c\xcb\x84\x9a\x5e\xf9\xe3\xe3\xa7\xc5\x57\x48\x5c\x98\xc2\x32\x53\xf0\xb7\xd1\x05\x18\xb7\xf5\x6a\x3b\x50\xe5\x06\x8c\x5c\xe4\x10\xa1\xf1\x15\xb8\x48\x55\xbc\x42\x7b\x26\xee\xa7\xce\x4a\x55\xef\x4e\xd9\x59\xec\xb6\x05\xe8\xe5\xe8\x76\xbb\x59\xe8\x7c\x3c\xae\x9f\xa1\xd3\xb7\xce\x64\x6a\x75\x27\x57\xe3\xf1\x90\xc7\xa7\xba\x02\x61\xcd\x4b\x88\xd8\x9f\x3a\x2d\x73\x60\x15\x17\x43\x9b\xd9\x97\x2f\x60\x1b\xb5\x76\xdb\xd9\x65\x1d\xae\x3b\x48\xdf\x17\xe5\x6a\xec\xc6\xe3\x00\x62\x83\x09\x70\xf1\xe3\xd8\xb5\x15\x82\x29\x4a\xdf\x91\x94\x69\xef\x8a\xc5\x6d\x4e\x30\x1e\xd3\x2f\xdc\x7b\xda\x6f\xa2\x5a\xaa\xb8\x09\x2e\x31\x20\x1d\x04\xaa\xcc\x73\x4e\xe6\x10\x30\xe4\xc2\x40\xe8\x4a\x30\x5c\x91\x65\xee\xd8\x31\xe2\x75\x16\x80\x59\xbf\xf5\x01\x59\x8f\xcb\x1e\x64\xe0\x4b\x6d\x02\x4f\xa3\x51\x86\x51\x70\xac\x34\xda\x93\xa2\x4b\x17\x93\xed\x48\xe4\xe6\x55\xb8\xc8\x54\xea\xe3\x12\x92\xf3\x96\x5f\x8a\x30\x52\xf1\x53\x36\x1f\x65\xfb\xbe\xd3\xd8\x5b\x0d\x9b\xd8\xab\xe8\x84\xb0\x63\x30\xc5\xe5\x04\x93\x0c\xd1\x17\x8e\xdc\xe9\xa3\x92\x34\x8a\x0d\x44\x85\xd1\x4e\x53\x92\xe1\x5a\xda\x4f\xdf\x55\x0b\x56\xdd\x05\xb4\x81\x6c\x14\x31\x63\xc2\x20\xba\x36\x7e\xcb\xab\x60\x76\xc0\x71\x43\xbc\xb4\x30\x22\xcc\xb0\x88\xfb\xb6\x6c\x1c\xb6\xc8\x19\x42\xce\x71\x88\x21\x34\x50\xe4\x32\x81\x80\xed\xd8\xb9\x39\x67\x15\x46\x3b\x33\xf3\x0e\x26\xa8\x4e\x17\xd0\x0d\x72\x4f\xb8\xf0\xfa\xa1\x90\x2a\xfd\x57\x1a\x1b\x2b\xfc\xfc\xc7\xe4\x77\xfa\x06\x8a\xc3\xf4\x85\xc1\x9a\x69\x51\x7a\x66\xde\xeb\x2c\x1d\x5d\xc6\xc8\xba\x86\x5d\x6c\xc2\x3c\xbd\xb0\x95\xc3\xd2\xe4\x5d\x98\x93\xcf\xe1\x2a\x73\x6f\x26\x82\x31\x2e\x32\x9c\x23\x0c\xa5\x17\x85\x74\x0e\x70\xd3\x5c\xe4\x31\x22\x53\x2e\x6a\xd2\x20\x4a\xb9\xb4\xee\x23\x76\xea\xc3\xa7\x65\x80\x36\xf9\xf9\x15\x17\x36\x96\xef\x55\x90\x85\x52\x25\x6b\x6d\xc4\x2e\xc7\xc4\x22\xe4\x4f\x96\x83\x92\x1b\x88\xf2\x8a\x47\x8c\x4d\xe5\x78\x5c\xfe\x84\x7f\xd9\x8c\x19\xa9\x56\x70\x51\xeb\xb3\x39\xf6\x10\x26\x16\x3c\x59\xef\x2c\xd1\xe3\x5a\xa5\x51\x79\x60\x93\x4f\x31\xf8\xef\xd9\xb7\xec\xcd\x04\x27\x16\x24\x41\xc2\xd1\x52\x12\x27\xfd\xe4\x48\x8e\xc9\x4d\xe8\x89\x19\x1a\x4c\xa7\x27\xdd\xa4\x4d\xe6\x16\x59\xc0\x3d\x40\x45\x3c\x09\x10\x91\xc7\xf5\x8a\xff\x1c\x84\x3f\xbc\xe7\x41\xf4\xf8\x79\xc2\xf1\x95\x77\x6e\xa6\x85\x77\xc4\x26\x13\x76\x5e\xcc\xde\xce\xcf\x99\x7f\x79\xb7\x2f\xb3\xcf\x66\x21\x2d\x5c\x20\x9c\x94\x09\x3e\xa2\x44\x20\xae\xeb\xc8\x08\x03\xf7\x91\x16\x75\x9a\x11\x8e\x5e\x2c\xa5\x4f\x0a\x9b\xb4\xaa\x84\x78\x96\x82\xf5\x8c\x30\xc1\x25\xce\xeb\xf8\x06\x64\x37\x29\x7e\xc1\xd2\xd8\x60\x97\x66\x16\xd3\xdb\xfe\x45\xf6\xd8\xf5\x43\x92\x97\x29\xa4\x37\xfa\x3b\x92\x1c\xb0\x6e\xa6\xdf\x62\x64\x0b\xe2\xe0\x52\xa8\x3d\xa7\x78\xe0\xd6\x99\xa5\xe6\x29\x6c\xd8\x46\x26\x8e\xd6\xfa\xdf\x54\x92\xf0\xd7\xa6\x2e\xc2\x8f\x85\x23\xed\xf0\x06\xee\x33\x8b\x2e\x3b\x78\xfa\x71\x5f\xe7\xb0\x01\xe5\x02\xe6\x0c\xab\xb7\x9f\x96\xa6\xd8\x15\x09\xe5\x58\xa7\x46\xde\x58\x75\x5a\x17\x47\xc4\x6e\x6d\x60\x89\x80\x0e\x46\x8a\x03\xfb\x55\x7e\x50\x6a\xb5\x62\x4f\x0d\xdd\x78\x01\xc7\x8a\x71\xa1\x5f\x5d\x89\x3b\x1a\xcb\xc3\xb5\x20\x27\x34\xec\x7b\xce\x2c\x48\x93\xac\xb1\xfb\xf8\x73\xe0\xa5\xd9\x3d\xc6\x9d\xa5\x08\x8c\xbe\x30\x60\xe9\x24\x18\x40\x27\xdb\xac\x50\xd5\x9a\x24\xc2\x57\xb9\x02\x3b\x59\x94\x76\x8b\x63\x60\x49\x47\xe3\xb0\x75\x5f\x1b\x76\xdb\x86\x13\x86\x21\xe3\x75\xcf\xb8\x78\x36\x6f\x4b\x7b\x04\x13\xfe\x6b\x73\x2d\x93\x75\xd0\xe5\x8a\x8c\xc6\xe1\x5c\xda\x75\x70\xca\x97\x14\x3b\xda\xe7\xbb\xa4\xd0\x11\xf4\x59\xb7\x6f\x94\x23\x7e\xe2\x30\x18\x0a\xdd\xd5\x80\x3f\x43\xac\x35\xc8\xf4\x59\x85\xe7\x79\xb9\x6e\x91\x69\x99\xc5\xf8\x4b\x9a\x35\x75\x10\xbd\x21\xcd\x85\x4e\xb7\x87\x34\xcc\x33\x4b\x05\x75\x35\xdf\x92\x17\xf9\x46\xad\xfc\xa1\x74\x0e\xdd\x88\x35\x1e\x1e\x39\x6a\x65\xc9\xb7\xa8\x7f\x3e\xf7\x4a\xa5\x15\x6d\xf0\x2a\x28\xa9\x06\xf9\xc9\x08\xf1\x8b\x45\x6d\xf8\x44\xc9\x93\xd2\xe0\x56\xe7\x0f\xa7\x38\x3e\x9a\x03\x74\x57\x3a\x8f\xd9\xc8\x42\x8e\x27\x20\x5e\xf4\x06\xd2\x6f\xcc\x8b\x9d\x56\x75\xd0\xde\x4e\x2f\x8b\xfa\x06\x42\xab\xc7\xa3\x06\xa1\xe9\x30\x3b\x6c\x7e\x2f\x25\xec\xb2\x57\x61\xf7\x07\xe1\x3d\x08\xc3\x6c\x2e\x5c\xdd\xab\x4f\x21\x20\x47\xa7\x59\x0f\xc3\xac\x4f\xc4\xce\xd3\xdd\x88\x5e\x25\x22\xf7\xb4\x3a\xa2\x87\x70\x27\xa7\xad\xcf\xb4\xc0\x7e\x36\x90\x62\xc3\x24\xf0\x60\xe4\x2f\x82\x81\xac\x76\xf5\x65\xb2\x1d\x61\xc4\x73\xcb\xe8\x26\xff\x51\x65\x2e\x93\xf9\xad\xc3\xbd\x03\xb3\xac\xc1\xe7\x4d\x28\xbf\xca\x87\xc0\x1f\x83\x4c\x16\xd9\xe4\xfe\x6a\xe2\x81\x62\x22\x95\x4e\xde\xe1\x2d\x2d\x62\x5f\xa9\x29\x84\x2d\x93\x04\xac\x8d\xfa\xd7\x4f\xc0\x29\xe8\xbc\x9b\xc0\xe3\x64\x23\x47\x97\x72\x30\x46\xf7\xaa\xd3\x1c\x97\x89\x56\x56\xe3\x80\xf6\x52\xc4\x9d\xf2\xf4\x33\xc5\x46\x58\xb7\x7a\x7b\xf3\x12\x51\x3f\xe2\x91\xdb\x07\xfd\xe9\x0d\xd6\x27\xe2\x7a\x21\x74\x23\x99\xee\xd7\xfb\xa1\x54\x9b\xf4\x54\xb0\xa4\x58\x53\x61\x06\x73\x0c\xe0\x14\x00\x50\x03\xda\x60\x10\xd5\x0c\x20\xd6\x7a\x34\x7e\xbb\xbe\x7b\x05\x38\xd4\xc6\xbd\xc8\xea\x34\x41\xf4\x22\xbc\xfa\x5f\x48\x9d\x6a\xfd\xd7\x9d\x3b\x4d\x36\xe9\x17\xb4\xea\x24\xde\xd8\xcc\x8b\xc7\x33\x5e\x9b\x2a\xc1\x7e\xd7\xc3\x33\x73\x7d\xd5\xce\xcc\x96\x7d\xa3\x9a\x7e\x2f\x52\xbd\x0b\x67\x59\xd3\xb5\x37\x4b\xfd\x91\x70\xf1\x52\x98\x99\x68\xa8\xd6\x5c\xd8\xbf\xc1\xd6\x06\xc7\xc5\xe5\x87\xfd\x4a\xd2\x7e\xb3\x9e\x22\xc4\x40\x97\xea\x96\xa3\x3d\x75\xbf\xd0\xab\x65\x4f\xd4\x2d\x9e\xf4\xb1\x27\xe5\x91\xa0\x39\x2c\xa9\xd2\xd3\x3a\x8a\xba\xdc\xbf\xe8\x0d\x0a\x29\x8e\x53\xb1\xe5\xbe\x06\x5c\xa4\x3a\x29\x69\x21\xc4\xde\x6f\x64\x1f\xb6\x1f\xd3\x80\x19\xad\x1d\x1e\x67\x15\x5e\x80\xff\x03\xb8\x09\xd8\xe5\xd0\x10\x00\x00"
 
 func jsExcluded_filesJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -444,12 +444,12 @@ func jsExcluded_filesJs() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "js/excluded_files.js", size: 4220, mode: os.FileMode(436), modTime: time.Unix(1559013209, 0)}
+	info := bindataFileInfo{name: "js/excluded_files.js", size: 4304, mode: os.FileMode(436), modTime: time.Unix(1792084193, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _jsHoundJs = "\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x3b\xed\x92\xdb\x36\x92\xaf\xc2\x41\xe5\x54\x44\x04\x71\x24\x67\xeb\x2a\x45\x99\x99\x73\x6c\x67\x37\xb7\x71\xbc\x37\xf6\x66\x7f\x28\x2a\x17\x86\x6c\x49\x88\x29\x80\x03\x80\xf3\x71\x1a\x56\xed\x83\xdc\xbd\xdc\x3e\xc9\x15\x3e\xf8\x29\x69\x46\xe3\xcb\x6e\x55\x2a\x16\x81\x06\xd0\xdd\xe8\x6f\xf4\x9c\xad\x4a\x9e\x6a\x26\x78\x08\x78\x77\x43\x65\xa0\x93\x5d\x35\xaf\x07\x03\x15\x4a\xbc\x63\xab\x50\x2f\xe4\x12\x4b\xd0\xa5\xe4\x81\xf9\x1d\xc1\x5d\x21\xa4\x56\x73\xb3\x84\x26\x66\x28\xd9\xb1\x58\x92\x3c\x3e\x9b\x11\x3f\x19\xef\xaa\x6a\xee\x17\x81\x59\x94\xd2\x3c\x0f\x69\xbd\x96\x50\xd2\xfe\x56\x98\xd0\x28\x4f\xce\xa6\xed\x58\xa5\xa2\x6d\x02\x44\x45\x69\xa2\x89\x8a\xb2\xa4\x45\x95\x68\x22\xf1\x4e\x45\xc2\xfc\xc4\x0f\x0f\xef\xaf\x7e\x83\x54\x47\x19\xac\x18\x87\xbf\x48\x51\x80\xd4\xf7\x16\x6c\x07\xbc\xdc\x82\xa4\x57\x39\xc4\x67\x53\xb2\x06\x1d\xcb\x0a\x57\x44\x45\x32\xe9\x92\x8e\x4a\xee\x56\x67\xe8\x2c\xd1\xf7\x05\x88\x55\xf0\xe1\x7e\x7b\x25\xf2\xd1\xc8\xfd\x1b\x69\xf1\x41\x4b\xc6\xd7\x1f\xe9\x7a\x34\x3a\x76\xe2\x3e\x2c\xd9\xdd\xd0\xbc\x84\x18\xbd\x13\x59\x99\x03\xaa\x30\x39\xb6\x18\x7d\xfa\x04\xca\x83\xd5\xcb\xce\xa6\x0e\x5d\xdd\x23\xdf\x5e\xca\x6c\xa4\x47\xa3\x10\x12\x15\x02\xc6\xe4\xdb\x91\xae\x6f\x08\xe6\x6c\x15\xfe\xc1\xcc\x22\x61\x8f\x42\x49\x4d\x13\x8c\x46\xe6\xbf\xa8\x3d\xa9\x5d\x64\xee\x52\x26\x1e\xb9\x54\x02\xd5\x10\xf2\x32\xcf\xb1\xd9\x4e\x45\x32\x94\xc7\x50\x97\x04\x65\xb0\xa2\x65\xae\xd1\x90\xe3\x8e\x0a\xa8\x30\x79\x61\x11\x52\x96\x2f\x2d\x93\x01\xaf\x84\x0c\xad\x18\x05\x8c\x07\x80\x55\x94\x85\x92\x50\xd2\x90\xab\xf1\xae\x11\x22\xbd\xac\xa2\x2b\xc6\x33\x8b\x17\xa1\x18\xd7\xf2\x25\x0d\x8f\x78\xb2\x2f\xcd\x03\x6a\x2f\x1a\x88\x76\xd7\xc8\xe3\x5e\xc5\x07\x26\x1b\x09\x36\x78\x69\x82\x28\x22\x1a\x13\x6d\x8e\x13\x83\x2b\xf1\x80\x9e\x45\x85\x14\x5a\x18\x22\xa3\x0d\x55\xef\x6f\x79\xcd\x2c\xa7\x05\x66\x81\xd9\xa3\x48\x10\x22\x2a\x54\x91\x4a\x66\xb8\x0a\x17\x3d\x19\x57\x46\x2e\x15\x04\x86\x67\xa9\x46\xad\x5a\x4a\x77\x60\xcd\x39\x65\x38\xa7\x31\x24\x10\x49\x28\x72\x9a\x42\x88\x76\x68\xac\xc6\xa8\x42\x44\x2f\xd4\xb2\x61\x13\x54\x87\x2f\x50\x1f\x95\x3d\xa2\xa3\xb7\x77\x05\xe5\xd9\x2f\x54\xaa\x44\x12\x1d\xfd\x55\xe6\x1f\xc5\x25\x14\x7d\xf2\x89\xd1\x67\x6e\xe5\xf2\x46\xb0\x2c\x98\x26\x49\x52\x0b\x24\x3a\x47\x56\xb8\x44\xa2\xa3\x52\xe6\x0d\x92\xe7\xbf\x46\x6b\xa6\xbf\x3a\x27\x08\x61\xc2\x12\xbd\x40\xa5\xcc\x27\x05\xd5\x1a\x24\x47\x4b\x92\x26\x2a\x52\xe5\x95\x13\x99\x50\x45\x39\x55\xfa\x47\x9e\xc1\xdd\xfb\x55\x88\xce\x11\x1e\xcf\x30\xc9\x13\x7a\x21\x43\x16\x51\x9e\x6e\x84\x24\xbb\x9c\x71\x88\x29\x59\xb1\x1c\x38\xdd\x42\x9c\x56\x38\x46\x68\x7e\xfe\x6b\x74\xcb\x3e\xb3\xaf\xce\x23\xb8\x83\x34\x14\x78\x34\x0a\x45\x22\xba\xa8\x98\xf9\x73\x82\xce\xcd\xbf\x08\x13\x95\xa8\xee\xec\x36\xf3\x78\xe6\x09\x42\xd8\x92\x53\x26\xe7\xe1\x9a\xe9\x87\xcd\x1a\xff\x47\x18\x7d\x7d\x81\xc3\xf8\xe1\xd7\x73\x1c\x46\x5f\xe3\xe6\x98\x79\x69\x0f\x42\xe7\xe7\x68\x5c\x2e\x5e\x2c\xc7\xc8\xfe\xf8\x43\x7b\x25\x32\x64\x0b\x74\x45\x15\x4c\x4a\x99\xa3\x25\xd9\x95\x32\x8f\x05\x29\xa8\xde\xc4\x8a\x48\xb8\x89\x39\x71\xc4\xc5\x39\x91\x50\x08\x4b\x16\x54\xb8\xaa\xc8\xa3\xd2\xe2\xd4\x59\x85\x53\x4c\x68\xd2\x91\xec\x6a\x4e\x5b\xe1\x4c\x76\x39\x53\x1a\x38\x48\x15\x2f\x96\x44\xd3\x22\xee\xea\x90\xde\x30\x15\x35\x10\x49\xff\x33\x52\x39\x4b\xc1\xec\x3f\x18\x2f\x4a\xb5\x09\x01\x57\xa4\xe4\xc3\x0d\x9d\x52\x0e\xe0\x99\xbf\x53\xc0\xf3\xc9\xec\x2c\x31\x56\xed\xcb\x0e\x56\x85\x9d\xd0\x64\x86\x71\x45\x24\x65\x0a\xba\x3a\x6d\x0e\x87\xe4\x95\x94\xf4\xbe\xa3\x9e\x76\x33\xef\x9b\xe4\xba\xdc\x02\xd7\x8a\x4c\xf1\x7c\xb0\xf7\x4a\xc8\xb7\x34\xdd\x84\x5d\xbb\xa4\x23\x5a\x14\xf9\xbd\xc5\x96\x00\x36\x77\x62\xd9\xce\x93\xe1\xcd\x40\xa4\xf4\x7d\x0e\x91\x02\xdd\x51\x3a\x65\x24\xaa\x22\xa2\x67\xb7\x6a\xad\xd6\x09\x42\xe3\x70\xfa\x00\x46\x16\x17\xcb\xb9\x8e\x72\xe0\x6b\xbd\xf9\x6e\x3a\xc7\x2a\x2a\xb9\xda\xb0\x95\x0e\x75\x47\x43\x6a\x88\xc9\x37\xa4\xfe\x89\x31\xd1\x49\x17\x66\x4a\x5a\x28\xdc\xda\xb6\xdf\x04\xe3\x21\x22\x06\x1b\xd6\xc3\xa6\x36\x1c\x09\x3c\x3c\xec\xae\x63\x84\x08\x8b\x91\x96\x25\x20\xab\x63\xca\x8c\x18\xa9\x54\x31\xfa\x1a\xf5\x25\xb2\x59\xac\x13\xfd\xf0\xb0\xab\x08\x5c\x84\xd0\x41\x65\x86\xed\x85\xe9\x10\x8d\x10\xde\xe7\xaf\x97\x16\x95\x40\x0d\x96\x20\x3c\x7f\x91\x24\xca\x13\x60\xc4\x64\x91\x41\x2a\x32\xf8\xeb\xe5\x8f\xaf\xc5\xb6\x10\x1c\xb8\x0e\xd5\x62\xba\xc4\xcb\xe4\xe0\xcc\x6c\xd9\x51\xea\xf1\xf9\x9a\xa0\x7f\x7b\x31\x45\x18\x63\x63\xe9\x70\xac\xab\x30\x17\x29\x35\xe7\x47\x0a\xa8\x4c\x37\xe6\x5a\x49\x9a\xec\x6e\x59\x9e\x7f\xb0\x23\x31\x87\xdb\x80\x92\x8c\x65\xbd\x6f\x03\xf0\x93\xa0\xd9\x3b\x21\xa1\x05\xd9\x1f\x79\x2b\xa5\x90\x7d\x80\x4b\xcb\x3f\x37\xf4\x0b\xcd\x99\x1f\x38\xa2\x37\x96\xdb\x44\x99\x38\xad\xf1\x60\x2b\x96\x6b\x90\x7b\xbc\x93\x89\x5e\xc0\x72\x34\x3a\x53\x0b\x58\x36\x97\xbd\x80\xa5\x09\xb4\x6c\x1c\x64\x4e\x7a\x2d\x4a\xae\x0f\xf8\x3e\xef\x2a\x3e\xc3\xbd\x0a\xdb\x93\xb1\xe7\x7e\x45\x0c\xea\xfb\xea\x65\x75\x41\x27\x83\x71\x9d\xb0\x10\xcf\x21\xea\x52\x1c\x59\x05\x0d\x81\x80\xdf\x99\x20\x74\x96\x24\x3a\xba\x36\x3e\xdb\x31\x37\xd4\xb8\x32\xe1\xc7\xa1\xf8\xec\x9d\xc8\x20\x7f\x43\x35\xad\x05\xe5\x3f\x3f\xbc\xff\x39\x2a\xa8\x54\x10\xb6\x73\x44\xda\x88\xb6\x1b\x64\x28\x2c\x17\x74\x99\xa8\x05\x6d\x98\xd2\xd2\x97\x48\x62\x1d\x98\x0e\x71\xf5\x55\x44\x7f\xa3\x77\xa1\x35\xc9\x88\x16\xec\xfc\x66\x76\x6e\x81\x10\xc9\xa8\xa6\x1f\xef\x0b\x88\xd1\x6f\x4a\x70\x44\x54\x99\xa6\xa0\x3a\x97\x66\x95\xde\xed\xa8\x88\xd9\x8c\x80\xbd\xf9\xa1\x65\x48\x05\x57\x22\x87\xc8\xce\x86\x0a\x57\xe6\x5a\xbc\x64\xed\x99\xe2\x56\x0a\x3d\xf3\xbc\xe5\x99\xb7\xf2\x41\x54\xf2\x86\x6a\x88\xb8\xb8\x0d\x6d\xe4\x86\x50\x92\x24\x21\x24\x5f\x45\x70\xa7\x81\x67\xe1\x4e\x69\xaa\x55\x8c\x56\x42\x6d\x44\x47\x7b\x89\xe4\xeb\x18\xc5\x2f\xa6\xa8\x22\x80\xb1\x43\xde\x44\x98\x9e\x0c\xf4\x35\x32\xe1\x40\x41\x25\xdd\xaa\x04\x88\xd9\x18\xa2\xeb\x26\x2f\x88\x24\xa8\x32\xd7\xc6\x58\x91\xe6\xe3\xfb\x7b\x1b\x2a\xec\x2a\xcf\xd5\xa8\xd1\x9b\x9a\x02\xa2\xa3\x4b\x07\x8b\xe7\x87\x18\xee\xf4\xd0\x71\x3c\x06\xa2\x2d\xd3\xff\xf8\xf6\xe3\x09\x77\x00\x36\x1e\x81\xc8\xea\x1c\xb6\x67\xdb\x9f\xcd\xd1\xf5\xd4\x1c\x72\x05\x5e\x65\xa0\x46\x87\xd0\x04\xa2\x0f\x86\x57\x84\x1b\x03\x5c\xcb\x90\x30\x32\x24\x31\x5b\x85\x72\x21\x96\x4e\xf8\x58\x62\x7e\xcf\xb9\xf3\x7d\x3b\x43\x73\x2c\xc8\x25\xdc\xc4\x2c\xba\x84\x1b\xa6\x98\xe0\xe4\x1d\xd5\xe9\x06\x54\xcc\x22\xff\x8b\xfc\x60\x4c\xe8\xdf\x98\xde\xd8\x81\x98\x45\xfd\x81\x0a\x57\x3c\x52\x42\xea\xf0\xb0\x5d\xad\xf7\xa9\x2d\x3a\x0c\x06\x1e\x1e\x0c\x31\x85\x88\x8c\x51\xcb\xc1\x18\x42\x2a\x21\xd4\x76\x10\x57\x4e\x6c\x52\xa3\x1f\xfc\xa0\xfd\x4d\x17\x6e\xfd\x32\x01\x1b\x09\xd6\x17\xcc\xf7\xee\x37\x25\x3a\xb2\x62\x95\xec\x3e\x80\xbc\x01\x19\xd3\xe8\x4d\x29\xad\x25\x25\x1f\x85\xa6\x79\xdc\x4a\xe5\xc4\x13\x1e\x53\x47\xef\xfb\x02\x38\x64\x15\x39\x2c\x1c\xfe\xa0\xfa\x00\x13\xf7\xec\x69\x92\x32\xf9\xe0\xfe\xfd\x1a\x75\x40\x1f\x37\x10\x28\x8b\x53\x70\x25\xc5\x67\x08\x32\x71\xcb\x91\xd3\xb3\xc6\x3c\x1f\xb6\xb5\x44\xd5\x26\xb7\x43\xeb\x02\x96\x44\x26\x6a\xc0\x6a\x42\x13\x35\xb8\xbd\x89\x24\x3c\x79\x47\xf5\x26\xda\x32\x1e\xbe\x80\x6f\x08\xc5\x44\x24\x34\x49\xf8\x05\x42\x31\x42\x63\x3e\xd7\x51\xd7\x6f\xf4\x94\x9a\x48\x1b\x51\xcf\x9d\x78\x35\xda\x6b\x11\x72\x3a\x48\x76\x46\x63\xe5\x18\xc5\x68\x2c\xbc\x1e\x43\x75\x82\x16\xb1\x67\x69\x91\x2b\x01\xc8\xe3\x5a\x24\xf7\xb4\x88\x26\xb2\xd6\x22\xe3\x79\x1a\x66\x75\xd8\x96\x0a\x9e\x52\x1d\xd2\x7a\x00\xbb\xeb\x1f\xb2\x82\x40\x2b\x02\xbf\xeb\xd5\xff\x4c\xb7\xf0\x83\x90\x56\x53\x1f\xf3\xb4\x06\x7f\xb6\x0a\xcf\x74\x3f\x51\x56\x2e\x97\xb1\x92\x30\xcc\x4b\x0c\xbc\x7c\x39\xed\x2f\xa0\xbd\x64\x46\x8e\x67\xf8\x70\x1e\xc4\xf7\x37\x24\x72\x32\x6b\x62\x35\xfe\x72\x7a\x41\xe3\xee\x5e\x7c\x3c\x23\x12\x8f\x51\x70\x1e\xa0\x31\xad\x48\x93\xa0\xc5\xc3\x04\xad\xb6\x1c\xe1\x94\xc8\x36\x8f\xc3\x61\x8f\x5a\x0f\x5a\x55\x24\x4f\x2e\x81\x36\xb5\x80\xd7\x39\x55\x2a\xdc\x65\x4c\x15\x39\xbd\x37\xec\x8b\x91\x59\xfe\xbe\x30\x47\x18\x3f\xc2\x33\x90\x07\xe2\x88\xee\x26\x6f\x73\x30\x01\x76\x88\x84\x5f\xe5\x53\x4d\x27\xd5\x52\x14\x2a\xb2\x03\x44\x41\x0e\xa9\x86\xac\x3b\x53\x8f\x55\x64\x08\x6e\xae\x94\x94\x4f\xa2\xeb\x6c\xcb\xf7\x54\x22\x92\xd6\x41\xe1\xdf\x58\x9e\xbf\x1b\x46\x40\x6d\x28\x33\x4f\xfb\x31\x8b\xa6\x45\x27\x05\xf0\xa1\x3d\x68\xe3\x26\x20\xdc\xd1\x3c\x77\xb1\x5b\x37\x76\x52\x26\x31\x30\x51\x64\x7d\xe4\x1b\x96\x3d\x72\x62\x24\x61\xa5\xa2\xeb\x68\x0d\xfa\xcd\xfb\x77\x3f\x8b\x0c\x6c\xe4\xa4\x40\xbf\xd2\x5a\xb2\xab\x52\x43\x88\x68\xa9\x85\xd9\x2f\x07\x0d\x88\x20\xb1\x5a\x21\x9f\x0d\x99\x0c\xc3\x5a\x87\xb0\x65\x92\x9f\xda\x50\xf5\x2a\xbb\xa1\x3c\x85\xec\x17\xc3\x35\x15\xe2\xd1\xc8\x2d\xda\x88\xdb\x7a\x2a\xc4\x04\xa2\x95\x48\x4b\x65\x82\x96\x35\xe8\x1f\x39\xd3\x8c\xe6\x96\xc4\xfd\xeb\xb5\xd1\x04\xc4\xae\x20\x53\x93\xbf\x58\x7a\x73\xb4\x58\x56\x15\xb9\x2e\x41\xde\xff\x51\xe8\x3f\xc3\xbd\x51\xc0\x9e\xba\xa9\x5b\xa6\xd3\x4d\x08\x86\x55\xaf\x45\x66\x9c\x0e\x55\x10\xfc\x61\x1a\xb7\xbc\xb0\x89\x46\x8f\x1f\x35\x7e\xf3\x2b\x09\xf4\xf3\xdc\x2e\xf9\xe6\x5b\xb7\x64\xc3\x32\x68\x69\xe9\x42\xcc\xbe\x71\x10\xaa\xbc\xda\x32\xfd\x5f\x06\xab\x10\x77\xf0\xfb\xc1\x6c\xba\x1f\x74\x1d\x60\xdb\xc3\xc3\x81\xa3\x2a\x97\x11\x3d\x8f\xd0\x6f\xbe\x8d\x6d\x80\xf6\x28\xb5\x56\xc6\x23\x2d\xd9\xb6\xb9\xb1\xfe\xd1\xe4\x98\xe0\x1c\x62\xd4\x31\x36\xd4\xd8\x1f\x61\x43\x5f\x46\x2a\xd2\x59\xde\x95\x8a\x8e\x6a\x0a\xee\x14\xee\x12\xae\x4b\x50\x1a\xbc\xf3\x5a\x37\x12\x8a\x9d\x80\x5d\xc2\xfa\xed\x5d\x71\xc0\x72\x98\x94\xc8\x4d\x86\x47\x09\xec\xf2\xa6\xc3\x06\x66\x48\xed\x43\xa6\x1b\x48\x3f\x43\x76\x81\xd8\x1a\xc5\x68\x8d\xdc\xe1\x0e\x95\x7d\x55\x4c\xa3\x36\x15\xeb\x9c\x6e\xc5\xda\x46\x22\xe0\x4d\x4f\x9b\x85\xb9\x48\x20\x49\xd2\xa8\x49\xab\xcc\x7d\x85\x90\x2c\x96\x98\xec\xae\xe3\xd3\x88\x70\x79\xf5\xc9\x02\x51\x3b\xfe\x36\x87\x27\x2c\x3e\x8d\x11\x2e\x03\x88\x11\x17\x05\xa0\xaa\x22\x6a\x9f\x1d\xb5\x47\x6c\x43\xa1\x3d\xec\x89\x4c\x1e\x3b\x8e\xd0\x47\x85\x7b\xee\x4d\xb8\x49\x25\x88\xac\x71\x4b\x3c\x6e\x49\x12\x6a\xf3\x1f\x44\x0c\x47\x5a\xfc\x24\x6e\x41\xbe\xa6\x0a\x42\x8c\x1f\x1e\x5c\x1d\x22\x49\xf4\xc3\x03\x9a\x99\x7f\x09\x6d\xf6\xb2\x07\x55\x64\x4f\x77\xf7\xa5\xcc\x25\x9d\xa7\x72\xfb\xe1\x61\x00\xef\x04\x62\x1f\xbe\x22\x5d\x85\x79\xcc\xd4\xd3\xec\xa6\xcf\x30\xdd\x99\xbc\xa2\xbc\x3f\x79\xe2\x3d\x1c\x60\xb4\x09\x03\xd0\x06\xd8\x7a\xa3\x11\xb1\x1e\xc4\x84\x1b\x66\xb0\xa0\x59\xc6\xf8\x1a\x11\x34\x9b\x16\x77\xc1\xd4\x8e\x6b\x82\xb6\xf4\x6e\xd2\x2c\x68\x46\x45\x41\x53\xa6\xef\xfd\x90\x65\x87\x1a\x98\x28\xd9\x3a\x90\xae\xa1\xfa\x57\x70\x61\x40\xe6\x74\x9f\xc6\xc3\xe4\xcd\xa6\xd3\xe2\x6e\x9f\xc4\x19\xc2\x44\xb5\xd4\xec\x07\x39\x1d\x3a\x9c\x55\xa8\x9d\x60\x9d\x18\x68\x93\x0f\xdb\x72\x4d\x07\xc8\x49\xcd\xa1\x9c\xcb\x97\x67\xaa\xda\x9d\xf7\xf7\x3c\xb4\x42\xbb\xa4\xf3\x50\x98\x95\xd7\xf1\x55\x27\xa0\x32\x07\x54\xb8\xce\xfe\x64\x17\x75\x9b\x60\x11\x9a\x20\xd4\x54\xa7\x47\xa3\x90\x26\x07\x23\xb8\x8c\xdd\x20\xb2\x4b\x4d\x98\xe5\xa2\x2b\xbb\x1a\x55\xe4\x19\xd0\x93\x1c\x56\xfa\xd8\x12\x8a\xc8\x6e\x23\x61\x15\x23\xb8\x4b\xf3\x32\x83\xec\x93\x93\xea\x8d\xde\xe6\x88\x74\xf6\xca\x19\xff\x3c\x59\x4b\x7a\x8f\x2a\x82\xde\x7a\xe0\xc0\x66\x63\x08\xe3\x67\x21\x24\xad\x38\x9c\x4a\xc4\x0d\xcd\x51\x45\x44\x28\x23\x9b\xe3\x62\x82\xb6\x2a\xd0\xe6\xa7\xd1\x8c\xe0\x1c\x3d\x7b\x1f\x97\x3d\xbb\x8d\x5c\xea\xf2\x25\x3b\x49\x97\x8a\x12\x14\xac\x3c\x13\x1e\x67\x03\xcb\x62\xc4\x78\x51\x3e\x41\xb9\x03\xa3\xc7\x80\xdc\x0e\x0e\xec\x1a\xf9\x1c\x53\xc3\x9d\x46\xc4\x26\x3a\x1b\x91\x1b\xe5\xf1\x61\x78\x70\x75\x6f\x9c\x3b\xdc\x15\x26\x77\x58\xd9\x25\xdd\xb0\x36\xb6\x51\x2d\x11\xfc\xcf\x70\xff\xc6\x44\x53\x56\x50\x07\xb1\x24\x11\xdc\x85\x2b\xbd\x49\x3b\x54\x9d\x7a\xf1\x57\xa5\xd6\x82\x4f\x68\x96\x4d\x04\x3f\x46\x9b\x03\xf2\xc4\x65\x22\xa3\xda\xa0\xf6\x3a\x67\xe9\xe7\xbd\x68\xaa\x3a\x89\xdb\x57\x4f\xf3\x9a\x66\x37\x9e\x37\xe6\xd7\x11\x70\x55\x50\xde\x27\x48\xa4\x9a\xa5\x82\x07\xfe\xdf\x49\xba\x81\x1b\x29\xf8\xa4\x2c\x02\x63\x8d\x27\x76\xdb\x1e\xf2\x5d\x23\x7d\x32\xdf\x56\x0c\xf2\xec\x18\x56\x39\xbd\x82\xdc\x28\xb0\xde\xe6\x3f\x08\x69\xa0\x8d\x20\x56\x04\x19\xc9\x0c\xfe\x42\xf5\x06\x3d\xeb\xa0\xc9\xa3\xf2\x59\x8b\x5e\x57\xe6\x0c\x07\xdd\xa9\x7d\xf1\x93\x5d\xa1\xf3\x00\x03\x29\x1b\x04\xf2\x7d\x29\xeb\xc5\xc9\x4f\xdd\xf5\x17\xf3\x8b\xad\xb9\x90\x30\x31\xe1\x94\xe1\xda\x8f\xf6\x33\x30\xb1\xcf\x3f\x83\x6f\x56\x24\x3b\x27\x7a\xe5\xb5\xd1\xd8\x95\xb8\xf3\xcc\x62\x0e\x9b\xdf\x99\xe4\x0e\xfc\xb6\xcc\x35\x73\x9e\xea\x93\x9f\x6e\x18\xe2\x0a\xef\x15\x41\x1f\xec\x7c\x60\x1c\xe2\xef\xc9\x0a\x77\xac\xe7\x85\xaf\xf2\x77\x77\x10\x72\x3b\x49\x05\xd7\x52\xe4\x41\x07\x4f\x44\xec\x47\xe1\xda\x1d\x14\xfb\x6f\x88\x9b\x9a\xdf\xec\xdf\x09\x60\xc7\xba\x1a\x7b\xfd\x94\x29\xee\x1a\x26\xca\x3d\xe3\xed\xaf\xbe\xb9\xe9\x04\x97\x47\x08\x82\x2d\x22\x36\x2d\x47\x4d\xfc\x65\x7d\x89\xbb\xe7\xc0\xdc\x25\x09\xdc\xcb\x91\xb1\xc5\x05\xd5\x1b\x12\x28\x5d\xae\x56\x41\xce\x3e\x43\xa0\x37\x54\x47\xc6\x7f\x52\x5b\x5e\x29\x0e\x74\x57\xd8\xc8\x06\xa2\x9f\x18\x87\x9f\xcb\xed\x15\x48\x22\x13\x88\xbe\x87\x95\x90\x75\x3a\x34\x87\xe8\xd5\x4a\x83\xac\x3f\x9b\x6c\xc9\x43\xed\x47\x34\x84\x36\x31\xcd\xce\xed\x1a\xab\x89\x1c\x53\xf2\x5a\x70\x0d\x5c\xc7\xe0\x2a\xe9\xf1\xd9\xac\xb2\xef\x76\x03\xd8\x16\xce\x22\x56\x03\x9b\x90\xaa\xc6\xe5\xc0\xa1\x72\xff\xd0\xb1\x1c\xcf\x8e\x1e\x5a\x91\xcd\xe1\x67\x5b\xcb\x91\x2d\x2d\xc2\xc2\x44\xe4\x8b\x25\xa1\xc9\xd4\x55\xf5\x1c\x03\xe8\x4b\x3e\xa7\xe3\xb1\x63\xa1\xb0\xaf\x50\x84\x25\xfa\x42\x2f\x9a\x97\xd9\xd9\x32\xf2\x38\x4c\x66\x73\xb1\x98\xd6\x9f\x2f\x13\x76\x21\x0e\xc6\x80\xe0\x21\xbe\x63\x17\xba\x7e\x7f\x8f\xf5\x68\xe4\x5f\x04\xec\x53\x69\xbb\xfd\x24\x64\x93\x7a\x05\x5e\x3a\x90\xe4\x6c\x8a\x2b\x1c\x87\xda\x44\xef\x76\x07\x6d\x62\x70\x81\xab\xfa\xb5\xa1\x3b\x21\x2b\xd2\x36\x64\x05\x10\xea\xf6\xb1\x16\xda\x57\x65\x63\x87\x3d\xff\x6c\x0f\x17\xe3\x1c\xe4\x9f\x3e\xbe\xfb\xa9\x9a\x67\x11\x24\x99\x48\xed\x73\xfb\x21\x45\x70\xd1\xe9\xea\xc9\x92\x9e\xf1\x26\xfe\x88\x5f\x18\xdc\xa2\x13\x0a\x56\xa2\x00\x6e\xc4\xa1\x22\x5a\xac\xd7\xcd\xf2\xbd\x22\x86\x8b\x89\x0d\xf4\x85\xfd\x4e\x73\xa1\x6a\xe0\x10\x3b\x35\x34\xb3\xcd\x50\x45\x3a\x9f\xfb\xdb\x35\x55\xc2\x1a\x01\x5c\x91\xee\x9e\x4f\xae\x30\x92\xf7\x78\x06\xe2\xea\x2e\xc6\xd6\xd4\xe9\x53\x3d\x72\x53\xa7\x4c\xf5\x80\xf1\x81\x75\xc6\xe8\xc6\x8c\x5f\x33\x4c\xad\x13\x76\x37\x7a\x95\x8b\xf4\xb3\xb2\x02\xdd\x9c\xe9\x5f\x5c\x79\x42\x8f\x8c\xef\xf5\xaa\x9d\x79\x41\xac\x6b\xe2\x59\x08\x91\x27\x1b\x37\x0f\x6b\x46\x7a\xfc\xa0\xd5\x9c\xf9\xdc\xe8\x64\x53\x0c\x4f\xa6\x75\xbb\xa1\xeb\xae\x51\xb6\xd0\x7e\x46\xf1\xce\xcb\x65\x16\x2a\xec\x2b\x5d\x55\x3b\x34\x6c\x7e\xa8\xb7\x9b\x50\xa3\x58\x75\x9b\x04\x26\x7e\x05\x7a\x09\xdb\xef\xd0\x38\x0b\xcd\x34\x1e\xa3\x97\xe7\xe6\xdb\xf5\x03\xf5\x5a\x2d\xea\x7d\x1a\x05\x91\xbe\x02\x83\x70\x15\x52\xa2\x9a\xc2\xd0\x69\xc6\x3e\x67\x1c\x9e\xcc\x84\xd2\xb6\x6a\x1f\xda\x07\x22\xaf\xc4\x44\xe3\x5e\x3e\xc4\xcb\x2d\x22\x9a\xca\x35\xe8\x18\x7d\xba\xca\x29\xff\x8c\xaa\x06\xfa\x88\xff\xd9\x0f\x24\x73\x93\x4d\x90\x8c\xf2\x35\x48\x51\xaa\xfc\xfe\x83\x51\x2e\xaf\xc3\xf1\xee\xd3\x27\xe3\x9c\x63\x5e\xb9\x74\xf2\x59\xe4\x6e\x8d\x34\xa0\x8a\xf0\x67\xaf\x34\x62\x1a\xa0\x71\x38\xd4\x51\x64\xfe\x8f\x62\x64\x55\x2a\x43\xf8\xd4\x1c\x4e\x33\x9d\xc3\xc0\xbb\xf6\x0c\xc3\x73\x2f\xc5\xfa\x5c\x6d\x54\xf5\x19\x71\x52\x0e\x93\x2b\x91\x99\x04\x96\x62\xeb\x6e\xb7\x27\x99\x3e\xe5\x8c\x9e\xe0\x07\x5f\x32\xd3\xa8\x1e\x0e\x07\xe6\xe1\x54\x43\x72\x33\xb4\x23\x85\x78\xda\x90\x6c\xfd\xeb\x76\xcf\x8e\xd8\xac\xb8\x7e\xf7\xe6\x89\xec\x5b\x0e\xd9\x3e\x4e\x1d\x14\x83\x15\xd9\x35\xe1\xfa\x04\x8d\xa9\x2d\x7a\xc6\xda\xf6\xc7\x01\xa9\x2d\x57\xec\xf2\x5e\x6e\x8c\x98\xb3\x5b\xf1\x26\x94\xed\x0b\xa3\x43\x36\x56\xd6\x83\x8b\x6e\x99\xc3\xdb\x81\x97\xd4\xb6\xeb\x3d\x9e\x01\x76\xa5\x58\x50\x39\x10\x9d\xf6\x2a\x2a\x82\xcc\xcf\x80\xe6\x79\x80\x08\x25\x28\x28\x84\x52\xec\x2a\x87\xc0\x73\x28\x60\x3c\x40\x24\x8d\x3a\x6f\x91\xa1\x7e\x4e\x74\xed\x52\x2a\x4e\x84\x15\x99\x9b\x93\xde\xeb\xbe\xd4\x4d\xf6\x5f\x14\x1f\xf3\x91\x96\x8e\x8e\x83\x74\xdf\xce\x3b\xbe\x97\xaf\x6b\x90\xf8\x60\xf8\x64\x7b\x59\xfb\x65\x4c\x83\xb1\xfd\xc4\xb6\x07\x40\x6a\xfb\xc6\x1e\x7a\x61\xb0\x15\xf6\x8b\x63\x4b\x16\x7a\xd9\x77\xd2\xc3\xc2\x7a\x0f\xb2\xef\xe2\x7d\x0f\xe0\xc0\x0d\x43\xe5\x49\x39\xc8\x90\x01\x8d\xa1\x89\xaa\x48\xba\x4f\xf2\x31\xf0\xd9\x41\xcd\x7c\x96\x81\x34\xaa\xf1\xaf\x31\x90\x06\xe5\x93\xab\x12\x5b\x58\xd3\xc9\xb0\x34\x61\x70\x45\xc7\x2a\x0e\xfb\x7b\x18\xcd\x46\xd5\x50\x63\x06\xa6\xed\xe4\xdd\x18\xcf\x58\x4a\xb5\x90\xc1\xb1\x8a\xc9\x21\x2e\x96\x05\x8a\x91\x6b\x24\x38\xca\x93\x23\x38\x6c\x3b\x56\xcc\x5b\x6e\x6f\x09\xe2\x03\xe6\xd3\xd8\xb6\x81\x31\x76\x56\x6f\x60\x8f\xbd\x55\xdb\x37\xca\x5d\x93\x1b\x0f\x02\x3d\x55\x39\x37\x73\x79\x82\xcd\x50\x65\xee\x83\xeb\x67\xbc\x9a\x77\x5a\xd5\x1e\x7f\x33\x77\x1d\x1d\xd6\x69\xfa\x3e\x0f\xf7\x61\x8b\x79\xb1\x8a\xae\xdd\xeb\x79\x47\x53\x5e\xe5\xf9\x09\xb6\x63\x60\x2c\x0c\xab\xf6\x8c\x45\x6d\x1d\xba\x16\xab\x67\x0b\xdc\x84\x3f\xbe\x77\xee\xbe\x02\xbf\xca\xf3\x8e\xba\x9f\x02\x3c\x3b\xe9\x81\xbd\xcb\x95\xea\x90\x75\x60\xab\xae\x94\x5a\x7e\xe2\xa7\x2d\x06\xcb\x62\xc4\xc5\xc4\xed\xde\x2b\x75\xd8\x1d\x8e\x96\x49\xb4\x14\x7c\x5d\x57\x16\xde\x5e\x5e\xbe\xbf\x8c\x51\xef\xb1\xc2\x21\x60\x62\x73\x03\x53\x3f\x96\xd5\xef\x1e\x96\x96\xd1\x68\x9a\x1c\x1a\xaf\xc3\xf1\xe7\x62\x5f\x11\xf4\x8f\xbf\xff\xcf\xcf\x42\x6f\x18\x5f\x07\x2b\x21\x83\x7b\x51\x92\xe0\x0d\xbd\x5d\x47\xff\xf8\xfb\xff\x3e\x56\x3c\x77\x74\x4c\x03\x8f\x01\xc2\x0d\xe6\x07\x31\xac\x1b\x26\xec\x98\x15\xd1\x2f\x40\xf6\x70\x29\x6e\xbb\x46\x64\xa7\x64\x1a\x23\xb6\xa5\x6b\x50\xe7\x57\xa5\xba\x8f\xd6\x6c\x75\xd4\x40\x76\x08\x70\xaa\xc6\xf8\x3a\x8a\x22\x43\xc3\xde\xb3\x54\x6d\x13\x0e\xd0\xf4\xf0\x60\xeb\x38\xba\x1f\x92\x59\x45\x7d\x84\xb6\x1b\x6f\xcc\x9c\x62\x8d\x55\x6b\xca\x9a\xf6\x46\x6b\xc1\xa2\xcb\xc6\x70\xd9\x77\xf1\xda\x5e\x41\xfd\xd0\xbd\xdf\xf4\x88\x89\xec\x04\x67\x6d\xe3\xfb\x68\x14\xca\x13\x1f\xa3\xa8\x25\xe2\xe8\x73\x54\x13\xcc\xf5\x63\x37\xa7\xe4\xff\xef\x42\xbb\xf5\x0e\x15\x26\x28\x70\x7f\x3b\x63\x42\xc0\x63\xa5\xca\xc3\x98\xd4\x26\xe4\x77\xa8\xf9\x3b\x44\x5e\x8b\x3c\xa7\x85\x02\x87\xca\xd3\xef\x13\x8d\xac\x4a\x62\x7b\x92\xc9\xfa\x49\x47\xf1\xaa\x28\x4e\xf3\x10\xcc\xbe\xee\xba\xf6\x61\x5b\x17\xbd\x58\x2c\xe3\xfa\x4d\xd4\xf7\xfa\x13\xb4\x17\x7d\x5d\xc7\x10\x5d\x13\x16\x43\xc4\xbc\xe8\xf8\x67\x7e\xdf\x01\xa1\xfd\x93\xa6\x7a\xba\x71\xcb\x96\x25\xa0\xdb\xe1\xd1\x7b\x98\xc5\x4d\x2b\x07\xf4\x5b\x39\xba\xe8\x34\x0d\x4e\x46\x5e\xd3\x4e\xeb\xea\xe0\x24\x9b\xda\xf8\x88\x53\xd5\x0d\x68\x9d\x7d\x5c\x37\x36\x6d\x89\x20\x4d\x67\x41\xed\x78\x7b\xa7\x3a\x67\x20\x9b\x64\x66\xb8\x73\xd3\x50\x13\x62\xd2\xfa\xd6\x16\xcb\xa6\xc3\x72\xc8\x11\xf7\x37\x95\xff\xcc\xa3\x5d\x87\xe6\xfe\x4d\x9c\x72\xaa\x35\x73\x6e\x53\x6d\x77\xbc\x65\x3c\x13\xb7\x11\xcd\xb2\xb7\x37\xc0\xf5\x4f\xfe\x2f\x75\x42\x54\x88\xc2\xde\x25\x22\xfb\x05\x6b\x66\x1b\x4e\xf6\xef\xa2\x6e\x99\x33\x88\xb6\x7f\x79\x60\xfc\xfe\xb0\x87\x69\xbf\x31\xaa\x2c\x32\xaa\xe1\x4f\x4c\x69\x21\xef\x43\xe8\x6e\xd1\xa4\x1a\x3d\x1e\x75\xba\x9f\x7a\x6b\x0f\xf4\xa1\x36\x7f\x8d\x52\x50\xbd\x31\xd1\xef\x18\x5d\x5c\x27\x68\x0c\x7c\xef\x8f\x5b\x20\xba\xc6\x63\x34\x62\xc7\x66\x99\x99\xb5\xfa\x72\x0c\xc2\x4e\x1a\x28\xdf\xef\x3f\xf6\xea\x30\xdf\x38\xfc\x6c\x81\xcc\x5f\x8b\xfd\xfb\x34\x5d\x11\x84\x5c\xa9\xe3\xf9\x29\x8b\xbd\xd0\x43\xd3\xa5\x77\x2c\xaa\xed\xd6\xf4\xad\x53\xde\xeb\xd6\xfd\x4d\xee\x93\x75\x1b\xa6\xdc\x50\xcf\x2a\x0c\x9a\x2e\x0e\xdc\xa8\xcf\xdb\x07\xc3\x47\x9c\xee\x65\xe3\xf6\xda\xb0\xb8\x8f\x9e\x8b\xac\xe7\x6e\xb1\x63\x4c\xcb\xe5\x43\x5b\xae\x2d\x2f\x30\x69\xea\xe1\x6b\xd0\x7e\xee\xfb\xfb\x1f\xb3\x10\x49\x21\x34\xc2\x46\xb0\x8c\xf2\x86\xb8\x5a\xe2\xf9\xff\x05\x00\x00\xff\xff\x5c\x65\x7a\xf7\xba\x3d\x00\x00"
+var _jsHoundJs = "\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xc5\x3b\x6b\x73\xdb\x46\x92\x7f\x85\x9e\xf2\xa9\x80\x70\x08\x91\x49\xea\x6a\x0b\x34\xa2\x73\x6c\x67\x37\x7b\x76\xbc\x27\x7b\xb3\x1f\x64\x9e\x6b\x48\x0c\x49\xc4\x20\x86\x9e\x19\x4a\xd6\x51\xa8\xda\x1f\x72\xf7\xe7\xf6\x97\x5c\xf7\x3c\xf0\x24\x25\x2a\x97\xec\x55\xa9\x44\x60\x9e\xdd\x3d\xfd\x9e\xc6\x93\xe5\xae\x58\xe8\x4c\x14\x01\x0f\xf7\xd7\x4c\x0e\x74\xb2\x2f\xa7\xbe\x71\xa0\x02\x19\xee\xb3\x65\xa0\xaf\xe4\x2c\x94\x5c\xef\x64\x31\xc0\xe7\x88\x7f\xd9\x0a\xa9\xd5\x14\xa7\xb0\x04\x9b\x92\x7d\x16\x4b\x9a\xc7\x4f\x26\xd4\x75\xc6\xfb\xb2\x9c\xba\x49\x1c\x27\x2d\x58\x9e\x07\xcc\xcf\xa5\x8c\xd6\xcf\x2a\x84\x97\x3c\x79\x32\xae\xdb\x4a\x15\x6d\x12\x4e\x55\xb4\x48\x34\xfc\x4f\x93\x1a\x54\xaa\x29\xc0\xa5\x22\x81\x8f\xe1\xdd\xdd\xdb\xf9\x2f\x7c\xa1\xa3\x94\x2f\xb3\x82\xff\x45\x8a\x2d\x97\xfa\xd6\x0c\xdb\xf3\x62\xb7\xe1\x92\xcd\x73\x1e\xc3\xe2\x2b\xae\x63\x59\x86\x25\xac\x27\x93\x26\xea\x64\x57\xd8\xd9\x29\x79\x92\xe8\xdb\x2d\x17\xcb\xc1\xbb\xdb\xcd\x5c\xe4\x67\x67\xf6\x37\xd2\xe2\x9d\x96\x59\xb1\x7a\xcf\x56\x67\x67\xc7\x76\xec\x8f\xa5\x40\xd6\x7c\xc7\x63\xf2\x46\xa4\xbb\x9c\x93\x32\xa4\xc7\x26\x93\x8f\x1f\xb9\x72\xc3\xfc\xb4\x27\x63\x0b\xae\x6e\xa1\x6f\x0e\x65\x72\xa6\xcf\xce\x02\x9e\x28\x40\x20\xa4\x7f\x38\xd3\xfe\x84\xf8\x14\x7a\xbf\xc5\x5e\x22\xcc\x56\x24\xf1\x38\xf1\xb3\x33\xfc\x8b\xea\x9d\xea\x49\x78\x96\x32\x71\xc0\x2d\x24\x67\x9a\x07\xc5\x2e\xcf\x43\x5c\x0e\x08\x06\xbc\x70\x04\x74\x49\x09\xb4\xb0\x5d\xae\x49\x97\xe2\x16\x0b\x0e\x58\x7f\x6d\x00\x52\x86\x2e\x35\x91\x79\xb8\x14\x32\x30\x6c\x34\xc8\x00\x8a\x10\x4e\x1a\xd6\x63\xb4\x42\x17\x90\xad\x98\x48\xcf\xca\x68\x9e\x15\xa9\x81\x8b\xb2\x30\xf4\xfc\x25\x91\x46\x45\xd2\xe7\xe6\x0e\xb6\x17\xd5\x88\x7a\xd5\xc8\xc1\x5e\xc6\x07\x3a\x2b\x0e\x46\xb8\x34\x25\x8c\x00\xf5\xa9\xc6\xed\x44\xe7\x48\xdc\x40\x47\xa2\xad\x14\x5a\x20\x92\xd1\x9a\xa9\xb7\x37\x85\x27\x96\x95\x02\x9c\x80\x6b\x6c\x13\x42\xa8\x02\xea\xaa\x64\x12\x96\xc1\x55\x8b\xc7\x15\xf2\xa5\xe2\x03\xa4\x19\x1c\x62\x2d\x96\xd2\x6e\xe8\x29\xa7\x90\x72\x3a\xe4\x09\x8f\x24\xdf\xe6\x6c\xc1\x03\xb2\x27\x43\x35\x24\x25\x40\x7b\xa5\x66\x15\x99\x78\x79\xf8\x00\xf5\x51\xde\xa3\x3a\x7a\xf5\x65\xcb\x8a\xf4\x67\x26\x55\x22\xe1\xf5\xaf\x32\x7f\x2f\x2e\xf9\xb6\x8d\x3e\x45\x79\x2e\x68\x6a\x38\xf3\x5a\x64\xe9\x60\x9c\x00\xd7\x39\xee\x22\xe7\xc4\xb0\x97\x48\x74\xb4\x93\x79\x05\xe6\xf9\x87\x68\x95\xe9\xa7\xe7\x94\x90\x90\x66\xa0\x47\x08\xf4\x8e\xb6\x4c\x6b\x0e\x93\x66\x74\x91\x00\x65\x76\x73\xcb\x34\x40\xa5\x9c\x29\xfd\x23\x48\xea\x97\xb7\xcb\x00\xd6\x0c\x87\x93\x90\xe6\x09\xbb\x90\x41\x16\xb1\x62\xb1\x16\x92\xee\x73\x40\x2c\x06\xfe\xc9\x72\x5e\xb0\x0d\x8f\x17\x65\x18\x13\x32\x65\x67\x67\xe9\x77\xf0\x2f\xbb\x22\x92\x15\x2b\x3e\xb2\xe3\xc9\x0c\x64\x28\x4f\x60\x81\x6e\x7b\xb5\x12\xfe\xbc\x2a\xd2\x38\x6d\xad\x19\x4e\x01\xf8\x9b\xec\x53\xf6\xf4\x1c\x34\x16\x5f\x04\x22\x84\x95\x44\x22\x9a\xc8\x61\x3f\x20\x77\x8e\xbf\x80\xa1\x02\x74\x1a\xbd\x9b\xd4\x61\x9e\x03\x17\x84\x86\x40\xbb\xe4\x3c\x00\x8a\xdc\xad\x57\xe1\xbf\x05\xd1\x57\x17\x61\x10\xdf\x7d\x38\x0f\xe1\x31\xac\xb6\x99\xee\xcc\x46\xe4\xfc\x9c\x0c\x77\x57\x5f\xcf\x86\xc4\x3c\x7c\x5b\x1f\xb3\xc1\x66\xce\x14\x1f\x01\x39\x11\x13\xf8\x89\x05\x05\xba\xae\x63\x45\x25\xbf\x8e\x0b\x6a\xd1\x8c\x73\x78\xdd\x0a\x83\x14\x08\x69\x59\xd2\x7b\x39\xd0\xaa\x08\x15\x8c\x41\x5d\x27\x0d\x69\x29\xa7\xac\x66\xf8\x04\x08\xa7\x34\x2f\xb8\x54\xf1\xd5\x8c\x6a\xb6\x8d\x9b\x72\xa9\xd7\x19\x1c\xa4\x1f\x91\xb4\x5f\x23\x95\x67\x40\x1c\x58\xbf\xd3\xbe\xdd\xa9\x35\xcc\x2e\xe9\xae\xe8\x2e\x68\x05\xbd\x33\x3e\x73\x5c\xc2\xc3\xe9\x68\x02\xda\x06\x48\xf6\xeb\x36\x56\x5b\xd3\xa1\xe9\x24\x84\xdd\x25\xcb\x14\x6f\xea\x09\xdc\x9c\x27\xcf\xa5\x64\xb7\x0d\x91\x37\x8b\x39\x7b\x27\x57\xa0\x0f\x0b\xb0\x71\xe3\x70\xda\x59\x1b\x04\xf8\x15\x5b\xac\x83\xa6\xae\xd3\x11\xdb\x6e\xf3\x5b\x03\x2d\x05\x84\xe1\x4c\x0c\xd9\x8b\xa4\x7b\x32\xb0\x8d\xbe\xcd\xe1\x3f\xd7\x0d\x41\x56\xc8\x51\x25\x15\x2d\x5d\xe8\x35\x85\x06\x46\x1b\x06\xe3\x3b\x8e\xbc\x78\x35\x9b\xea\x08\x18\x7a\xa5\xd7\xdf\x8d\xa7\xa0\x78\x77\x85\x5a\x67\x4b\x1d\xe8\x86\xcc\xf9\x11\xa3\x6f\xa8\x7f\x04\x63\x03\xe4\x6e\x8c\x19\xd3\x7a\x54\x58\xeb\xcb\x5f\x44\x56\x04\x84\x22\x34\x59\x0b\x1a\xaf\x8c\x12\x7e\x77\xb7\xff\x0c\xc2\x49\xb3\x98\x68\xb9\x03\xcd\x93\x67\x20\xf9\x2c\x8f\xc9\x92\xe5\x0a\xde\x51\xe2\x14\x8e\x40\x2e\x85\x87\xaf\x48\x9b\x43\xab\xc5\x00\x22\x58\xac\xa4\xfc\x22\xe0\x0d\xd0\x26\xa1\x39\x40\x1d\x90\x33\x12\xf6\xe9\xed\xb8\x47\x81\xe6\x74\xc3\x12\x10\xc4\xaf\x13\x90\x53\x8b\x10\xb2\xcd\x55\xca\x17\x22\xe5\x7f\xbd\xfc\xf1\x85\xd8\x80\xac\xc0\x61\x06\xea\x6a\x3c\x0b\x67\xc9\xc1\x9e\xc9\xac\x21\xe4\xc3\xf3\x15\x25\xff\xf2\xf5\x98\x84\xc0\x3e\x40\xb8\x30\xd6\x65\x90\x8b\x05\xc3\xfd\xe1\xe8\x98\x5c\xac\xf1\x98\x41\xd5\xed\x6f\xb2\x3c\x7f\x67\x5a\xe2\x82\xdf\x0c\x18\x4d\xb3\xb4\xf5\x8e\x03\x5e\x0b\x96\xbe\x11\x92\xd7\x43\xfa\x2d\xaf\xa4\x04\xd1\x6e\x0d\xb8\x34\xf4\xb3\x4d\x3f\xb3\x3c\x73\x0d\x47\xe4\xc8\x50\x1b\x58\x64\x5f\x3b\x70\x11\x1c\x05\x9c\x4d\x8f\x76\x12\xb4\x36\x07\x25\xfa\x44\xc1\x4f\x75\xf8\xf0\x8c\xce\x9c\xf1\xb5\x70\xa7\x17\x02\xe4\xf6\x80\x7d\x75\xe6\xe8\x13\xbf\x55\x41\xbd\x73\xe8\xa8\x5f\x52\x04\xbd\x2f\x6e\x46\x36\x74\xd2\x69\xd7\x49\x16\x84\x53\x30\xe7\x0d\x8c\x23\x23\xb0\xc0\x29\xdc\xad\x0c\xd2\xf1\x04\xec\x52\xf4\x19\xfd\x02\x4b\x5c\x90\xba\x12\x5d\x9c\x43\x3e\x20\x58\x44\x9e\xbf\x64\x9a\x79\x46\xf9\xf3\xbb\xb7\x3f\x45\x5b\xb0\x85\x3c\xa8\xfb\xa8\x34\x5e\x73\xd3\x91\x51\xa1\xbc\x62\xb3\x44\xc1\x3f\x4f\x94\x1a\x3f\xb0\xa3\xc6\x44\xea\x20\x2c\x9f\x46\xec\x17\xf6\x25\x30\x2a\x9a\xb0\x6d\x76\x7e\x3d\x39\x37\x83\x08\x4d\x61\xed\xf7\x00\x47\x4c\x7e\x51\xa2\x00\x3f\x61\xb7\x58\x70\xd5\x38\x34\xa3\x04\xec\x8a\x40\x0f\x58\x8c\x72\x73\xf2\x5d\x4d\xb1\x10\x85\x12\xa0\x29\x4c\x2f\xcc\x2a\xf1\x58\x1c\x67\xf5\x54\x73\xcd\x85\x8e\x78\x4e\x13\x4d\x6b\xfe\x00\xce\x00\xbc\x79\x54\x88\x9b\xc0\x78\x87\x04\x3c\xcc\x04\x5c\xd1\xa7\x60\xa3\x40\xb7\xa5\xc1\x5e\x69\x06\x11\x00\x59\x0a\xb5\x16\x0d\xe9\xa5\xb2\x58\xc5\x24\x06\x79\x00\x58\xc3\xd0\x02\x8f\x5e\xac\x43\x03\x86\xa0\xcb\x01\x04\x66\x1b\x90\x4a\x8a\x0b\xf3\xe8\x73\x15\x7b\xc0\x30\x05\xae\x1a\x2a\x2f\x5a\xbd\x7c\x7f\x6b\xdc\x11\x90\x7e\x4b\xd5\xa8\x92\x1b\x8f\x01\x8c\xbd\xb4\x63\xc3\xe9\x21\x82\x5b\x39\xb4\x14\x8f\x81\x68\x86\xe8\x7f\x7c\xf5\xfe\x84\x33\xe0\xc6\xe3\xe1\x91\x91\xb9\xd0\xec\x6d\x1e\xab\xad\x7d\xd7\x94\x83\x46\x73\x22\xc3\x3d\x38\x60\x46\x81\x0d\x91\x56\xb4\x40\x85\xec\x79\x48\x20\x0f\xc9\x10\x56\x96\x57\x62\x66\x99\x2f\x4b\xf0\x79\x5a\x58\x5b\xb8\x47\x9c\xc1\xa8\x5f\x82\x35\xcf\x60\xb9\xeb\x4c\x01\x38\xf4\x0d\xd3\x8b\x35\xa8\xcc\x2c\x72\x4f\xf4\x07\x54\xa1\x7f\xcb\xf4\xda\x34\x40\x47\xbb\x01\x58\x01\xf4\x10\x04\x5c\xc1\x61\xbd\xea\xd7\xf1\x1a\x9e\x77\x1a\xee\xee\x10\x99\xad\x88\x50\xa9\xe5\x1c\x15\x21\x93\x80\xb7\x69\x04\x95\x67\xd8\x66\x81\xf2\x51\x1c\xd4\xbf\x8b\x2b\x3b\x7f\x96\x70\xe3\x6d\xfa\x03\x2e\x7a\xe7\xbb\x80\x16\xc3\x56\xc9\xfe\x1d\x97\xd7\x5c\xc6\x2c\x7a\xb9\x93\x46\x93\xd2\xf7\x42\x83\xd9\xa8\xb9\x72\xe4\x10\x87\x31\xe6\xf7\xed\x16\x4c\x6e\x5a\xd2\xc3\xcc\xe1\x36\xf2\x1b\xa0\x1f\xd4\x93\x24\x85\x31\xa7\x8d\x77\x91\x5b\x15\xe8\x7b\xc5\x51\x1d\x4c\xfb\x87\x8e\x32\x02\xbe\x26\xb3\x47\x7f\xe1\x7e\x63\xf2\x7e\x0d\xbe\x94\x81\x7d\x30\x97\xe2\x13\x1f\xa4\xe2\xa6\x20\x56\x1e\x2b\x35\x7e\x58\x27\x53\xe5\x55\x73\x83\x26\xa0\x66\x41\xf7\xa8\xce\x91\x00\x53\xa9\xce\x29\x8f\x24\xf0\x17\x3c\xad\xa3\x0d\x18\xe5\xaf\xf9\x37\x10\x3c\x81\x8f\xc0\x92\xa4\xb8\x20\x04\x0c\xec\xb0\x00\x34\x9a\xf6\xa5\x25\xfc\x14\x23\xb2\xc2\x9e\x65\x56\x4b\xb9\x01\xc8\xca\x2a\xdd\xa3\x64\xcb\x21\xac\x35\x14\x4e\xde\xe1\x40\x1f\x96\xb6\xec\x51\xd2\x66\xd3\x11\xf2\xb8\xb4\xc9\x9e\xb4\xb1\x44\x7a\x69\x43\x0b\x55\x11\xab\x41\x36\xd0\x8f\x60\x90\x03\xe6\x1b\x42\xcb\x26\x5d\x52\x00\x1d\x2a\x56\xf9\x7f\x61\x91\x9f\xc0\x4d\xff\x41\x48\x23\xf9\xf7\x59\x6e\xc4\x13\xc8\xf4\x44\xb7\x83\x7b\x65\xa3\x2f\xc3\x31\xdd\x48\x0a\xc7\xcb\x67\xe3\xf6\x04\xd6\x0a\xbf\x24\x44\x5b\x87\x23\xb7\xa2\xbf\x20\x95\xa3\x49\xe5\x0b\x16\xcf\xc6\x17\x2c\x6e\xae\x55\x0c\x27\x40\xab\x21\x19\x9c\x0f\xc8\x90\x95\xb4\x0a\x2a\xe3\x7e\x50\xe9\x75\x11\xf8\x99\xb2\x8e\x3e\x43\x1c\xd0\xc4\xb8\x1a\x0e\x27\x93\x27\x97\x9c\x55\x59\x8c\x17\x00\x9b\x0a\xf6\x69\x06\x3e\x1e\xbb\x45\x22\xc6\x04\x97\x78\xbb\xc5\x8d\xd0\x3a\x01\xdc\xf2\x80\x77\xd2\x5c\xe4\x55\xce\xd1\x8d\x0f\x88\x70\xb3\x5c\x90\x6c\x65\x00\xfc\x6f\x15\x99\x06\xaa\x78\x0e\x2e\x0d\x4f\x9b\x3d\xbe\xad\xa4\xdd\xe1\x78\xb0\x74\xf7\x20\xb8\x56\x63\x7d\xcf\x24\xa1\x0b\xef\x6a\xfe\x0d\xa4\xf5\x4d\xd7\xaf\xaa\x1d\xa4\xe9\xa2\xed\x09\x41\xd8\xd4\x08\x34\x5c\x00\xc1\x35\x1a\x1f\x1e\xec\x21\x54\xb1\x1e\x61\xd3\x23\x53\x18\x7e\xa0\x6f\xea\xb7\x7c\x99\xa5\xf7\xec\x08\x47\xb1\x54\xd1\xe7\x68\xc5\xf5\xcb\xb7\x6f\x7e\x02\xf7\xc8\xf8\x63\xb0\xc9\x73\x0d\xc7\x3e\xdf\xc1\x46\x84\xed\xb4\xc0\xf5\x72\xae\xc1\xbf\x27\x62\xb9\x24\x2e\xe6\xc2\x38\xc6\xe8\x92\xa0\x26\x92\xeb\x5a\x33\xf5\x3c\xbd\x86\x90\x95\xa7\x3f\x23\xd5\x54\x00\xc1\xb6\x9d\xb4\x16\x37\xbe\x2b\x08\xc1\xd8\x2e\xc5\x62\xa7\xd0\x15\x02\x28\x7e\x2c\x32\x9d\xb1\xdc\xa0\xd8\x3f\x5e\xe3\xa3\x80\xd7\x6c\x52\x49\x1e\x7d\xf0\x2b\xa4\x7b\x00\x3e\xfa\xbc\xe3\xf2\xf6\x8f\x42\xff\x3b\xbf\x45\x31\x6c\x09\x9d\xba\xc9\x40\x59\x80\xe5\x07\x52\xbd\x00\x5c\xc1\x94\x41\xc4\x3d\xf8\x76\x1c\xd7\xb4\x30\xe1\x4b\x8b\x1e\x1e\xbe\xe9\x1c\xce\xfa\xd3\xd4\x4c\xf9\xe6\x0f\x76\xca\x3a\x4b\x79\x8d\x4b\x73\xc4\xe4\x1b\x3b\x02\x04\x68\x93\xe9\xff\x40\xa8\x82\xb0\x01\xdf\x0f\xb8\x68\xdf\x95\x3b\x40\xb6\xbb\xbb\x03\x5b\x95\x36\xce\x7a\x1c\xa2\x00\xb5\x71\xfb\xee\xc5\xd6\xf0\x78\x04\x87\xbf\xa9\x4e\xac\xbd\x35\x3d\xc6\x38\x87\x08\x75\x8c\x0c\x1e\xfa\x23\x64\x68\xf3\x48\x49\x1b\xd3\x9b\x5c\xd1\x10\x4d\x51\x58\x81\xbb\xe4\x40\x61\x88\xd6\x9d\xa9\x5b\x55\x1c\x1a\x5a\x06\xbb\xe4\xab\x57\x5f\xb6\xa7\x4b\x43\x8b\x1e\x9d\xb8\x60\x89\x99\x01\x13\xfd\xb6\xa7\x80\x41\x5a\x7c\xe2\xa9\x49\xf5\xd6\x09\xbe\xf3\xab\xe8\xab\xe1\xc5\x7f\x3e\xdd\x97\x70\xa6\x57\x1f\x66\x1f\x3e\xcc\x30\xd2\xfc\xf0\xe1\x29\x04\xba\xa0\x91\x21\xd0\xb3\xc0\xd5\x6a\x12\x36\xc8\x90\x8c\x07\x97\xbf\x20\xd9\x0a\x6c\xf7\x8a\x58\xc4\x2c\x9a\x7d\xc4\x16\x51\x1d\x3c\x06\xf5\xba\x46\x64\x8c\xef\xc4\x9d\x5a\xab\xe3\x46\xeb\x93\x24\x30\xb5\x0a\x04\x91\x17\x00\x9b\xab\x59\x48\x21\xfe\x3f\x89\x58\x2e\x13\x70\x32\xb3\x79\x17\xa4\xce\x42\xd0\x2c\x3e\x8d\x10\x36\x66\x89\x49\x21\xb6\x8d\x8c\xc4\x69\xa7\xd4\x99\x0d\xbc\xa9\xfa\xc4\xf4\x16\xbb\x76\xe9\x7a\xb8\x83\x8d\xbe\x0f\x58\xb0\xb8\xf7\xc3\x03\x2e\xe0\x7d\xa4\x9a\x3a\xeb\x83\xb1\x15\x18\x55\x07\x7c\xe2\x80\x87\x50\x4e\xe3\x1f\x8f\xb2\x30\xd2\xe2\xb5\xb8\xe1\xf2\x05\x80\x00\x5c\x7f\x77\x67\x13\x35\x09\xa6\x5b\xc8\x04\x7f\x69\x51\xcd\xef\x27\x79\xea\x15\x71\xaf\x63\x6b\x71\xb7\x16\x2f\x41\xcb\x38\x74\xa0\x89\xe0\xa5\x90\x87\xd3\x20\x51\xd2\x9e\x4a\xeb\xeb\x75\x1b\xe1\x9f\xca\x28\xb8\x4f\x6b\xbc\xe5\xe5\xfe\x78\x38\xca\x86\x1e\xb9\x4f\xe6\x59\x7a\xdd\x3e\x0c\xdd\xe8\x9c\xb3\xa2\xdd\x79\x22\x13\x1c\x38\x44\xf4\x91\xc8\x9a\x67\xab\xb5\x06\x06\x47\xc3\x8a\xbe\x18\x36\x6e\x59\x9a\xe2\x35\x0b\x25\x93\xf1\xf6\xcb\x60\x6c\xda\x35\x25\x1b\xf6\x65\x54\x4d\xa8\x5a\xc5\x96\x2d\x32\x7d\xeb\x9a\x0c\x39\x54\x47\x73\xcb\xda\xae\x36\xf5\xf7\x3f\x83\x0a\x1d\x34\xc7\x7d\x1c\x0f\xa3\x37\x19\x03\xea\x7d\x14\x27\x98\x87\xaf\xb1\xe9\xfb\x7e\x0d\x3c\xac\x42\xf3\xbe\x81\x8f\xae\x34\x26\x1f\x4c\x6e\xac\x31\xc8\x72\xcd\xa1\x00\xd7\xe5\xc2\x4a\xef\xe5\xb4\xd7\x3c\x34\x43\xdb\x08\xff\x90\xf7\x99\x7b\xb7\xb3\xe1\x67\xe2\x06\x65\xe8\x43\x6d\xd9\x04\xdd\x44\xb3\xa0\x0b\x08\xa9\xae\x06\x40\xf3\xb2\xe4\xa0\x63\x9b\x66\xd7\xe0\xd5\x2e\xd0\xfb\xb4\x4e\xa7\x99\x4d\x30\x81\x77\xf2\xe8\x51\xce\x97\xfa\xd8\x14\x06\x13\xd6\x70\xc2\x31\xe1\x5f\x16\xf9\x2e\xe5\xe9\x47\xcb\xd5\x6b\xbd\xc9\xc1\xb1\xad\xd7\xca\xb3\xe2\xd3\x68\x25\xd9\x2d\x2c\x45\x5e\xb9\xc1\x03\x13\xd2\xa2\x75\x7b\x0c\x40\xd2\xb0\xc3\xa9\x48\x00\x71\x61\xac\x80\x08\xd3\x24\x14\x40\x1a\x36\x6a\xa0\xf1\x11\x25\x63\x00\x71\xcd\x63\xd7\xb1\xa9\x0a\xbb\x90\x8d\xeb\x7e\xcd\x4a\xd2\xc6\xf3\x30\x71\xe9\x88\x70\x3f\x19\xb2\x34\x26\x59\xb1\xdd\x3d\x80\xb9\x1d\xc6\x8e\x0d\xb2\x2b\xd8\x61\x9f\x89\x0b\xd4\x35\x04\xff\x84\x1a\x2f\x64\x2d\x72\x14\x1e\x17\x9d\x0c\xe6\xb7\xe8\x73\xf0\x2f\x5b\x0c\xa9\x96\x66\x4a\xd3\xdb\x8f\x8d\xb3\x4f\x45\x01\x4e\xe6\x4b\x74\x32\x0d\xa3\x76\x5c\x6c\xe8\xb6\x5e\x5c\xab\xd3\x34\x95\xa7\x1e\x3c\x04\x1a\x5a\x14\x23\xd0\x0f\x23\x08\xd4\x8e\xe0\x66\x07\x39\xe4\x52\x91\x32\x8d\xa0\xbd\xc8\xb3\xc5\xa7\x9e\x93\x59\x9e\x44\xed\xf9\xc3\xb4\x06\x9d\xe8\x68\x83\x4f\x47\x86\xab\x2d\x2b\xda\x08\x09\x50\x0d\x0b\x51\x0c\xdc\xef\x08\xec\xed\xb5\x84\xdf\xdd\x76\x80\xda\x78\x64\x96\x6d\x01\xdf\x54\xd2\x27\xd3\x6d\x99\xf1\x3c\x3d\x06\x55\xce\xe6\x3c\x47\x01\x06\x59\xfd\x01\x13\x15\x96\x11\x41\x3e\x91\x33\x07\x7f\x61\x7a\x4d\x1e\xb5\xd1\xe8\x5e\xfe\xf4\xac\xd7\xe4\x39\xa4\xa0\xdd\xb5\xcd\x7e\xb2\xc9\x74\x6e\x40\x87\xcb\x3a\xf1\x4d\x9b\xcb\x5a\xe1\xc3\x43\x67\xfd\xab\xe9\x95\xad\x0a\x21\xf9\x08\x7d\x39\xa4\xda\x8f\xe6\x75\x80\xbe\xd0\xef\x41\x37\xc3\x92\x8d\x1d\x9d\xf0\x1a\x4f\x6d\x2e\xbe\x38\x62\x65\x16\x9a\xdf\x0b\x65\xe7\xca\xc1\xe6\x99\xce\xb1\x04\x06\xd3\x6b\x10\xef\xf0\x81\x11\xec\x01\x53\x83\x4c\xd1\xc1\xcd\x3a\x83\xe6\x4c\x0d\xe6\x10\x6b\x0d\xc0\x22\x0e\x94\x29\xa3\x51\x83\x3c\xfb\xc4\x07\x2c\x9a\x07\x8b\x10\x69\xf6\xda\xad\xf7\x3b\xd1\xab\x06\xf7\x10\xad\x7c\xef\x6f\x4d\xad\xc6\xf8\xcd\x2e\xd7\x99\xb5\xeb\x1f\x5d\x77\x45\x4b\x7b\x27\x04\x44\x78\x67\xfa\x07\xe8\x3e\xfc\x96\x84\xb0\xdb\x3a\x4a\xb8\x0b\xa8\xe6\x0a\x42\x6e\x46\xa0\x7b\xb4\x14\xf9\xa0\x01\x27\xa1\xe6\x65\x6b\xab\x7d\x54\xf6\x5f\x3c\xae\xd2\xcc\x93\x7f\xa5\x3c\xb4\xc4\xf3\xd0\xeb\xf0\x74\xea\xcd\x59\xe1\x48\x6f\x9e\xda\xca\xb9\xe1\x8a\x1f\x41\x88\x6f\x08\x35\xb9\x1d\x52\x79\xab\xc6\xf2\x5a\xa9\x18\x20\xe7\xd3\x81\xbd\xd4\x44\xcb\x85\xc5\x11\x74\xa0\xf4\x6e\xb9\xb4\x6c\xa7\xd7\x4c\x47\xe8\x6d\x30\x93\xa3\xdb\x1e\x28\x2e\x32\x7e\x20\x8f\x5e\x67\x05\xff\x69\xb7\x99\x73\x49\xf1\x6a\xe7\x7b\xbe\xc4\xa4\xb1\xf5\x16\xa7\x3c\x7a\xbe\x84\x2d\xfc\x6b\x15\x16\xbb\x51\x7d\xff\x0f\xf6\xf3\x1e\xe0\xde\xae\x1a\x83\x17\x33\x64\xf4\x05\x50\x1f\x30\x03\xd7\xcf\xde\xe4\x3c\x99\x94\xe6\x4a\xb9\x33\xb6\x1e\x67\x00\xf3\x83\xd1\x01\xf5\xb0\x1c\xd8\x54\xf6\x37\x1d\xca\xe1\xe4\xe8\xa6\x10\x07\x1c\xae\x30\x30\x14\xd9\xb0\x6d\xb0\xc5\xf8\x05\x28\xc4\x92\xb1\x4d\x10\x5b\x02\xb0\x67\xc5\x94\x0d\x87\x96\x84\xc2\x5c\x90\x62\xe5\xcf\x85\xbe\xaa\x8a\x08\x26\xb3\xc8\xc1\x30\x9a\x4c\xc5\xd5\xd8\xbf\x3e\x4b\xb2\x0b\x71\xd0\x63\xe6\x6e\xc4\x77\xd9\x85\xf6\xa5\x22\xb1\xc6\x2b\x5e\x03\xb5\xb9\xc5\xaf\x97\x1f\x05\xd9\xc8\xcf\x08\x67\x76\x08\x78\xe8\x80\x57\x1c\x68\x8c\x75\xcc\x0a\x58\xe6\x95\x88\xb0\xf4\x59\x9a\x66\x87\x2c\x69\x1d\xed\x0e\x38\xd6\x6d\xf8\x3a\x02\x5e\x17\x40\xa0\xd5\x72\xf4\x33\x25\x8c\x59\x51\x70\xf9\xa7\xf7\x6f\x5e\x97\xd3\x34\xe2\x49\x0a\xe6\x06\x59\xf5\x90\x20\x58\x5f\x7e\xf9\x60\x5e\x18\x6d\xaf\xdb\xe2\xe7\x8c\xdf\x90\x13\xb2\x9e\x62\xcb\x0b\x64\x07\x10\x46\xb1\x5a\x55\xd3\x7b\x99\x30\x1b\x41\xe0\xe8\x0b\xf3\xbe\xc8\x85\xf2\x83\x83\xd0\x8a\x21\xf6\x56\x4d\xe0\xef\xd6\xaf\xfd\xe5\xaa\x54\xb3\x07\x00\x13\xcb\x8d\x35\x1f\x9c\x81\x9c\x77\x7f\xbc\x66\x93\x77\xa8\x6b\x7c\xb0\xe9\x5b\xae\x7d\x80\xe9\x1b\xd0\x63\xf0\xf1\xb5\x6d\x43\x2f\x00\x89\x8a\xdc\x58\xb7\xce\x73\xb1\xf8\x84\xd5\x1e\x77\x77\x86\x97\x7b\x5d\x86\xd7\x2b\x70\x98\xe7\x6b\x56\xb3\x2d\x56\xa8\x5d\xb1\x1e\x73\x83\x50\xb0\x83\x93\x8b\x7e\xa9\xe7\x13\xc7\xc8\xfe\x7a\x26\x0d\x78\xe4\xc8\x16\x56\x77\xc6\xc8\x7d\xae\xd1\x48\xde\x74\x8a\x32\x5d\xdd\xcb\x24\x63\x5f\xad\x6b\x0b\xc9\x94\xb9\xf3\x79\x02\x9b\x3a\xbe\x4e\xa1\xc9\xa5\x5b\xcb\xba\xa9\x5b\xe7\xe3\x97\x1b\x19\x0c\x7d\x45\x10\x88\x84\x9d\x41\x9e\xf1\xcd\x77\x64\x98\x06\xd8\x1d\x0e\xc9\xb3\x73\x7c\xb7\xa5\x6f\xad\xaa\x22\xbf\x4e\x25\x60\xd2\xa5\xea\x48\x58\x06\x8c\xaa\x2a\x83\x78\x9a\xb1\xc0\x22\xbd\x07\xe3\x4e\x77\xc1\x77\xb1\xa8\xaf\x91\x50\xf5\x51\x60\x17\x9a\x87\x71\xb7\x99\xf9\x93\x02\x89\x6f\x6e\x55\xec\xc0\xb4\x68\x26\xb1\x9a\x98\x7c\x9c\xe7\xac\xf8\x04\x5b\xfb\xd1\xe1\xa9\x5e\x7d\x8e\xa1\x1d\x4d\xb1\xe8\x50\x8a\x9d\xca\x6f\xdf\xa1\xec\x3a\x15\x11\xef\x3f\x7e\x44\xdb\x1f\x17\xa5\x8d\xed\x1f\x45\x8d\x0d\xa2\x09\x30\x15\x8f\x9e\x89\x52\x30\x20\xc3\xa0\xab\x02\x08\xfe\x27\xe0\x14\xa1\xc4\xa6\x24\x3c\x35\xa0\x36\x9e\x5f\xc7\x78\xb7\xf4\xce\x43\x67\x96\x39\x1e\xeb\x1d\x5a\xd6\x90\x30\x0d\x6f\x59\x4f\xc2\xfa\x27\x6a\xfc\x01\xac\xbc\x95\x8f\xf0\xe1\x72\x3e\x9a\x8b\x14\x53\x11\x2c\x34\xae\xc0\xe6\x24\xb5\xac\xac\x42\x16\xc5\xc1\x8b\xfd\x45\xe4\x9b\x83\x8e\xea\x3a\x55\xc9\x5d\x77\x75\x1c\x68\xbd\x07\x95\xdc\xc6\x15\x85\xb4\x14\x99\xc9\x6f\xf8\x72\x91\x22\x91\x6d\xad\x24\xd1\x21\xb9\x87\x87\x96\x74\x5f\x05\x5e\x23\x02\x7e\x0a\x42\x12\x6b\x53\x66\xca\xa9\xd7\xaa\xb1\xcd\x60\x60\xa1\x29\xb5\x8a\x33\x5e\x07\xb2\xbe\x70\xb7\xc0\xc6\xca\x78\x17\xa2\x99\xb0\x72\xc7\xfa\x8c\x99\xaa\xd7\xfb\x63\xf9\xa6\x08\x08\xbc\x2c\x6d\xf1\x5d\x7d\x14\x18\x4b\xc0\xe3\x80\xe5\xf9\x80\x50\x06\x5e\x21\xb8\xa7\x2a\x9b\x03\xef\x3b\x0a\x61\xfd\x0d\x38\xc0\x51\xe3\xca\x3d\xd0\xe1\xa3\xb8\x06\xfd\x5d\x88\x34\x0d\xcb\x5c\x9f\x74\x21\xfd\x6b\x4d\x78\xfb\xe2\xfc\x3e\xfb\x6d\xf0\x68\x18\x6f\xfb\x6e\x2d\xf7\x5b\xf9\xc2\x0f\x89\x0f\xba\x76\xa6\xcc\xbc\x9d\x90\x46\x88\xcd\x6b\x68\x4a\x67\xa4\x36\x25\x27\x81\x63\x06\x73\xcd\x73\x71\x6c\xca\x95\x9e\xb5\x1d\x88\xf8\xbe\x91\x6d\xf7\xc3\x95\xd2\x76\x5c\x04\xac\x60\xa6\x1e\xad\x1e\x41\x3a\x38\x06\xe8\xf1\xd1\x45\x1f\xe5\x63\xc3\x27\x07\x25\xf3\x51\xda\x15\x45\xe3\x9f\xa3\x5d\x11\xe4\x93\xf3\x4b\x1b\xbe\x62\xa3\x6e\x92\x09\x61\x25\xe5\xc9\xd6\x0c\x25\x1b\x18\xbe\x23\x31\x1d\xd5\x76\xf2\x6a\x59\x91\x66\x0b\xa6\x85\x1c\x1c\xcb\x7d\x1d\xa2\xe2\x6e\x4b\x30\x97\x87\xf5\x32\x47\x69\x72\x04\x86\x4d\x43\x8b\x39\xcd\xed\x34\x41\x7c\x40\x7d\xa2\x6e\xeb\x28\x63\xab\xf5\x3a\xfa\xd8\x69\xb5\xbe\x52\x6e\xaa\xdc\xb8\xe3\x84\x62\x0a\x0a\x75\xc6\xe5\x09\x3a\x03\x6b\x91\x2c\xb4\x8f\x28\x0b\x69\x54\x78\xde\x5f\x14\x62\x0b\x9c\x8c\xd1\x74\x65\x4f\xf6\xc5\x64\x6f\x62\x15\x7d\xb6\xe5\x21\x0d\x49\x79\x9e\xe7\x27\xe8\x8e\x8e\xb2\x40\x52\xf5\x94\x85\xd7\x0e\x4d\x8d\xd5\xd2\x05\xb6\xc3\x6d\xdf\xda\xb7\x2f\xc0\xd0\xdd\x10\xf7\x53\x06\x4f\x4e\xaa\x20\x69\x52\xa5\x3c\xa4\x1d\xf0\xdb\xb9\x9a\x4b\x0d\x3d\xc3\x87\x35\x06\xa6\x60\x0a\x31\xb2\xab\xb7\xd2\x30\x66\x85\xa3\x29\x1c\x0d\x82\xb1\xf2\x59\x8f\x57\x97\x97\x6f\x2f\x63\xd2\xba\x76\xb2\x00\xa0\xdf\x8f\x63\xfc\xb5\xa7\xbf\xc1\x32\xb8\x9c\x9d\x8d\x93\x43\xed\xde\xd5\x7f\x2c\xf4\x60\x6a\xff\xf1\xf7\xff\xfe\x49\xc0\x8a\xc5\xca\x24\xf7\x6e\xc5\x8e\x0e\x5e\xb2\x9b\x55\xf4\x8f\xbf\xff\xcf\x7d\xd7\x20\x16\x8f\xf1\xc0\x41\x40\xc2\x0a\xf2\xe4\x30\xe4\x8d\x36\xc3\xa2\xbf\x02\xd8\xc3\x49\xc2\x0d\x50\x75\xaf\xe4\x02\x14\xd3\x86\xad\xb8\x3a\x9f\xef\xd4\x6d\xb4\xca\x96\xe4\xde\xe4\xba\x45\xc0\x8a\x1a\x60\x1f\x45\x98\x4f\x9a\xf6\x2e\x18\xbd\x4e\x38\x80\x93\x89\x3a\xb1\x6e\x6f\xd3\x13\xd4\x7b\x70\xbb\x76\xca\xcc\x0a\xd6\x50\xd5\xaa\xac\xaa\x0a\x36\x1a\x0c\xcb\x86\x9d\xe2\x32\xc5\x19\x5e\x5f\x71\x5f\x6d\xd1\xaf\x15\xc6\xa4\x4e\xed\x9c\xd5\xdf\x8f\x80\xf0\xca\x13\xaf\x15\x99\x41\x42\x3d\x78\x31\xd3\xf6\xdd\xac\x90\xff\x9f\xaf\x4c\x8c\x75\x28\x31\x0f\x68\x3f\x6b\x43\x17\xf0\x58\x1a\xf5\x30\x24\x5e\x85\xfc\x06\xb7\x37\x16\x90\x17\x22\xcf\xd9\x56\x71\x0b\xca\xc3\x37\x4d\x15\xaf\x4a\x6a\x4a\xf9\xe9\xea\x41\x43\xf1\x7c\xbb\x3d\xcd\x42\x64\xe6\x9e\xde\x56\xdd\x9b\x9c\xed\xc5\xd5\x2c\xf6\xb7\xdb\xee\x13\x19\x4a\x7a\xde\xd7\xe7\x18\x0b\x49\x32\xf8\x9f\x55\x05\x33\x55\x25\x87\x63\x26\x57\xc2\xe1\x0a\x73\xb4\xbb\xae\x56\x0f\xd7\x2a\x9a\x24\x08\x6f\x16\x1e\xb5\x2e\xdd\xc3\xaa\xc2\x88\xb7\x2b\x8c\x9a\x00\x56\x35\x7d\xc8\xc1\x8b\x46\x0d\x78\x67\x27\x13\xec\x38\x1f\x54\xf9\x9a\xcb\xc6\x3a\xf6\xb3\x06\x56\x23\x41\xab\xaa\x11\x6f\x8a\x5b\xbb\x5a\xf3\x20\xab\xf0\xa6\xbb\x72\x55\x43\x86\xb5\x8b\x95\xb5\xad\xa1\xac\x4a\x90\xbb\x14\xb1\x1f\x40\xff\x9e\x5b\xdb\x6a\xe5\xfe\x49\x9c\xb2\xab\x51\x7c\x76\x51\x6d\x56\xbc\x01\x87\x4e\xdc\x44\x2c\x4d\x5f\x5d\x03\x07\xbe\x76\x9f\xc0\x05\x64\x0b\x5e\x0f\xce\x25\xb4\x9f\x5e\xcf\x4c\xa1\x52\xff\x2c\x7c\x95\x28\x02\x5a\x7f\xc2\x83\x9e\x40\xb7\x6c\xaf\x5f\x0b\xb8\xdb\xa6\xb0\xdb\x9f\x60\x7f\x21\x6f\xa1\xb5\xb1\x44\x15\x7c\xb4\x68\xd4\x28\xf8\x6b\xcd\x3d\x50\x80\x5d\x7d\xd6\x85\x77\x09\xe8\x0f\x0f\xc9\xc5\xe7\x84\x0c\x79\xd1\xfb\x4a\x0c\xbf\x69\x19\x92\xb3\xec\x58\x6f\x86\xbd\x4e\x7e\x8e\x8d\x71\xdd\x38\xd2\x48\xd6\xb1\x71\xa6\x13\x47\xb9\x4f\x6c\x86\x4e\x70\xa6\x6b\x8b\x89\x49\xdc\xb9\x03\x34\x9f\x88\x6a\xb0\xd9\xc4\xa6\x49\x1e\x1f\xee\x98\xa3\x3f\xd4\xbd\x73\x46\x49\xd5\xa5\xcc\xae\xf6\xcf\x59\x6c\x5f\xa0\x67\x5f\xb3\x76\xe5\x9d\x6d\x6c\x6b\x94\x46\x47\x4b\xb5\x74\xaa\x72\x0e\xb0\x85\x4b\x07\x74\x9a\x8f\xd8\xf2\xcb\xca\x9a\xd6\xde\x76\x1b\x72\xeb\xb0\x4f\xed\x64\x4b\xb3\xfa\x00\x0e\x2d\xb9\x32\x64\x0a\x69\x75\x05\x00\x8c\xe6\xfa\xbe\xbf\xfd\x31\x05\x97\x58\x08\x8d\x77\x4f\x36\x4d\x04\x11\xfa\x2c\x9c\xfe\x2f\x36\x86\x32\x91\xac\x41\x00\x00"
 
 func jsHoundJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -464,7 +464,7 @@ func jsHoundJs() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "js/hound.js", size: 15802, mode: os.FileMode(436), modTime: time.Unix(1559013209, 0)}
+	info := bindataFileInfo{name: "js/hound.js", size: 16812, mode: os.FileMode(436), modTime: time.Unix(1792084170, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -0,0 +1,387 @@
+package vcs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/etsy/hound/config"
+)
+
+// The file in the working directory that records where the archive came
+// from and which revision of it was extracted.
+const archiveMetaFilename = ".hound-archive"
+
+func init() {
+	Register(newArchive, "archive")
+}
+
+var archiveClient = &http.Client{
+	Timeout: 30 * time.Minute,
+}
+
+// Indexes the contents of a .tar, .tar.gz, .tar.bz2 or .zip archive that is
+// downloaded over http(s). The revision is the archive's ETag, or failing
+// that its Last-Modified time, or failing that the sha1 of its contents.
+type ArchiveDriver struct {
+	// The number of leading path elements to remove from each entry, like
+	// tar's --strip-components. Release archives often keep everything in
+	// a versioned directory, which would otherwise rename every file on
+	// each release.
+	StripComponents int `json:"strip-components"`
+}
+
+type archiveMeta struct {
+	Url          string
+	Rev          string
+	ETag         string
+	LastModified string
+}
+
+func newArchive(b []byte) (Driver, error) {
+	d := &ArchiveDriver{}
+
+	if b == nil {
+		return d, nil
+	}
+
+	if e := json.Unmarshal(b, d); e != nil {
+		return nil, e
+	}
+
+	if d.StripComponents < 0 {
+		return nil, fmt.Errorf("archive: strip-components can't be negative")
+	}
+	return d, nil
+}
+
+func readArchiveMeta(dir string) (*archiveMeta, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, archiveMetaFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	var m archiveMeta
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *archiveMeta) write(dir string) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, archiveMetaFilename), b, 0644)
+}
+
+// An archive extracted with a different strip-components is laid out
+// differently, so it gets a directory of its own.
+func (g *ArchiveDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
+	var variant string
+	if g.StripComponents > 0 {
+		variant = fmt.Sprintf("strip-components=%d", g.StripComponents)
+	}
+	return generateVariantWorkingDir(dbpath, repo.Url, variant), nil
+}
+
+func (g *ArchiveDriver) HeadRev(dir string) (string, error) {
+	m, err := readArchiveMeta(dir)
+	if err != nil {
+		return "", err
+	}
+	return m.Rev, nil
+}
+
+func (g *ArchiveDriver) Pull(dir string) (string, error) {
+	m, err := readArchiveMeta(dir)
+	if err != nil {
+		return "", err
+	}
+
+	m, err = g.fetch(dir, m)
+	if err != nil {
+		log.Printf("Failed to update archive in %s: %s. Continuing...", dir, err)
+		return "", err
+	}
+
+	return m.Rev, nil
+}
+
+func (g *ArchiveDriver) Clone(dir, url string) (string, error) {
+	m, err := g.fetch(dir, &archiveMeta{Url: url})
+	if err != nil {
+		log.Printf("Failed to download archive %s: %s. Continuing...", ScrubUrl(url), err)
+		return "", err
+	}
+
+	return m.Rev, nil
+}
+
+func (g *ArchiveDriver) SpecialFiles() []string {
+	return []string{
+		archiveMetaFilename,
+	}
+}
+
+// Download the archive unless it's unchanged since prev was extracted and,
+// if it has changed, extract it in place of whatever is in dir.
+func (g *ArchiveDriver) fetch(dir string, prev *archiveMeta) (*archiveMeta, error) {
+	req, err := http.NewRequest("GET", prev.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+
+	res, err := archiveClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified && prev.Rev != "" {
+		return prev, nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("archive: GET %s: %s", ScrubUrl(prev.Url), res.Status)
+	}
+
+	par := filepath.Dir(dir)
+	f, err := ioutil.TempFile(par, "archive-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), res.Body); err != nil {
+		return nil, err
+	}
+
+	m := &archiveMeta{
+		Url:          prev.Url,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+
+	switch {
+	case m.ETag != "":
+		m.Rev = m.ETag
+	case m.LastModified != "":
+		m.Rev = m.LastModified
+	default:
+		m.Rev = hex.EncodeToString(h.Sum(nil))
+	}
+
+	if m.Rev == prev.Rev {
+		return prev, nil
+	}
+
+	// extract next to the working dir and swap it in once complete, so a
+	// failure never leaves a half extracted archive behind.
+	tmp, err := ioutil.TempDir(par, "archive-")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.extract(f, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+
+	if err := m.write(tmp); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Extract the archive in f into dst, the format is worked out from its
+// first few bytes.
+func (g *ArchiveDriver) extract(f *os.File, dst string) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(4)
+
+	switch {
+	case strings.HasPrefix(string(head), "PK\x03\x04"):
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return g.extractZip(f, fi.Size(), dst)
+	case strings.HasPrefix(string(head), "\x1f\x8b"):
+		r, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return g.extractTar(r, dst)
+	case strings.HasPrefix(string(head), "BZh"):
+		return g.extractTar(bzip2.NewReader(br), dst)
+	}
+
+	return g.extractTar(br, dst)
+}
+
+func (g *ArchiveDriver) extractTar(r io.Reader, dst string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name, err := g.entryPath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(filepath.Join(dst, name), 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeRemoteFile(filepath.Join(dst, name), tr); err != nil {
+				return err
+			}
+		}
+		// links and special files are never indexed, so they are skipped
+	}
+}
+
+func (g *ArchiveDriver) extractZip(r io.ReaderAt, size int64, dst string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range zr.File {
+		name, err := g.entryPath(zf.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+
+		if zf.FileInfo().IsDir() {
+			if err := os.MkdirAll(filepath.Join(dst, name), 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+
+		err = writeRemoteFile(filepath.Join(dst, name), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Split a slash separated path from a remote source into its elements,
+// leaving out empty ones. Paths that would escape the directory they are
+// written to are an error.
+func splitRemotePath(name string) ([]string, error) {
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			return nil, fmt.Errorf("illegal path %s", name)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// The path an entry is extracted to, relative to the working dir. It's empty
+// for entries that are removed entirely by StripComponents. Entries that
+// would end up outside of the working dir are an error.
+func (g *ArchiveDriver) entryPath(name string) (string, error) {
+	parts, err := splitRemotePath(name)
+	if err != nil {
+		return "", fmt.Errorf("archive: %s", err)
+	}
+
+	if len(parts) <= g.StripComponents {
+		return "", nil
+	}
+
+	return filepath.Join(parts[g.StripComponents:]...), nil
+}
+
+// Write the contents of r to path, creating any missing parent directories.
+func writeRemoteFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	w, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
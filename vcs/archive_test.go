@@ -0,0 +1,194 @@
+package vcs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeTarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// Tests that an archive is downloaded on clone and only downloaded again
+// once its ETag changes.
+func TestArchiveCloneAndPull(t *testing.T) {
+	var (
+		etag     = `"v1"`
+		body     = makeTarGz(t, map[string]string{"proj-1/a.txt": "one", "proj-1/b.txt": "two"})
+		requests = 0
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	wd, err := New("archive", []byte(`{"strip-components": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(dbpath, "vcs-archive")
+	rev, err := wd.PullOrClone(dir, srv.URL+"/proj.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rev != etag {
+		t.Fatalf("expected a rev of %s, got %s", etag, rev)
+	}
+
+	if s := readFile(t, filepath.Join(dir, "a.txt")); s != "one" {
+		t.Fatalf("expected a.txt to contain one, got %s", s)
+	}
+
+	// nothing has changed
+	if rev, err = wd.PullOrClone(dir, srv.URL+"/proj.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	if rev != etag || requests != 2 {
+		t.Fatalf("expected an unchanged rev after 2 requests, got %s after %d", rev, requests)
+	}
+
+	// a new release
+	etag = `"v2"`
+	body = makeTarGz(t, map[string]string{"proj-2/a.txt": "uno"})
+	if rev, err = wd.PullOrClone(dir, srv.URL+"/proj.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	if rev != etag {
+		t.Fatalf("expected a rev of %s, got %s", etag, rev)
+	}
+
+	if s := readFile(t, filepath.Join(dir, "a.txt")); s != "uno" {
+		t.Fatalf("expected a.txt to contain uno, got %s", s)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "b.txt")); !os.IsNotExist(err) {
+		t.Fatal("expected b.txt to be gone after the update")
+	}
+
+	if head, err := wd.HeadRev(dir); err != nil || head != etag {
+		t.Fatalf("expected a head rev of %s, got %s (%v)", etag, head, err)
+	}
+}
+
+// Tests that zip archives are extracted and that entries can't escape the
+// working dir.
+func TestArchiveZip(t *testing.T) {
+	body := makeZip(t, map[string]string{"src/main.go": "package main"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	dbpath, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbpath)
+
+	wd, err := New("archive", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(dbpath, "vcs-zip")
+	rev, err := wd.Clone(dir, srv.URL+"/src.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without an ETag or Last-Modified, the rev is the sha1 of the archive
+	if len(rev) != 40 {
+		t.Fatalf("expected a sha1 rev, got %s", rev)
+	}
+
+	if s := readFile(t, filepath.Join(dir, "src", "main.go")); s != "package main" {
+		t.Fatalf("expected src/main.go to be extracted, got %s", s)
+	}
+
+	body = makeZip(t, map[string]string{"../evil.go": "package evil"})
+	if _, err := wd.Pull(dir); err == nil {
+		t.Fatal("expected an entry outside of the working dir to be rejected")
+	}
+
+	if _, err := os.Stat(filepath.Join(dbpath, "evil.go")); !os.IsNotExist(err) {
+		t.Fatal("expected evil.go not to be written")
+	}
+
+	// the failed update left the previous contents alone
+	if s := readFile(t, filepath.Join(dir, "src", "main.go")); s != "package main" {
+		t.Fatalf("expected src/main.go to survive, got %s", s)
+	}
+}
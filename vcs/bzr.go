@@ -55,7 +55,7 @@ func (g *BzrDriver) Pull(dir string) (string, error) {
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("Failed to bzr pull %s, see output below\n%sContinuing...", dir, out)
-		return "", err
+		return "", &CommandError{err, out}
 	}
 
 	return g.HeadRev(dir)
@@ -71,8 +71,8 @@ func (g *BzrDriver) Clone(dir, url string) (string, error) {
 	cmd.Dir = par
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("Failed to clone %s, see output below\n%sContinuing...", url, out)
-		return "", err
+		log.Printf("Failed to clone %s, see output below\n%sContinuing...", ScrubUrl(url), out)
+		return "", &CommandError{err, out}
 	}
 
 	return g.HeadRev(dir)
@@ -1,19 +1,34 @@
 package vcs
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/etsy/hound/config"
 )
 
-const defaultRef = "master"
+const (
+	defaultRef      = "master"
+	defaultUsername = "x-access-token"
+
+	// A git credential helper that answers with the username and token
+	// found in the environment.
+	credentialHelper = "!f() { echo username=$HOUND_GIT_USERNAME; echo password=$HOUND_GIT_TOKEN; }; f"
+)
 
 func init() {
 	Register(newGit, "git")
@@ -21,6 +36,38 @@ func init() {
 
 type GitDriver struct {
 	Ref string `json:"ref"`
+
+	// Path to a private key to use for ssh remotes.
+	SshKey string `json:"ssh-key"`
+
+	// Credentials to use for https remotes. These are handed to git through
+	// the environment so they never show up in a command line or a log.
+	Username string `json:"username"`
+	Token    string `json:"token"`
+
+	// Check out submodules (recursively) on clone and pull so that their
+	// files are indexed as part of the repo.
+	Submodules bool `json:"submodules"`
+
+	// Fetch the contents of the files tracked by git LFS (which needs
+	// git-lfs installed) so that they are indexed. By default they are never
+	// downloaded: the files are checked out as LFS pointers, which aren't
+	// indexed.
+	LFS bool `json:"lfs"`
+
+	// Check out each of these branches, rather than Ref, into a directory
+	// of its own in the working dir. Each one is searched as a virtual repo
+	// named after its directory, which is the branch with any / turned
+	// into a -.
+	Branches []string `json:"branches"`
+
+	// Fetch this many days of history, rather than only the latest commit,
+	// so that the files changed in that time can be dated (see ModTimes).
+	// 0, the default, doesn't date files at all.
+	HistoryDays int `json:"history-days"`
+
+	// The commands are killed once this is done, see WithContext.
+	ctx context.Context
 }
 
 func newGit(b []byte) (Driver, error) {
@@ -35,19 +82,139 @@ func newGit(b []byte) (Driver, error) {
 	if e := json.Unmarshal(b, d); e != nil {
 		return nil, e
 	}
+
+	if d.HistoryDays < 0 {
+		return nil, fmt.Errorf("git: history-days must not be negative, got %d", d.HistoryDays)
+	}
+
+	dirs := map[string]string{}
+	for _, branch := range d.Branches {
+		dir := branchDir(branch)
+		if dir == "" || dir == "." || dir == ".." || strings.HasPrefix(branch, "-") {
+			return nil, fmt.Errorf("git: invalid branch %q", branch)
+		}
+
+		if other, ok := dirs[dir]; ok {
+			return nil, fmt.Errorf("git: branches %q and %q would be checked out in the same directory", other, branch)
+		}
+		dirs[dir] = branch
+	}
+
 	return d, nil
 }
 
+// The directory in the working dir that branch is checked out into.
+func branchDir(branch string) string {
+	return strings.Replace(branch, "/", "-", -1)
+}
+
+// A driver for one of the branches, which is checked out like a repo with
+// the branch as its ref.
+func (g *GitDriver) forBranch(branch string) *GitDriver {
+	b := *g
+	b.Ref = branch
+	b.Branches = nil
+	return &b
+}
+
+func (g *GitDriver) WithContext(ctx context.Context) Driver {
+	d := *g
+	d.ctx = ctx
+	return &d
+}
+
+// Repos that check out other refs of the same url each get a directory of
+// their own. The default ref keeps the directory it always had, so does a
+// bare repo (a mirror has every ref, only the working trees are per ref).
+// Before the ref was part of the name, every ref of a url was checked out in
+// the same directory. If that's still around and checked out at this ref,
+// it's moved into place instead of being cloned again.
 func (g *GitDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
-	return generateWorkingDir(dbpath, repo.Url), nil
+	// a branch can't have a space in it
+	if len(g.Branches) > 0 {
+		return generateVariantWorkingDir(dbpath, repo.Url, "branches "+strings.Join(g.Branches, " ")), nil
+	}
+
+	legacy := generateWorkingDir(dbpath, repo.Url)
+	if g.Ref == defaultRef || (exists(legacy) && g.isBare(legacy)) {
+		return legacy, nil
+	}
+
+	dir := generateVariantWorkingDir(dbpath, repo.Url, g.Ref)
+	if !exists(dir) && exists(legacy) && g.checkedOutBranch(legacy) == g.Ref {
+		// another searcher of the same url and ref may have moved it first
+		if err := os.Rename(legacy, dir); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// The branch that's checked out in dir, empty if there's none.
+func (g *GitDriver) checkedOutBranch(dir string) string {
+	out, err := g.command(dir, "symbolic-ref", "--short", "-q", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Create a git command that runs in dir and is set up with the credentials
+// from the driver's config.
+func (g *GitDriver) command(dir string, args ...string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if g.ctx != nil {
+		cmd = exec.CommandContext(g.ctx, "git", args...)
+		killGroupOnCancel(cmd)
+	} else {
+		cmd = exec.Command("git", args...)
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	// keeps an installed git-lfs from downloading the contents on checkout
+	if !g.LFS {
+		cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=1")
+	}
+
+	if g.SshKey != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf(
+			"GIT_SSH_COMMAND=ssh -i '%s' -o IdentitiesOnly=yes",
+			g.SshKey))
+	}
+
+	if g.Token != "" {
+		username := g.Username
+		if username == "" {
+			username = defaultUsername
+		}
+
+		cmd.Env = append(cmd.Env,
+			"HOUND_GIT_USERNAME="+username,
+			"HOUND_GIT_TOKEN="+g.Token)
+
+		// the helper reads the credentials back out of the environment.
+		cmd.Args = append([]string{
+			"git",
+			"-c", "credential.helper=",
+			"-c", "credential.helper=" + credentialHelper,
+		}, args...)
+	}
+
+	return cmd
 }
 
 func (g *GitDriver) HeadRev(dir string) (string, error) {
-	cmd := exec.Command(
-		"git",
+	if len(g.Branches) > 0 {
+		return g.branchesRev(dir)
+	}
+	return g.headRev(dir)
+}
+
+func (g *GitDriver) headRev(dir string) (string, error) {
+	cmd := g.command(dir,
 		"rev-parse",
 		"HEAD")
-	cmd.Dir = dir
 	r, err := cmd.StdoutPipe()
 	if err != nil {
 		return "", err
@@ -67,37 +234,135 @@ func (g *GitDriver) HeadRev(dir string) (string, error) {
 	return strings.TrimSpace(buf.String()), cmd.Wait()
 }
 
-func run(desc, dir, cmd string, args ...string) error {
-	c := exec.Command(cmd, args...)
-	c.Dir = dir
+// A single revision for all of the branches checked out in dir, which changes
+// whenever one of them does.
+func (g *GitDriver) branchesRev(dir string) (string, error) {
+	var revs []string
+	for _, branch := range g.Branches {
+		rev, err := g.headRev(filepath.Join(dir, branchDir(branch)))
+		if err != nil {
+			return "", err
+		}
+		revs = append(revs, branch+" "+rev)
+	}
+	return hashFor(strings.Join(revs, "\n")), nil
+}
+
+// The directories the branches are checked out in, there are none unless
+// the driver has branches.
+func (g *GitDriver) Roots(dir string) ([]string, error) {
+	var roots []string
+	for _, branch := range g.Branches {
+		roots = append(roots, branchDir(branch))
+	}
+	return roots, nil
+}
+
+func (g *GitDriver) BranchRev(dir, root string) (string, error) {
+	return g.headRev(filepath.Join(dir, root))
+}
+
+func run(desc string, c *exec.Cmd) error {
 	if out, err := c.CombinedOutput(); err != nil {
 		log.Printf(
 			"Failed to %s %s, see output below\n%sContinuing...",
 			desc,
-			dir,
+			c.Dir,
 			out)
-		return err
+		return &CommandError{err, out}
 	}
 	return nil
 }
 
+// Is the repo in dir a bare repo (one without a working tree)?
+func (g *GitDriver) isBare(dir string) bool {
+	out, err := g.command(dir, "rev-parse", "--is-bare-repository").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// Does the repo in dir have a remote with the given name?
+func (g *GitDriver) hasRemote(dir, name string) bool {
+	return g.command(dir, "remote", "get-url", name).Run() == nil
+}
+
+// The working tree that a bare repo in dir is checked out into, it sits
+// next to the bare repo. Each ref has a working tree of its own.
+func (g *GitDriver) bareWorkTree(dir string) string {
+	if g.Ref == defaultRef {
+		return dir + "-worktree"
+	}
+	return dir + "-worktree-" + hashFor(g.Ref)
+}
+
+func (g *GitDriver) WorkTree(dir string) string {
+	if len(g.Branches) == 0 && g.isBare(dir) {
+		return g.bareWorkTree(dir)
+	}
+	return dir
+}
+
+// Update a bare repo from its remote (if it has one, a local mirror may be
+// kept up to date by other means) and then bring its working tree up to
+// date, cloning it from the bare repo the first time around.
+func (g *GitDriver) pullBare(dir string) (string, error) {
+	if g.hasRemote(dir, "origin") {
+		if err := run("git fetch", g.command(dir,
+			"fetch",
+			"--prune",
+			"--no-tags",
+			"origin",
+			fmt.Sprintf("+%s:%s", g.Ref, g.Ref))); err != nil {
+			return "", err
+		}
+	}
+
+	wt := g.bareWorkTree(dir)
+	if exists(wt) {
+		return g.Pull(wt)
+	}
+	return g.Clone(wt, dir)
+}
+
 func (g *GitDriver) Pull(dir string) (string, error) {
-	if err := run("git fetch", dir,
-		"git",
+	if len(g.Branches) > 0 {
+		for _, branch := range g.Branches {
+			if _, err := g.forBranch(branch).Pull(filepath.Join(dir, branchDir(branch))); err != nil {
+				return "", err
+			}
+		}
+		return g.branchesRev(dir)
+	}
+
+	if g.isBare(dir) {
+		return g.pullBare(dir)
+	}
+
+	if err := run("git fetch", g.command(dir,
 		"fetch",
 		"--prune",
 		"--no-tags",
 		"--depth", "1",
 		"origin",
-		fmt.Sprintf("+%s:remotes/origin/%s", g.Ref, g.Ref)); err != nil {
+		fmt.Sprintf("+%s:remotes/origin/%s", g.Ref, g.Ref))); err != nil {
 		return "", err
 	}
 
-	if err := run("git reset", dir,
-		"git",
+	if err := run("git reset", g.command(dir,
 		"reset",
 		"--hard",
-		fmt.Sprintf("origin/%s", g.Ref)); err != nil {
+		fmt.Sprintf("origin/%s", g.Ref))); err != nil {
+		return "", err
+	}
+
+	if err := g.fetchHistory(dir); err != nil {
+		return "", err
+	}
+
+	if err := g.fetchLFS(dir); err != nil {
+		return "", err
+	}
+
+	if err := g.updateSubmodules(dir); err != nil {
 		return "", err
 	}
 
@@ -105,24 +370,597 @@ func (g *GitDriver) Pull(dir string) (string, error) {
 }
 
 func (g *GitDriver) Clone(dir, url string) (string, error) {
+	if len(g.Branches) > 0 {
+		return g.cloneBranches(dir, url)
+	}
+
 	par, rep := filepath.Split(dir)
-	cmd := exec.Command(
-		"git",
+	cmd := g.command(par,
 		"clone",
 		"--depth", "1",
 		"--branch", g.Ref,
 		url,
 		rep)
-	cmd.Dir = par
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("Failed to clone %s, see output below\n%sContinuing...", url, out)
+		out = []byte(strings.Replace(string(out), url, ScrubUrl(url), -1))
+		log.Printf("Failed to clone %s, see output below\n%sContinuing...", ScrubUrl(url), out)
+		return "", &CommandError{err, out}
+	}
+
+	if err := g.fetchHistory(dir); err != nil {
+		return "", err
+	}
+
+	if err := g.fetchLFS(dir); err != nil {
+		return "", err
+	}
+
+	if err := g.updateSubmodules(dir); err != nil {
 		return "", err
 	}
 
 	return g.HeadRev(dir)
 }
 
+// Clone each of the branches into a directory of its own in dir. Pull expects
+// all of them to be there, so dir is removed again if one of them fails.
+func (g *GitDriver) cloneBranches(dir, url string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	for _, branch := range g.Branches {
+		if _, err := g.forBranch(branch).Clone(filepath.Join(dir, branchDir(branch)), url); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+
+	return g.branchesRev(dir)
+}
+
+// Note that the clones are shallow, so lines that were last changed before
+// the oldest fetched commit are attributed to that commit.
+func (g *GitDriver) Blame(dir, rev, path string) ([]*BlameLine, error) {
+	args := []string{"blame", "--porcelain"}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	args = append(args, "--", path)
+
+	out, err := g.command(dir, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseGitBlame(out), nil
+}
+
+// Parse the output of git blame --porcelain. Each line of the file is
+// preceded by a header naming its commit, the details of a commit are only
+// given the first time it appears.
+func parseGitBlame(out []byte) []*BlameLine {
+	var lines []*BlameLine
+	commits := map[string]*BlameLine{}
+
+	var cur *BlameLine
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			// the content of the line ends each entry
+			if cur != nil {
+				lines = append(lines, cur)
+			}
+			cur = nil
+		case cur == nil:
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+
+			cur = commits[fields[0]]
+			if cur == nil {
+				cur = &BlameLine{Sha: fields[0]}
+				commits[fields[0]] = cur
+			}
+		case strings.HasPrefix(line, "author "):
+			cur.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			t, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64)
+			if err == nil {
+				cur.Date = time.Unix(t, 0).UTC()
+			}
+		}
+	}
+
+	return lines
+}
+
+// Note that the clones are shallow, so from is only known if it was fetched
+// before, which is the case when it's the previous head.
+func (g *GitDriver) Changes(dir, from, to string) ([]string, error) {
+	// a submodule shows up as a single path, not as the files in it, and
+	// the branches each have their own revisions
+	if g.Submodules || len(g.Branches) > 0 {
+		return nil, ErrChangesNotSupported
+	}
+	return g.diffNames(g.WorkTree(dir), from, to)
+}
+
+// The paths that differ between the revisions from and to of the repo in
+// dir, which may be bare.
+func (g *GitDriver) diffNames(dir, from, to string) ([]string, error) {
+	out, err := g.command(dir,
+		"diff",
+		"--name-only",
+		"--no-renames",
+		"-z",
+		from,
+		to).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// The most commits DiffFiles deepens a shallow clone by while looking for
+// where the two ends of a range branched off.
+const maxDiffDepth = 4096
+
+// The clones are shallow, so the ends of rng are fetched like ResolveRev
+// does, and for base...head the history is deepened until they meet.
+func (g *GitDriver) DiffFiles(dir, rng string) ([]string, error) {
+	if g.Submodules || len(g.Branches) > 0 {
+		return nil, ErrDiffNotSupported
+	}
+
+	base, head, threeDot, err := ParseRange(rng)
+	if err != nil {
+		return nil, &RangeError{rng, err}
+	}
+
+	from, err := g.ResolveRev(dir, base)
+	if err != nil {
+		return nil, &RangeError{rng, err}
+	}
+
+	to, err := g.ResolveRev(dir, head)
+	if err != nil {
+		return nil, &RangeError{rng, err}
+	}
+
+	if threeDot {
+		files, err := g.changesSinceMergeBase(dir, from, to, fetchableRev(base, from), fetchableRev(head, to))
+		if err == errNoMergeBase {
+			return nil, &RangeError{rng, fmt.Errorf("%s and %s have no common ancestor", base, head)}
+		}
+		return files, err
+	}
+
+	return g.Changes(dir, from, to)
+}
+
+// What to fetch rev, which resolved to the full revision sha, by. Not every
+// server lets a commit be fetched by its sha, but an abbreviated one can't be
+// fetched at all.
+func fetchableRev(rev, sha string) string {
+	if strings.HasPrefix(sha, rev) {
+		return sha
+	}
+	return rev
+}
+
+// Returned by changesSinceMergeBase when a and b have no common ancestor,
+// not even in the history it fetched.
+var errNoMergeBase = errors.New("no common ancestor")
+
+// The paths that the full revision b changed since it branched off a. While
+// the shallow clone doesn't go back to where they branched off, more of their
+// history is fetched, by the names fetchA and fetchB. That history goes into
+// a scratch repo (see scratchRepo) rather than the clone, which would
+// otherwise keep it for good and grow with every diff anyone asks for.
+func (g *GitDriver) changesSinceMergeBase(dir, a, b, fetchA, fetchB string) ([]string, error) {
+	if out, err := g.command(dir, "merge-base", a, b).Output(); err == nil {
+		return g.Changes(dir, strings.TrimSpace(string(out)), b)
+	}
+
+	if !g.hasRemote(dir, "origin") {
+		return nil, errNoMergeBase
+	}
+
+	scratch, err := g.scratchRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	for depth := 64; depth <= maxDiffDepth; depth *= 2 {
+		if err := run("git fetch", g.command(scratch,
+			"fetch",
+			"--no-tags",
+			fmt.Sprintf("--deepen=%d", depth),
+			"origin",
+			fetchA,
+			fetchB)); err != nil {
+			break
+		}
+
+		out, err := g.command(scratch, "merge-base", a, b).Output()
+		if err == nil {
+			return g.diffNames(scratch, strings.TrimSpace(string(out)), b)
+		}
+	}
+	return nil, errNoMergeBase
+}
+
+// Make a bare repo in the temp dir that borrows the objects of the shallow
+// clone in dir, and has the same shallow history and origin. What's fetched
+// into it only ends up in its own objects, the caller removes it when it's
+// done.
+func (g *GitDriver) scratchRepo(dir string) (string, error) {
+	gitPath := func(name string) (string, error) {
+		out, err := g.command(dir, "rev-parse", "--git-path", name).Output()
+		if err != nil {
+			return "", err
+		}
+
+		p := strings.TrimSpace(string(out))
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		return p, nil
+	}
+
+	objects, err := gitPath("objects")
+	if err != nil {
+		return "", err
+	}
+
+	shallow, err := gitPath("shallow")
+	if err != nil {
+		return "", err
+	}
+
+	url, err := g.command(dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", err
+	}
+
+	scratch, err := ioutil.TempDir("", "hound-merge-base")
+	if err != nil {
+		return "", err
+	}
+
+	if err := g.initScratchRepo(scratch, objects, shallow, strings.TrimSpace(string(url))); err != nil {
+		os.RemoveAll(scratch)
+		return "", err
+	}
+	return scratch, nil
+}
+
+func (g *GitDriver) initScratchRepo(scratch, objects, shallow, url string) error {
+	if err := run("git init", g.command(scratch, "init", "--bare", "-q")); err != nil {
+		return err
+	}
+
+	if err := run("git remote", g.command(scratch, "remote", "add", "origin", url)); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(
+		filepath.Join(scratch, "objects", "info", "alternates"),
+		[]byte(objects+"\n"),
+		0644); err != nil {
+		return err
+	}
+
+	// a clone that isn't shallow has nothing to deepen
+	b, err := ioutil.ReadFile(shallow)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(scratch, "shallow"), b, 0644)
+}
+
+// The start of the history that is fetched, see HistoryDays.
+func (g *GitDriver) historyStart() time.Time {
+	return time.Now().AddDate(0, 0, -g.HistoryDays)
+}
+
+// Fetch the history of the last HistoryDays days into the shallow clone in
+// dir, and the commit before them, whose files show what the oldest of them
+// changed. That moves the edge of the history along with every pull. git
+// refuses a window without any commits, in which case the latest commit is
+// all there is to it.
+func (g *GitDriver) fetchHistory(dir string) error {
+	if g.HistoryDays == 0 {
+		return nil
+	}
+
+	refspec := fmt.Sprintf("+%s:remotes/origin/%s", g.Ref, g.Ref)
+	out, err := g.command(dir,
+		"fetch",
+		"--no-tags",
+		"--shallow-since="+g.historyStart().Format(time.RFC3339),
+		"origin",
+		refspec).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(out), "no commits selected for shallow requests") {
+			return nil
+		}
+		log.Printf("Failed to fetch the history of %s, see output below\n%sContinuing...", dir, out)
+		return &CommandError{err, out}
+	}
+
+	return run("git fetch", g.command(dir,
+		"fetch",
+		"--no-tags",
+		"--deepen=1",
+		"origin",
+		refspec))
+}
+
+// Only the history that was fetched can tell when a file was last changed,
+// and the clones are shallow. HistoryDays of history are fetched, so the
+// files changed in that time are dated, and the rest are known to be older.
+// A commit at the edge of the history (whose parents weren't fetched) seems
+// to add every file, it is never part of the window.
+func (g *GitDriver) ModTimes(dir string) (map[string]time.Time, time.Time, error) {
+	if len(g.Branches) > 0 || g.HistoryDays == 0 {
+		return nil, time.Time{}, ErrModTimesNotSupported
+	}
+
+	start := g.historyStart()
+
+	tree := g.WorkTree(dir)
+
+	out, err := g.command(tree, "ls-files", "-z").Output()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	tracked := map[string]bool{}
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			tracked[path] = true
+		}
+	}
+
+	edges := map[string]bool{}
+	if out, err := g.command(tree, "rev-parse", "--git-path", "shallow").Output(); err == nil {
+		shallow := strings.TrimSpace(string(out))
+		if !filepath.IsAbs(shallow) {
+			shallow = filepath.Join(tree, shallow)
+		}
+
+		if b, err := ioutil.ReadFile(shallow); err == nil {
+			for _, sha := range strings.Fields(string(b)) {
+				edges[sha] = true
+			}
+		}
+	}
+
+	// each commit is \x01sha time followed by the files it changed, newest
+	// first, so the first time a file shows up is the last time it changed
+	cmd := g.command(tree,
+		"log",
+		"--format=%x01%H %ct",
+		"--name-only",
+		"--no-renames",
+		"-z",
+		"HEAD")
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	times := map[string]time.Time{}
+	err = parseGitLogTimes(bufio.NewReader(r), tracked, edges, start, times)
+
+	// the rest of the history isn't needed once every file is dated
+	cmd.Process.Kill()
+	cmd.Wait()
+
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return times, start, nil
+}
+
+// Read the output of git log (see ModTimes) from r into times, for the files
+// in tracked, until all of them are dated or the commits are older than
+// start. The files of the commits in edges are skipped.
+func parseGitLogTimes(r *bufio.Reader, tracked, edges map[string]bool, start time.Time, times map[string]time.Time) error {
+	var (
+		date time.Time
+		skip bool
+		done bool
+	)
+
+	for !done && len(times) < len(tracked) {
+		tok, err := r.ReadString(0)
+		if err == io.EOF {
+			done = true
+		} else if err != nil {
+			return err
+		}
+
+		tok = strings.TrimPrefix(strings.TrimSuffix(tok, "\x00"), "\n")
+		if strings.HasPrefix(tok, "\x01") {
+			fields := strings.Fields(tok[1:])
+			if len(fields) != 2 {
+				return fmt.Errorf("unexpected git log output %q", tok)
+			}
+
+			secs, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return err
+			}
+
+			date, skip = time.Unix(secs, 0).UTC(), edges[fields[0]]
+			if date.Before(start) {
+				return nil
+			}
+			continue
+		}
+
+		if tok == "" || skip || !tracked[tok] {
+			continue
+		}
+
+		if _, ok := times[tok]; !ok {
+			times[tok] = date
+		}
+	}
+	return nil
+}
+
+// Numbers the refs ResolveRev fetches revisions into.
+var fetchedRevs uint64
+
+// The clones are shallow, so a revision that was never fetched is fetched
+// from origin on its own first. That takes a full sha, and not every server
+// lets a commit be fetched by its sha, tags and branches always can be.
+func (g *GitDriver) ResolveRev(dir, rev string) (string, error) {
+	// anything else could be taken for an option
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("invalid revision %q", rev)
+	}
+
+	if sha, err := g.revParse(dir, rev); err == nil {
+		return sha, nil
+	}
+
+	// a : would make rev a refspec of its own
+	if !g.hasRemote(dir, "origin") || strings.Contains(rev, ":") {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+
+	// the fetch goes into a ref of its own rather than FETCH_HEAD, which
+	// every fetch of the repo writes, and the lock keeps it from running
+	// alongside a pull of the shallow clone
+	unlock := lockDir(dir)
+	defer unlock()
+
+	ref := fmt.Sprintf("refs/hound/tmp/%d", atomic.AddUint64(&fetchedRevs, 1))
+	if err := run("git fetch", g.command(dir,
+		"fetch",
+		"--no-tags",
+		"--depth", "1",
+		"origin",
+		"+"+rev+":"+ref)); err != nil {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	defer g.command(dir, "update-ref", "-d", ref).Run()
+
+	return g.revParse(dir, ref)
+}
+
+// The sha of the commit rev names in the repo in dir.
+func (g *GitDriver) revParse(dir, rev string) (string, error) {
+	out, err := g.command(dir,
+		"rev-parse",
+		"--verify",
+		"--quiet",
+		rev+"^{commit}").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Note that submodules aren't part of the export.
+func (g *GitDriver) Export(dir, rev, dst string) error {
+	cmd := g.command(dir,
+		"archive",
+		"--format=tar",
+		rev)
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// git archive writes the same kind of tar the archive driver reads
+	if err := (&ArchiveDriver{}).extractTar(r, dst); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	return cmd.Wait()
+}
+
+// Fetch and check out the contents of the files tracked by git LFS, if
+// enabled. Repos that don't track anything with LFS are left alone, so they
+// don't need git-lfs installed.
+func (g *GitDriver) fetchLFS(dir string) error {
+	if !g.LFS || !tracksLFS(dir) {
+		return nil
+	}
+
+	return run("git lfs pull", g.command(dir,
+		"lfs",
+		"pull",
+		"origin"))
+}
+
+// Does the .gitattributes at the top of the working tree in dir have any
+// paths tracked by LFS?
+func tracksLFS(dir string) bool {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		for _, attr := range fields[1:] {
+			if attr == "filter=lfs" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check out the submodules at the commits recorded in the repo, if enabled.
+// Once checked out, a submodule's .git is skipped like any other so its files
+// are indexed with paths relative to the repo.
+func (g *GitDriver) updateSubmodules(dir string) error {
+	if !g.Submodules {
+		return nil
+	}
+
+	return run("git submodule update", g.command(dir,
+		"submodule",
+		"update",
+		"--init",
+		"--recursive",
+		"--force"))
+}
+
 func (g *GitDriver) SpecialFiles() []string {
 	return []string{
 		".git",
@@ -2,11 +2,16 @@ package vcs
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/etsy/hound/config"
 )
@@ -15,25 +20,59 @@ func init() {
 	Register(newHg, "hg", "mercurial")
 }
 
-type MercurialDriver struct{}
+type MercurialDriver struct {
+	// A bookmark or named branch to track instead of the tip of the
+	// default branch.
+	Branch string `json:"branch"`
+
+	// The commands are killed once this is done, see WithContext.
+	ctx context.Context
+}
 
 func newHg(b []byte) (Driver, error) {
-	return &MercurialDriver{}, nil
+	var d MercurialDriver
+
+	if b != nil {
+		if err := json.Unmarshal(b, &d); err != nil {
+			return nil, err
+		}
+	}
+
+	return &d, nil
+}
+
+// Create an hg command that runs in dir.
+func (g *MercurialDriver) command(dir string, args ...string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if g.ctx != nil {
+		cmd = exec.CommandContext(g.ctx, "hg", args...)
+		killGroupOnCancel(cmd)
+	} else {
+		cmd = exec.Command("hg", args...)
+	}
+	cmd.Dir = dir
+	return cmd
+}
+
+func (g *MercurialDriver) WithContext(ctx context.Context) Driver {
+	d := *g
+	d.ctx = ctx
+	return &d
 }
 
+// Repos that track other branches of the same url each get a directory of
+// their own, the default branch keeps the one it always had.
 func (g *MercurialDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
-	return generateWorkingDir(dbpath, repo.Url), nil
+	return generateVariantWorkingDir(dbpath, repo.Url, g.Branch), nil
 }
 
 func (g *MercurialDriver) HeadRev(dir string) (string, error) {
-	cmd := exec.Command(
-		"hg",
+	cmd := g.command(dir,
 		"log",
 		"-r",
 		".",
 		"--template",
 		"{node}")
-	cmd.Dir = dir
 	r, err := cmd.StdoutPipe()
 	if err != nil {
 		return "", err
@@ -53,12 +92,43 @@ func (g *MercurialDriver) HeadRev(dir string) (string, error) {
 	return strings.TrimSpace(buf.String()), cmd.Wait()
 }
 
+// Update the working copy to the tracked bookmark or branch, which must
+// exist in the repo.
+func (g *MercurialDriver) update(dir string) error {
+	cmd := g.command(dir, "log", "-r", g.Branch, "-l", "1", "--template", "{node}")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("hg: no bookmark or branch named %q: %s",
+			g.Branch, strings.TrimSpace(string(out)))
+	}
+
+	cmd = g.command(dir, "update", "-C", g.Branch)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Failed to hg update %s to %s, see output below\n%sContinuing...", dir, g.Branch, out)
+		return &CommandError{err, out}
+	}
+
+	return nil
+}
+
 func (g *MercurialDriver) Pull(dir string) (string, error) {
-	cmd := exec.Command("hg", "pull", "-u")
-	cmd.Dir = dir
-	err := cmd.Run()
+	args := []string{"pull", "-u"}
+	if g.Branch != "" {
+		// the update to the bookmark or branch is done separately
+		args = args[:1]
+	}
+
+	cmd := g.command(dir, args...)
+	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", err
+		log.Printf("Failed to hg pull %s, see output below\n%sContinuing...", dir, out)
+		return "", &CommandError{err, out}
+	}
+
+	if g.Branch != "" {
+		if err := g.update(dir); err != nil {
+			return "", err
+		}
 	}
 
 	return g.HeadRev(dir)
@@ -66,20 +136,75 @@ func (g *MercurialDriver) Pull(dir string) (string, error) {
 
 func (g *MercurialDriver) Clone(dir, url string) (string, error) {
 	par, rep := filepath.Split(dir)
-	cmd := exec.Command(
-		"hg",
-		"clone",
-		url,
-		rep)
-	cmd.Dir = par
+	args := []string{"clone", url, rep}
+	if g.Branch != "" {
+		// don't check anything out until the branch has been validated
+		args = []string{"clone", "-U", url, rep}
+	}
+
+	cmd := g.command(par, args...)
 	cmd.Stdout = ioutil.Discard
 	if err := cmd.Run(); err != nil {
 		return "", err
 	}
 
+	if g.Branch != "" {
+		if err := g.update(dir); err != nil {
+			return "", err
+		}
+	}
+
 	return g.HeadRev(dir)
 }
 
+func (g *MercurialDriver) Blame(dir, rev, path string) ([]*BlameLine, error) {
+	args := []string{"annotate", "-T", "json", "-u", "-c", "-d"}
+	if rev != "" {
+		args = append(args, "-r", rev)
+	}
+	args = append(args, "--", path)
+
+	cmd := g.command(dir, args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseHgBlame(out)
+}
+
+// Parse the output of hg annotate -T json.
+func parseHgBlame(out []byte) ([]*BlameLine, error) {
+	var files []struct {
+		Lines []struct {
+			Node string
+			User string
+			// a unix timestamp and a timezone offset
+			Date []float64
+		}
+	}
+
+	if err := json.Unmarshal(out, &files); err != nil {
+		return nil, err
+	}
+
+	var lines []*BlameLine
+	for _, file := range files {
+		for _, line := range file.Lines {
+			b := &BlameLine{
+				Sha:    line.Node,
+				Author: line.User,
+			}
+			if len(line.Date) > 0 {
+				b.Date = time.Unix(int64(line.Date[0]), 0).UTC()
+			}
+			lines = append(lines, b)
+		}
+	}
+
+	return lines, nil
+}
+
 func (g *MercurialDriver) SpecialFiles() []string {
 	return []string{
 		".hg",
@@ -0,0 +1,39 @@
+package vcs
+
+import "testing"
+
+func TestParseHgBlame(t *testing.T) {
+	out := `[
+ {
+  "abspath": "a.py",
+  "lines": [{"date": [1500000000.0, 0], "line": "import os\n", "node": "1f0dee641bb7258c56bd60e93edfa2405381c41e", "user": "alice"},
+            {"date": [1600000000.0, 0], "line": "\n", "node": "2f0dee641bb7258c56bd60e93edfa2405381c41e", "user": "bob"}],
+  "path": "a.py"
+ }
+]`
+
+	lines, err := parseHgBlame([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	if lines[1].Author != "bob" || lines[1].Date.Unix() != 1600000000 {
+		t.Fatalf("expected line 2 to be bob's, got %v", lines[1])
+	}
+}
+
+// Tests that the hg driver is able to parse its config.
+func TestHgConfig(t *testing.T) {
+	d, err := New("hg", []byte(`{"branch" : "stable"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hg := d.Driver.(*MercurialDriver); hg.Branch != "stable" {
+		t.Fatalf("expected branch of \"stable\", got %s", hg.Branch)
+	}
+}
@@ -1,10 +1,18 @@
 package vcs
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"os"
-	"strings"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/etsy/hound/config"
 )
@@ -13,23 +21,95 @@ func init() {
 	Register(newLocal, "local")
 }
 
-type LocalDriver struct{}
+// How the local driver works out the revision of a directory.
+const (
+	// The modification time of the directory itself, which is cheap but
+	// changes when the directory is touched and misses changes to files
+	// in its subdirectories.
+	RevisionMtime = "mtime"
+
+	// A hash of the path, size and modification time of every file.
+	RevisionTree = "tree"
+
+	// A hash of the path and contents of every file, which only changes
+	// when the contents do.
+	RevisionContent = "content"
+)
+
+const (
+	defaultMaxHashedFiles = 100000
+	defaultMaxHashedBytes = 256 << 20
+)
+
+// The tree was too big to hash, see LocalDriver.MaxHashedFiles.
+var errHashBudget = errors.New("local: too many files to hash")
+
+type LocalDriver struct {
+	// When set, the directory is treated as a parent of many checkouts and
+	// each immediate subdirectory that looks like a vcs checkout becomes
+	// its own virtual repo.
+	MultiRoot bool `json:"multi-root"`
+
+	// One of the Revision constants, RevisionMtime by default.
+	Revision string `json:"revision"`
+
+	// The most files (and, for RevisionContent, bytes) hashed for a
+	// revision. A bigger tree falls back to the directory's modification
+	// time.
+	MaxHashedFiles int   `json:"max-hashed-files"`
+	MaxHashedBytes int64 `json:"max-hashed-bytes"`
+}
 
 func newLocal(b []byte) (Driver, error) {
-	return &LocalDriver{}, nil
+	d := &LocalDriver{
+		Revision:       RevisionMtime,
+		MaxHashedFiles: defaultMaxHashedFiles,
+		MaxHashedBytes: defaultMaxHashedBytes,
+	}
+
+	if b == nil {
+		return d, nil
+	}
+
+	if e := json.Unmarshal(b, d); e != nil {
+		return nil, e
+	}
+
+	switch d.Revision {
+	case RevisionMtime, RevisionTree, RevisionContent:
+	default:
+		return nil, fmt.Errorf("local: unknown revision %q", d.Revision)
+	}
+	return d, nil
 }
 
+// The directory is indexed where it is. Hound never writes to it, so repos
+// with the same path (or the same base name) can't get in each other's way.
 func (g *LocalDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
 	return strings.TrimPrefix(repo.Url, "file://"), nil
 }
 
+// The directory is the user's.
+func (g *LocalDriver) ManagesWorkingDir() bool {
+	return false
+}
+
 func (g *LocalDriver) HeadRev(dir string) (string, error) {
 	realdir, err := filepath.EvalSymlinks(dir)
 	if err != nil {
-		fmt.Printf("Failed to read symlink ", dir)
+		fmt.Println("Failed to read symlink ", dir)
 		return "", err
 	}
 
+	if g.Revision == RevisionTree || g.Revision == RevisionContent {
+		rev, err := g.hashTree(realdir)
+		if err != errHashBudget {
+			return rev, err
+		}
+
+		log.Printf("Too many files to hash in %s, using its modification time instead", realdir)
+	}
+
 	stat, err := os.Stat(realdir)
 	if err != nil {
 		fmt.Println("failed to determine modification time of ", realdir)
@@ -39,6 +119,138 @@ func (g *LocalDriver) HeadRev(dir string) (string, error) {
 	return stat.ModTime().String(), nil
 }
 
+// Hash the files under dir, skipping the special files (like .git), for a
+// revision that only changes when they do. Returns errHashBudget if there
+// are more of them than the driver is willing to hash.
+func (g *LocalDriver) hashTree(dir string) (string, error) {
+	skip := map[string]bool{}
+	for _, name := range g.SpecialFiles() {
+		skip[name] = true
+	}
+
+	h := sha1.New()
+	files := 0
+	var bytes int64
+
+	// the walk is in lexical order, so the hash doesn't depend on the order
+	// the files were created in
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if skip[info.Name()] && path != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		files++
+		if g.MaxHashedFiles > 0 && files > g.MaxHashedFiles {
+			return errHashBudget
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if g.Revision == RevisionTree {
+			fmt.Fprintf(h, "%s\x00%d\x00%d\x00%s\n", rel, info.Size(), info.ModTime().UnixNano(), info.Mode())
+			return nil
+		}
+
+		bytes += info.Size()
+		if g.MaxHashedBytes > 0 && bytes > g.MaxHashedBytes {
+			return errHashBudget
+		}
+
+		fmt.Fprintf(h, "%s\x00%d\x00%s\n", rel, info.Size(), info.Mode())
+		return hashContents(h, path, info)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Write the contents of the file at path to w, or for a symbolic link the
+// path it points to.
+func hashContents(w io.Writer, path string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, target)
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// The files are dated by their modification times, the special files (and
+// whatever is in them) are left out. Every other file is dated.
+func (g *LocalDriver) ModTimes(dir string) (map[string]time.Time, time.Time, error) {
+	realdir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	skip := map[string]bool{}
+	for _, name := range g.SpecialFiles() {
+		skip[name] = true
+	}
+
+	times := map[string]time.Time{}
+	err = filepath.Walk(realdir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if skip[info.Name()] && path != realdir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(realdir, path)
+		if err != nil {
+			return err
+		}
+
+		times[filepath.ToSlash(rel)] = info.ModTime().UTC()
+		return nil
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return times, time.Time{}, nil
+}
+
 func (g *LocalDriver) Pull(dir string) (string, error) {
 	return g.HeadRev(dir)
 }
@@ -59,3 +271,33 @@ func (g *LocalDriver) SpecialFiles() []string {
 		".svn",
 	}
 }
+
+// Return the immediate subdirectories of dir that contain one of the
+// special files, which is what marks them as a vcs checkout. This is
+// always empty unless the driver is configured as multi-root.
+func (g *LocalDriver) Roots(dir string) ([]string, error) {
+	if !g.MultiRoot {
+		return nil, nil
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+
+		for _, special := range g.SpecialFiles() {
+			if exists(filepath.Join(dir, info.Name(), special)) {
+				roots = append(roots, info.Name())
+				break
+			}
+		}
+	}
+
+	return roots, nil
+}
@@ -0,0 +1,169 @@
+package vcs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Tests that a multi-root local driver only reports subdirectories that
+// look like vcs checkouts.
+func TestLocalMultiRoots(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, path := range []string{
+		filepath.Join("foo", ".git"),
+		filepath.Join("bar", ".hg"),
+		"baz",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := New("local", []byte(`{"multi-root": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots, err := d.Roots(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roots) != 2 || roots[0] != "bar" || roots[1] != "foo" {
+		t.Fatalf("expected roots of [bar foo], got %v", roots)
+	}
+
+	d, err = New("local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots, err = d.Roots(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roots) != 0 {
+		t.Fatalf("expected no roots for a single root driver, got %v", roots)
+	}
+}
+
+func TestLocalRevisions(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string, mtime time.Time) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	then := time.Now().Add(-time.Hour)
+	write("a.txt", "a", then)
+	write("sub/b.txt", "b", then)
+
+	rev := func(cfg string) string {
+		d, err := New("local", []byte(cfg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rev, err := d.HeadRev(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rev
+	}
+
+	content := rev(`{"revision": "content"}`)
+	tree := rev(`{"revision": "tree"}`)
+
+	// touching the directory or a file only changes the tree revision
+	now := time.Now()
+	if err := os.Chtimes(dir, now, now); err != nil {
+		t.Fatal(err)
+	}
+	write("a.txt", "a", now)
+	if rev(`{"revision": "content"}`) != content {
+		t.Fatal("expected the content revision not to change")
+	}
+	if rev(`{"revision": "tree"}`) == tree {
+		t.Fatal("expected the tree revision to change")
+	}
+
+	// a change to a file in a subdirectory that keeps its mtime and size
+	// changes the content revision, changes to vcs files don't
+	write("sub/b.txt", "c", then)
+	write(".git/HEAD", "x", now)
+	if rev(`{"revision": "content"}`) == content {
+		t.Fatal("expected the content revision to change")
+	}
+
+	// too big a tree falls back to the mtime
+	if got, want := rev(`{"revision": "content", "max-hashed-files": 1}`), rev(`{}`); got != want {
+		t.Fatalf("expected a revision of %s, got %s", want, got)
+	}
+	if got, want := rev(`{"revision": "content", "max-hashed-bytes": 1}`), rev(`{}`); got != want {
+		t.Fatalf("expected a revision of %s, got %s", want, got)
+	}
+
+	if _, err := New("local", []byte(`{"revision": "ctime"}`)); err == nil {
+		t.Fatal("expected an unknown revision to be rejected")
+	}
+}
+
+func TestLocalModTimes(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, path := range []string{"a.txt", "sub/b.txt", ".git/HEAD"} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	then := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "sub", "b.txt"), then, then); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := New("local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	times, since, err := d.ModTimes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 2 || times["a.txt"].IsZero() || !times["sub/b.txt"].Equal(then) {
+		t.Fatalf("expected a.txt and sub/b.txt to be dated, got %v", times)
+	}
+	if !since.IsZero() {
+		t.Fatalf("expected every file to be dated, got dates since %s", since)
+	}
+}
@@ -0,0 +1,506 @@
+package vcs
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/etsy/hound/config"
+)
+
+// The file in the working directory that records which objects were synced
+// and their ETags.
+const objectsMetaFilename = ".hound-objects"
+
+// The sha256 of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func init() {
+	Register(newS3, "s3")
+	Register(newGcs, "gcs")
+}
+
+var objectStoreClient = &http.Client{
+	Timeout: 10 * time.Minute,
+}
+
+// Indexes the objects under a prefix of an S3 bucket, the url is of the form
+// s3://bucket/prefix. Anything that speaks the S3 API can be used by setting
+// the endpoint, which is how the gcs driver talks to Google Cloud Storage
+// (using HMAC keys). The revision is a hash of the keys and ETags of all of
+// the objects.
+type ObjectStoreDriver struct {
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+
+	// Requests are unsigned if there is no access key, which works for
+	// public buckets.
+	AccessKeyId     string `json:"access-key-id"`
+	SecretAccessKey string `json:"secret-access-key"`
+	SessionToken    string `json:"session-token"`
+
+	// Used to sign requests, which makes it replaceable in tests.
+	now func() time.Time
+}
+
+type objectsMeta struct {
+	Url string
+	Rev string
+
+	// The ETag of each object, by key.
+	Objects map[string]string
+}
+
+func newObjectStore(b []byte, d *ObjectStoreDriver) (Driver, error) {
+	d.now = time.Now
+
+	if b != nil {
+		if e := json.Unmarshal(b, d); e != nil {
+			return nil, e
+		}
+	}
+
+	if d.AccessKeyId != "" && d.SecretAccessKey == "" {
+		return nil, fmt.Errorf("objectstore: access-key-id needs a secret-access-key")
+	}
+
+	d.Endpoint = strings.TrimRight(d.Endpoint, "/")
+	return d, nil
+}
+
+func newS3(b []byte) (Driver, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	d := &ObjectStoreDriver{
+		Region:          region,
+		AccessKeyId:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	dr, err := newObjectStore(b, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Endpoint == "" {
+		d.Endpoint = "https://s3." + d.Region + ".amazonaws.com"
+	}
+	return dr, nil
+}
+
+func newGcs(b []byte) (Driver, error) {
+	return newObjectStore(b, &ObjectStoreDriver{
+		Endpoint: "https://storage.googleapis.com",
+		Region:   "auto",
+	})
+}
+
+// Split a s3://bucket/prefix (or gs://bucket/prefix) url into the bucket and
+// the prefix.
+func parseBucketUrl(u string) (string, string, error) {
+	p, err := url.Parse(u)
+	if err != nil {
+		return "", "", err
+	}
+
+	if (p.Scheme != "s3" && p.Scheme != "gs") || p.Host == "" {
+		return "", "", fmt.Errorf("objectstore: expected a url of the form s3://bucket/prefix, got %s", ScrubUrl(u))
+	}
+
+	return p.Host, strings.TrimPrefix(p.Path, "/"), nil
+}
+
+func readObjectsMeta(dir string) (*objectsMeta, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, objectsMetaFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	var m objectsMeta
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *objectsMeta) write(dir string) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, objectsMetaFilename), b, 0644)
+}
+
+// The revision of a set of objects, which changes whenever any object is
+// added, removed or changed.
+func objectsRev(objects map[string]string) string {
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha1.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s %s\n", key, objects[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (g *ObjectStoreDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
+	return generateWorkingDir(dbpath, repo.Url), nil
+}
+
+func (g *ObjectStoreDriver) HeadRev(dir string) (string, error) {
+	m, err := readObjectsMeta(dir)
+	if err != nil {
+		return "", err
+	}
+	return m.Rev, nil
+}
+
+func (g *ObjectStoreDriver) Clone(dir, url string) (string, error) {
+	// sync into a fresh directory that is only put in place once complete
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), "objects-")
+	if err != nil {
+		return "", err
+	}
+
+	m, err := g.sync(tmp, &objectsMeta{Url: url})
+	if err == nil {
+		err = os.Rename(tmp, dir)
+	}
+
+	if err != nil {
+		os.RemoveAll(tmp)
+		log.Printf("Failed to sync %s: %s. Continuing...", ScrubUrl(url), err)
+		return "", err
+	}
+
+	return m.Rev, nil
+}
+
+func (g *ObjectStoreDriver) Pull(dir string) (string, error) {
+	m, err := readObjectsMeta(dir)
+	if err != nil {
+		return "", err
+	}
+
+	m, err = g.sync(dir, m)
+	if err != nil {
+		log.Printf("Failed to sync %s: %s. Continuing...", dir, err)
+		return "", err
+	}
+
+	return m.Rev, nil
+}
+
+func (g *ObjectStoreDriver) SpecialFiles() []string {
+	return []string{
+		objectsMetaFilename,
+	}
+}
+
+// Bring dir up to date with the bucket, only the objects whose ETag differs
+// from the one in prev are downloaded. The new state is only recorded once
+// everything has been synced, so a failed sync is retried in full.
+func (g *ObjectStoreDriver) sync(dir string, prev *objectsMeta) (*objectsMeta, error) {
+	bucket, prefix, err := parseBucketUrl(prev.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := g.list(bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &objectsMeta{
+		Url:     prev.Url,
+		Rev:     objectsRev(objects),
+		Objects: objects,
+	}
+
+	if m.Rev == prev.Rev {
+		return prev, nil
+	}
+
+	for key, etag := range objects {
+		if prev.Objects[key] == etag {
+			continue
+		}
+
+		path, err := objectPath(prefix, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := g.download(bucket, key, filepath.Join(dir, path)); err != nil {
+			return nil, err
+		}
+	}
+
+	for key := range prev.Objects {
+		if _, ok := objects[key]; ok {
+			continue
+		}
+
+		path, err := objectPath(prefix, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.Remove(filepath.Join(dir, path)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if err := m.write(dir); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// The path of the object with key, relative to the working dir.
+func objectPath(prefix, key string) (string, error) {
+	parts, err := splitRemotePath(strings.TrimPrefix(key, prefix))
+	if err != nil {
+		return "", fmt.Errorf("objectstore: %s", err)
+	}
+	return filepath.Join(parts...), nil
+}
+
+type listBucketResult struct {
+	IsTruncated bool
+	NextMarker  string
+	Contents    []struct {
+		Key  string
+		ETag string
+	}
+}
+
+// List the ETags of the objects under prefix, by key. Keys ending in a slash
+// are placeholders for directories and are left out.
+func (g *ObjectStoreDriver) list(bucket, prefix string) (map[string]string, error) {
+	objects := map[string]string{}
+	marker := ""
+	for {
+		q := url.Values{}
+		q.Set("prefix", prefix)
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+
+		res, err := g.get(bucket, "", q)
+		if err != nil {
+			return nil, err
+		}
+
+		var page listBucketResult
+		err = xml.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range page.Contents {
+			if !strings.HasSuffix(c.Key, "/") {
+				objects[c.Key] = c.ETag
+			}
+			marker = c.Key
+		}
+
+		if !page.IsTruncated || len(page.Contents) == 0 {
+			return objects, nil
+		}
+
+		if page.NextMarker != "" {
+			marker = page.NextMarker
+		}
+	}
+}
+
+func (g *ObjectStoreDriver) download(bucket, key, path string) error {
+	res, err := g.get(bucket, key, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// write next to the file and rename, so it's never left half written
+	tmp := path + ".part"
+	if err := writeRemoteFile(tmp, res.Body); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Make a signed GET request for the key in bucket (or the bucket itself if
+// key is empty).
+func (g *ObjectStoreDriver) get(bucket, key string, q url.Values) (*http.Response, error) {
+	path := "/" + uriEncode(bucket, false)
+	if key != "" {
+		path += "/" + uriEncode(key, true)
+	}
+
+	query := canonicalQuery(q)
+	u := g.Endpoint + path
+	if query != "" {
+		u += "?" + query
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.AccessKeyId != "" {
+		g.sign(req, path, query, g.now())
+	}
+
+	res, err := objectStoreClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("objectstore: GET %s: %s", path, res.Status)
+	}
+
+	return res, nil
+}
+
+// Sign the request with AWS signature version 4.
+func (g *ObjectStoreDriver) sign(req *http.Request, path, query string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", emptyPayloadHash)
+	if g.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", g.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": emptyPayloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if g.SessionToken != "" {
+		headers["x-amz-security-token"] = g.SessionToken
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders string
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		query,
+		canonicalHeaders,
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + g.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+g.SecretAccessKey), date)
+	key = hmacSha256(key, g.Region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		g.AccessKeyId,
+		scope,
+		signedHeaders,
+		hex.EncodeToString(hmacSha256(key, stringToSign))))
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	io.WriteString(h, data)
+	return h.Sum(nil)
+}
+
+// Encode s the way signature version 4 expects, where everything but the
+// unreserved characters is escaped (and slashes too, unless keepSlash).
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// The query string with its parameters sorted and encoded as signature
+// version 4 expects.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for key := range q {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, v := range q[key] {
+			parts = append(parts, uriEncode(key, false)+"="+uriEncode(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
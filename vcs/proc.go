@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package vcs
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Run cmd in a process group of its own and kill the whole group once its
+// context is done, so the helpers it started (like git-remote-https or ssh)
+// go with it instead of keeping its output open. Wait stops waiting for the
+// output commandWaitDelay after that in any case.
+func killGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = commandWaitDelay
+}
@@ -0,0 +1,12 @@
+package vcs
+
+import (
+	"os/exec"
+)
+
+// Windows has no process groups to kill, so only the command itself is
+// killed once its context is done, and Wait stops waiting for the output
+// of whatever it started commandWaitDelay after that.
+func killGroupOnCancel(cmd *exec.Cmd) {
+	cmd.WaitDelay = commandWaitDelay
+}
@@ -18,10 +18,16 @@ func newRsync(b []byte) (Driver, error) {
 	return &RsyncDriver{}, nil
 }
 
+// Like the local driver, the directory is indexed where it is.
 func (g *RsyncDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
     return strings.TrimPrefix(repo.Url, "file://"), nil
 }
 
+// The directory is the one at the repo's url.
+func (g *RsyncDriver) ManagesWorkingDir() bool {
+	return false
+}
+
 func (g *RsyncDriver) HeadRev(dir string) (string, error) {
 	return "n/a", nil
 }
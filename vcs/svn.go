@@ -3,10 +3,12 @@ package vcs
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/etsy/hound/config"
@@ -19,6 +21,12 @@ func init() {
 type SVNDriver struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+
+	// A path under the repo url to check out instead, e.g. branches/foo.
+	Branch string `json:"branch"`
+
+	// A revision number to pin the checkout to instead of following HEAD.
+	Revision string `json:"revision"`
 }
 
 func newSvn(b []byte) (Driver, error) {
@@ -30,11 +38,59 @@ func newSvn(b []byte) (Driver, error) {
 		}
 	}
 
+	if d.Revision != "" {
+		if _, err := strconv.ParseUint(d.Revision, 10, 64); err != nil {
+			return nil, fmt.Errorf("svn: revision must be a number, got %q", d.Revision)
+		}
+	}
+
+	d.Branch = strings.Trim(d.Branch, "/")
+
 	return &d, nil
 }
 
+// The url that is checked out, which is the branch's when there is one.
+func (g *SVNDriver) branchUrl(url string) string {
+	if g.Branch == "" {
+		return url
+	}
+	return strings.TrimRight(url, "/") + "/" + g.Branch
+}
+
+// The url pegged at the pinned revision, if any.
+func (g *SVNDriver) pegUrl(url string) string {
+	if g.Revision == "" {
+		return g.branchUrl(url)
+	}
+	return g.branchUrl(url) + "@" + g.Revision
+}
+
 func (g *SVNDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
-	return generateWorkingDir(dbpath, repo.Url), nil
+	// each branch gets a checkout of its own, so that changing the branch
+	// in the config doesn't require switching an existing checkout.
+	return generateWorkingDir(dbpath, g.branchUrl(repo.Url)), nil
+}
+
+// Make sure the branch exists (at the pinned revision, if any) before
+// checking it out, so that a typo gives a useful error.
+func (g *SVNDriver) validate(url string) error {
+	cmd := exec.Command(
+		"svn",
+		"info",
+		"--username",
+		g.Username,
+		"--password",
+		g.Password,
+		g.pegUrl(url))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		what := "HEAD"
+		if g.Revision != "" {
+			what = "revision " + g.Revision
+		}
+		return fmt.Errorf("svn: %s does not exist at %s: %s",
+			ScrubUrl(g.branchUrl(url)), what, strings.TrimSpace(string(out)))
+	}
+	return nil
 }
 
 func (g *SVNDriver) HeadRev(dir string) (string, error) {
@@ -69,17 +125,26 @@ func (g *SVNDriver) Pull(dir string) (string, error) {
 		g.Username,
 		"--password",
 		g.Password)
+	if g.Revision != "" {
+		cmd.Args = append(cmd.Args, "-r", g.Revision)
+	}
 	cmd.Dir = dir
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("Failed to SVN update %s, see output below\n%sContinuing...", dir, out)
-		return "", err
+		return "", &CommandError{err, out}
 	}
 
 	return g.HeadRev(dir)
 }
 
 func (g *SVNDriver) Clone(dir, url string) (string, error) {
+	if g.Branch != "" || g.Revision != "" {
+		if err := g.validate(url); err != nil {
+			return "", err
+		}
+	}
+
 	par, rep := filepath.Split(dir)
 	cmd := exec.Command(
 		"svn",
@@ -89,13 +154,13 @@ func (g *SVNDriver) Clone(dir, url string) (string, error) {
 		g.Username,
 		"--password",
 		g.Password,
-		url,
+		g.pegUrl(url),
 		rep)
 	cmd.Dir = par
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("Failed to checkout %s, see output below\n%sContinuing...", url, out)
-		return "", err
+		log.Printf("Failed to checkout %s, see output below\n%sContinuing...", ScrubUrl(url), out)
+		return "", &CommandError{err, out}
 	}
 
 	return g.HeadRev(dir)
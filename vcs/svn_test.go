@@ -2,6 +2,8 @@ package vcs
 
 import (
 	"testing"
+
+	"github.com/etsy/hound/config"
 )
 
 // Tests that the svn driver is able to parse its config.
@@ -22,3 +24,32 @@ func TestSvnConfig(t *testing.T) {
 		t.Fatalf("expected password of \"svn_password\", got %s", svn.Password)
 	}
 }
+
+// Tests that the svn driver can be pinned to a branch and a revision.
+func TestSvnBranchAndRevision(t *testing.T) {
+	d, err := New("svn", []byte(`{"branch" : "/branches/release/", "revision" : "1234"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svn := d.Driver.(*SVNDriver)
+	if u := svn.pegUrl("https://svn.example.com/repo/"); u != "https://svn.example.com/repo/branches/release@1234" {
+		t.Fatalf("expected the branch pegged at r1234, got %s", u)
+	}
+
+	trunk, err := New("svn", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &config.Repo{Url: "https://svn.example.com/repo"}
+	a, _ := svn.WorkingDirForRepo("data", repo)
+	b, _ := trunk.WorkingDirForRepo("data", repo)
+	if a == b {
+		t.Fatal("expected the branch to have a working dir of its own")
+	}
+
+	if _, err := New("svn", []byte(`{"revision" : "HEAD~1"}`)); err == nil {
+		t.Fatal("expected a revision that isn't a number to be rejected")
+	}
+}
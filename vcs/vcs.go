@@ -1,9 +1,14 @@
 package vcs
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/etsy/hound/config"
 )
@@ -32,6 +37,188 @@ type Driver interface {
 	SpecialFiles() []string
 }
 
+// Implemented by drivers whose working directory can hold more than one
+// repo. Each of the returned names is exposed as a virtual repo.
+type MultiRootDriver interface {
+	// Return the names of the top level directories in dir that are repos.
+	Roots(dir string) ([]string, error)
+}
+
+// Implemented by multi-root drivers whose roots are branches of the one repo
+// rather than repos of their own, each checked out at a revision of its own.
+type BranchesDriver interface {
+	// Return the revision of the branch that's checked out in root of dir.
+	BranchRev(dir, root string) (string, error)
+}
+
+// Returned by WorkDir.BranchRev for drivers that don't implement
+// BranchesDriver.
+var ErrBranchesNotSupported = errors.New("vcs: checking out many branches is not supported")
+
+// Implemented by drivers that can give up on what they are doing, by killing
+// the commands they run, once a context is done.
+type ContextDriver interface {
+	// Return a copy of the driver that gives up once ctx is done.
+	WithContext(ctx context.Context) Driver
+}
+
+// Returned by WorkDir.PullOrCloneTimeout when the pull or clone took longer
+// than it was allowed to. It's considered transient, see IsTransient.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("vcs: gave up after %s", e.Timeout)
+}
+
+// Returned by WorkDir.PullOrCloneTimeout when the pull or clone of the same
+// directory that last timed out is still running. It's considered transient,
+// see IsTransient.
+var ErrStillRunning = errors.New("vcs: the last pull or clone is still running")
+
+// How long Wait waits for the output of a command that was killed, which
+// the helpers it started may hold open, and for a killed pull or clone to
+// return, see PullOrCloneTimeout.
+var commandWaitDelay = 5 * time.Second
+
+// The working directories whose pull or clone timed out but is still
+// running, see PullOrCloneTimeout.
+var stillRunning = struct {
+	lck  sync.Mutex
+	dirs map[string]bool
+}{dirs: map[string]bool{}}
+
+// The locks of the working directories, which keep the commands that change
+// one (pulls, clones and fetches of other revisions) from running at the same
+// time, see lockDir.
+var dirLocks = struct {
+	lck  sync.Mutex
+	dirs map[string]*sync.Mutex
+}{dirs: map[string]*sync.Mutex{}}
+
+// Lock the working directory dir for a command that changes it. Returns the
+// func that unlocks it.
+func lockDir(dir string) func() {
+	dirLocks.lck.Lock()
+	l := dirLocks.dirs[dir]
+	if l == nil {
+		l = &sync.Mutex{}
+		dirLocks.dirs[dir] = l
+	}
+	dirLocks.lck.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// The result of a pull or clone run in the background.
+type result struct {
+	rev string
+	err error
+}
+
+// Implemented by drivers whose working directory doesn't always hold the
+// checked out files itself (e.g. a bare git repo).
+type WorkTreeDriver interface {
+	// Return the directory that holds the checked out files of dir.
+	WorkTree(dir string) string
+}
+
+// Implemented by drivers whose working directory may not be a clone that
+// hound made in the dbpath (e.g. the directory the local driver indexes in
+// place), which must never be removed.
+type ManagedDriver interface {
+	// Is the working directory a clone hound made and can remove?
+	ManagesWorkingDir() bool
+}
+
+// Who last changed a line and when.
+type BlameLine struct {
+	Sha    string
+	Author string
+	Date   time.Time
+}
+
+// Implemented by drivers that can tell who last changed each line of a file.
+type BlameDriver interface {
+	// Return the blame for each line of the file at path (relative to dir)
+	// as of revision rev, or the checked out revision if rev is empty. The
+	// first entry is for line 1.
+	Blame(dir, rev, path string) ([]*BlameLine, error)
+}
+
+// Returned by WorkDir.Blame for drivers that don't implement BlameDriver.
+var ErrBlameNotSupported = errors.New("vcs: blame is not supported")
+
+// Implemented by drivers that can tell which files changed between two
+// revisions, which lets an index be updated rather than built from scratch.
+type ChangesDriver interface {
+	// Return the paths (relative to the working tree of dir and slash
+	// separated) of the files that were added, modified or deleted between
+	// revisions from and to.
+	Changes(dir, from, to string) ([]string, error)
+}
+
+// Returned by WorkDir.Changes for drivers that don't implement ChangesDriver.
+var ErrChangesNotSupported = errors.New("vcs: listing changes is not supported")
+
+// Implemented by drivers that can tell which files a range of revisions
+// changed, like the commits of a pull request, which lets a search be
+// narrowed to them.
+type DiffDriver interface {
+	// Return the paths (relative to the working tree of dir and slash
+	// separated) of the files changed by rng, which is either base..head,
+	// the changes between the two revisions, or base...head, the changes
+	// on head since it branched off base.
+	DiffFiles(dir, rng string) ([]string, error)
+}
+
+// Returned by WorkDir.DiffFiles for drivers that don't implement DiffDriver.
+var ErrDiffNotSupported = errors.New("vcs: diffing a range of revisions is not supported")
+
+// Returned by DiffDriver.DiffFiles for a range that's malformed, names a
+// revision that can't be found or whose ends have nothing in common, as
+// opposed to one the vcs failed to diff.
+type RangeError struct {
+	Range string
+	Err   error
+}
+
+func (e *RangeError) Error() string {
+	return e.Err.Error()
+}
+
+// Implemented by drivers that can tell when the files in the working tree
+// were last changed, which lets searches be narrowed to recent changes.
+type ModTimesDriver interface {
+	// Return when each file in the working tree of dir (by its slash
+	// separated path) was last changed, for the files that were changed at
+	// or after the time that is also returned. That time is zero when every
+	// file is dated, the others are known to be older.
+	ModTimes(dir string) (map[string]time.Time, time.Time, error)
+}
+
+// Returned by WorkDir.ModTimes for drivers that don't implement
+// ModTimesDriver.
+var ErrModTimesNotSupported = errors.New("vcs: dating files is not supported")
+
+// Implemented by drivers that can get at revisions of the repo other than the
+// one that is checked out, which is what searching an older revision needs.
+type ExportDriver interface {
+	// Return the full revision that rev (say an abbreviated commit or a
+	// tag) names in the repo in dir.
+	ResolveRev(dir, rev string) (string, error)
+
+	// Write the files of the repo in dir, as they were at the full revision
+	// rev, into the directory dst.
+	Export(dir, rev, dst string) error
+}
+
+// Returned by WorkDir.ResolveRev and WorkDir.Export for drivers that don't
+// implement ExportDriver.
+var ErrExportNotSupported = errors.New("vcs: searching other revisions is not supported")
+
 // An API to interact with a vcs working directory. This is
 // what clients will interact with.
 type WorkDir struct {
@@ -74,8 +261,200 @@ func exists(path string) bool {
 // A utility method that carries out the common operation of cloning
 // if the working directory is absent and pulling otherwise.
 func (w *WorkDir) PullOrClone(dir, url string) (string, error) {
+	unlock := lockDir(dir)
+	defer unlock()
+
 	if exists(dir) {
 		return w.Pull(dir)
 	}
 	return w.Clone(dir, url)
 }
+
+// Pull or clone like PullOrClone, but give up once timeout has passed (0 never
+// does). The commands of drivers that implement ContextDriver are killed, and
+// a clone that was cut short is removed so that it's cloned again next time.
+// Other drivers, and commands that don't die, are left to finish in the
+// background, only the caller stops waiting for them. Until they do, pulls
+// of the same directory fail with ErrStillRunning rather than run alongside.
+func (w *WorkDir) PullOrCloneTimeout(dir, url string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return w.PullOrClone(dir, url)
+	}
+
+	stillRunning.lck.Lock()
+	running := stillRunning.dirs[dir]
+	stillRunning.lck.Unlock()
+	if running {
+		return "", ErrStillRunning
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	d, cancelable := w.Driver, false
+	if c, ok := d.(ContextDriver); ok {
+		d, cancelable = c.WithContext(ctx), true
+	}
+
+	cloning := !exists(dir)
+	ch := make(chan result, 1)
+	go func() {
+		rev, err := (&WorkDir{d}).PullOrClone(dir, url)
+		ch <- result{rev, err}
+	}()
+
+	select {
+	case r := <-ch:
+		if ctx.Err() == nil {
+			return r.rev, r.err
+		}
+	case <-ctx.Done():
+		if !cancelable {
+			leaveRunning(dir, cloning, ch)
+			return "", &TimeoutError{timeout}
+		}
+
+		// killed commands return right away, though only if they die
+		select {
+		case <-ch:
+		case <-time.After(2 * commandWaitDelay):
+			leaveRunning(dir, cloning, ch)
+			return "", &TimeoutError{timeout}
+		}
+	}
+
+	if cloning {
+		os.RemoveAll(dir)
+	}
+	return "", &TimeoutError{timeout}
+}
+
+// Keep pulls out of dir until the pull or clone that was given up on, whose
+// result comes on ch, is done. A clone that fails is removed then.
+func leaveRunning(dir string, cloning bool, ch <-chan result) {
+	stillRunning.lck.Lock()
+	stillRunning.dirs[dir] = true
+	stillRunning.lck.Unlock()
+
+	go func() {
+		r := <-ch
+		if cloning && r.err != nil {
+			os.RemoveAll(dir)
+		}
+
+		stillRunning.lck.Lock()
+		delete(stillRunning.dirs, dir)
+		stillRunning.lck.Unlock()
+	}()
+}
+
+// Return the directory with the checked out files of the working directory,
+// this is the directory that should be indexed.
+func (w *WorkDir) WorkTree(dir string) string {
+	if t, ok := w.Driver.(WorkTreeDriver); ok {
+		return t.WorkTree(dir)
+	}
+	return dir
+}
+
+// Is the working directory a clone hound made, see ManagedDriver? Drivers
+// that don't say are taken to clone into the dbpath.
+func (w *WorkDir) ManagesWorkingDir() bool {
+	if m, ok := w.Driver.(ManagedDriver); ok {
+		return m.ManagesWorkingDir()
+	}
+	return true
+}
+
+// Return the blame for each line of the file at path, see BlameDriver.
+func (w *WorkDir) Blame(dir, rev, path string) ([]*BlameLine, error) {
+	if b, ok := w.Driver.(BlameDriver); ok {
+		return b.Blame(dir, rev, path)
+	}
+	return nil, ErrBlameNotSupported
+}
+
+// Return the files that changed between two revisions, see ChangesDriver.
+func (w *WorkDir) Changes(dir, from, to string) ([]string, error) {
+	if c, ok := w.Driver.(ChangesDriver); ok {
+		return c.Changes(dir, from, to)
+	}
+	return nil, ErrChangesNotSupported
+}
+
+// Return the files a range of revisions changed, see DiffDriver.
+func (w *WorkDir) DiffFiles(dir, rng string) ([]string, error) {
+	if d, ok := w.Driver.(DiffDriver); ok {
+		return d.DiffFiles(dir, rng)
+	}
+	return nil, ErrDiffNotSupported
+}
+
+// Split a range of revisions, base..head or base...head, into its ends and
+// whether it's the three dot kind, which starts where head branched off base.
+func ParseRange(rng string) (string, string, bool, error) {
+	sep := "..."
+	i := strings.Index(rng, sep)
+	if i < 0 {
+		sep = ".."
+		i = strings.Index(rng, sep)
+	}
+	if i < 0 {
+		return "", "", false, fmt.Errorf("invalid range %q, expected base..head or base...head", rng)
+	}
+
+	base, head := rng[:i], rng[i+len(sep):]
+	if base == "" || head == "" || strings.Contains(head, "..") {
+		return "", "", false, fmt.Errorf("invalid range %q, expected base..head or base...head", rng)
+	}
+	return base, head, sep == "...", nil
+}
+
+// Return when the files in the working tree were last changed, see
+// ModTimesDriver.
+func (w *WorkDir) ModTimes(dir string) (map[string]time.Time, time.Time, error) {
+	if m, ok := w.Driver.(ModTimesDriver); ok {
+		return m.ModTimes(dir)
+	}
+	return nil, time.Time{}, ErrModTimesNotSupported
+}
+
+// Resolve rev to a full revision, see ExportDriver.
+func (w *WorkDir) ResolveRev(dir, rev string) (string, error) {
+	if e, ok := w.Driver.(ExportDriver); ok {
+		return e.ResolveRev(dir, rev)
+	}
+	return "", ErrExportNotSupported
+}
+
+// Write the files of another revision into dst, see ExportDriver.
+func (w *WorkDir) Export(dir, rev, dst string) error {
+	if e, ok := w.Driver.(ExportDriver); ok {
+		return e.Export(dir, rev, dst)
+	}
+	return ErrExportNotSupported
+}
+
+// Return the names of the repos found under the working directory. This is
+// nil for drivers that only ever manage a single repo.
+func (w *WorkDir) Roots(dir string) ([]string, error) {
+	if m, ok := w.Driver.(MultiRootDriver); ok {
+		return m.Roots(dir)
+	}
+	return nil, nil
+}
+
+// Are the roots of the working directory, if it has any (see Roots),
+// branches of the one repo? See BranchesDriver.
+func (w *WorkDir) HasBranches() bool {
+	_, ok := w.Driver.(BranchesDriver)
+	return ok
+}
+
+// Return the revision of the branch checked out in root, see BranchesDriver.
+func (w *WorkDir) BranchRev(dir, root string) (string, error) {
+	if b, ok := w.Driver.(BranchesDriver); ok {
+		return b.BranchRev(dir, root)
+	}
+	return "", ErrBranchesNotSupported
+}
@@ -1,7 +1,15 @@
 package vcs
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/etsy/hound/config"
 )
 
 // TODO(knorton): Write tests for the vcs interactions
@@ -19,3 +27,143 @@ func TestNilConfigs(t *testing.T) {
 		}
 	}
 }
+
+// Make sure only network related failures are retried.
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{&CommandError{errors.New("exit status 128"), []byte("fatal: unable to access 'https://example.com/': Could not resolve host: example.com")}, true},
+		{&CommandError{errors.New("exit status 128"), []byte("fatal: The remote end hung up unexpectedly")}, true},
+		{&CommandError{errors.New("exit status 128"), []byte("fatal: Authentication failed for 'https://example.com/'")}, false},
+		{&CommandError{errors.New("exit status 128"), []byte("ERROR: Repository not found.")}, false},
+		{errors.New("Location /foo not found."), false},
+		{&TimeoutError{time.Minute}, true},
+	}
+
+	for i, test := range tests {
+		if IsTransient(test.err) != test.transient {
+			t.Fatalf("case %d: expected transient of %t", i, test.transient)
+		}
+	}
+}
+
+// A driver whose clones and pulls hang until it's released, or its context
+// is done if it has one.
+type hangingDriver struct {
+	release chan struct{}
+	ctx     context.Context
+}
+
+func (d *hangingDriver) hang(dir string) (string, error) {
+	var done <-chan struct{}
+	if d.ctx != nil {
+		done = d.ctx.Done()
+	}
+
+	select {
+	case <-d.release:
+		return "rev", nil
+	case <-done:
+		return "", d.ctx.Err()
+	}
+}
+
+func (d *hangingDriver) WorkingDirForRepo(dbpath string, repo *config.Repo) (string, error) {
+	return dbpath, nil
+}
+
+func (d *hangingDriver) Clone(dir, url string) (string, error) {
+	// leave a partial clone behind
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", err
+	}
+	return d.hang(dir)
+}
+
+func (d *hangingDriver) Pull(dir string) (string, error)    { return d.hang(dir) }
+func (d *hangingDriver) HeadRev(dir string) (string, error) { return "rev", nil }
+func (d *hangingDriver) SpecialFiles() []string             { return nil }
+
+type cancelableDriver struct {
+	hangingDriver
+}
+
+func (d *cancelableDriver) WithContext(ctx context.Context) Driver {
+	c := *d
+	c.ctx = ctx
+	return &c
+}
+
+func TestPullOrCloneTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clone := filepath.Join(dir, "clone")
+	timeout := 20 * time.Millisecond
+
+	// a driver that can be cancelled has its partial clone removed
+	wd := &WorkDir{&cancelableDriver{hangingDriver{release: make(chan struct{})}}}
+	if _, err := wd.PullOrCloneTimeout(clone, "url", timeout); !IsTransient(err) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if exists(clone) {
+		t.Fatal("expected the partial clone to be removed")
+	}
+
+	// others are only given up on
+	release := make(chan struct{})
+	defer close(release)
+	wd = &WorkDir{&hangingDriver{release: release}}
+	if _, err := wd.PullOrCloneTimeout(clone, "url", timeout); err == nil {
+		t.Fatal("expected a timeout")
+	} else if _, ok := err.(*TimeoutError); !ok {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	// and nothing else pulls into the clone until they're done
+	done := make(chan struct{})
+	close(done)
+	wd = &WorkDir{&cancelableDriver{hangingDriver{release: done}}}
+	if _, err := wd.PullOrCloneTimeout(clone, "url", time.Minute); err != ErrStillRunning {
+		t.Fatalf("expected the clone to still be busy, got %v", err)
+	}
+
+	// nothing times out if it finishes in time
+	if rev, err := wd.PullOrCloneTimeout(filepath.Join(dir, "other"), "url", time.Minute); err != nil || rev != "rev" {
+		t.Fatalf("expected rev, got %s (%v)", rev, err)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		rng        string
+		base, head string
+		threeDot   bool
+	}{
+		{"main..feature", "main", "feature", false},
+		{"main...feature", "main", "feature", true},
+		{"v1.0...abc123", "v1.0", "abc123", true},
+	}
+
+	for _, test := range tests {
+		base, head, threeDot, err := ParseRange(test.rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if base != test.base || head != test.head || threeDot != test.threeDot {
+			t.Fatalf("%s: expected %s, %s and %v, got %s, %s and %v",
+				test.rng, test.base, test.head, test.threeDot, base, head, threeDot)
+		}
+	}
+
+	for _, rng := range []string{"", "main", "main..", "...feature", "a..b..c"} {
+		if _, _, _, err := ParseRange(rng); err == nil {
+			t.Fatalf("expected %q to be invalid", rng)
+		}
+	}
+}
@@ -4,9 +4,77 @@ import (
 	"crypto/sha1"
   "encoding/hex"
 	"fmt"
+	"net/url"
   "path/filepath"
+	"strings"
 )
 
+// Output from a failed vcs command that indicates the failure is caused by
+// the network rather than by the repo itself, which means it is worth
+// trying again.
+var transientErrors = []string{
+	"could not resolve host",
+	"connection refused",
+	"connection reset",
+	"connection timed out",
+	"operation timed out",
+	"network is unreachable",
+	"temporary failure",
+	"the remote end hung up",
+	"early eof",
+	"rpc failed",
+	"gnutls_handshake",
+	"503 service unavailable",
+	"502 bad gateway",
+}
+
+// The error returned when a vcs command fails. It keeps the output of the
+// command around so callers can figure out what kind of failure it was.
+type CommandError struct {
+	Err    error
+	Output []byte
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+// Is the failure likely to go away if the operation is retried? Only
+// failures that are known to be network related (or timed out) are
+// considered transient, things like bad credentials or a missing repo will
+// never succeed.
+func IsTransient(err error) bool {
+	if _, ok := err.(*TimeoutError); ok || err == ErrStillRunning {
+		return true
+	}
+
+	ce, ok := err.(*CommandError)
+	if !ok {
+		return false
+	}
+
+	out := strings.ToLower(string(ce.Output))
+	for _, msg := range transientErrors {
+		if strings.Contains(out, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove any credentials embedded in the url so that it is safe to log.
+// Urls that can't be parsed (like scp style ssh remotes) can't carry a
+// password and are returned as is.
+func ScrubUrl(u string) string {
+	p, err := url.Parse(u)
+	if err != nil || p.User == nil {
+		return u
+	}
+
+	p.User = url.User("xxxxx")
+	return p.String()
+}
+
 // Utility function for producing a hex encoded sha1 hash for a string.
 func hashFor(name string) string {
 	h := sha1.New()
@@ -18,3 +86,14 @@ func hashFor(name string) string {
 func generateWorkingDir(dbpath string, url string) string {
 	return filepath.Join(dbpath, fmt.Sprintf("vcs-%s", hashFor(url)))
 }
+
+// The vcs directory of a repo that checks out something other than the
+// default (like a branch) from url, so that it never shares a directory
+// with a repo that checks out something else from the same url. A NUL can't
+// be part of a url, so no other url and variant end up with the same name.
+func generateVariantWorkingDir(dbpath, url, variant string) string {
+	if variant == "" {
+		return generateWorkingDir(dbpath, url)
+	}
+	return generateWorkingDir(dbpath, url+"\x00"+variant)
+}
@@ -0,0 +1,42 @@
+// Package version describes the build of hound that is running. Sha and
+// BuildTime are set when building, e.g.
+//
+//	go install -ldflags "-X github.com/etsy/hound/version.Sha=$(git rev-parse HEAD)" ...
+package version
+
+import (
+	"runtime"
+	"time"
+)
+
+var (
+	// The git commit the binary was built from.
+	Sha = "unknown"
+
+	// When the binary was built, in RFC 3339 format.
+	BuildTime = "unknown"
+
+	// When the process started.
+	Started = time.Now()
+)
+
+type Info struct {
+	Sha       string
+	BuildTime string
+	GoVersion string
+	Started   time.Time
+
+	// How long the process has been running, in seconds.
+	Uptime int64
+}
+
+// Get the version of the running build.
+func Get() *Info {
+	return &Info{
+		Sha:       Sha,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Started:   Started,
+		Uptime:    int64(time.Since(Started) / time.Second),
+	}
+}